@@ -0,0 +1,225 @@
+package messagebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// 重投相关的 QueryParams 键
+const (
+	// HeaderDeliveryAttempt 消息的投递次数，首次投递不携带，重投时从 2 开始
+	HeaderDeliveryAttempt = "x-delivery-attempt"
+	// HeaderDeliveryError 转发到死信主题时附带的最后一次处理失败原因
+	HeaderDeliveryError = "x-delivery-error"
+)
+
+// 重投参数的默认值
+const (
+	defaultRedeliveryAttempts = 5
+	defaultRedeliveryDelay    = time.Second
+	defaultAckTimeout         = 30 * time.Second
+)
+
+var (
+	// ErrNacked 表示处理函数调用 Nack 时未提供原因
+	ErrNacked = errors.New("消息被拒绝")
+	// ErrAckTimeout 表示处理函数在 AckTimeout 内既未确认也未拒绝消息
+	ErrAckTimeout = errors.New("等待消息确认超时")
+)
+
+// RedeliveryPolicy 表示处理失败的消息的重投策略
+//
+// 重投由客户端内部的延迟队列完成，对 MQTT、NATS、JetStream 等所有类型一致；
+// go-mod-messaging 在消息进入接收通道时即向 JetStream 确认，断开连接时尚未重投的消息会被放弃。
+type RedeliveryPolicy struct {
+	// MaxAttempts 包含首次投递在内的最大投递次数，默认 5
+	MaxAttempts int
+	// Delay 处理失败到重投的间隔，默认 1s，可被 Delivery.NackWithDelay 覆盖
+	Delay time.Duration
+	// DeadLetterTopic 达到最大投递次数后转发到的死信主题，为空时只记录错误
+	DeadLetterTopic string
+	// AckTimeout SubscribeWithAck 的处理函数确认消息的最长时间，超时视为拒绝，默认 30s
+	AckTimeout time.Duration
+}
+
+// validate 校验重投策略
+func (p RedeliveryPolicy) validate() error {
+	if p.MaxAttempts < 0 || p.Delay < 0 || p.AckTimeout < 0 {
+		return fmt.Errorf("MaxAttempts、Delay 和 AckTimeout 不能为负数")
+	}
+	if strings.ContainsAny(p.DeadLetterTopic, "+#*>") {
+		return fmt.Errorf("DeadLetterTopic 不能包含通配符: %s", p.DeadLetterTopic)
+	}
+	return nil
+}
+
+func (p RedeliveryPolicy) maxAttempts() int {
+	if p.MaxAttempts == 0 {
+		return defaultRedeliveryAttempts
+	}
+	return p.MaxAttempts
+}
+
+func (p RedeliveryPolicy) delay() time.Duration {
+	if p.Delay == 0 {
+		return defaultRedeliveryDelay
+	}
+	return p.Delay
+}
+
+func (p RedeliveryPolicy) ackTimeout() time.Duration {
+	if p.AckTimeout == 0 {
+		return defaultAckTimeout
+	}
+	return p.AckTimeout
+}
+
+// Delivery 表示一次待确认的消息投递
+type Delivery struct {
+	Topic   string                // 实际接收主题
+	Message types.MessageEnvelope // 解码后的消息
+	Attempt int                   // 投递次数，从 1 开始
+
+	once    sync.Once
+	settled chan struct{}
+	err     error
+}
+
+// AckHandler 表示需要显式确认消息的处理函数，可以在返回后异步调用 Ack 或 Nack
+type AckHandler func(delivery *Delivery)
+
+// Ack 确认消息已处理完成，只有第一次 Ack 或 Nack 生效
+func (d *Delivery) Ack() {
+	d.settle(nil)
+}
+
+// Nack 拒绝消息，按订阅的 RedeliveryPolicy 延迟后重投
+func (d *Delivery) Nack(err error) {
+	d.NackWithDelay(err, 0)
+}
+
+// NackWithDelay 拒绝消息并在 delay 后重投，delay 为 0 时使用 RedeliveryPolicy.Delay
+func (d *Delivery) NackWithDelay(err error, delay time.Duration) {
+	if err == nil {
+		err = ErrNacked
+	}
+	d.settle(&nackError{err: err, delay: delay})
+}
+
+func (d *Delivery) settle(err error) {
+	d.once.Do(func() {
+		d.err = err
+		close(d.settled)
+	})
+}
+
+// nackError 携带处理函数指定的重投间隔
+type nackError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *nackError) Error() string { return e.err.Error() }
+func (e *nackError) Unwrap() error { return e.err }
+
+// SubscribeWithAck 订阅多个主题，处理函数须显式确认或拒绝每条消息
+// 被拒绝、确认超时或处理函数 panic 的消息按 opts.Redelivery 重投，未设置时使用默认策略
+func (c *Client) SubscribeWithAck(topics []string, handler AckHandler, opts SubscribeOptions) error {
+	if handler == nil {
+		return fmt.Errorf("消息处理函数不能为空")
+	}
+	if opts.Redelivery == nil {
+		opts.Redelivery = &RedeliveryPolicy{}
+	}
+	timeout := opts.Redelivery.ackTimeout()
+	return c.SubscribeWithOptions(topics, func(topic string, message types.MessageEnvelope) error {
+		delivery := &Delivery{
+			Topic:   topic,
+			Message: message,
+			Attempt: deliveryAttempt(message),
+			settled: make(chan struct{}),
+		}
+		handler(delivery)
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-delivery.settled:
+		case <-timer.C:
+			delivery.settle(ErrAckTimeout)
+		}
+		return delivery.err
+	}, opts)
+}
+
+// deliveryAttempt 返回消息的投递次数
+func deliveryAttempt(message types.MessageEnvelope) int {
+	if attempt, err := strconv.Atoi(message.QueryParams[HeaderDeliveryAttempt]); err == nil && attempt > 1 {
+		return attempt
+	}
+	return 1
+}
+
+// redeliver 按订阅的 RedeliveryPolicy 安排处理失败的消息重投，延迟到期后消息重新进入订阅的消息循环，
+// 与新消息一样经 Worker（保持 Ordered/OrderingKey 顺序）处理并占用在途预算；消费位置在重投结束后才提交
+// raw 为解码前的原始消息，size 为其 Payload 字节数；返回 false 表示订阅未设置重投策略或已达到最大投递次数
+func (c *Client) redeliver(sub *subscription, topic string, raw types.MessageEnvelope, size int64, err error) bool {
+	policy := sub.opts.Redelivery
+	if policy == nil {
+		return false
+	}
+	attempt := deliveryAttempt(raw)
+	if attempt >= policy.maxAttempts() {
+		return false
+	}
+	delay := policy.delay()
+	var nack *nackError
+	if errors.As(err, &nack) && nack.delay > 0 {
+		delay = nack.delay
+	}
+	raw.QueryParams = withParam(raw.QueryParams, HeaderDeliveryAttempt, strconv.Itoa(attempt+1))
+	c.stats.redelivered.Add(1)
+	c.log(LogSubscribe).Warn("消息处理失败，稍后重投", c.logFields("topic", topic, "correlationId", raw.CorrelationID, "attempt", attempt, "delay", delay, "error", err)...)
+	c.lifecycle.spawn(stageSubscribe, func(stop <-chan struct{}) {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-stop:
+			c.log(LogSubscribe).Warn("客户端断开连接，放弃待重投的消息", c.logFields("topic", topic, "correlationId", raw.CorrelationID)...)
+			return
+		case <-sub.done:
+			return
+		}
+		select {
+		case sub.redeliveries <- inboundMessage{envelope: raw, size: size}:
+		case <-stop:
+			c.log(LogSubscribe).Warn("客户端断开连接，放弃待重投的消息", c.logFields("topic", topic, "correlationId", raw.CorrelationID)...)
+		case <-sub.done:
+		}
+	})
+	return true
+}
+
+// deadLetter 将无法继续重试的消息转发到死信主题，附带失败原因和原始主题
+func (c *Client) deadLetter(topic string, raw types.MessageEnvelope, deadLetterTopic string, err error) {
+	if deadLetterTopic == "" {
+		return
+	}
+	dead := raw
+	dead.QueryParams = withParam(raw.QueryParams, HeaderDeliveryError, err.Error())
+	dead.QueryParams[HeaderOriginalTopic] = topic
+	ctx := context.WithValue(context.Background(), skipSchemaKey{}, true)
+	if dErr := c.interceptPublish(ctx, deadLetterTopic, dead, c.sendDirect); dErr != nil {
+		c.log(LogSubscribe).Error("转发死信消息失败", c.logFields("topic", topic, "deadLetterTopic", deadLetterTopic, "error", dErr)...)
+		return
+	}
+	c.stats.deadLettered.Add(1)
+	c.log(LogSubscribe).Warn("消息处理失败，已转发到死信主题", c.logFields("topic", topic, "correlationId", raw.CorrelationID, "deadLetterTopic", deadLetterTopic)...)
+}
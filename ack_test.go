@@ -0,0 +1,178 @@
+package messagebus_test
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// resumingClient 为内存客户端补充 OffsetResumer，以便启用 OffsetStore
+type resumingClient struct {
+	messaging.MessageClient
+}
+
+func (resumingClient) ResumeFrom(messagebus.OffsetStore) error { return nil }
+
+func TestRedeliveryDefersOffsetCommit(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	store := messagebus.NewMemoryOffsetStore()
+	factory := broker.MessageClientFactory("consumer")
+	client, err := messagebus.NewClientWithOptions(
+		messagebus.WithConfig(testConfig()),
+		messagebus.WithOffsetStore(store),
+		messagebus.WithMessageClientFactory(func(config types.MessageBusConfig) (messaging.MessageClient, error) {
+			inner, err := factory(config)
+			if err != nil {
+				return nil, err
+			}
+			return resumingClient{inner}, nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	release := make(chan struct{})
+	handled := make(chan string, 4)
+	err = client.SubscribeWithOptions([]string{"test/offset"}, func(_ string, msg types.MessageEnvelope) error {
+		offset := msg.QueryParams[messagebus.HeaderOffset]
+		if offset == "1" && msg.QueryParams[messagebus.HeaderDeliveryAttempt] == "" {
+			handled <- "fail-1"
+			return errors.New("暂时失败")
+		}
+		if offset == "1" {
+			<-release
+		}
+		handled <- offset
+		return nil
+	}, messagebus.SubscribeOptions{Redelivery: &messagebus.RedeliveryPolicy{Delay: 20 * time.Millisecond}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := broker.MessageClientFactory("raw")(types.MessageBusConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := raw.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	for _, offset := range []int{1, 2} {
+		envelope := types.MessageEnvelope{
+			Payload:     []byte("x"),
+			ContentType: "text/plain",
+			QueryParams: map[string]string{messagebus.HeaderOffsetKey: "k", messagebus.HeaderOffset: strconv.Itoa(offset)},
+		}
+		if err := raw.Publish(envelope, "test/offset"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []string{"fail-1", "2"} {
+		select {
+		case got := <-handled:
+			if got != want {
+				t.Fatalf("处理顺序 %s，期望 %s", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("等待 %s 超时", want)
+		}
+	}
+	if offset, ok, _ := store.Load("k"); ok {
+		t.Errorf("重投完成前已提交位置 %d", offset)
+	}
+
+	close(release)
+	select {
+	case got := <-handled:
+		if got != "1" {
+			t.Fatalf("重投处理 %s，期望 1", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("等待重投超时")
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if offset, ok, _ := store.Load("k"); ok && offset == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			offset, ok, _ := store.Load("k")
+			t.Fatalf("重投完成后位置为 %d (%v)，期望 2", offset, ok)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRedeliveryKeepsOrderingKeySerial(t *testing.T) {
+	client := newMockClient(t)
+
+	var (
+		active  atomic.Int32
+		overlap atomic.Bool
+		mutex   sync.Mutex
+		done    = map[string]bool{}
+	)
+	err := client.SubscribeWithOptions([]string{"test/ordered/#"}, func(_ string, msg types.MessageEnvelope) error {
+		if active.Add(1) > 1 {
+			overlap.Store(true)
+		}
+		defer active.Add(-1)
+		time.Sleep(5 * time.Millisecond)
+		if msg.QueryParams[messagebus.HeaderDeliveryAttempt] == "" {
+			return errors.New("首次投递失败")
+		}
+		mutex.Lock()
+		done[msg.CorrelationID] = true
+		mutex.Unlock()
+		return nil
+	}, messagebus.SubscribeOptions{
+		Workers:     4,
+		OrderingKey: func(string, types.MessageEnvelope) string { return "same" },
+		Redelivery:  &messagebus.RedeliveryPolicy{Delay: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const total = 8
+	for i := 0; i < total; i++ {
+		envelope, err := client.CreateMessageEnvelope("x", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		envelope.CorrelationID = strconv.Itoa(i)
+		if err := client.PublishEnvelope("test/ordered/"+strconv.Itoa(i), envelope); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mutex.Lock()
+		n := len(done)
+		mutex.Unlock()
+		if n == total {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("只有 %d/%d 条消息重投成功", n, total)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if overlap.Load() {
+		t.Error("同一顺序键的重投消息与其他消息并发处理")
+	}
+}
@@ -0,0 +1,443 @@
+// Package amqp 提供 AMQP 0-9-1（RabbitMQ）消息总线后端，实现与 MQTT 相同的发布、订阅和请求-响应语义
+//
+// 该包单独存放，只有导入它的程序才会依赖 github.com/rabbitmq/amqp091-go。使用时将 Type 设置为
+// messagebus.TypeAMQP，并通过 Factory 设置 MessageClientFactory：
+//
+//	client, err := messagebus.NewClientWithOptions(
+//	    messagebus.WithBroker("rabbitmq.local", 5672, "amqp", messagebus.TypeAMQP),
+//	    messagebus.WithMessageClientFactory(amqp.Factory(amqp.Config{DurableQueues: true})),
+//	)
+//
+// 所有消息发布到一个 topic 类型的交换机，MessageBus 主题的 / 转换为路由键的 .，
+// 通配符 + (*) 与 # (>) 分别对应绑定键中的 * 与 #；完整主题保存在消息头 HeaderTopic 中。
+package amqp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+	amqp091 "github.com/rabbitmq/amqp091-go"
+)
+
+// HeaderTopic 是 AMQP 消息头中保存完整 MessageBus 主题的键
+const HeaderTopic = "messagebus-topic"
+
+const (
+	defaultExchange       = "edgex"
+	defaultPrefetch       = 100
+	defaultPublishTimeout = 10 * time.Second
+)
+
+// Config 表示 AMQP 后端专用参数
+type Config struct {
+	// Exchange topic 交换机名称，默认 edgex，连接时自动声明为持久化交换机
+	Exchange string
+	// VirtualHost 虚拟主机，默认为 /
+	VirtualHost string
+	// DurableQueues 为 true 时每个订阅使用持久化的命名队列，客户端离线期间的消息保留在队列中，
+	// 同名队列的多个实例分摊消息；为 false 时使用连接断开即删除的独占队列
+	DurableQueues bool
+	// QueuePrefix 持久化队列名前缀，默认为 ClientID，队列名为 前缀:订阅主题
+	QueuePrefix string
+	// Prefetch 每个订阅未确认消息的上限，默认 100
+	Prefetch int
+	// PublishTimeout 启用发布确认时等待 Broker 确认的最长时间，默认 10 秒
+	PublishTimeout time.Duration
+}
+
+// Factory 返回创建 AMQP 底层客户端的 MessageClientFactory
+func Factory(config Config) messagebus.MessageClientFactory {
+	return func(busConfig types.MessageBusConfig) (messaging.MessageClient, error) {
+		return NewMessageClient(busConfig, config)
+	}
+}
+
+// consumer 表示一个订阅使用的 AMQP 通道
+type consumer struct {
+	channel *amqp091.Channel
+	tag     string
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// MessageClient 使用 amqp091-go 实现 messaging.MessageClient
+type MessageClient struct {
+	config    Config
+	url       string
+	dial      amqp091.Config
+	queueBase string
+	confirm   bool
+
+	mutex     sync.Mutex
+	conn      *amqp091.Connection
+	publisher *amqp091.Channel
+	consumers map[string]*consumer
+}
+
+var _ messaging.MessageClient = (*MessageClient)(nil)
+
+// NewMessageClient 按 MessageBus 底层配置创建 AMQP 客户端，Connect 时才建立连接
+// Config.ConfirmPublish 启用时发布使用 publisher confirms，等待 Broker 确认后返回
+func NewMessageClient(busConfig types.MessageBusConfig, config Config) (*MessageClient, error) {
+	if config.Exchange == "" {
+		config.Exchange = defaultExchange
+	}
+	if config.Prefetch <= 0 {
+		config.Prefetch = defaultPrefetch
+	}
+	if config.PublishTimeout <= 0 {
+		config.PublishTimeout = defaultPublishTimeout
+	}
+	scheme := "amqp"
+	dial := amqp091.Config{Vhost: config.VirtualHost, Properties: amqp091.NewConnectionProperties()}
+	switch strings.ToLower(busConfig.Broker.Protocol) {
+	case "amqps", "tls", "ssl":
+		tlsConfig, err := messagebus.TLSConfigFromOptions(busConfig.Optional)
+		if err != nil {
+			return nil, err
+		}
+		scheme = "amqps"
+		dial.TLSClientConfig = tlsConfig
+	}
+	clientID := busConfig.Optional["ClientId"]
+	if clientID != "" {
+		dial.Properties.SetClientConnectionName(clientID)
+	}
+	target := url.URL{Scheme: scheme, Host: net.JoinHostPort(busConfig.Broker.Host, strconv.Itoa(busConfig.Broker.Port)), Path: "/"}
+	if username := busConfig.Optional["Username"]; username != "" {
+		target.User = url.UserPassword(username, busConfig.Optional["Password"])
+	}
+	queueBase := config.QueuePrefix
+	if queueBase == "" {
+		queueBase = clientID
+	}
+	if config.DurableQueues && queueBase == "" {
+		return nil, fmt.Errorf("DurableQueues 需要设置 QueuePrefix 或 ClientID")
+	}
+	confirm, _ := strconv.ParseBool(busConfig.Optional[messagebus.OptionalConfirmPublish])
+	return &MessageClient{
+		config:    config,
+		url:       target.String(),
+		dial:      dial,
+		queueBase: queueBase,
+		confirm:   confirm,
+		consumers: make(map[string]*consumer),
+	}, nil
+}
+
+// Connect 建立连接、声明交换机并打开发布通道
+func (c *MessageClient) Connect() error {
+	conn, err := amqp091.DialConfig(c.url, c.dial)
+	if err != nil {
+		return fmt.Errorf("连接AMQP Broker失败: %w", err)
+	}
+	publisher, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("打开AMQP通道失败: %w", err)
+	}
+	if err := publisher.ExchangeDeclare(c.config.Exchange, amqp091.ExchangeTopic, true, false, false, false, nil); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("声明交换机 %s 失败: %w", c.config.Exchange, err)
+	}
+	if c.confirm {
+		if err := publisher.Confirm(false); err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("启用发布确认失败: %w", err)
+		}
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+	c.conn = conn
+	c.publisher = publisher
+	return nil
+}
+
+// Publish 将信封编码为 JSON 后发布
+func (c *MessageClient) Publish(message types.MessageEnvelope, topic string) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("编码信封失败: %w", err)
+	}
+	return c.publish(topic, data, "application/json")
+}
+
+// PublishWithSizeLimit 在编码后的信封不超过 limit KB 时发布，limit 不大于 0 表示不限制
+func (c *MessageClient) PublishWithSizeLimit(message types.MessageEnvelope, topic string, limit int64) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("编码信封失败: %w", err)
+	}
+	if limit > 0 && int64(len(data)) > limit*1024 {
+		return fmt.Errorf("消息大小 %d 字节超过限制 %d KB", len(data), limit)
+	}
+	return c.publish(topic, data, "application/json")
+}
+
+// PublishBinaryData 直接发布原始数据
+func (c *MessageClient) PublishBinaryData(data []byte, topic string) error {
+	return c.publish(topic, data, "application/octet-stream")
+}
+
+// publish 发布一条消息，启用发布确认时等待 Broker 的 ack
+func (c *MessageClient) publish(topic string, body []byte, contentType string) error {
+	c.mutex.Lock()
+	publisher := c.publisher
+	c.mutex.Unlock()
+	if publisher == nil {
+		return fmt.Errorf("AMQP客户端未连接")
+	}
+	msg := amqp091.Publishing{
+		Headers:     amqp091.Table{HeaderTopic: topic},
+		ContentType: contentType,
+		Timestamp:   time.Now(),
+		Body:        body,
+	}
+	if c.config.DurableQueues {
+		msg.DeliveryMode = amqp091.Persistent
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.PublishTimeout)
+	defer cancel()
+	key := routingKey(topic)
+	if !c.confirm {
+		if err := publisher.PublishWithContext(ctx, c.config.Exchange, key, false, false, msg); err != nil {
+			return fmt.Errorf("发布到 %s 失败: %w", topic, err)
+		}
+		return nil
+	}
+	confirmation, err := publisher.PublishWithDeferredConfirmWithContext(ctx, c.config.Exchange, key, false, false, msg)
+	if err != nil {
+		return fmt.Errorf("发布到 %s 失败: %w", topic, err)
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("等待 %s 的发布确认失败: %w", topic, err)
+	}
+	if !acked {
+		return fmt.Errorf("Broker拒绝了发布到 %s 的消息", topic)
+	}
+	return nil
+}
+
+// Subscribe 为每个主题声明队列并绑定到交换机，收到的信封发送到对应的通道
+func (c *MessageClient) Subscribe(topics []types.TopicChannel, messageErrors chan error) error {
+	return c.subscribe(topics, messageErrors, false, c.config.DurableQueues)
+}
+
+// SubscribeBinaryData 与 Subscribe 相同，但将原始数据包装为信封的 Payload
+func (c *MessageClient) SubscribeBinaryData(topics []types.TopicChannel, messageErrors chan error) error {
+	return c.subscribe(topics, messageErrors, true, c.config.DurableQueues)
+}
+
+// subscribe 为每个主题启动消费者，任一主题失败时停止本次已启动的消费者
+func (c *MessageClient) subscribe(topics []types.TopicChannel, messageErrors chan error, binary bool, durable bool) error {
+	var started []string
+	for _, topic := range topics {
+		if err := c.startConsumer(topic, messageErrors, binary, durable); err != nil {
+			_ = c.Unsubscribe(started...)
+			return err
+		}
+		started = append(started, topic.Topic)
+	}
+	return nil
+}
+
+// startConsumer 在独立通道上声明并绑定队列，启动消费循环
+func (c *MessageClient) startConsumer(topic types.TopicChannel, messageErrors chan error, binary bool, durable bool) error {
+	c.mutex.Lock()
+	conn := c.conn
+	c.mutex.Unlock()
+	if conn == nil {
+		return fmt.Errorf("AMQP客户端未连接")
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("打开AMQP通道失败: %w", err)
+	}
+	if err := channel.Qos(c.config.Prefetch, 0, false); err != nil {
+		_ = channel.Close()
+		return fmt.Errorf("设置预取数量失败: %w", err)
+	}
+	var queue amqp091.Queue
+	if durable {
+		queue, err = channel.QueueDeclare(c.queueBase+":"+topic.Topic, true, false, false, false, nil)
+	} else {
+		queue, err = channel.QueueDeclare("", false, true, true, false, nil)
+	}
+	if err != nil {
+		_ = channel.Close()
+		return fmt.Errorf("声明订阅 %s 的队列失败: %w", topic.Topic, err)
+	}
+	if err := channel.QueueBind(queue.Name, bindingKey(topic.Topic), c.config.Exchange, false, nil); err != nil {
+		_ = channel.Close()
+		return fmt.Errorf("绑定订阅 %s 失败: %w", topic.Topic, err)
+	}
+	tag := uuid.NewString()
+	deliveries, err := channel.Consume(queue.Name, tag, !durable, false, false, false, nil)
+	if err != nil {
+		_ = channel.Close()
+		return fmt.Errorf("订阅 %s 失败: %w", topic.Topic, err)
+	}
+	sub := &consumer{channel: channel, tag: tag, quit: make(chan struct{}), done: make(chan struct{})}
+
+	c.mutex.Lock()
+	if existing, ok := c.consumers[topic.Topic]; ok {
+		defer existing.stop()
+	}
+	c.consumers[topic.Topic] = sub
+	c.mutex.Unlock()
+
+	go func() {
+		defer close(sub.done)
+		c.consume(deliveries, sub.quit, topic, messageErrors, binary, durable)
+	}()
+	return nil
+}
+
+// consume 将投递的消息解码后发送到订阅通道，持久化队列在消息交给通道后确认
+func (c *MessageClient) consume(deliveries <-chan amqp091.Delivery, quit <-chan struct{}, topic types.TopicChannel, messageErrors chan error, binary bool, durable bool) {
+	for delivery := range deliveries {
+		busTopic, _ := delivery.Headers[HeaderTopic].(string)
+		if busTopic == "" {
+			busTopic = strings.ReplaceAll(delivery.RoutingKey, ".", "/")
+		}
+		var envelope types.MessageEnvelope
+		if binary {
+			envelope.Payload = delivery.Body
+		} else if err := json.Unmarshal(delivery.Body, &envelope); err != nil {
+			sendError(messageErrors, fmt.Errorf("解码主题 %s 的信封失败: %w", busTopic, err))
+			if durable {
+				_ = delivery.Nack(false, false)
+			}
+			continue
+		}
+		envelope.ReceivedTopic = busTopic
+		select {
+		case topic.Messages <- envelope:
+		case <-quit:
+			// 未交给订阅方的消息不确认，持久化队列会在通道关闭后重新投递
+			return
+		}
+		if durable {
+			_ = delivery.Ack(false)
+		}
+	}
+}
+
+// sendError 以非阻塞方式发送错误，通道为空或已满时丢弃
+func sendError(messageErrors chan error, err error) {
+	if messageErrors == nil {
+		return
+	}
+	select {
+	case messageErrors <- err:
+	default:
+	}
+}
+
+// stop 取消消费并关闭通道，等待消费循环退出
+func (s *consumer) stop() {
+	close(s.quit)
+	_ = s.channel.Cancel(s.tag, false)
+	_ = s.channel.Close()
+	<-s.done
+}
+
+// Request 发布请求并等待 responseTopicPrefix/RequestID 上的响应
+func (c *MessageClient) Request(message types.MessageEnvelope, requestTopic string, responseTopicPrefix string, timeout time.Duration) (*types.MessageEnvelope, error) {
+	if message.RequestID == "" {
+		message.RequestID = uuid.NewString()
+	}
+	responseTopic := strings.TrimSuffix(responseTopicPrefix, "/") + "/" + message.RequestID
+	responses := make(chan types.MessageEnvelope, 1)
+	// 响应只应由本次请求接收，使用独占队列
+	if err := c.subscribe([]types.TopicChannel{{Topic: responseTopic, Messages: responses}}, nil, false, false); err != nil {
+		return nil, err
+	}
+	defer func() { _ = c.Unsubscribe(responseTopic) }()
+	if err := c.Publish(message, requestTopic); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case response := <-responses:
+		return &response, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("等待 %s 的响应超时", responseTopic)
+	}
+}
+
+// Unsubscribe 停止指定主题的消费者，持久化队列及其中的消息保留在 Broker 上
+func (c *MessageClient) Unsubscribe(topics ...string) error {
+	c.mutex.Lock()
+	var stopping []*consumer
+	for _, topic := range topics {
+		if sub, ok := c.consumers[topic]; ok {
+			stopping = append(stopping, sub)
+			delete(c.consumers, topic)
+		}
+	}
+	c.mutex.Unlock()
+	for _, sub := range stopping {
+		sub.stop()
+	}
+	return nil
+}
+
+// Disconnect 停止所有消费者并关闭连接
+func (c *MessageClient) Disconnect() error {
+	c.mutex.Lock()
+	consumers := c.consumers
+	c.consumers = make(map[string]*consumer)
+	conn := c.conn
+	c.conn = nil
+	c.publisher = nil
+	c.mutex.Unlock()
+	for _, sub := range consumers {
+		sub.stop()
+	}
+	if conn != nil && !conn.IsClosed() {
+		return conn.Close()
+	}
+	return nil
+}
+
+// routingKey 将 MessageBus 主题转换为路由键，层级中的 . 替换为 _ 以免被当作分隔符
+func routingKey(topic string) string {
+	levels := strings.Split(topic, "/")
+	for i, level := range levels {
+		levels[i] = strings.ReplaceAll(level, ".", "_")
+	}
+	return strings.Join(levels, ".")
+}
+
+// bindingKey 将订阅主题转换为绑定键，+ (*) 对应 *，# (>) 对应 #
+func bindingKey(topic string) string {
+	levels := strings.Split(topic, "/")
+	for i, level := range levels {
+		switch level {
+		case "+", "*":
+			levels[i] = "*"
+		case "#", ">":
+			levels[i] = "#"
+		default:
+			levels[i] = strings.ReplaceAll(level, ".", "_")
+		}
+	}
+	return strings.Join(levels, ".")
+}
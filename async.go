@@ -0,0 +1,45 @@
+package messagebus
+
+import (
+	"context"
+)
+
+// defaultMaxAsyncPublishes 是未配置 MaxAsyncPublishes 时同时进行的异步发布数量上限
+const defaultMaxAsyncPublishes = 64
+
+// PublishResult 表示一次异步发布的投递结果
+type PublishResult struct {
+	Topic         string // 发布的主题
+	CorrelationID string // 发布信封的 CorrelationID
+	Err           error  // 投递错误，nil 表示发布成功
+}
+
+// PublishAsync 异步发布消息，返回的通道在发布完成后收到一次结果并关闭
+//
+// 调用方无需等待即可连续发布，再按需从各自的通道读取结果。同时进行的异步发布数量受
+// Config.MaxAsyncPublishes 限制，达到上限时 PublishAsync 阻塞直到有发布完成。
+// 投递语义与 Publish 相同：启用 ConfirmPublish 时结果反映 Broker 确认，
+// 启用存储转发时暂存成功即视为发布成功。断开连接时会等待进行中的异步发布完成。
+func (c *Client) PublishAsync(topic string, data interface{}) <-chan PublishResult {
+	results := make(chan PublishResult, 1)
+	payload, contentType, err := c.encodePayload(data)
+	if err != nil {
+		results <- PublishResult{Topic: topic, Err: err}
+		close(results)
+		return results
+	}
+	if c.closing.Load() {
+		results <- PublishResult{Topic: topic, Err: ErrClientClosing}
+		close(results)
+		return results
+	}
+	envelope := newEnvelope(payload, contentType)
+	c.asyncSlots <- struct{}{}
+	c.lifecycle.spawn(stagePublish, func(<-chan struct{}) {
+		defer func() { <-c.asyncSlots }()
+		err := c.publishEnvelope(acceptedPublish(context.Background()), topic, envelope)
+		results <- PublishResult{Topic: topic, CorrelationID: envelope.CorrelationID, Err: err}
+		close(results)
+	})
+	return results
+}
@@ -0,0 +1,136 @@
+package messagebus
+
+import (
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// OverflowPolicy 表示订阅缓冲区已满时对新消息的处理策略
+type OverflowPolicy string
+
+const (
+	OverflowBlock      OverflowPolicy = "block"       // 阻塞底层客户端直到缓冲区有空位（默认）
+	OverflowDropOldest OverflowPolicy = "drop-oldest" // 丢弃缓冲区中最早的消息，为新消息腾出位置
+	OverflowDropNewest OverflowPolicy = "drop-newest" // 丢弃新到达的消息
+	OverflowSpill      OverflowPolicy = "spill"       // 将新消息写入 SpillStore，缓冲区有空位后按顺序取回
+)
+
+// ingressBuffer 是非阻塞策略下底层客户端写入的中转通道大小
+const ingressBuffer = 16
+
+// usesIngress 判断订阅是否需要中转通道，阻塞策略且未启用优先级队列时底层客户端直接写入缓冲区
+func (o SubscribeOptions) usesIngress() bool {
+	return o.PriorityLanes || (o.Overflow != "" && o.Overflow != OverflowBlock)
+}
+
+// pumpMessages 将中转通道中的消息按溢出策略放入订阅缓冲区，高优先级消息放入高优先级队列
+func (c *Client) pumpMessages(sub *subscription, stop <-chan struct{}) {
+	if sub.opts.Overflow == OverflowSpill {
+		c.pumpSpill(sub, stop)
+		return
+	}
+	for {
+		select {
+		case msg := <-sub.ingress:
+			switch {
+			case sub.urgent(msg):
+				c.offerUrgent(sub, msg, stop)
+			case sub.opts.Overflow == "" || sub.opts.Overflow == OverflowBlock:
+				select {
+				case sub.messages <- msg:
+				case <-stop:
+				case <-sub.done:
+				}
+			default:
+				c.offer(sub, msg)
+			}
+		case <-stop:
+			return
+		case <-sub.done:
+			return
+		}
+	}
+}
+
+// offer 按 drop-oldest 或 drop-newest 策略放入缓冲区，不会阻塞
+func (c *Client) offer(sub *subscription, msg types.MessageEnvelope) {
+	select {
+	case sub.messages <- msg:
+		return
+	default:
+	}
+	if sub.opts.Overflow == OverflowDropOldest {
+		select {
+		case <-sub.messages:
+			c.dropOverflow(sub)
+		default:
+		}
+		select {
+		case sub.messages <- msg:
+			return
+		default:
+		}
+	}
+	c.dropOverflow(sub)
+}
+
+// pumpSpill 在缓冲区已满或 SpillStore 中仍有积压时将消息写入 SpillStore，有空位后按顺序取回
+// SpillStore 中的消息在断开连接后保留，下次使用同一存储订阅时优先投递
+func (c *Client) pumpSpill(sub *subscription, stop <-chan struct{}) {
+	store := sub.opts.SpillStore
+	pending, err := store.Len()
+	if err != nil {
+		c.log(LogSubscribe).Error("读取溢出存储失败", c.logFields("topic", sub.topic, "error", err)...)
+	}
+	for {
+		var head QueuedMessage
+		var out chan types.MessageEnvelope
+		if pending > 0 {
+			var ok bool
+			if head, ok, err = store.Peek(); err != nil {
+				c.log(LogSubscribe).Error("读取溢出存储失败", c.logFields("topic", sub.topic, "error", err)...)
+			} else if ok {
+				out = sub.messages
+			} else {
+				pending = 0
+			}
+		}
+		select {
+		case out <- head.Envelope:
+			if err := store.Remove(); err != nil {
+				c.log(LogSubscribe).Error("删除溢出存储中的消息失败", c.logFields("topic", sub.topic, "error", err)...)
+			}
+			pending--
+		case msg := <-sub.ingress:
+			if sub.urgent(msg) {
+				c.offerUrgent(sub, msg, stop)
+				continue
+			}
+			if pending == 0 {
+				select {
+				case sub.messages <- msg:
+					continue
+				default:
+				}
+			}
+			if err := store.Append(QueuedMessage{Topic: sub.topic, Envelope: msg, QueuedAt: time.Now()}); err != nil {
+				c.log(LogSubscribe).Error("写入溢出存储失败，丢弃消息", c.logFields("topic", sub.topic, "correlationId", msg.CorrelationID, "error", err)...)
+				c.dropOverflow(sub)
+				continue
+			}
+			pending++
+			c.stats.spill(sub.topic)
+		case <-stop:
+			return
+		case <-sub.done:
+			return
+		}
+	}
+}
+
+// dropOverflow 记录一条因缓冲区已满被丢弃的消息
+func (c *Client) dropOverflow(sub *subscription) {
+	c.stats.dropOverflow(sub.topic)
+	c.metrics.ObserveOverflowDrop(sub.topic)
+}
@@ -0,0 +1,182 @@
+package messagebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 批量发布的默认参数
+const (
+	defaultBatchMaxSize       = 100
+	defaultBatchFlushInterval = time.Second
+	defaultBatchConcurrency   = 8
+)
+
+// BatchOptions 表示批量发布器的刷新阈值
+type BatchOptions struct {
+	MaxSize       int           // 累积多少条消息时立即刷新，默认 100
+	FlushInterval time.Duration // 定时刷新间隔，默认 1 秒
+	Concurrency   int           // 刷新时并发发布的消息数，默认 8
+}
+
+// withDefaults 返回填充默认值后的批量选项
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.MaxSize <= 0 {
+		o.MaxSize = defaultBatchMaxSize
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = defaultBatchFlushInterval
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultBatchConcurrency
+	}
+	return o
+}
+
+// PublishBatch 将多条消息分别封装为信封并发布到同一主题
+// 所有消息先完成编码，再以流水线方式并发发布，避免逐条等待 Broker 确认；返回所有失败消息的合并错误
+func (c *Client) PublishBatch(topic string, messages []interface{}) error {
+	payloads := make([]interface{}, len(messages))
+	for i, data := range messages {
+		payload, err := toPayload(data)
+		if err != nil {
+			return fmt.Errorf("编码第 %d 条消息失败: %w", i, err)
+		}
+		payloads[i] = payload
+	}
+	return c.publishPayloads(context.Background(), topic, payloads, defaultBatchConcurrency)
+}
+
+// publishPayloads 以有限并发发布已编码的消息
+func (c *Client) publishPayloads(ctx context.Context, topic string, payloads []interface{}, concurrency int) error {
+	if !c.IsConnected() {
+		return ErrNotConnected
+	}
+	errs := make([]error, len(payloads))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, payload := range payloads {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := c.publish(ctx, topic, payload, "application/json"); err != nil {
+				errs[i] = fmt.Errorf("发布第 %d 条消息失败: %w", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// BatchPublisher 缓存发往同一主题的消息，达到数量阈值或定时器到期时批量发布
+// 客户端断开连接时会在停止发布阶段刷新剩余消息并关闭发布器，重新连接后需创建新的发布器
+type BatchPublisher struct {
+	client  *Client
+	topic   string
+	opts    BatchOptions
+	mutex   sync.Mutex
+	pending []interface{}
+	closed  bool
+	done    chan struct{}
+}
+
+// NewBatchPublisher 创建发往指定主题的批量发布器，并启动定时刷新
+func (c *Client) NewBatchPublisher(topic string, opts BatchOptions) *BatchPublisher {
+	b := &BatchPublisher{
+		client: c,
+		topic:  topic,
+		opts:   opts.withDefaults(),
+		done:   make(chan struct{}),
+	}
+	c.lifecycle.spawn(stagePublish, b.run)
+	return b
+}
+
+// Add 编码并缓存一条消息，缓存数量达到 MaxSize 时立即刷新并返回刷新结果
+func (b *BatchPublisher) Add(data interface{}) error {
+	payload, err := toPayload(data)
+	if err != nil {
+		return err
+	}
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return fmt.Errorf("批量发布器已关闭")
+	}
+	b.pending = append(b.pending, payload)
+	full := len(b.pending) >= b.opts.MaxSize
+	b.mutex.Unlock()
+	if full {
+		return b.Flush()
+	}
+	return nil
+}
+
+// Flush 立即发布所有缓存的消息
+func (b *BatchPublisher) Flush() error {
+	return b.flush(context.Background())
+}
+
+// flush 发布所有缓存的消息
+func (b *BatchPublisher) flush(ctx context.Context) error {
+	b.mutex.Lock()
+	payloads := b.pending
+	b.pending = nil
+	b.mutex.Unlock()
+	if len(payloads) == 0 {
+		return nil
+	}
+	return b.client.publishPayloads(ctx, b.topic, payloads, b.opts.Concurrency)
+}
+
+// Pending 返回尚未发布的消息数量
+func (b *BatchPublisher) Pending() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return len(b.pending)
+}
+
+// Close 停止定时刷新并发布剩余消息，之后 Add 将返回错误
+func (b *BatchPublisher) Close() error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.done)
+	b.mutex.Unlock()
+	return b.Flush()
+}
+
+// run 定时刷新缓存，客户端停止发布阶段时刷新剩余消息后退出
+func (b *BatchPublisher) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(b.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := b.Flush(); err != nil {
+				b.client.log(LogPublish).Error("批量发布失败", b.client.logFields("topic", b.topic, "error", err)...)
+			}
+		case <-stop:
+			b.mutex.Lock()
+			b.closed = true
+			b.mutex.Unlock()
+			// 断开连接前已缓存的消息视为已接受的发布，不受停止接受新发布的限制
+			if err := b.flush(acceptedPublish(context.Background())); err != nil {
+				b.client.log(LogPublish).Error("断开连接前刷新批量消息失败", b.client.logFields("topic", b.topic, "error", err)...)
+			}
+			return
+		case <-b.done:
+			return
+		}
+	}
+}
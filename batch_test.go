@@ -0,0 +1,113 @@
+package messagebus_test
+
+import (
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+)
+
+// waitForPublished 等待 Broker 上 topic 的消息数达到 n
+func waitForPublished(t *testing.T, broker *messagebustest.Broker, topic string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(publishedPayloads(t, broker, topic)) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s 上只有 %d 条消息，期望 %d", topic, len(publishedPayloads(t, broker, topic)), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBatchPublisherFlushesAtMaxSize(t *testing.T) {
+	client, err := messagebustest.NewMockClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	batch := client.NewBatchPublisher("test/batch", messagebus.BatchOptions{MaxSize: 3, FlushInterval: time.Hour})
+	for _, text := range []string{"a", "b"} {
+		if err := batch.Add(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := batch.Pending(); n != 2 || len(client.Published()) != 0 {
+		t.Fatalf("未达到 MaxSize 时 Pending = %d，已发布 %d 条", n, len(client.Published()))
+	}
+	if err := batch.Add("c"); err != nil {
+		t.Fatal(err)
+	}
+	if n := batch.Pending(); n != 0 || len(client.Published()) != 3 {
+		t.Fatalf("达到 MaxSize 后 Pending = %d，已发布 %d 条，期望立即发布 3 条", n, len(client.Published()))
+	}
+
+	if err := batch.Add("d"); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(client.Published()); n != 4 {
+		t.Errorf("Close 后已发布 %d 条，期望发布剩余消息共 4 条", n)
+	}
+	if err := batch.Add("e"); err == nil {
+		t.Error("关闭后 Add 应返回错误")
+	}
+}
+
+func TestBatchPublisherFlushesOnInterval(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	client, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	batch := client.NewBatchPublisher("test/batch", messagebus.BatchOptions{FlushInterval: 20 * time.Millisecond})
+	if err := batch.Add("tick"); err != nil {
+		t.Fatal(err)
+	}
+	waitForPublished(t, broker, "test/batch", 1)
+
+	// 断开连接时刷新剩余消息并关闭发布器
+	batch = client.NewBatchPublisher("test/batch/stop", messagebus.BatchOptions{FlushInterval: time.Hour})
+	for _, text := range []string{"x", "y"} {
+		if err := batch.Add(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := client.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if got := publishedPayloads(t, broker, "test/batch/stop"); len(got) != 2 {
+		t.Errorf("断开连接后发布了 %v，期望刷新剩余的 2 条消息", got)
+	}
+	if err := batch.Add("z"); err == nil {
+		t.Error("客户端断开后 Add 应返回错误")
+	}
+}
+
+func TestPublishBatch(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	client, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	messages := make([]interface{}, 20)
+	for i := range messages {
+		messages[i] = map[string]int{"n": i}
+	}
+	if err := client.PublishBatch("test/batch", messages); err != nil {
+		t.Fatal(err)
+	}
+	if got := publishedPayloads(t, broker, "test/batch"); len(got) != 20 {
+		t.Errorf("发布了 %d 条消息，期望 20", len(got))
+	}
+	if err := client.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.PublishBatch("test/batch", messages); err == nil {
+		t.Error("未连接时 PublishBatch 应返回错误")
+	}
+}
@@ -0,0 +1,345 @@
+// Package bench 为 messagebus 客户端生成可配置速率、大小和主题分布的压测流量，
+// 统计发布延迟与经回环订阅测得的端到端投递延迟，并输出 JSON 或 CSV 报告用于容量规划
+package bench
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+)
+
+// HeaderSentAt 是压测消息携带发送时间（Unix 纳秒）的 QueryParams 键，用于计算端到端延迟
+const HeaderSentAt = "x-bench-sent-at"
+
+// TopicPlaceholder 在 Config.Topic 中会被替换为主题序号 (0 ~ Topics-1)
+const TopicPlaceholder = "{n}"
+
+// 压测参数的默认值
+const (
+	defaultCount = 1000
+	defaultSize  = 256
+	defaultWait  = 5 * time.Second
+)
+
+// Config 表示压测参数
+type Config struct {
+	// Topic 发布主题，可包含 {n} 占位符将消息轮流发布到 Topics 个主题，默认为 edgex/bench/<随机 ID>/{n}
+	Topic string
+	// Topics 主题数，默认 1
+	Topics int
+	// Count 发布的消息总数，默认 1000；设置 Duration 时以先达到者为准
+	Count int
+	// Duration 最长发布时长，0 表示只受 Count 限制
+	Duration time.Duration
+	// Size 每条消息的 Payload 字节数，默认 256
+	Size int
+	// Rate 所有发布者合计每秒发布的消息数，0 表示不限速
+	Rate float64
+	// Publishers 并发发布的 goroutine 数，默认 1
+	Publishers int
+	// NoLoopback 为 true 时不订阅压测主题，只统计发布延迟
+	NoLoopback bool
+	// Wait 发布完成后等待回环消息的最长时间，默认 5 秒
+	Wait time.Duration
+}
+
+// Latency 表示一组延迟的统计
+type Latency struct {
+	Samples int           `json:"samples"`
+	Mean    time.Duration `json:"mean"`
+	P50     time.Duration `json:"p50"`
+	P90     time.Duration `json:"p90"`
+	P95     time.Duration `json:"p95"`
+	P99     time.Duration `json:"p99"`
+	Max     time.Duration `json:"max"`
+}
+
+// Report 表示一次压测的结果
+type Report struct {
+	Topic          string        `json:"topic"`
+	Topics         int           `json:"topics"`
+	Size           int           `json:"size"`
+	Publishers     int           `json:"publishers"`
+	TargetRate     float64       `json:"targetRate"`
+	Published      int           `json:"published"`
+	PublishErrors  int           `json:"publishErrors"`
+	Received       int           `json:"received"`
+	Lost           int           `json:"lost"` // 开启回环时未在等待时间内收到的消息数
+	Duration       time.Duration `json:"duration"`
+	PublishRate    float64       `json:"publishRate"` // 每秒发布成功的消息数
+	ReceiveRate    float64       `json:"receiveRate"` // 每秒收到的回环消息数
+	Throughput     float64       `json:"throughputBytes"`
+	PublishLatency Latency       `json:"publishLatency"`
+	EndToEnd       Latency       `json:"endToEndLatency"`
+}
+
+// withDefaults 返回填充默认值后的压测参数
+func (c Config) withDefaults() Config {
+	if c.Topic == "" {
+		c.Topic = "edgex/bench/" + uuid.NewString() + "/" + TopicPlaceholder
+	}
+	if c.Topics <= 0 {
+		c.Topics = 1
+	}
+	if c.Count <= 0 {
+		c.Count = defaultCount
+	}
+	if c.Size <= 0 {
+		c.Size = defaultSize
+	}
+	if c.Publishers <= 0 {
+		c.Publishers = 1
+	}
+	if c.Wait <= 0 {
+		c.Wait = defaultWait
+	}
+	return c
+}
+
+// validate 校验压测参数
+func (c Config) validate() error {
+	if c.Topics < 0 || c.Count < 0 || c.Size < 0 || c.Rate < 0 || c.Publishers < 0 || c.Duration < 0 || c.Wait < 0 {
+		return fmt.Errorf("压测参数不能为负数")
+	}
+	if strings.ContainsAny(c.Topic, "+#*>") {
+		return fmt.Errorf("压测主题不能包含通配符: %s", c.Topic)
+	}
+	if c.Topic != "" && c.Topics > 1 && !strings.Contains(c.Topic, TopicPlaceholder) {
+		return fmt.Errorf("Topics 大于 1 时主题须包含 %s 占位符", TopicPlaceholder)
+	}
+	return nil
+}
+
+// topic 返回第 i 条消息的发布主题
+func (c Config) topic(i int) string {
+	return strings.ReplaceAll(c.Topic, TopicPlaceholder, strconv.Itoa(i%c.Topics))
+}
+
+// recorder 收集延迟样本
+type recorder struct {
+	mutex   sync.Mutex
+	samples []time.Duration
+}
+
+func (r *recorder) add(d time.Duration) {
+	r.mutex.Lock()
+	r.samples = append(r.samples, d)
+	r.mutex.Unlock()
+}
+
+// latency 计算延迟统计，分位数使用最近秩法
+func (r *recorder) latency() Latency {
+	r.mutex.Lock()
+	samples := append([]time.Duration(nil), r.samples...)
+	r.mutex.Unlock()
+	if len(samples) == 0 {
+		return Latency{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	var total time.Duration
+	for _, s := range samples {
+		total += s
+	}
+	percentile := func(p float64) time.Duration {
+		rank := int(p*float64(len(samples))+0.999999) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		return samples[rank]
+	}
+	return Latency{
+		Samples: len(samples),
+		Mean:    total / time.Duration(len(samples)),
+		P50:     percentile(0.50),
+		P90:     percentile(0.90),
+		P95:     percentile(0.95),
+		P99:     percentile(0.99),
+		Max:     samples[len(samples)-1],
+	}
+}
+
+// Run 使用已连接的客户端执行压测，ctx 取消时提前结束并返回已收集的结果
+func Run(ctx context.Context, client *messagebus.Client, config Config) (*Report, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	config = config.withDefaults()
+	var publishLatency, endToEnd recorder
+	var received atomic.Int64
+	if !config.NoLoopback {
+		topics := make([]string, 0, config.Topics)
+		for i := 0; i < config.Topics; i++ {
+			topics = append(topics, config.topic(i))
+		}
+		err := client.Subscribe(topics, func(_ string, message types.MessageEnvelope) error {
+			if sent, err := strconv.ParseInt(message.QueryParams[HeaderSentAt], 10, 64); err == nil {
+				endToEnd.add(time.Since(time.Unix(0, sent)))
+			}
+			received.Add(1)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("订阅压测主题失败: %w", err)
+		}
+		defer client.Unsubscribe(topics...)
+	}
+
+	payload := make([]byte, config.Size)
+	if _, err := rand.Read(payload); err != nil {
+		return nil, err
+	}
+	publishCtx := ctx
+	if config.Duration > 0 {
+		var cancel context.CancelFunc
+		publishCtx, cancel = context.WithTimeout(ctx, config.Duration)
+		defer cancel()
+	}
+	var interval time.Duration
+	if config.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / config.Rate)
+	}
+
+	var next, published, failed atomic.Int64
+	var wg sync.WaitGroup
+	start := time.Now()
+	for p := 0; p < config.Publishers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= config.Count || publishCtx.Err() != nil {
+					return
+				}
+				if interval > 0 && sleepUntil(publishCtx, start.Add(time.Duration(i)*interval)) != nil {
+					return
+				}
+				sentAt := time.Now()
+				envelope := types.MessageEnvelope{
+					CorrelationID: uuid.NewString(),
+					ContentType:   "application/octet-stream",
+					QueryParams:   map[string]string{HeaderSentAt: strconv.FormatInt(sentAt.UnixNano(), 10)},
+					Payload:       payload,
+				}
+				if err := client.PublishEnvelope(config.topic(i), envelope); err != nil {
+					failed.Add(1)
+					continue
+				}
+				publishLatency.add(time.Since(sentAt))
+				published.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	publishElapsed := time.Since(start)
+
+	if !config.NoLoopback {
+		deadline := time.NewTimer(config.Wait)
+		ticker := time.NewTicker(10 * time.Millisecond)
+	wait:
+		for received.Load() < published.Load() {
+			select {
+			case <-ticker.C:
+			case <-deadline.C:
+				break wait
+			case <-ctx.Done():
+				break wait
+			}
+		}
+		ticker.Stop()
+		deadline.Stop()
+	}
+	elapsed := time.Since(start)
+
+	report := &Report{
+		Topic:          config.Topic,
+		Topics:         config.Topics,
+		Size:           config.Size,
+		Publishers:     config.Publishers,
+		TargetRate:     config.Rate,
+		Published:      int(published.Load()),
+		PublishErrors:  int(failed.Load()),
+		Received:       int(received.Load()),
+		Duration:       elapsed,
+		PublishRate:    float64(published.Load()) / publishElapsed.Seconds(),
+		Throughput:     float64(published.Load()) * float64(config.Size) / publishElapsed.Seconds(),
+		PublishLatency: publishLatency.latency(),
+		EndToEnd:       endToEnd.latency(),
+	}
+	if !config.NoLoopback {
+		report.Lost = max(report.Published-report.Received, 0)
+		report.ReceiveRate = float64(report.Received) / elapsed.Seconds()
+	}
+	return report, nil
+}
+
+// sleepUntil 等待到 deadline 或 ctx 取消
+func sleepUntil(ctx context.Context, deadline time.Time) error {
+	d := time.Until(deadline)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WriteJSON 以缩进的 JSON 输出报告，延迟以纳秒为单位
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// csvHeader 是 CSV 报告的列名，延迟以毫秒为单位
+var csvHeader = []string{
+	"topic", "topics", "size", "publishers", "target_rate", "published", "publish_errors", "received", "lost",
+	"duration_ms", "publish_rate", "receive_rate", "throughput_bytes",
+	"publish_p50_ms", "publish_p90_ms", "publish_p95_ms", "publish_p99_ms", "publish_max_ms",
+	"e2e_p50_ms", "e2e_p90_ms", "e2e_p95_ms", "e2e_p99_ms", "e2e_max_ms",
+}
+
+// WriteCSV 输出一行 CSV 报告，header 为 true 时先输出列名，便于多次压测追加到同一文件
+func (r *Report) WriteCSV(w io.Writer, header bool) error {
+	writer := csv.NewWriter(w)
+	if header {
+		if err := writer.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	ms := func(d time.Duration) string {
+		return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+	}
+	num := func(f float64) string { return strconv.FormatFloat(f, 'f', 1, 64) }
+	row := []string{
+		r.Topic, strconv.Itoa(r.Topics), strconv.Itoa(r.Size), strconv.Itoa(r.Publishers), num(r.TargetRate),
+		strconv.Itoa(r.Published), strconv.Itoa(r.PublishErrors), strconv.Itoa(r.Received), strconv.Itoa(r.Lost),
+		ms(r.Duration), num(r.PublishRate), num(r.ReceiveRate), num(r.Throughput),
+		ms(r.PublishLatency.P50), ms(r.PublishLatency.P90), ms(r.PublishLatency.P95), ms(r.PublishLatency.P99), ms(r.PublishLatency.Max),
+		ms(r.EndToEnd.P50), ms(r.EndToEnd.P90), ms(r.EndToEnd.P95), ms(r.EndToEnd.P99), ms(r.EndToEnd.Max),
+	}
+	if err := writer.Write(row); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
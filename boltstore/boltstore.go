@@ -0,0 +1,171 @@
+// Package boltstore 提供基于 bbolt 的持久化存储转发后端，进程重启后暂存消息不会丢失
+package boltstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucketName 存放暂存消息的 bucket，键为单调递增的序号
+var bucketName = []byte("outbox")
+
+// Store 是基于 bbolt 文件的 messagebus.OutboxStore 实现，超过 MaxMessages 时丢弃最旧的消息
+type Store struct {
+	db          *bolt.DB
+	maxMessages int
+	mutex       sync.Mutex // 保护 count，与写事务一同更新
+	count       int
+}
+
+// record 是消息在磁盘上的编码形式，[]byte 与其他 Payload 分开保存以保持发布时的类型
+type record struct {
+	Topic    string                `json:"topic"`
+	QueuedAt time.Time             `json:"queuedAt"`
+	Envelope types.MessageEnvelope `json:"envelope"`
+	Bytes    []byte                `json:"bytes,omitempty"`
+	Raw      json.RawMessage       `json:"raw,omitempty"`
+}
+
+// Open 打开或创建指定路径的存储文件，maxMessages <= 0 表示不限制消息数
+func Open(path string, maxMessages int) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("打开存储文件 %s 失败: %w", path, err)
+	}
+	store := &Store{db: db, maxMessages: maxMessages}
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(bucketName)
+		if err != nil {
+			return err
+		}
+		return bucket.ForEach(func(_, _ []byte) error {
+			store.count++
+			return nil
+		})
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("初始化存储文件失败: %w", err)
+	}
+	return store, nil
+}
+
+// Close 关闭存储文件
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Append 实现 messagebus.OutboxStore
+func (s *Store) Append(message messagebus.QueuedMessage) error {
+	rec := record{Topic: message.Topic, QueuedAt: message.QueuedAt, Envelope: message.Envelope}
+	switch payload := message.Envelope.Payload.(type) {
+	case []byte:
+		rec.Bytes = payload
+	case nil:
+	default:
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("编码Payload失败: %w", err)
+		}
+		rec.Raw = raw
+	}
+	rec.Envelope.Payload = nil
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	count := s.count
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(itob(seq), data); err != nil {
+			return err
+		}
+		count++
+		cursor := bucket.Cursor()
+		for s.maxMessages > 0 && count > s.maxMessages {
+			if key, _ := cursor.First(); key == nil {
+				break
+			}
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+			count--
+		}
+		return nil
+	})
+	if err == nil {
+		s.count = count
+	}
+	return err
+}
+
+// Peek 实现 messagebus.OutboxStore
+func (s *Store) Peek() (messagebus.QueuedMessage, bool, error) {
+	var rec record
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		_, data := tx.Bucket(bucketName).Cursor().First()
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &rec)
+	})
+	if err != nil || !found {
+		return messagebus.QueuedMessage{}, false, err
+	}
+	envelope := rec.Envelope
+	switch {
+	case rec.Bytes != nil:
+		envelope.Payload = rec.Bytes
+	case rec.Raw != nil:
+		envelope.Payload = rec.Raw
+	}
+	return messagebus.QueuedMessage{Topic: rec.Topic, Envelope: envelope, QueuedAt: rec.QueuedAt}, true, nil
+}
+
+// Remove 实现 messagebus.OutboxStore
+func (s *Store) Remove() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	removed := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(bucketName).Cursor()
+		if key, _ := cursor.First(); key == nil {
+			return nil
+		}
+		removed = true
+		return cursor.Delete()
+	})
+	if err == nil && removed {
+		s.count--
+	}
+	return err
+}
+
+// Len 实现 messagebus.OutboxStore
+func (s *Store) Len() (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.count, nil
+}
+
+// itob 将序号编码为大端字节，保证键按写入顺序排列
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
@@ -0,0 +1,108 @@
+package boltstore_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/boltstore"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+func TestStorePersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.db")
+	store, err := boltstore.Open(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queuedAt := time.Now().Truncate(time.Millisecond)
+	messages := []messagebus.QueuedMessage{
+		{Topic: "test/a", QueuedAt: queuedAt, Envelope: types.MessageEnvelope{CorrelationID: "1", Payload: []byte("raw")}},
+		{Topic: "test/b", QueuedAt: queuedAt, Envelope: types.MessageEnvelope{CorrelationID: "2", Payload: map[string]int{"n": 2}}},
+		{Topic: "test/c", QueuedAt: queuedAt, Envelope: types.MessageEnvelope{CorrelationID: "3"}},
+	}
+	for _, msg := range messages {
+		if err := store.Append(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = boltstore.Open(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if n, _ := store.Len(); n != len(messages) {
+		t.Fatalf("重新打开后 Len = %d，期望 %d", n, len(messages))
+	}
+	for i, want := range messages {
+		got, ok, err := store.Peek()
+		if err != nil || !ok {
+			t.Fatalf("第 %d 条消息 Peek = %v, %v", i+1, ok, err)
+		}
+		if got.Topic != want.Topic || got.Envelope.CorrelationID != want.Envelope.CorrelationID || !got.QueuedAt.Equal(want.QueuedAt) {
+			t.Errorf("第 %d 条消息 = %+v，期望 %+v", i+1, got, want)
+		}
+		if err := store.Remove(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, _ := store.Len(); n != 0 {
+		t.Errorf("取出所有消息后 Len = %d", n)
+	}
+}
+
+func TestStoreKeepsPayloadTypes(t *testing.T) {
+	store, err := boltstore.Open(filepath.Join(t.TempDir(), "outbox.db"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.Append(messagebus.QueuedMessage{Topic: "test/bytes", Envelope: types.MessageEnvelope{Payload: []byte("raw")}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(messagebus.QueuedMessage{Topic: "test/json", Envelope: types.MessageEnvelope{Payload: map[string]int{"n": 2}}}); err != nil {
+		t.Fatal(err)
+	}
+	msg, _, _ := store.Peek()
+	if data, ok := msg.Envelope.Payload.([]byte); !ok || string(data) != "raw" {
+		t.Errorf("[]byte Payload 取出为 %T %v", msg.Envelope.Payload, msg.Envelope.Payload)
+	}
+	_ = store.Remove()
+	msg, _, _ = store.Peek()
+	data, err := messagebus.EnvelopePayloadBytes(msg.Envelope)
+	if err != nil || string(data) != `{"n":2}` {
+		t.Errorf("JSON Payload 取出为 %s (%v)", data, err)
+	}
+}
+
+func TestStoreDropsOldestBeyondMaxMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.db")
+	store, err := boltstore.Open(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, topic := range []string{"m0", "m1", "m2"} {
+		if err := store.Append(messagebus.QueuedMessage{Topic: topic}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	store, err = boltstore.Open(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if n, _ := store.Len(); n != 2 {
+		t.Fatalf("Len = %d，期望 2", n)
+	}
+	if msg, _, _ := store.Peek(); msg.Topic != "m1" {
+		t.Errorf("队首 = %q，期望最旧的 m0 已被丢弃", msg.Topic)
+	}
+}
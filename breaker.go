@@ -0,0 +1,138 @@
+package messagebus
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// 熔断器的默认参数
+const (
+	defaultCircuitOpenDuration   = 30 * time.Second
+	defaultCircuitHalfOpenProbes = 1
+)
+
+// ErrCircuitOpen 表示发布熔断器处于打开状态，发布被直接拒绝
+var ErrCircuitOpen = errors.New("发布熔断器已打开")
+
+// CircuitState 表示发布熔断器的状态
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"   // 正常发布
+	CircuitOpen     CircuitState = "open"     // 拒绝发布，等待 OpenDuration 后进入半开
+	CircuitHalfOpen CircuitState = "halfOpen" // 放行少量试探发布，全部成功后关闭
+)
+
+// CircuitBreakerConfig 表示发布熔断器参数
+type CircuitBreakerConfig struct {
+	// FailureThreshold 连续发布失败多少次后打开熔断器，0 表示不启用
+	FailureThreshold int
+	// OpenDuration 熔断器打开后拒绝发布的时长，之后进入半开状态，默认 30 秒
+	OpenDuration time.Duration
+	// HalfOpenProbes 半开状态下放行的试探发布数，全部成功后关闭熔断器，任一失败则重新打开，默认 1
+	HalfOpenProbes int
+}
+
+// withDefaults 返回填充了默认值的熔断器配置
+func (b CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if b.OpenDuration <= 0 {
+		b.OpenDuration = defaultCircuitOpenDuration
+	}
+	if b.HalfOpenProbes <= 0 {
+		b.HalfOpenProbes = defaultCircuitHalfOpenProbes
+	}
+	return b
+}
+
+// circuitBreaker 记录发布熔断器的状态
+type circuitBreaker struct {
+	mutex     sync.Mutex
+	state     CircuitState
+	failures  int       // 关闭状态下的连续失败次数
+	openedAt  time.Time // 最近一次打开的时间
+	probes    int       // 半开状态下已放行的试探数
+	successes int       // 半开状态下成功的试探数
+}
+
+// CircuitState 返回发布熔断器的当前状态，未启用时总是 CircuitClosed
+func (c *Client) CircuitState() CircuitState {
+	c.breaker.mutex.Lock()
+	defer c.breaker.mutex.Unlock()
+	if c.breaker.state == "" {
+		return CircuitClosed
+	}
+	return c.breaker.state
+}
+
+// breakerAllow 判断当前是否允许发布，熔断器打开或半开试探名额已用完时返回 ErrCircuitOpen
+func (c *Client) breakerAllow() error {
+	if c.config.CircuitBreaker.FailureThreshold <= 0 {
+		return nil
+	}
+	cfg := c.config.CircuitBreaker.withDefaults()
+	b := &c.breaker
+	b.mutex.Lock()
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < cfg.OpenDuration {
+			b.mutex.Unlock()
+			return ErrCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+		b.probes = 1
+		b.successes = 0
+		b.mutex.Unlock()
+		c.log(LogPublish).Info("发布熔断器进入半开状态", c.logFields()...)
+		c.emitEvent(LifecycleEvent{Type: EventCircuitHalfOpen})
+		return nil
+	case CircuitHalfOpen:
+		if b.probes >= cfg.HalfOpenProbes {
+			b.mutex.Unlock()
+			return ErrCircuitOpen
+		}
+		b.probes++
+	}
+	b.mutex.Unlock()
+	return nil
+}
+
+// breakerRecord 记录一次放行发布的结果并按需切换熔断器状态
+func (c *Client) breakerRecord(err error) {
+	if c.config.CircuitBreaker.FailureThreshold <= 0 {
+		return
+	}
+	cfg := c.config.CircuitBreaker.withDefaults()
+	b := &c.breaker
+	b.mutex.Lock()
+	var event *LifecycleEvent
+	switch b.state {
+	case CircuitHalfOpen:
+		if err != nil {
+			b.state, b.openedAt = CircuitOpen, time.Now()
+			event = &LifecycleEvent{Type: EventCircuitOpen, Err: err}
+		} else if b.successes++; b.successes >= cfg.HalfOpenProbes {
+			b.state, b.failures = CircuitClosed, 0
+			event = &LifecycleEvent{Type: EventCircuitClosed}
+		}
+	case CircuitOpen:
+		// 打开前已放行的发布在打开后才完成，不影响状态
+	default:
+		if err == nil {
+			b.failures = 0
+		} else if b.failures++; b.failures >= cfg.FailureThreshold {
+			b.state, b.openedAt = CircuitOpen, time.Now()
+			event = &LifecycleEvent{Type: EventCircuitOpen, Attempt: b.failures, Err: err}
+		}
+	}
+	b.mutex.Unlock()
+	if event == nil {
+		return
+	}
+	if event.Type == EventCircuitOpen {
+		c.log(LogPublish).Warn("发布连续失败，熔断器已打开", c.logFields("openDuration", cfg.OpenDuration, "error", err)...)
+	} else {
+		c.log(LogPublish).Info("发布熔断器已关闭", c.logFields()...)
+	}
+	c.emitEvent(*event)
+}
@@ -0,0 +1,103 @@
+package messagebus_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// failingPublisher 在 fail 为 true 时使 Publish 失败
+type failingPublisher struct {
+	messaging.MessageClient
+	fail *atomic.Bool
+}
+
+func (c *failingPublisher) Publish(message types.MessageEnvelope, topic string) error {
+	if c.fail.Load() {
+		return errors.New("broker 拒绝发布")
+	}
+	return c.MessageClient.Publish(message, topic)
+}
+
+func TestCircuitBreakerOpensAndHalfOpens(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	factory := broker.MessageClientFactory("breaker")
+	var fail atomic.Bool
+	config := testConfig()
+	config.CircuitBreaker = messagebus.CircuitBreakerConfig{FailureThreshold: 2, OpenDuration: 50 * time.Millisecond}
+	client, err := messagebus.NewClientWithOptions(
+		messagebus.WithConfig(config),
+		messagebus.WithLogger(logger.NewMockClient()),
+		messagebus.WithMessageClientFactory(func(busConfig types.MessageBusConfig) (messaging.MessageClient, error) {
+			inner, err := factory(busConfig)
+			if err != nil {
+				return nil, err
+			}
+			return &failingPublisher{MessageClient: inner, fail: &fail}, nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	collectEvents(t, client, messagebus.EventConnected)
+
+	// openAfterFailures 连续发布失败直到熔断器打开，之后的发布被直接拒绝
+	openAfterFailures := func() {
+		t.Helper()
+		fail.Store(true)
+		for i := 0; i < 2; i++ {
+			if err := client.Publish("test/breaker", "x"); err == nil || errors.Is(err, messagebus.ErrCircuitOpen) {
+				t.Fatalf("第 %d 次发布应因 Broker 失败，实际 %v", i+1, err)
+			}
+		}
+		collectEvents(t, client, messagebus.EventCircuitOpen)
+		if state := client.CircuitState(); state != messagebus.CircuitOpen {
+			t.Fatalf("连续失败后状态 = %s，期望 open", state)
+		}
+		fail.Store(false)
+		if err := client.Publish("test/breaker", "x"); !errors.Is(err, messagebus.ErrCircuitOpen) {
+			t.Fatalf("熔断器打开时发布应返回 ErrCircuitOpen，实际 %v", err)
+		}
+	}
+
+	openAfterFailures()
+	published := len(broker.Published())
+	time.Sleep(60 * time.Millisecond)
+	// OpenDuration 过后放行一次试探发布，成功后关闭
+	if err := client.Publish("test/breaker", "probe"); err != nil {
+		t.Fatal(err)
+	}
+	collectEvents(t, client, messagebus.EventCircuitClosed)
+	if state := client.CircuitState(); state != messagebus.CircuitClosed {
+		t.Fatalf("试探成功后状态 = %s，期望 closed", state)
+	}
+	if n := len(broker.Published()); n != published+1 {
+		t.Errorf("试探发布后 Broker 收到 %d 条消息，期望 %d", n, published+1)
+	}
+
+	// 半开状态下试探失败则重新打开
+	openAfterFailures()
+	time.Sleep(60 * time.Millisecond)
+	fail.Store(true)
+	if err := client.Publish("test/breaker", "probe"); err == nil || errors.Is(err, messagebus.ErrCircuitOpen) {
+		t.Fatalf("试探发布应因 Broker 失败，实际 %v", err)
+	}
+	got := collectEvents(t, client, messagebus.EventCircuitOpen)
+	if len(got) == 0 || got[0] != messagebus.EventCircuitHalfOpen {
+		t.Errorf("事件序列 %v，期望先进入半开再打开", got)
+	}
+	if state := client.CircuitState(); state != messagebus.CircuitOpen {
+		t.Fatalf("试探失败后状态 = %s，期望 open", state)
+	}
+}
@@ -0,0 +1,272 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// HeaderBridgeVia 是信封 QueryParams 中记录消息已经过的桥接名称（逗号分隔）的键，用于防止环路
+const HeaderBridgeVia = "x-bridge-via"
+
+// defaultBridgeMaxHops 是未指定 MaxHops 时消息最多经过的桥接数
+const defaultBridgeMaxHops = 8
+
+// BridgeRule 表示一条桥接转发规则
+type BridgeRule struct {
+	// Source 在源客户端上订阅的主题，可包含通配符
+	Source string
+	// Target 目标主题，为空时沿用原主题；Source 与 Target 均以 # (或 >) 结尾时用 Target 的前缀替换匹配的前缀，
+	// 例如 edgex/events/# → site42/edgex/events/#
+	Target string
+	// Rewrite 自定义主题改写，设置后忽略 Target
+	Rewrite func(topic string) string
+	// SourceQoS 源订阅使用的 QoS，nil 表示沿用源客户端配置
+	SourceQoS *int
+	// TargetQoS 目标发布使用的 QoS，nil 表示沿用目标客户端配置（含离线存储转发与 ConfirmPublish）
+	TargetQoS *int
+}
+
+// BridgeConfig 表示桥接参数
+type BridgeConfig struct {
+	// Name 桥接名称，写入 HeaderBridgeVia 用于防止环路；默认由两端 ClientID 排序后组成，
+	// 因此同一对客户端之间的双向桥接默认同名，消息不会被转发回来源
+	Name string
+	// MaxHops 消息最多经过的桥接数，超过时丢弃，默认 8
+	MaxHops int
+	// Rules 转发规则
+	Rules []BridgeRule
+}
+
+// BridgeStats 表示桥接的运行统计
+type BridgeStats struct {
+	Forwarded    uint64 // 转发成功的消息数
+	DroppedLoops uint64 // 因已经过本桥接或超过 MaxHops 被丢弃的消息数
+	Errors       uint64 // 转发失败的次数
+}
+
+// Bridge 将源客户端上匹配规则的消息转发到目标客户端，例如将本地 MQTT 的部分主题复制到云端 NATS
+//
+// 桥接占用源客户端上规则的订阅主题，同一客户端上对相同主题的其他订阅会被替换，建议为桥接使用专用客户端。
+// 转发的信封保留 Payload 与元数据，经过目标客户端的发布拦截器、压缩与加密后发出。
+type Bridge struct {
+	source  *Client
+	target  *Client
+	name    string
+	maxHops int
+	rules   []BridgeRule
+
+	mutex   sync.Mutex
+	started bool
+
+	forwarded atomic.Uint64
+	loops     atomic.Uint64
+	errors    atomic.Uint64
+}
+
+// NewBridge 校验规则并创建从 source 到 target 的桥接，调用 Start 后开始转发
+func NewBridge(source, target *Client, config BridgeConfig) (*Bridge, error) {
+	if source == nil || target == nil {
+		return nil, fmt.Errorf("桥接的源客户端和目标客户端不能为空")
+	}
+	if source == target {
+		return nil, fmt.Errorf("桥接的源客户端和目标客户端不能相同")
+	}
+	if len(config.Rules) == 0 {
+		return nil, fmt.Errorf("桥接至少需要一条规则")
+	}
+	name := config.Name
+	if name == "" {
+		if source.config.ClientID == "" || target.config.ClientID == "" {
+			return nil, fmt.Errorf("客户端未设置 ClientID 时必须指定桥接 Name")
+		}
+		ids := []string{source.config.ClientID, target.config.ClientID}
+		sort.Strings(ids)
+		name = ids[0] + "<>" + ids[1]
+	}
+	if strings.Contains(name, ",") {
+		return nil, fmt.Errorf("桥接 Name 不能包含逗号")
+	}
+	maxHops := config.MaxHops
+	if maxHops <= 0 {
+		maxHops = defaultBridgeMaxHops
+	}
+	seen := make(map[string]struct{}, len(config.Rules))
+	for _, rule := range config.Rules {
+		if err := rule.validate(target.config.Type); err != nil {
+			return nil, err
+		}
+		if _, ok := seen[rule.Source]; ok {
+			return nil, fmt.Errorf("桥接规则的源主题 %s 重复", rule.Source)
+		}
+		seen[rule.Source] = struct{}{}
+	}
+	return &Bridge{
+		source:  source,
+		target:  target,
+		name:    name,
+		maxHops: maxHops,
+		rules:   append([]BridgeRule(nil), config.Rules...),
+	}, nil
+}
+
+// validate 校验桥接规则
+func (r BridgeRule) validate(targetType string) error {
+	if strings.TrimSpace(r.Source) == "" {
+		return fmt.Errorf("桥接规则的源主题不能为空")
+	}
+	if r.Rewrite == nil && strings.ContainsAny(r.Target, "+*#>") {
+		if !isPrefixPattern(r.Target) || !isPrefixPattern(r.Source) {
+			return fmt.Errorf("桥接规则 %s → %s 的目标主题只能以 # 结尾，且源主题也须以 # 结尾", r.Source, r.Target)
+		}
+	}
+	if r.SourceQoS != nil && (*r.SourceQoS < 0 || *r.SourceQoS > 2) {
+		return fmt.Errorf("桥接规则 %s 的 SourceQoS 必须为 0、1 或 2", r.Source)
+	}
+	if r.TargetQoS != nil {
+		if err := (PublishOptions{QoS: *r.TargetQoS}).validate(targetType); err != nil {
+			return fmt.Errorf("桥接规则 %s 的 TargetQoS 无效: %w", r.Source, err)
+		}
+	}
+	return nil
+}
+
+// isPrefixPattern 判断主题是否只在末尾包含多层通配符
+func isPrefixPattern(topic string) bool {
+	prefix, ok := wildcardPrefix(topic)
+	return ok && !strings.ContainsAny(prefix, "+*#>")
+}
+
+// wildcardPrefix 返回以 # (或 >) 结尾的主题去掉通配符后的前缀（不含末尾的 /）
+func wildcardPrefix(topic string) (string, bool) {
+	if topic == "#" || topic == ">" {
+		return "", true
+	}
+	if strings.HasSuffix(topic, "/#") || strings.HasSuffix(topic, "/>") {
+		return topic[:len(topic)-2], true
+	}
+	return "", false
+}
+
+// targetTopic 返回消息转发到的目标主题
+func (r BridgeRule) targetTopic(topic string) string {
+	switch {
+	case r.Rewrite != nil:
+		return r.Rewrite(topic)
+	case r.Target == "":
+		return topic
+	}
+	targetPrefix, ok := wildcardPrefix(r.Target)
+	if !ok {
+		return r.Target
+	}
+	sourcePrefix, _ := wildcardPrefix(r.Source)
+	rest := topic
+	if sourcePrefix != "" {
+		rest = strings.TrimPrefix(strings.TrimPrefix(topic, sourcePrefix), "/")
+	}
+	switch {
+	case targetPrefix == "":
+		return rest
+	case rest == "":
+		return targetPrefix
+	default:
+		return targetPrefix + "/" + rest
+	}
+}
+
+// Start 在源客户端上订阅所有规则的主题并开始转发，任一订阅失败时取消已建立的订阅
+func (b *Bridge) Start() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.started {
+		return fmt.Errorf("桥接 %s 已启动", b.name)
+	}
+	var subscribed []string
+	for _, rule := range b.rules {
+		opts := SubscribeOptions{QoS: rule.SourceQoS}
+		if err := b.source.SubscribeWithOptions([]string{rule.Source}, b.forward(rule), opts); err != nil {
+			if len(subscribed) > 0 {
+				_ = b.source.Unsubscribe(subscribed...)
+			}
+			return fmt.Errorf("启动桥接 %s 失败: %w", b.name, err)
+		}
+		subscribed = append(subscribed, rule.Source)
+	}
+	b.started = true
+	b.source.log(LogPublish).Info("桥接已启动", b.source.logFields("bridge", b.name, "rules", len(b.rules))...)
+	return nil
+}
+
+// Stop 取消源客户端上的桥接订阅，停止转发
+func (b *Bridge) Stop() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.started {
+		return nil
+	}
+	topics := make([]string, len(b.rules))
+	for i, rule := range b.rules {
+		topics[i] = rule.Source
+	}
+	b.started = false
+	if err := b.source.Unsubscribe(topics...); err != nil {
+		return fmt.Errorf("停止桥接 %s 失败: %w", b.name, err)
+	}
+	b.source.log(LogPublish).Info("桥接已停止", b.source.logFields("bridge", b.name)...)
+	return nil
+}
+
+// Stats 返回桥接的运行统计
+func (b *Bridge) Stats() BridgeStats {
+	return BridgeStats{
+		Forwarded:    b.forwarded.Load(),
+		DroppedLoops: b.loops.Load(),
+		Errors:       b.errors.Load(),
+	}
+}
+
+// forward 返回按规则转发消息的处理函数
+func (b *Bridge) forward(rule BridgeRule) MessageHandler {
+	return func(topic string, message types.MessageEnvelope) error {
+		var via []string
+		if value := message.QueryParams[HeaderBridgeVia]; value != "" {
+			via = strings.Split(value, ",")
+		}
+		if len(via) >= b.maxHops || containsString(via, b.name) {
+			b.loops.Add(1)
+			return nil
+		}
+		message.QueryParams = copyQueryParams(message.QueryParams)
+		message.QueryParams[HeaderBridgeVia] = strings.Join(append(via, b.name), ",")
+		message.ReceivedTopic = ""
+		target := rule.targetTopic(topic)
+		var err error
+		if rule.TargetQoS == nil {
+			err = b.target.publishEnvelope(context.Background(), target, message)
+		} else {
+			err = b.target.publishEnvelopeWithOptions(context.Background(), target, message, &PublishOptions{QoS: *rule.TargetQoS})
+		}
+		if err != nil {
+			b.errors.Add(1)
+			return fmt.Errorf("桥接 %s 将 %s 转发到 %s 失败: %w", b.name, topic, target, err)
+		}
+		b.forwarded.Add(1)
+		return nil
+	}
+}
+
+// containsString 判断 values 中是否包含 s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package messagebus_test
+
+import (
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+func TestBridgeForwardsAndStopsLoops(t *testing.T) {
+	localBroker, cloudBroker := messagebustest.NewBroker(), messagebustest.NewBroker()
+	newClient := func(broker *messagebustest.Broker, clientID string) *messagebustest.MockClient {
+		client, err := messagebustest.NewMockClientWithBroker(broker, messagebus.WithClientID(clientID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+		return client
+	}
+	local, cloud := newClient(localBroker, "local"), newClient(cloudBroker, "cloud")
+	device, app := newClient(localBroker, "device"), newClient(cloudBroker, "app")
+
+	uplink, err := messagebus.NewBridge(local.Client, cloud.Client, messagebus.BridgeConfig{Rules: []messagebus.BridgeRule{
+		{Source: "edgex/events/#", Target: "site42/edgex/events/#"},
+		{Source: "edgex/alerts", Rewrite: func(topic string) string { return "site42/" + topic }},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 反向桥接与 uplink 连接同一对客户端，默认同名，转发过去的消息不会被转发回来
+	downlink, err := messagebus.NewBridge(cloud.Client, local.Client, messagebus.BridgeConfig{Rules: []messagebus.BridgeRule{
+		{Source: "site42/#", Target: "edgex/#"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bridge := range []*messagebus.Bridge{uplink, downlink} {
+		if err := bridge.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = bridge.Stop() })
+	}
+	received := make(chan types.MessageEnvelope, 16)
+	if err := app.Subscribe([]string{"site42/#"}, func(_ string, msg types.MessageEnvelope) error {
+		received <- msg
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := device.Publish("edgex/events/device/d1", "reading"); err != nil {
+		t.Fatal(err)
+	}
+	if err := device.Publish("edgex/alerts", "alert"); err != nil {
+		t.Fatal(err)
+	}
+	// 两条规则各自订阅，转发顺序不确定，按主题比对
+	want := map[string]string{"site42/edgex/events/device/d1": "reading", "site42/edgex/alerts": "alert"}
+	for n := len(want); n > 0; n-- {
+		msg := receiveOne(t, received)
+		data, _ := messagebus.EnvelopePayloadBytes(msg)
+		if payload, ok := want[msg.ReceivedTopic]; !ok || string(data) != payload {
+			t.Errorf("云端收到 %s: %q，期望 %v", msg.ReceivedTopic, data, want)
+		}
+		delete(want, msg.ReceivedTopic)
+		if msg.QueryParams[messagebus.HeaderBridgeVia] != "cloud<>local" {
+			t.Errorf("%s = %q，期望记录经过的桥接", messagebus.HeaderBridgeVia, msg.QueryParams[messagebus.HeaderBridgeVia])
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for downlink.Stats().DroppedLoops < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stats := uplink.Stats(); stats.Forwarded != 2 || stats.DroppedLoops != 0 {
+		t.Errorf("uplink 统计 = %+v，期望转发 2 条", stats)
+	}
+	if stats := downlink.Stats(); stats.Forwarded != 0 || stats.DroppedLoops != 2 {
+		t.Errorf("downlink 统计 = %+v，期望丢弃 2 条环路消息", stats)
+	}
+	for _, msg := range localBroker.Published() {
+		if msg.Topic != "edgex/events/device/d1" && msg.Topic != "edgex/alerts" {
+			t.Errorf("消息被转发回本地: %s", msg.Topic)
+		}
+	}
+
+	// 停止后不再转发
+	if err := uplink.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := device.Publish("edgex/events/device/d1", "late"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-received:
+		t.Errorf("桥接停止后仍转发了 %s", msg.ReceivedTopic)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,119 @@
+// Package brokertest 在进程内启动嵌入式 MQTT Broker，用于发布、订阅和请求-响应的端到端测试
+//
+// 与 messagebustest 的内存实现不同，这里的客户端通过真实的 go-mod-messaging MQTT 客户端和 TCP 连接收发消息，
+// 适合在 CI 中运行而无需外部 Broker。
+package brokertest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"testing"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/google/uuid"
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+// MQTTBroker 是监听本地随机端口的嵌入式 MQTT Broker，允许任意客户端连接
+type MQTTBroker struct {
+	Host   string // 监听地址，固定为 127.0.0.1
+	Port   int    // 实际监听端口
+	server *mqtt.Server
+}
+
+// StartMQTT 启动嵌入式 MQTT Broker，测试结束时自动关闭
+func StartMQTT(t testing.TB) *MQTTBroker {
+	t.Helper()
+	broker, err := NewMQTTBroker()
+	if err != nil {
+		t.Fatalf("启动嵌入式 MQTT Broker 失败: %v", err)
+	}
+	t.Cleanup(func() { _ = broker.Close() })
+	return broker
+}
+
+// NewMQTTBroker 启动嵌入式 MQTT Broker，调用方负责调用 Close
+func NewMQTTBroker() (*MQTTBroker, error) {
+	server := mqtt.New(&mqtt.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		return nil, err
+	}
+	tcp := listeners.NewTCP(listeners.Config{ID: "brokertest", Address: "127.0.0.1:0"})
+	if err := server.AddListener(tcp); err != nil {
+		return nil, err
+	}
+	host, port, err := net.SplitHostPort(tcp.Address())
+	if err != nil {
+		_ = server.Close()
+		return nil, err
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		_ = server.Close()
+		return nil, err
+	}
+	if err := server.Serve(); err != nil {
+		_ = server.Close()
+		return nil, err
+	}
+	return &MQTTBroker{Host: host, Port: portNumber, server: server}, nil
+}
+
+// Config 返回连接到该 Broker 的客户端配置，ClientID 随机生成以免冲突
+func (b *MQTTBroker) Config() messagebus.Config {
+	return messagebus.Config{
+		Host:     b.Host,
+		Port:     b.Port,
+		Protocol: "tcp",
+		Type:     messagebus.TypeMQTT,
+		ClientID: "brokertest-" + uuid.NewString(),
+	}
+}
+
+// NewClient 创建连接到该 Broker 的客户端，opts 在 Config 之后应用，测试结束时自动断开
+func (b *MQTTBroker) NewClient(t testing.TB, opts ...messagebus.Option) *messagebus.Client {
+	t.Helper()
+	all := append([]messagebus.Option{
+		messagebus.WithConfig(b.Config()),
+		messagebus.WithLogger(logger.NewMockClient()),
+	}, opts...)
+	client, err := messagebus.NewClientWithOptions(all...)
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("连接嵌入式 MQTT Broker 失败: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Disconnect() })
+	return client
+}
+
+// URL 返回 Broker 地址，例如 tcp://127.0.0.1:54321
+func (b *MQTTBroker) URL() string {
+	return fmt.Sprintf("tcp://%s", net.JoinHostPort(b.Host, strconv.Itoa(b.Port)))
+}
+
+// DropClient 由 Broker 一侧关闭指定 ClientID 的连接，模拟网络中断或 Broker 踢下线，ClientID 未连接时返回 false
+func (b *MQTTBroker) DropClient(clientID string) bool {
+	client, ok := b.server.Clients.Get(clientID)
+	if !ok || client.Closed() {
+		return false
+	}
+	client.Stop(errors.New("brokertest: 连接被主动关闭"))
+	return true
+}
+
+// Close 关闭 Broker 及其所有连接
+func (b *MQTTBroker) Close() error {
+	return b.server.Close()
+}
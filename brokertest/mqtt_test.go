@@ -0,0 +1,90 @@
+package brokertest_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/brokertest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+func TestMQTTPublishSubscribe(t *testing.T) {
+	broker := brokertest.StartMQTT(t)
+	subscriber := broker.NewClient(t)
+	publisher := broker.NewClient(t)
+
+	received := make(chan types.MessageEnvelope, 1)
+	if err := subscriber.Subscribe([]string{"brokertest/events/#"}, func(_ string, msg types.MessageEnvelope) error {
+		received <- msg
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := publisher.Publish("brokertest/events/d1", map[string]string{"k": "v"}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-received:
+		if msg.ReceivedTopic != "brokertest/events/d1" {
+			t.Errorf("ReceivedTopic = %q，期望 brokertest/events/d1", msg.ReceivedTopic)
+		}
+		data, err := messagebus.EnvelopePayloadBytes(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var payload map[string]string
+		if err := json.Unmarshal(data, &payload); err != nil || payload["k"] != "v" {
+			t.Errorf("Payload = %s，期望 {\"k\":\"v\"}", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("订阅未收到消息")
+	}
+}
+
+func TestMQTTRequestResponse(t *testing.T) {
+	broker := brokertest.StartMQTT(t)
+	responder := broker.NewClient(t)
+	requester := broker.NewClient(t)
+
+	if err := responder.RegisterRequestHandler("brokertest/command", func(_ context.Context, request types.MessageEnvelope) (interface{}, error) {
+		return map[string]string{"echo": request.RequestID}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	envelope, err := requester.CreateMessageEnvelope(map[string]string{"cmd": "ping"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope.RequestID = "req-1"
+	response, err := requester.Request(envelope, "brokertest/command", "brokertest/response", 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.RequestID != "req-1" {
+		t.Errorf("响应 RequestID = %q，期望 req-1", response.RequestID)
+	}
+	data, err := messagebus.EnvelopePayloadBytes(*response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal(data, &payload); err != nil || payload["echo"] != "req-1" {
+		t.Errorf("响应 Payload = %s，期望 {\"echo\":\"req-1\"}", data)
+	}
+}
+
+func TestMQTTBrokerURL(t *testing.T) {
+	broker := brokertest.StartMQTT(t)
+	if broker.Port == 0 || broker.URL() == "" {
+		t.Fatalf("Broker 未监听端口: %+v", broker)
+	}
+	config := broker.Config()
+	if config.Host != broker.Host || config.Port != broker.Port || config.Type != messagebus.TypeMQTT {
+		t.Errorf("Config() = %+v 与 Broker 地址不一致", config)
+	}
+}
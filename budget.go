@@ -0,0 +1,75 @@
+package messagebus
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// byteBudget 统计所有订阅中正在处理的消息字节数，超出预算时阻塞新的投递
+type byteBudget struct {
+	mutex   sync.Mutex
+	limit   int64
+	current int64
+	waitCh  chan struct{}
+}
+
+func newByteBudget(limit int64) *byteBudget {
+	return &byteBudget{limit: limit, waitCh: make(chan struct{})}
+}
+
+// acquire 占用 n 字节预算，预算不足时阻塞直至有处理完成、stop 关闭（断开连接）或 done 关闭（取消订阅）
+// 当前无在途消息时即使单条消息超过预算也允许通过，避免永久阻塞
+func (b *byteBudget) acquire(n int64, stop, done <-chan struct{}) bool {
+	for {
+		b.mutex.Lock()
+		if b.current == 0 || b.current+n <= b.limit {
+			b.current += n
+			b.mutex.Unlock()
+			return true
+		}
+		waitCh := b.waitCh
+		b.mutex.Unlock()
+		select {
+		case <-waitCh:
+		case <-stop:
+			return false
+		case <-done:
+			return false
+		}
+	}
+}
+
+// release 释放 n 字节预算并唤醒等待者
+func (b *byteBudget) release(n int64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.current -= n
+	close(b.waitCh)
+	b.waitCh = make(chan struct{})
+}
+
+// inFlight 返回当前在途字节数
+func (b *byteBudget) inFlight() int64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.current
+}
+
+// payloadSize 估算 Payload 的字节数
+func payloadSize(payload interface{}) int64 {
+	switch v := payload.(type) {
+	case nil:
+		return 0
+	case []byte:
+		return int64(len(v))
+	case string:
+		return int64(len(v))
+	default:
+		// 只计数不保留编码结果，Encoder 追加的换行不计入
+		var n byteCounter
+		if err := json.NewEncoder(&n).Encode(v); err != nil {
+			return 0
+		}
+		return int64(n) - 1
+	}
+}
@@ -0,0 +1,150 @@
+package messagebus_test
+
+import (
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// budgetClient 返回在途字节预算为 1 的客户端，并订阅一个在收到消息后阻塞至 release 关闭的主题
+func budgetClient(t *testing.T) (client *messagebustest.MockClient, release chan struct{}) {
+	t.Helper()
+	config := testConfig()
+	config.MaxInFlightBytes = 1
+	client = newMockClient(t, messagebus.WithConfig(config))
+	started := make(chan struct{})
+	release = make(chan struct{})
+	err := client.Subscribe([]string{"test/slow"}, func(string, types.MessageEnvelope) error {
+		close(started)
+		<-release
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Publish("test/slow", "hold"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("占用预算的消息未被处理")
+	}
+	return client, release
+}
+
+func TestInFlightBudgetBackpressure(t *testing.T) {
+	client, release := budgetClient(t)
+	received := make(chan struct{}, 1)
+	err := client.Subscribe([]string{"test/fast"}, func(string, types.MessageEnvelope) error {
+		received <- struct{}{}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Publish("test/fast", "next"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-received:
+		t.Fatal("预算耗尽时不应投递新消息")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if inFlight := client.Stats().InFlightBytes; inFlight <= 0 {
+		t.Fatalf("InFlightBytes = %d，期望大于 0", inFlight)
+	}
+
+	close(release)
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("预算释放后消息未被投递")
+	}
+}
+
+func TestInFlightBudgetUnsubscribeReleasesWaiter(t *testing.T) {
+	client, release := budgetClient(t)
+	received := make(chan struct{}, 1)
+	err := client.Subscribe([]string{"test/fast"}, func(string, types.MessageEnvelope) error {
+		received <- struct{}{}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Publish("test/fast", "next"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := client.Unsubscribe("test/fast"); err != nil {
+		t.Fatal(err)
+	}
+	// 等待预算的订阅应随取消订阅退出，预算释放后不再处理已取消订阅的消息
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	select {
+	case <-received:
+		t.Fatal("取消订阅后仍处理了等待预算的消息")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if inFlight := client.Stats().InFlightBytes; inFlight != 0 {
+		t.Fatalf("InFlightBytes = %d，期望 0", inFlight)
+	}
+}
+
+func TestInFlightBudgetDecodedPayloadSize(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	config := testConfig()
+	config.MaxInFlightBytes = 1 << 20
+	client, err := messagebustest.NewMockClientWithBroker(broker, messagebus.WithConfig(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	received := make(chan struct{}, 4)
+	err = client.SubscribeWithOptions([]string{"test/decoded"}, func(string, types.MessageEnvelope) error {
+		received <- struct{}{}
+		return nil
+	}, messagebus.SubscribeOptions{Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := broker.MessageClientFactory("raw")(types.MessageBusConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := raw.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	// 内存 Broker 经 JSON 传输，对象 Payload 到达时为已解码的 map
+	for i := 0; i < 2; i++ {
+		envelope := types.MessageEnvelope{ContentType: "application/json", Payload: map[string]interface{}{"a": 1}}
+		if err := raw.Publish(envelope, "test/decoded"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatal("消息未被处理")
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for client.Stats().InFlightBytes != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	stats := client.Stats()
+	if stats.InFlightBytes != 0 {
+		t.Errorf("处理完成后 InFlightBytes = %d，期望 0", stats.InFlightBytes)
+	}
+	if want := uint64(2 * len(`{"a":1}`)); stats.BytesIn != want {
+		t.Errorf("BytesIn = %d，期望 %d", stats.BytesIn, want)
+	}
+}
@@ -0,0 +1,246 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+)
+
+// 分块消息在信封 QueryParams 中使用的键
+const (
+	HeaderChunkID    = "x-chunk-id"    // 同一条消息的所有分块取值相同
+	HeaderChunkIndex = "x-chunk-index" // 分块序号，从 0 开始
+	HeaderChunkCount = "x-chunk-count" // 分块总数
+)
+
+// 分块重组的默认值
+const (
+	defaultReassemblyTimeout = 30 * time.Second
+	defaultMaxPendingBytes   = 64 * 1024 * 1024
+)
+
+// maxChunkCount 是一条消息允许的最大分块数，按 64KB 分块已达 256MB，远超 MaxPendingBytes 的默认值；
+// 接收端据此拒绝伪造的超大分块总数，避免为其分配分块表
+const maxChunkCount = 4096
+
+// ChunkingConfig 表示大 Payload 的分块发布与重组参数
+//
+// 分块在压缩和加密之后进行，每个分块作为独立信封发布，携带原信封的 CorrelationID、RequestID 和 QueryParams。
+// MaxPayloadSize 大于 0 时收到的分块按 QueryParams 中的标记自动重组，处理函数、请求响应和流式响应收到的都是完整消息；
+// 未启用分块时分块信封原样交给处理函数。
+type ChunkingConfig struct {
+	// MaxPayloadSize Payload 超过该字节数时拆分为多个分块，0 表示不拆分也不重组
+	// 信封以 JSON 传输时 Payload 经 base64 编码后约膨胀 1/3，应留出余量，例如 Broker 上限为 256KB 时设为 180KB
+	MaxPayloadSize int
+	// ReassemblyTimeout 收到第一个分块后等待其余分块的时长，超时的未完成消息由后台定期丢弃，默认 30 秒
+	ReassemblyTimeout time.Duration
+	// MaxPendingBytes 所有未完成消息已收到的分块总字节数上限，超出时丢弃最早开始的未完成消息，默认 64MB
+	MaxPendingBytes int64
+}
+
+// validate 校验分块参数
+func (c ChunkingConfig) validate() error {
+	if c.MaxPayloadSize < 0 || c.ReassemblyTimeout < 0 || c.MaxPendingBytes < 0 {
+		return fmt.Errorf("Chunking 的 MaxPayloadSize、ReassemblyTimeout 和 MaxPendingBytes 不能为负数")
+	}
+	return nil
+}
+
+// chunkingInterceptor 将超过 MaxPayloadSize 的 Payload 拆分为多个分块依次发布，任一分块发布失败时停止并返回错误
+func chunkingInterceptor(config ChunkingConfig) PublishInterceptor {
+	return func(next PublishHandler) PublishHandler {
+		return func(ctx context.Context, topic string, envelope types.MessageEnvelope) error {
+			if envelope.QueryParams[HeaderChunkID] != "" {
+				return next(ctx, topic, envelope)
+			}
+			data, err := outboundPayloadBytes(envelope.Payload)
+			if err != nil {
+				return err
+			}
+			if len(data) <= config.MaxPayloadSize {
+				return next(ctx, topic, envelope)
+			}
+			count := (len(data) + config.MaxPayloadSize - 1) / config.MaxPayloadSize
+			if count > maxChunkCount {
+				return fmt.Errorf("Payload 共 %d 字节，按 MaxPayloadSize 拆分为 %d 个分块，超过上限 %d", len(data), count, maxChunkCount)
+			}
+			id := uuid.NewString()
+			for i := 0; i < count; i++ {
+				end := min((i+1)*config.MaxPayloadSize, len(data))
+				chunk := envelope
+				chunk.Payload = data[i*config.MaxPayloadSize : end]
+				chunk.QueryParams = copyQueryParams(envelope.QueryParams)
+				chunk.QueryParams[HeaderChunkID] = id
+				chunk.QueryParams[HeaderChunkIndex] = strconv.Itoa(i)
+				chunk.QueryParams[HeaderChunkCount] = strconv.Itoa(count)
+				if err := next(ctx, topic, chunk); err != nil {
+					return fmt.Errorf("发布第 %d/%d 个分块失败: %w", i+1, count, err)
+				}
+			}
+			return nil
+		}
+	}
+}
+
+// partialMessage 表示正在重组的消息
+type partialMessage struct {
+	first    types.MessageEnvelope // 第一个收到的分块，重组后沿用其元数据
+	parts    [][]byte
+	received int
+	size     int64
+	started  time.Time
+}
+
+// chunkAssembler 按订阅范围和分块 ID 重组分块消息
+type chunkAssembler struct {
+	mutex   sync.Mutex
+	pending map[string]*partialMessage
+	size    int64 // 所有未完成消息已收到的字节数
+}
+
+// reassemble 处理一个收到的信封：不是分块或未启用分块时原样返回 complete=true；分块未收齐时返回 complete=false；
+// 收齐时返回重组后的信封。scope 区分同一消息被多个订阅收到的情况
+func (c *Client) reassemble(scope string, envelope types.MessageEnvelope) (types.MessageEnvelope, bool, error) {
+	id := envelope.QueryParams[HeaderChunkID]
+	if id == "" || c.config.Chunking.MaxPayloadSize <= 0 {
+		return envelope, true, nil
+	}
+	index, err := strconv.Atoi(envelope.QueryParams[HeaderChunkIndex])
+	if err != nil {
+		return envelope, false, fmt.Errorf("分块序号无效: %q", envelope.QueryParams[HeaderChunkIndex])
+	}
+	count, err := strconv.Atoi(envelope.QueryParams[HeaderChunkCount])
+	if err != nil || count <= 0 || count > maxChunkCount || index < 0 || index >= count {
+		return envelope, false, fmt.Errorf("分块总数无效: %q (序号 %d)", envelope.QueryParams[HeaderChunkCount], index)
+	}
+	data, err := payloadBytes(envelope.Payload)
+	if err != nil {
+		return envelope, false, err
+	}
+	if len(data) == 0 {
+		return envelope, false, fmt.Errorf("分块消息 %s 的第 %d 个分块为空", id, index)
+	}
+
+	cfg := c.config.Chunking
+	timeout := c.reassemblyTimeout()
+	limit := cfg.MaxPendingBytes
+	if limit <= 0 {
+		limit = defaultMaxPendingBytes
+	}
+	key := scope + "\x00" + id
+	now := time.Now()
+
+	a := &c.chunks
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.pending == nil {
+		a.pending = make(map[string]*partialMessage)
+	}
+	a.expire(now, timeout, c)
+	msg, ok := a.pending[key]
+	if !ok {
+		if int64(count-1)*int64(len(data)) > limit {
+			return envelope, false, fmt.Errorf("分块消息 %s 的预计大小超过 MaxPendingBytes", id)
+		}
+		msg = &partialMessage{first: envelope, parts: make([][]byte, count), started: now}
+		a.pending[key] = msg
+	}
+	if len(msg.parts) != count {
+		return envelope, false, fmt.Errorf("分块消息 %s 的分块总数不一致: %d != %d", id, count, len(msg.parts))
+	}
+	if msg.parts[index] != nil {
+		// 重复投递的分块
+		return envelope, false, nil
+	}
+	msg.parts[index] = append([]byte{}, data...)
+	msg.received++
+	msg.size += int64(len(data))
+	a.size += int64(len(data))
+	if msg.received < count {
+		a.evict(limit, key, c)
+		return envelope, false, nil
+	}
+
+	delete(a.pending, key)
+	a.size -= msg.size
+	payload := make([]byte, 0, msg.size)
+	for _, part := range msg.parts {
+		payload = append(payload, part...)
+	}
+	assembled := msg.first
+	assembled.Payload = payload
+	assembled.ReceivedTopic = envelope.ReceivedTopic
+	assembled.QueryParams = copyQueryParams(msg.first.QueryParams)
+	delete(assembled.QueryParams, HeaderChunkID)
+	delete(assembled.QueryParams, HeaderChunkIndex)
+	delete(assembled.QueryParams, HeaderChunkCount)
+	return assembled, true, nil
+}
+
+// reassemblyTimeout 返回等待其余分块的时长
+func (c *Client) reassemblyTimeout() time.Duration {
+	if c.config.Chunking.ReassemblyTimeout > 0 {
+		return c.config.Chunking.ReassemblyTimeout
+	}
+	return defaultReassemblyTimeout
+}
+
+// sweepChunks 定期丢弃超时的未完成消息，不再有分块到达时也能释放其占用的内存，随 Disconnect 停止
+func (c *Client) sweepChunks(stop <-chan struct{}) {
+	timeout := c.reassemblyTimeout()
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			a := &c.chunks
+			a.mutex.Lock()
+			a.expire(now, timeout, c)
+			a.mutex.Unlock()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// expire 丢弃超过 timeout 仍未收齐的消息，调用方需持有 a.mutex
+func (a *chunkAssembler) expire(now time.Time, timeout time.Duration, c *Client) {
+	for key, msg := range a.pending {
+		if now.Sub(msg.started) >= timeout {
+			a.drop(key, msg, c, "分块重组超时")
+		}
+	}
+}
+
+// evict 在总字节数超过 limit 时按开始时间丢弃最早的未完成消息，keep 最后丢弃；调用方需持有 a.mutex
+func (a *chunkAssembler) evict(limit int64, keep string, c *Client) {
+	for a.size > limit {
+		oldestKey := ""
+		var oldest *partialMessage
+		for key, msg := range a.pending {
+			if key != keep && (oldest == nil || msg.started.Before(oldest.started)) {
+				oldestKey, oldest = key, msg
+			}
+		}
+		if oldest == nil {
+			oldestKey, oldest = keep, a.pending[keep]
+		}
+		a.drop(oldestKey, oldest, c, "未完成的分块消息超过 MaxPendingBytes")
+		if oldestKey == keep {
+			return
+		}
+	}
+}
+
+// drop 丢弃一条未完成的消息并记录原因，调用方需持有 a.mutex
+func (a *chunkAssembler) drop(key string, msg *partialMessage, c *Client, reason string) {
+	delete(a.pending, key)
+	a.size -= msg.size
+	c.log(LogSubscribe).Warn("丢弃未完成的分块消息", c.logFields("topic", msg.first.ReceivedTopic, "chunkId", msg.first.QueryParams[HeaderChunkID],
+		"received", msg.received, "count", len(msg.parts), "reason", reason)...)
+}
@@ -0,0 +1,210 @@
+package messagebus_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// chunkReceiver 订阅 test/chunk 并将收到的消息写入返回的通道
+func chunkReceiver(t *testing.T, client *messagebustest.MockClient) <-chan types.MessageEnvelope {
+	t.Helper()
+	received := make(chan types.MessageEnvelope, 16)
+	if err := client.Subscribe([]string{"test/chunk"}, func(_ string, msg types.MessageEnvelope) error {
+		received <- msg
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return received
+}
+
+func TestChunkingRoundTrip(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	publisher, err := messagebustest.NewMockClientWithBroker(broker, messagebus.WithChunking(1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = publisher.Close() })
+	subscriber, err := messagebustest.NewMockClientWithBroker(broker, messagebus.WithChunking(1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = subscriber.Close() })
+	received := chunkReceiver(t, subscriber)
+
+	payload := bytes.Repeat([]byte("0123456789"), 1000)
+	if err := publisher.PublishBinary("test/chunk", payload); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(broker.Published()); n != 10 {
+		t.Errorf("发布了 %d 个分块，期望 10", n)
+	}
+	select {
+	case msg := <-received:
+		data, err := messagebus.EnvelopePayloadBytes(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, payload) {
+			t.Errorf("重组后 %d 字节，期望 %d 字节", len(data), len(payload))
+		}
+		if msg.QueryParams[messagebus.HeaderChunkID] != "" {
+			t.Error("重组后的消息不应携带分块标记")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("未收到重组后的消息")
+	}
+	select {
+	case msg := <-received:
+		t.Errorf("只应收到一条完整消息，又收到 %v", msg.QueryParams)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestChunksPassThroughWithoutChunking(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	publisher, err := messagebustest.NewMockClientWithBroker(broker, messagebus.WithChunking(1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = publisher.Close() })
+	subscriber, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = subscriber.Close() })
+	received := chunkReceiver(t, subscriber)
+
+	if err := publisher.PublishBinary("test/chunk", bytes.Repeat([]byte("x"), 3000)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case msg := <-received:
+			if msg.QueryParams[messagebus.HeaderChunkCount] != "3" {
+				t.Errorf("未启用分块时应原样收到分块，QueryParams = %v", msg.QueryParams)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("只收到 %d 个分块，期望 3", i)
+		}
+	}
+}
+
+func TestChunkReassemblyRejectsInvalidChunks(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	subscriber, err := messagebustest.NewMockClientWithBroker(broker, messagebus.WithChunking(1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = subscriber.Close() })
+	received := chunkReceiver(t, subscriber)
+
+	raw, err := broker.MessageClientFactory("raw")(types.MessageBusConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := raw.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name    string
+		count   int
+		payload []byte
+	}{
+		{"count above cap", 1_000_000_000, []byte("x")},
+		{"count just above cap", 4097, []byte("x")},
+		{"empty chunk", 2, []byte{}},
+	}
+	for i, tc := range cases {
+		envelope := types.MessageEnvelope{
+			ContentType: "application/octet-stream",
+			Payload:     tc.payload,
+			QueryParams: map[string]string{
+				messagebus.HeaderChunkID:    "bad-" + strconv.Itoa(i),
+				messagebus.HeaderChunkIndex: "0",
+				messagebus.HeaderChunkCount: strconv.Itoa(tc.count),
+			},
+		}
+		if err := raw.Publish(envelope, "test/chunk"); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case busErr := <-subscriber.Errors():
+			var decodeErr *messagebus.DecodeError
+			if busErr.Op != "decode" || !errors.As(busErr.Err, &decodeErr) {
+				t.Errorf("%s: 错误 %+v，期望 decode 错误", tc.name, busErr)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%s: 未报告分块错误", tc.name)
+		}
+	}
+	select {
+	case msg := <-received:
+		t.Errorf("无效分块不应交给处理函数: %v", msg.QueryParams)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestChunkingRejectsTooManyChunks(t *testing.T) {
+	client := newMockClient(t, messagebus.WithChunking(1))
+	if err := client.PublishBinary("test/chunk", bytes.Repeat([]byte("x"), 4097)); err == nil {
+		t.Error("分块数超过上限时发布应返回错误")
+	}
+	client.ExpectNotPublished(t, "test/chunk")
+}
+
+func TestChunkReassemblyExpiresWithoutTraffic(t *testing.T) {
+	config := testConfig()
+	config.Chunking = messagebus.ChunkingConfig{MaxPayloadSize: 1024, ReassemblyTimeout: 100 * time.Millisecond}
+	lc := &captureLogger{}
+	broker := messagebustest.NewBroker()
+	subscriber, err := messagebustest.NewMockClientWithBroker(broker, messagebus.WithConfig(config), messagebus.WithLogger(lc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = subscriber.Close() })
+	chunkReceiver(t, subscriber)
+
+	raw, err := broker.MessageClientFactory("raw")(types.MessageBusConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := raw.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	// 只发布两个分块中的第一个，之后不再有任何消息到达
+	envelope := types.MessageEnvelope{
+		ContentType: "application/octet-stream",
+		Payload:     []byte("first"),
+		QueryParams: map[string]string{
+			messagebus.HeaderChunkID:    "lonely",
+			messagebus.HeaderChunkIndex: "0",
+			messagebus.HeaderChunkCount: "2",
+		},
+	}
+	if err := raw.Publish(envelope, "test/chunk"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if line, ok := lc.find("丢弃未完成的分块消息"); ok {
+			if !strings.Contains(fmt.Sprint(line.args...), "分块重组超时") {
+				t.Errorf("丢弃原因 = %v，期望分块重组超时", line.args)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("没有后续分块时未完成的消息未在 ReassemblyTimeout 后被丢弃")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
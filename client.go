@@ -2,25 +2,73 @@
 package messagebus
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	pahoMqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
 	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
 	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client 表示一个简化版的 EdgeX MessageBus 客户端
 type Client struct {
-	client        messaging.MessageClient               // 底层消息客户端
-	lc            logger.LoggingClient                  // 日志客户端
-	isConnected   bool                                  // 是否已连接
-	mutex         sync.RWMutex                          // 并发读写锁
-	subscriptions map[string]chan types.MessageEnvelope // 订阅的主题及其消息通道
-	errorChan     chan error                            // 错误通道
-	stopChan      chan struct{}                         // 停止通道
-	wg            sync.WaitGroup                        // 用于等待所有 goroutine 退出
+	client         messaging.MessageClient                   // 底层消息客户端
+	busConfig      types.MessageBusConfig                    // 创建底层客户端使用的配置
+	credentials    Credentials                               // 当前使用的凭据
+	config         Config                                    // 客户端配置
+	lc             logger.LoggingClient                      // 日志客户端
+	loggers        map[LogComponent]logger.LoggingClient     // 按组件过滤级别并附加 component 字段的日志客户端
+	isConnected    bool                                      // 是否已连接
+	mutex          sync.RWMutex                              // 并发读写锁
+	subscriptions  map[string]*subscription                  // 订阅的主题及其订阅状态
+	errorChan      chan error                                // 底层客户端报告异步错误的通道
+	busErrors      chan BusError                             // 带上下文的异步错误通道
+	legacyErrors   chan error                                // GetErrorChannel 返回的通道，首次调用时创建，之后错误只写入该通道
+	errorsMu       sync.Mutex                                // 保护 legacyErrors、errorsClosed 及错误通道的写入
+	errorsClosed   bool                                      // Close 后错误通道已关闭
+	stopping       bool                                      // 是否正在断开连接
+	lifecycle      *lifecycle                                // 后台 goroutine 生命周期管理
+	budget         *byteBudget                               // 在途消息字节预算，未配置时为 nil
+	reconnect      reconnectState                            // 重连状态
+	stats          *statsCollector                           // 运行时统计
+	metrics        MetricsRecorder                           // 指标记录，未配置 Config.Metrics 时为空实现
+	callbacks      callbackState                             // 连接状态变化回调
+	configInfo     EdgeXMessageBusInfo                       // 配置中心中当前生效的 MessageBus 段
+	configUpdates  chan interface{}                          // 配置中心推送的 MessageBus 配置，未使用配置中心时为 nil
+	configErrors   chan error                                // 配置中心监听错误
+	configWatch    messaging.MessageClient                   // 供配置中心监听使用的底层连接，Close 时断开
+	outboxDraining atomic.Bool                               // 是否正在转发暂存消息
+	health         healthState                               // 健康检查状态
+	events         chan LifecycleEvent                       // 生命周期事件通道
+	willClient     pahoMqtt.Client                           // 携带遗嘱的 MQTT 连接，未配置遗嘱时为 nil
+	variantsMu     sync.Mutex                                // 保护 variants
+	variants       map[clientVariant]messaging.MessageClient // 按 QoS 与保留标志建立的额外连接
+	asyncSlots     chan struct{}                             // 异步发布并发槽位
+	breaker        circuitBreaker                            // 发布熔断器状态
+	limiter        publishLimiter                            // 发布限速状态
+	requests       requestMux                                // 共享响应订阅与等待中的请求
+	closing        atomic.Bool                               // 是否正在平滑断开连接，此时拒绝新的发布
+	handling       atomic.Int64                              // 已从订阅通道取出但尚未处理完的消息数
+	middleware     []HandlerMiddleware                       // 订阅中间件，按注册顺序由外向内包装处理函数
+	lastError      atomic.Pointer[BusError]                  // 最近一次报告到错误通道的错误
+	lastRoundTrip  atomic.Int64                              // 最近一次成功的往返探测耗时（纳秒）
+	periodicMu     sync.Mutex                                // 保护 periodic
+	periodic       map[*PeriodicPublisher]struct{}           // 随连接启停的定时发布器
+	tapsMu         sync.RWMutex                              // 保护 taps
+	taps           map[*tapObserver]struct{}                 // Tap 观察者
+	subState       subscriptionState                         // 订阅状态持久化
+	collision      collisionState                            // ClientID 冲突检测
+	chunks         chunkAssembler                            // 未收齐的分块消息
+	offsets        offsetTracker                             // 各位置键尚未提交的消费位置
 }
 
 // Config 表示 MessageBus 配置参数
@@ -30,16 +78,137 @@ type Config struct {
 	Protocol string
 	Type     string
 	ClientID string
-	Username string
-	Password string
-	QoS      int
+	// ClientIDPrefix ClientID 为空时按 <前缀>-<主机名>-<随机后缀> 自动生成 ClientID，见 GenerateClientID
+	ClientIDPrefix string
+	// ClientIDCollision Broker 端 ClientID 冲突（会话接管）的检测与处理，仅 mqtt 类型支持
+	ClientIDCollision ClientIDCollisionConfig
+	Username          string
+	Password          string
+	QoS               int
+	// CleanSession MQTT 3.1.1 的 Clean Session 标志，nil 表示使用 MQTT 默认值 true；
+	// 设置为 false（可使用 CleanSessionFlag）并配合固定的 ClientID 后，Broker 会保留 QoS 1/2 订阅，
+	// 离线期间发布的消息在重新连接后投递，仅 mqtt 类型支持
+	CleanSession *bool
+	// SessionExpiryInterval MQTT 5 的会话过期时间（整秒），0 表示不设置；内置的 MQTT 3.1.1 客户端不发送该属性，
+	// 会话保留时长由 Broker 配置决定，仅通过 Optional[OptionalSessionExpiryInterval] 传递给支持 MQTT 5 的客户端工厂
+	SessionExpiryInterval time.Duration
+	// CertFile/KeyFile 客户端证书及私钥文件，用于双向 TLS
+	CertFile string
+	KeyFile  string
+	// CAFile 用于校验 Broker 证书的 CA 文件
+	CAFile string
+	// SkipCertVerify 是否跳过 Broker 证书校验，仅用于测试环境
+	SkipCertVerify bool
+	// TLSConfig 可选的 TLS 配置，其中的客户端证书和 InsecureSkipVerify 会传递给底层客户端
+	TLSConfig *tls.Config
+	// WebSocket ws/wss 协议的握手路径和 HTTP 头，设置后经本地中继连接 Broker
+	WebSocket WebSocketConfig
+	// ProxyURL 连接 Broker 使用的代理，支持 http://（HTTP CONNECT）、socks5:// 和 socks5h://，
+	// 可在地址中携带用户名和密码；设置后经本地中继连接 Broker，kafka 类型不支持
+	ProxyURL string
+	// Credentials 凭据提供者（如 EdgeX 秘密存储），设置后覆盖 Username/Password 及证书
+	Credentials CredentialsProvider
+	// CredentialsRefreshInterval 定期刷新凭据的间隔，凭据变化时自动重新认证，0 表示不刷新
+	CredentialsRefreshInterval time.Duration
+	// Tags 为客户端附加的标签（如 tenant、region），会作为结构化字段出现在每条日志及客户端信息中
+	Tags map[string]string
+	// ContractVersion 信封遵循的 EdgeX 契约版本 (v2, v3)，默认 v3
+	ContractVersion ContractVersion
+	// MaxInFlightBytes 所有订阅中正在处理的消息字节总数上限，超出时暂停投递新消息，0 表示不限制
+	MaxInFlightBytes int64
+	// Metrics 创建客户端指标记录的工厂，nil 表示不采集指标；Prometheus 实现见 prommetrics.New
+	Metrics MetricsFactory
+	// ResponseTopicPrefix RegisterRequestHandler 在请求未携带响应主题时使用的响应主题前缀
+	ResponseTopicPrefix string
+	// TracerProvider 用于创建发布/消费 Span 的 OpenTelemetry TracerProvider，为空时不创建 Span
+	TracerProvider trace.TracerProvider
+	// Propagator 在信封 QueryParams 中传播追踪上下文的传播器，默认为 W3C TraceContext 与 Baggage
+	Propagator propagation.TextMapPropagator
+	// JetStream NATS JetStream 专用参数，仅在 Type 为 nats-jetstream 时可设置
+	JetStream JetStreamConfig
+	// Codec Publish 使用的编解码器，信封 ContentType 随之设置；为空时保持原样发布 JSON Payload。
+	// 传入 []byte 时视为已编码的数据，不经 Codec 重复序列化
+	Codec Codec
+	// Outbox 存储转发参数，配置 Store 后 Broker 不可达时消息暂存并在连接恢复后按顺序转发
+	Outbox OutboxConfig
+	// HealthFailureThreshold 连续多少次健康检查失败判定为不健康，0 表示不升级
+	HealthFailureThreshold int
+	// ReconnectOnUnhealthy 判定为不健康时是否自动重连
+	ReconnectOnUnhealthy bool
+	// HealthProbeTimeout 大于 0 时 HealthCheck 会向私有探测主题发布消息并在该时间内等待其经 Broker 返回，
+	// 用于发现本地状态正常但 Broker 已无响应的情况；0 表示只检查本地状态
+	HealthProbeTimeout time.Duration
+	// Reconnect 自动重连及退避参数
+	Reconnect ReconnectConfig
+	// ShutdownTimeout 断开连接时等待所有后台 goroutine 退出的总时长，默认 30 秒
+	ShutdownTimeout time.Duration
+	// DrainTimeout 断开连接时处理剩余缓冲消息的最长时间，0 表示不排空并等待处理函数自然退出
+	DrainTimeout time.Duration
+	// ErrorOverflow 错误通道已满时的处理策略，默认 drop-newest
+	ErrorOverflow ErrorOverflowPolicy
+	// Will MQTT 遗嘱消息，客户端异常掉线时由 Broker 发布，仅 mqtt 类型支持
+	Will *WillConfig
+	// ConfirmPublish 发布时等待 Broker 确认并返回投递错误，启用后发布不再进入离线存储转发队列
+	ConfirmPublish bool
+	// MaxAsyncPublishes 同时进行的异步发布数量上限，默认 64
+	MaxAsyncPublishes int
+	// Logging 按组件的日志级别和 Payload 转储参数
+	Logging LogConfig
+	// Lag 慢处理函数与订阅通道积压检测参数，未设置阈值时不检测
+	Lag LagConfig
+	// CircuitBreaker 发布熔断器参数，FailureThreshold 为 0 时不启用
+	CircuitBreaker CircuitBreakerConfig
+	// PublishRateLimit 发布限速参数，未设置速率时不限速
+	PublishRateLimit RateLimitConfig
+	// BaseTopicPrefix EdgeX 主题前缀，默认 edgex
+	BaseTopicPrefix string
+	// ServiceName EdgeX 服务名，用于构造事件主题，默认为 ClientID
+	ServiceName string
+	// PublishInterceptors 发布拦截器，所有发出的信封（含请求）按顺序经过这些拦截器
+	PublishInterceptors []PublishInterceptor
+	// Compression 发布时的 Payload 压缩参数，收到的压缩消息总会按信封中的标记自动解压
+	Compression CompressionConfig
+	// Encryption 端到端 Payload 加密参数，设置 KeyProvider 后发布时加密、接收时解密
+	Encryption EncryptionConfig
+	// Chunking 大 Payload 分块参数，设置 MaxPayloadSize 后发布时拆分，收到的分块总会自动重组
+	Chunking ChunkingConfig
+	// TopicPrefix 所有主题在 Broker 上的命名空间前缀（如 site42），发布、订阅时自动加上，收到消息时自动去掉
+	TopicPrefix string
+	// TopicRewrites 主题改写规则，按顺序在加前缀之前应用，收到消息时按相反顺序还原
+	TopicRewrites []TopicRewriter
+	// Schemas 主题关联的 Payload Schema，设置后发布时拒绝不符合的 Payload，接收时不符合的消息不交给处理函数
+	Schemas SchemaRegistry
+	// SubscriptionState 订阅状态持久化参数，配置 File 后订阅集合写入磁盘，配置 Restore 后连接时自动恢复
+	SubscriptionState SubscriptionStateConfig
+	// OffsetStore 消费位置存储，设置后订阅从上次处理完的位置之后继续消费，底层客户端须实现 OffsetResumer（如 kafka 子包）
+	OffsetStore OffsetStore
+	// MessageClientFactory 创建底层 go-mod-messaging 客户端，默认为 messaging.NewMessageClient，
+	// 可替换为内存实现用于测试（见 messagebustest 包）
+	MessageClientFactory MessageClientFactory
 }
 
+// MessageClientFactory 根据底层配置创建 go-mod-messaging 客户端
+type MessageClientFactory func(config types.MessageBusConfig) (messaging.MessageClient, error)
+
 // MessageHandler 定义处理消息的函数类型
 type MessageHandler func(topic string, message types.MessageEnvelope) error
 
+// defaultErrorChannelSize 是错误通道的默认缓冲大小
+const defaultErrorChannelSize = 10
+
 // NewClient 创建一个新的 MessageBus 客户端实例
 func NewClient(config Config, lc logger.LoggingClient) (*Client, error) {
+	return newClient(config, lc, defaultErrorChannelSize)
+}
+
+// newClient 按配置创建客户端，errorChanSize 为错误通道缓冲大小
+func newClient(config Config, lc logger.LoggingClient, errorChanSize int) (*Client, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.ClientID == "" && config.ClientIDPrefix != "" {
+		config.ClientID = GenerateClientID(config.ClientIDPrefix)
+	}
 	messageBusConfig := types.MessageBusConfig{
 		Broker: types.HostInfo{
 			Host:     config.Host,
@@ -60,110 +229,717 @@ func NewClient(config Config, lc logger.LoggingClient) (*Client, error) {
 	if config.QoS > 0 {
 		messageBusConfig.Optional["Qos"] = fmt.Sprintf("%d", config.QoS)
 	}
-	client, err := messaging.NewMessageClient(messageBusConfig)
+	tlsOpts, err := tlsOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range tlsOpts {
+		messageBusConfig.Optional[k] = v
+	}
+	sessionOpts, err := sessionOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range sessionOpts {
+		messageBusConfig.Optional[k] = v
+	}
+	jsOpts, err := jetStreamOptions(config)
 	if err != nil {
 		return nil, err
 	}
-	return &Client{
+	for k, v := range jsOpts {
+		messageBusConfig.Optional[k] = v
+	}
+	if config.Reconnect.Enabled && (config.MessageClientFactory != nil || !reconnectsOnDrop(config)) {
+		// 无法观察连接断开时交给底层客户端自行重连，否则由 connectionLost 按 Config.Reconnect 重连
+		messageBusConfig.Optional["AutoReconnect"] = "true"
+	}
+	if config.ConfirmPublish {
+		messageBusConfig.Optional[OptionalConfirmPublish] = "true"
+	}
+	var creds Credentials
+	if config.Credentials != nil {
+		if creds, err = config.Credentials.Credentials(); err != nil {
+			return nil, fmt.Errorf("获取凭据失败: %w", err)
+		}
+		applyCredentials(messageBusConfig.Optional, creds)
+	}
+	client, err := newMessageClient(config, messageBusConfig)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string, len(config.Tags))
+	for k, v := range config.Tags {
+		tags[k] = v
+	}
+	config.Tags = tags
+	var budget *byteBudget
+	if config.MaxInFlightBytes > 0 {
+		budget = newByteBudget(config.MaxInFlightBytes)
+	}
+	errorChan := make(chan error, errorChanSize)
+	busErrors := make(chan BusError, errorChanSize)
+	maxAsyncPublishes := config.MaxAsyncPublishes
+	if maxAsyncPublishes <= 0 {
+		maxAsyncPublishes = defaultMaxAsyncPublishes
+	}
+	c := &Client{
 		client:        client,
+		busConfig:     messageBusConfig,
+		credentials:   creds,
+		config:        config,
 		lc:            lc,
-		subscriptions: make(map[string]chan types.MessageEnvelope),
-		errorChan:     make(chan error, 10),
-		stopChan:      make(chan struct{}),
-	}, nil
+		loggers:       newComponentLoggers(lc, config.Logging),
+		subscriptions: make(map[string]*subscription),
+		periodic:      make(map[*PeriodicPublisher]struct{}),
+		taps:          make(map[*tapObserver]struct{}),
+		errorChan:     errorChan,
+		busErrors:     busErrors,
+		lifecycle:     newLifecycle(),
+		budget:        budget,
+		stats:         newStatsCollector(),
+		events:        make(chan LifecycleEvent, lifecycleEventBuffer),
+		asyncSlots:    make(chan struct{}, maxAsyncPublishes),
+	}
+	c.metrics = nopMetrics{}
+	if config.Metrics != nil {
+		metrics, err := config.Metrics(MetricsInfo{ClientID: config.ClientID, Tags: c.Tags(), ErrorQueueDepth: c.errorQueueDepth})
+		if err != nil {
+			return nil, fmt.Errorf("创建指标失败: %w", err)
+		}
+		if metrics != nil {
+			c.metrics = metrics
+		}
+	}
+	c.watchBrokerDisconnects(client)
+	return c, nil
+}
+
+// newMessageClient 使用 Config.MessageClientFactory（未设置时为 messaging.NewMessageClient）创建底层客户端，
+// 需要自行建立连接（如 ws/wss）时经本地中继连接 Broker，配置了 TopicPrefix 或 TopicRewrites 时包装为转换主题的客户端
+func newMessageClient(config Config, busConfig types.MessageBusConfig) (messaging.MessageClient, error) {
+	dial, err := brokerDialerFor(config, busConfig)
+	if err != nil {
+		return nil, err
+	}
+	var relay *transportRelay
+	if dial != nil {
+		if relay, err = newTransportRelay(dial); err != nil {
+			return nil, err
+		}
+		if busConfig, err = relayedBusConfig(busConfig, relay.address); err != nil {
+			relay.close()
+			return nil, err
+		}
+	}
+	var client messaging.MessageClient
+	if config.MessageClientFactory != nil {
+		client, err = config.MessageClientFactory(busConfig)
+	} else {
+		client, err = messaging.NewMessageClient(busConfig)
+	}
+	if err == nil {
+		err = resumeFrom(config, client)
+	}
+	if err != nil {
+		if relay != nil {
+			relay.close()
+		}
+		return nil, err
+	}
+	if relay != nil {
+		client = &relayClient{MessageClient: client, relay: relay}
+	}
+	if mapper := newTopicMapper(config); mapper != nil {
+		client = newRewritingClient(client, mapper)
+	}
+	return client, nil
 }
 
 // Connect 连接到 MessageBus
 func (c *Client) Connect() error {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
 	if c.isConnected {
+		c.mutex.Unlock()
 		return nil
 	}
+	if c.isClosed() {
+		c.mutex.Unlock()
+		return ErrClientClosed
+	}
+	if c.stopping {
+		c.mutex.Unlock()
+		return ErrClientClosing
+	}
 	if err := c.client.Connect(); err != nil {
+		c.mutex.Unlock()
+		c.log(LogConnection).Error("连接MessageBus失败", c.logFields("error", err)...)
 		return err
 	}
 	c.isConnected = true
+	c.mutex.Unlock()
+	if err := c.connectWill(); err != nil {
+		c.log(LogConnection).Error("建立遗嘱连接失败", c.logFields("error", err)...)
+		c.mutex.Lock()
+		_ = c.client.Disconnect()
+		c.isConnected = false
+		c.mutex.Unlock()
+		return err
+	}
+	c.stats.connectedAt.Store(time.Now().UnixNano())
+	c.log(LogConnection).Info("已连接到MessageBus", c.logFields("host", c.config.Host, "port", c.config.Port)...)
+	c.lifecycle.spawn(stageSubscribe, c.forwardErrors)
+	if c.config.Credentials != nil && c.config.CredentialsRefreshInterval > 0 {
+		c.lifecycle.spawn(stageReconnect, c.watchCredentials)
+	}
+	if c.configUpdates != nil {
+		c.lifecycle.spawn(stageReconnect, c.watchConfigProvider)
+	}
+	if c.config.Lag.QueueHighWater > 0 {
+		c.lifecycle.spawn(stageSubscribe, c.watchQueueLag)
+	}
+	if c.config.Chunking.MaxPayloadSize > 0 {
+		c.lifecycle.spawn(stageSubscribe, c.sweepChunks)
+	}
+	c.emitEvent(LifecycleEvent{Type: EventConnected})
+	c.startOutboxDrain()
+	c.startPeriodic()
+	c.restoreSubscriptions()
 	return nil
 }
 
-// Disconnect 断开与 MessageBus 的连接，并停止所有订阅处理
+// Disconnect 断开与 MessageBus 的连接，并按重连、发布、订阅的顺序停止所有后台 goroutine
+// 若配置了 DrainTimeout，会在超时时间内继续处理已缓冲的消息，超时后放弃剩余消息；
+// 等待后台 goroutine 退出的总时长不超过 ShutdownTimeout
 func (c *Client) Disconnect() error {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	if !c.isConnected || c.client == nil {
+	if !c.isConnected || c.client == nil || c.stopping {
+		c.mutex.Unlock()
 		return nil
 	}
-	close(c.stopChan)
-	c.wg.Wait()
+	c.stopping = true
+	c.mutex.Unlock()
+	return c.closeConnection(c.shutdownTimeout())
+}
+
+// closeConnection 停止所有后台 goroutine 后断开底层连接，调用前须已将 stopping 置为 true
+func (c *Client) closeConnection(timeout time.Duration) error {
+	if stuck := c.lifecycle.shutdown(timeout); len(stuck) > 0 {
+		c.log(LogConnection).Warn("等待后台任务退出超时，继续断开连接", c.logFields("stages", stuck)...)
+	}
+	c.disconnectWill()
+	c.closeVariants()
+
+	c.mutex.Lock()
+	c.stopping = false
 	if err := c.client.Disconnect(); err != nil {
+		c.mutex.Unlock()
+		c.log(LogConnection).Error("断开MessageBus连接失败", c.logFields("error", err)...)
 		return err
 	}
 	c.isConnected = false
+	c.mutex.Unlock()
+	c.stats.connectedAt.Store(0)
+	c.log(LogConnection).Info("已断开MessageBus连接", c.logFields()...)
+	c.emitEvent(LifecycleEvent{Type: EventDisconnected})
 	return nil
 }
 
+// shutdownTimeout 返回断开连接时等待后台 goroutine 的总时长
+func (c *Client) shutdownTimeout() time.Duration {
+	if c.config.ShutdownTimeout > 0 {
+		return c.config.ShutdownTimeout
+	}
+	return defaultShutdownTimeout
+}
+
 // Publish 发布消息到指定主题
+// data 为 types.MessageEnvelope 或 *types.MessageEnvelope 时按 PublishEnvelope 原样发布
 func (c *Client) Publish(topic string, data interface{}) error {
-	if !c.IsConnected() {
-		return fmt.Errorf("MessageBus未连接")
+	switch envelope := data.(type) {
+	case types.MessageEnvelope:
+		return c.PublishEnvelope(topic, envelope)
+	case *types.MessageEnvelope:
+		if envelope == nil {
+			return fmt.Errorf("信封不能为空")
+		}
+		return c.PublishEnvelope(topic, *envelope)
 	}
-	payload, err := toPayload(data)
+	payload, contentType, err := c.encodePayload(data)
 	if err != nil {
 		return err
 	}
-	return c.client.Publish(types.MessageEnvelope{
+	return c.publish(context.Background(), topic, payload, contentType)
+}
+
+// encodePayload 按 Config.Codec（未设置时为 JSON）编码 Publish 的数据
+// []byte 视为已按 Codec 编码的数据直接发布，不再重复序列化
+func (c *Client) encodePayload(data interface{}) (interface{}, string, error) {
+	if c.config.Codec != nil {
+		if raw, ok := data.([]byte); ok {
+			return raw, c.config.Codec.ContentType(), nil
+		}
+		payload, err := c.config.Codec.MarshalPayload(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("序列化Payload失败: %w", err)
+		}
+		return payload, c.config.Codec.ContentType(), nil
+	}
+	payload, err := toPayload(data)
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, "application/json", nil
+}
+
+// publish 以指定内容类型构造信封并发布，ctx 中的追踪上下文会注入到信封中
+func (c *Client) publish(ctx context.Context, topic string, payload interface{}, contentType string) error {
+	return c.publishEnvelope(ctx, topic, newEnvelope(payload, contentType))
+}
+
+// newEnvelope 以指定内容类型构造待发布的信封
+func newEnvelope(payload interface{}, contentType string) types.MessageEnvelope {
+	return types.MessageEnvelope{
 		CorrelationID: uuid.NewString(),
 		Payload:       payload,
-		ContentType:   "application/json",
-	}, topic)
+		ContentType:   contentType,
+	}
+}
+
+// publishEnvelope 补充发送时间、追踪上下文和契约版本后发布信封
+// 配置了存储转发时，未连接、发布失败或队列中仍有待转发消息时信封会被暂存；启用 ConfirmPublish 时不暂存
+func (c *Client) publishEnvelope(ctx context.Context, topic string, envelope types.MessageEnvelope) error {
+	if c.config.ConfirmPublish {
+		return c.publishEnvelopeWithOptions(ctx, topic, envelope, c.confirmOptions())
+	}
+	return c.publishEnvelopeWithOptions(ctx, topic, envelope, nil)
+}
+
+// publishEnvelopeWithOptions 按发布选项发布信封，opts 为 nil 时使用主连接并支持存储转发
+func (c *Client) publishEnvelopeWithOptions(ctx context.Context, topic string, envelope types.MessageEnvelope, opts *PublishOptions) (err error) {
+	if c.closing.Load() && !isAcceptedPublish(ctx) {
+		return ErrClientClosing
+	}
+	queueable := opts == nil && c.outboxEnabled()
+	if !c.IsConnected() && !queueable {
+		return ErrNotConnected
+	}
+	ctx, span := c.startSpan(ctx, "publish", topic, trace.SpanKindProducer)
+	defer func() { c.endSpan(span, err) }()
+	stampSentAt(&envelope, time.Now())
+	c.stampPublisher(&envelope)
+	c.injectTraceContext(ctx, &envelope)
+	if envelope.ApiVersion == "" {
+		if err := applyContractVersion(c.contractVersion(), &envelope); err != nil {
+			return err
+		}
+	}
+	return c.interceptPublish(ctx, topic, envelope, func(ctx context.Context, topic string, envelope types.MessageEnvelope) error {
+		return c.sendEnvelope(ctx, topic, envelope, opts, queueable)
+	})
+}
+
+// sendEnvelope 在限速、存储转发和熔断控制下发出经过拦截器处理的信封
+func (c *Client) sendEnvelope(ctx context.Context, topic string, envelope types.MessageEnvelope, opts *PublishOptions, queueable bool) error {
+	size := payloadSize(envelope.Payload)
+	if err := c.waitPublishRate(ctx, topic, size); err != nil {
+		return err
+	}
+	if queueable && (!c.IsConnected() || c.outboxPending()) {
+		// 队列非空时新消息也需排队，保证按发布顺序转发
+		if err := c.enqueue(topic, envelope); err != nil {
+			return err
+		}
+		c.startOutboxDrain()
+		return nil
+	}
+	if err := c.breakerAllow(); err != nil {
+		if queueable {
+			return c.enqueue(topic, envelope)
+		}
+		return err
+	}
+	err := c.publishTo(topic, envelope, opts)
+	c.breakerRecord(err)
+	if err != nil {
+		c.stats.publishErrors.Add(1)
+		c.reconnectInBackground(err)
+		if queueable {
+			return c.enqueue(topic, envelope)
+		}
+		return publishError(topic, err)
+	}
+	c.metrics.ObservePublish()
+	c.stats.recordPublish(topic, size)
+	c.dumpPayload("publish", topic, envelope)
+	c.tap(TapOutbound, topic, envelope)
+	return nil
 }
 
 // Subscribe 订阅多个主题，并使用指定处理函数处理接收的消息
 func (c *Client) Subscribe(topics []string, handler MessageHandler) error {
+	return c.SubscribeWithOptions(topics, handler, SubscribeOptions{})
+}
+
+// SubscribeWithOptions 订阅多个主题，并按订阅选项处理接收的消息
+func (c *Client) SubscribeWithOptions(topics []string, handler MessageHandler, opts SubscribeOptions) error {
+	if err := validateSubscription(topics, handler); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
 	if !c.IsConnected() {
-		return fmt.Errorf("MessageBus未连接")
+		return ErrNotConnected
 	}
+	topics, err := c.sharedTopics(topics, opts.Group)
+	if err != nil {
+		return err
+	}
+	handler = c.applyMiddleware(handler)
+	topics = uniqueTopics(topics)
+	subs := make([]*subscription, len(topics))
 	topicChannels := make([]types.TopicChannel, len(topics))
 	for i, topic := range topics {
-		ch := make(chan types.MessageEnvelope, 100)
-		c.subscriptions[topic] = ch
-		topicChannels[i] = types.TopicChannel{Topic: topic, Messages: ch}
+		subs[i] = newSubscription(topic, handler, opts)
+		topicChannels[i] = types.TopicChannel{Topic: c.wireTopic(topic), Messages: subs[i].ingress}
 	}
-	if err := c.client.Subscribe(topicChannels, c.errorChan); err != nil {
+	subscriber, err := c.subscriberFor(opts)
+	if err != nil {
 		return err
 	}
+	if err := subscriber.Subscribe(topicChannels, c.errorChan); err != nil {
+		c.log(LogSubscribe).Error("订阅主题失败", c.logFields("topics", topics, "error", err)...)
+		return subscribeError("subscribe", topics, err)
+	}
+	c.mutex.Lock()
+	for _, sub := range subs {
+		if old, ok := c.subscriptions[sub.topic]; ok {
+			close(old.done)
+		}
+		c.subscriptions[sub.topic] = sub
+	}
+	c.mutex.Unlock()
+	for _, sub := range subs {
+		c.lifecycle.spawn(stageSubscribe, func(stop <-chan struct{}) {
+			c.handleMessages(sub, stop)
+		})
+		if sub.opts.usesIngress() {
+			c.lifecycle.spawn(stageSubscribe, func(stop <-chan struct{}) {
+				c.pumpMessages(sub, stop)
+			})
+		}
+	}
+	c.saveSubscriptionState()
+	c.emitEvent(LifecycleEvent{Type: EventSubscribed, Topics: topics})
+	return nil
+}
+
+// Unsubscribe 取消订阅指定主题，停止对应的消息处理 goroutine 并取消底层订阅
+//
+// 底层客户端在取消订阅后仍可能投递少量在途消息，因此消息通道不会被关闭，
+// 而是随订阅一起被丢弃，避免向已关闭通道发送导致 panic。
+func (c *Client) Unsubscribe(topics ...string) error {
+	if len(topics) == 0 {
+		return nil
+	}
+	if !c.IsConnected() {
+		return ErrNotConnected
+	}
+	topics = uniqueTopics(topics)
+	forgotPending := c.forgetPendingSubscriptions(topics)
+	c.mutex.Lock()
+	subs := make([]*subscription, 0, len(topics))
+	known := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		if sub, ok := c.subscriptions[topic]; ok {
+			subs = append(subs, sub)
+			known = append(known, topic)
+			delete(c.subscriptions, topic)
+		}
+	}
+	c.mutex.Unlock()
+	if len(known) == 0 {
+		if forgotPending {
+			c.saveSubscriptionState()
+		}
+		return nil
+	}
+	for _, sub := range subs {
+		close(sub.done)
+	}
+	for _, group := range groupByClientVariant(subs) {
+		subscriber, err := c.subscriberFor(group[0].opts)
+		if err == nil {
+			err = subscriber.Unsubscribe(c.wireTopics(group)...)
+		}
+		if err != nil {
+			c.log(LogSubscribe).Error("取消订阅失败", c.logFields("topics", known, "error", err)...)
+			return err
+		}
+	}
+	c.saveSubscriptionState()
+	c.log(LogSubscribe).Info("已取消订阅", c.logFields("topics", known)...)
+	c.emitEvent(LifecycleEvent{Type: EventUnsubscribed, Topics: known})
+	return nil
+}
+
+// validateSubscription 在注册时校验订阅参数，避免错误的处理函数直到收到第一条消息才暴露
+func validateSubscription(topics []string, handler MessageHandler) error {
+	if handler == nil {
+		return fmt.Errorf("消息处理函数不能为空")
+	}
+	if len(topics) == 0 {
+		return fmt.Errorf("订阅主题不能为空")
+	}
 	for _, topic := range topics {
-		c.wg.Add(1)
-		go c.handleMessages(topic, handler)
+		if topic == "" {
+			return fmt.Errorf("订阅主题不能为空字符串")
+		}
 	}
 	return nil
 }
 
-// handleMessages 处理订阅主题的消息循环
-func (c *Client) handleMessages(topic string, handler MessageHandler) {
-	defer c.wg.Done()
-	ch, ok := c.subscriptions[topic]
-	if !ok {
-		return
+// uniqueTopics 按原顺序去除重复的主题
+func uniqueTopics(topics []string) []string {
+	seen := make(map[string]struct{}, len(topics))
+	unique := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		if _, ok := seen[topic]; ok {
+			continue
+		}
+		seen[topic] = struct{}{}
+		unique = append(unique, topic)
 	}
+	return unique
+}
+
+// handleMessages 处理订阅主题的消息循环，启用优先级队列时总是先处理其中的消息
+func (c *Client) handleMessages(sub *subscription, stop <-chan struct{}) {
+	pool := newWorkerPool(sub, func(msg inboundMessage) {
+		c.process(sub, msg)
+		c.releaseBudget(msg.size)
+		c.handling.Add(-1)
+	})
+	defer pool.close()
 	for {
+		// 优先响应停止信号，剩余的缓冲消息交给受 DrainTimeout 约束的 drain 处理
 		select {
-		case msg, ok := <-ch:
-			if !ok {
+		case <-stop:
+			pool.close()
+			c.drain(sub)
+			return
+		default:
+		}
+		select {
+		case msg := <-sub.priority:
+			if !c.handleMessage(sub, pool, msg, stop) {
+				return
+			}
+			continue
+		default:
+		}
+		select {
+		case msg := <-sub.priority:
+			if !c.handleMessage(sub, pool, msg, stop) {
+				return
+			}
+		case msg, ok := <-sub.messages:
+			if !ok || !c.handleMessage(sub, pool, msg, stop) {
 				return
 			}
-			actualTopic := msg.ReceivedTopic
-			if actualTopic == "" {
-				actualTopic = topic
+		case msg := <-sub.redeliveries:
+			c.handling.Add(1)
+			if !c.deliver(sub, pool, msg, stop) {
+				return
+			}
+		case <-stop:
+			// 先等待 Worker 处理完已排队的消息，再排空通道，保持处理顺序
+			pool.close()
+			c.drain(sub)
+			return
+		case <-sub.done:
+			return
+		}
+	}
+}
+
+// handleMessage 过滤一条消息并交给 Worker 或直接处理，返回 false 表示消息循环应退出
+func (c *Client) handleMessage(sub *subscription, pool *workerPool, msg types.MessageEnvelope, stop <-chan struct{}) bool {
+	c.handling.Add(1)
+	c.trackOffset(msg)
+	if !sub.sample() {
+		c.stats.dropBySampling(sub.topic)
+		c.commitOffset(sub.topic, msg)
+		c.handling.Add(-1)
+		return true
+	}
+	if sub.expired(msg, time.Now()) {
+		c.stats.dropStale(sub.topic)
+		c.commitOffset(sub.topic, msg)
+		c.handling.Add(-1)
+		return true
+	}
+	if sub.opts.NoEcho && c.isEcho(msg) {
+		c.stats.dropEcho(sub.topic)
+		c.commitOffset(sub.topic, msg)
+		c.handling.Add(-1)
+		return true
+	}
+	return c.deliver(sub, pool, inboundMessage{envelope: msg, size: payloadSize(msg.Payload)}, stop)
+}
+
+// deliver 占用在途预算后将消息交给 Worker 或直接处理，返回 false 表示消息循环应退出
+// 重投的消息已通过过滤，从这里重新进入订阅队列，仍受 Worker 顺序和在途预算约束
+func (c *Client) deliver(sub *subscription, pool *workerPool, msg inboundMessage, stop <-chan struct{}) bool {
+	if c.budget != nil && !c.budget.acquire(msg.size, stop, sub.done) {
+		c.handling.Add(-1)
+		select {
+		case <-stop:
+			pool.close()
+			c.drain(sub)
+		default:
+		}
+		return false
+	}
+	if pool == nil {
+		c.process(sub, msg)
+		c.releaseBudget(msg.size)
+		c.handling.Add(-1)
+		return true
+	}
+	if !pool.submit(msg, stop, sub.done) {
+		c.releaseBudget(msg.size)
+		c.handling.Add(-1)
+		select {
+		case <-stop:
+			pool.close()
+			c.drain(sub)
+		default:
+		}
+		return false
+	}
+	return true
+}
+
+// releaseBudget 释放消息占用的在途字节预算
+func (c *Client) releaseBudget(size int64) {
+	if c.budget != nil {
+		c.budget.release(size)
+	}
+}
+
+// process 处理一条消息并提交消费位置；处理失败且已安排重投时暂不提交，由重投的消息处理完后提交
+func (c *Client) process(sub *subscription, msg inboundMessage) {
+	if !c.dispatch(sub, msg.envelope, msg.size) {
+		c.commitOffset(sub.topic, msg.envelope)
+	}
+}
+
+// dispatch 将消息交给处理函数，并记录处理失败的错误，返回 true 表示消息已安排重投
+// size 为收到时估算的 Payload 字节数，分块重组后按完整消息重新计算
+func (c *Client) dispatch(sub *subscription, msg types.MessageEnvelope, size int64) bool {
+	actualTopic := msg.ReceivedTopic
+	if actualTopic == "" {
+		actualTopic = sub.topic
+	}
+	chunked := msg.QueryParams[HeaderChunkID] != ""
+	msg, complete, err := c.reassemble(sub.topic, msg)
+	if err != nil {
+		c.log(LogSubscribe).Warn("丢弃无效的分块", c.logFields("topic", actualTopic, "error", err)...)
+		c.reportError("decode", actualTopic, &DecodeError{Topic: actualTopic, CorrelationID: msg.CorrelationID, Err: err})
+		return false
+	}
+	if !complete {
+		return false
+	}
+	if chunked {
+		size = payloadSize(msg.Payload)
+	}
+	start := time.Now()
+	raw := msg
+	redelivered := false
+	msg, err = c.decodeInbound(msg)
+	if err != nil {
+		err = &DecodeError{Topic: actualTopic, CorrelationID: msg.CorrelationID, Err: err}
+	} else {
+		c.dumpPayload("receive", actualTopic, msg)
+		c.tap(TapInbound, actualTopic, msg)
+		if err = c.checkInboundSchema(sub, actualTopic, msg); err == nil {
+			if err = c.callHandlerWithRetry(sub, actualTopic, msg); err != nil {
+				redelivered = c.handleFailure(sub, actualTopic, raw, size, err)
+			}
+		}
+	}
+	elapsed := time.Since(start)
+	c.metrics.ObserveHandler(sub.topic, elapsed, err)
+	c.stats.recordReceive(sub.topic, size, elapsed)
+	if err != nil {
+		c.stats.handlerErrors.Add(1)
+		if redelivered {
+			return true
+		}
+		c.log(LogSubscribe).Error("消息处理失败", c.logFields("topic", actualTopic, "correlationId", msg.CorrelationID, "error", err)...)
+		c.reportError("handle", actualTopic, err)
+	}
+	return false
+}
+
+// drain 在断开连接时于 DrainTimeout 内处理通道中剩余的缓冲消息
+// 每条消息的处理都受整体超时约束，阻塞的处理函数不会拖住断开流程
+func (c *Client) drain(sub *subscription) {
+	if c.config.DrainTimeout <= 0 {
+		return
+	}
+	deadline := time.NewTimer(c.config.DrainTimeout)
+	defer deadline.Stop()
+	for {
+		var msg inboundMessage
+		select {
+		case envelope := <-sub.priority:
+			msg = c.receive(envelope)
+		case msg = <-sub.redeliveries:
+		default:
+			select {
+			case envelope, ok := <-sub.messages:
+				if !ok {
+					return
+				}
+				msg = c.receive(envelope)
+			case <-deadline.C:
+				c.log(LogSubscribe).Warn("排空超时，放弃剩余缓冲消息", c.logFields("topic", sub.topic, "remaining", len(sub.messages)+len(sub.priority))...)
+				return
+			default:
+				return
 			}
-			_ = handler(actualTopic, msg)
-		case <-c.stopChan:
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			c.process(sub, msg)
+		}()
+		select {
+		case <-done:
+		case <-deadline.C:
+			c.log(LogSubscribe).Warn("排空超时，放弃正在处理的消息", c.logFields("topic", sub.topic, "correlationId", msg.envelope.CorrelationID)...)
 			return
 		}
 	}
 }
 
+// receive 登记排空时从通道读出的消息的消费位置，并估算 Payload 字节数
+func (c *Client) receive(envelope types.MessageEnvelope) inboundMessage {
+	c.trackOffset(envelope)
+	return inboundMessage{envelope: envelope, size: payloadSize(envelope.Payload)}
+}
+
+// messageClient 返回当前的底层消息客户端，凭据轮换时会被替换
+func (c *Client) messageClient() messaging.MessageClient {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.client
+}
+
 // IsConnected 判断当前是否已连接到 MessageBus
 func (c *Client) IsConnected() bool {
 	c.mutex.RLock()
@@ -171,6 +947,98 @@ func (c *Client) IsConnected() bool {
 	return c.isConnected
 }
 
+// GetSubscribedTopics 返回当前已订阅的主题列表
+func (c *Client) GetSubscribedTopics() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	topics := make([]string, 0, len(c.subscriptions))
+	for topic := range c.subscriptions {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// GetClientInfo 返回客户端的基本信息，包括连接参数、订阅数量和标签
+func (c *Client) GetClientInfo() map[string]interface{} {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	info := map[string]interface{}{
+		"clientId":       c.config.ClientID,
+		"host":           c.config.Host,
+		"port":           c.config.Port,
+		"protocol":       c.config.Protocol,
+		"type":           c.config.Type,
+		"isConnected":    c.isConnected,
+		"isReconnecting": c.reconnect.active,
+		"subscriptions":  len(c.subscriptions),
+		"tags":           c.Tags(),
+	}
+	if c.reconnect.active {
+		info["reconnectAttempt"] = c.reconnect.attempt
+		if !c.reconnect.nextRetry.IsZero() {
+			info["nextRetry"] = c.reconnect.nextRetry
+		}
+	}
+	return info
+}
+
+// Diagnostics 是客户端状态的快照，用于排查问题或导出到诊断接口
+type Diagnostics struct {
+	ClientID      string            // 当前使用的 ClientID
+	Type          string            // MessageBus 类型
+	Broker        string            // Broker 地址，格式为 <protocol>://<host>:<port>
+	Connected     bool              // 是否已连接
+	Reconnecting  bool              // 是否正在重连
+	Subscriptions []string          // 已订阅的主题，按字典序排列
+	Tags          map[string]string // 客户端标签
+	CircuitState  CircuitState      // 发布熔断器状态
+	LastError     *BusError         // 最近一次报告到错误通道的错误，没有时为 nil
+	Stats         Stats             // 运行时统计
+}
+
+// Diagnostics 返回客户端状态的快照，其中包含客户端标签
+func (c *Client) Diagnostics() Diagnostics {
+	c.mutex.RLock()
+	connected, reconnecting := c.isConnected, c.reconnect.active
+	c.mutex.RUnlock()
+	return Diagnostics{
+		ClientID:      c.ClientID(),
+		Type:          c.config.Type,
+		Broker:        fmt.Sprintf("%s://%s:%d", c.config.Protocol, c.config.Host, c.config.Port),
+		Connected:     connected,
+		Reconnecting:  reconnecting,
+		Subscriptions: c.GetSubscribedTopics(),
+		Tags:          c.Tags(),
+		CircuitState:  c.CircuitState(),
+		LastError:     c.lastError.Load(),
+		Stats:         c.Stats(),
+	}
+}
+
+// Tags 返回客户端标签的副本
+func (c *Client) Tags() map[string]string {
+	tags := make(map[string]string, len(c.config.Tags))
+	for k, v := range c.config.Tags {
+		tags[k] = v
+	}
+	return tags
+}
+
+// logFields 在给定的键值对后追加 clientId 和客户端标签，作为结构化日志字段
+func (c *Client) logFields(kv ...interface{}) []interface{} {
+	fields := append(kv, "clientId", c.config.ClientID)
+	keys := make([]string, 0, len(c.config.Tags))
+	for k := range c.config.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fields = append(fields, k, c.config.Tags[k])
+	}
+	return fields
+}
+
 // toPayload 将任意数据转换为字节切片
 func toPayload(data interface{}) (interface{}, error) {
 	switch v := data.(type) {
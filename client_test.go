@@ -0,0 +1,185 @@
+package messagebus_test
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+func newMockClient(t *testing.T, opts ...messagebus.Option) *messagebustest.MockClient {
+	t.Helper()
+	client, err := messagebustest.NewMockClient(opts...)
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestSubscribeRejectsInvalidArguments(t *testing.T) {
+	client := newMockClient(t)
+	handler := func(string, types.MessageEnvelope) error { return nil }
+	cases := []struct {
+		name    string
+		topics  []string
+		handler messagebus.MessageHandler
+	}{
+		{"nil handler", []string{"test/a"}, nil},
+		{"nil topics", nil, handler},
+		{"empty topics", []string{}, handler},
+		{"empty topic", []string{"test/a", ""}, handler},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			done := make(chan error, 1)
+			go func() { done <- client.Subscribe(tc.topics, tc.handler) }()
+			select {
+			case err := <-done:
+				if err == nil {
+					t.Fatal("期望订阅失败")
+				}
+			case <-time.After(time.Second):
+				t.Fatal("订阅未立即返回")
+			}
+			if topics := client.GetSubscribedTopics(); len(topics) != 0 {
+				t.Fatalf("订阅失败后不应留下订阅，实际 %v", topics)
+			}
+		})
+	}
+}
+
+// captureLogger 记录所有日志调用，用于断言结构化字段
+type captureLogger struct {
+	mutex sync.Mutex
+	lines []logLine
+}
+
+type logLine struct {
+	level string
+	msg   string
+	args  []interface{}
+}
+
+func (l *captureLogger) record(level, msg string, args []interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.lines = append(l.lines, logLine{level: level, msg: msg, args: args})
+}
+
+// find 返回第一条消息为 msg 的日志
+func (l *captureLogger) find(msg string) (logLine, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, line := range l.lines {
+		if line.msg == msg {
+			return line, true
+		}
+	}
+	return logLine{}, false
+}
+
+func (l *captureLogger) SetLogLevel(string) error              { return nil }
+func (l *captureLogger) LogLevel() string                      { return "TRACE" }
+func (l *captureLogger) Trace(msg string, args ...interface{}) { l.record("TRACE", msg, args) }
+func (l *captureLogger) Debug(msg string, args ...interface{}) { l.record("DEBUG", msg, args) }
+func (l *captureLogger) Info(msg string, args ...interface{})  { l.record("INFO", msg, args) }
+func (l *captureLogger) Warn(msg string, args ...interface{})  { l.record("WARN", msg, args) }
+func (l *captureLogger) Error(msg string, args ...interface{}) { l.record("ERROR", msg, args) }
+func (l *captureLogger) Tracef(msg string, args ...interface{}) {
+	l.record("TRACE", fmt.Sprintf(msg, args...), nil)
+}
+func (l *captureLogger) Debugf(msg string, args ...interface{}) {
+	l.record("DEBUG", fmt.Sprintf(msg, args...), nil)
+}
+func (l *captureLogger) Infof(msg string, args ...interface{}) {
+	l.record("INFO", fmt.Sprintf(msg, args...), nil)
+}
+func (l *captureLogger) Warnf(msg string, args ...interface{}) {
+	l.record("WARN", fmt.Sprintf(msg, args...), nil)
+}
+func (l *captureLogger) Errorf(msg string, args ...interface{}) {
+	l.record("ERROR", fmt.Sprintf(msg, args...), nil)
+}
+
+// fields 将键值对形式的日志参数转换为映射
+func fields(args []interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		if key, ok := args[i].(string); ok {
+			m[key] = args[i+1]
+		}
+	}
+	return m
+}
+
+func TestTagsInLogsAndDiagnostics(t *testing.T) {
+	lc := &captureLogger{}
+	tags := map[string]string{"tenant": "acme", "region": "eu-1"}
+	client := newMockClient(t, messagebus.WithLogger(lc), messagebus.WithTags(tags))
+
+	line, ok := lc.find("已连接到MessageBus")
+	if !ok {
+		t.Fatal("未记录连接日志")
+	}
+	got := fields(line.args)
+	for k, v := range tags {
+		if got[k] != v {
+			t.Errorf("日志字段 %s = %v，期望 %s（全部字段 %v）", k, got[k], v, got)
+		}
+	}
+	if got["clientId"] != client.ClientID() {
+		t.Errorf("日志字段 clientId = %v，期望 %s", got["clientId"], client.ClientID())
+	}
+
+	diag := client.Diagnostics()
+	if !reflect.DeepEqual(diag.Tags, tags) {
+		t.Errorf("Diagnostics().Tags = %v，期望 %v", diag.Tags, tags)
+	}
+	if !diag.Connected {
+		t.Error("Diagnostics().Connected 应为 true")
+	}
+	diag.Tags["tenant"] = "changed"
+	if client.Tags()["tenant"] != "acme" {
+		t.Error("修改 Diagnostics 返回的标签不应影响客户端")
+	}
+}
+
+func TestSubscribeDeduplicatesTopics(t *testing.T) {
+	client := newMockClient(t)
+	var mutex sync.Mutex
+	calls := 0
+	err := client.Subscribe([]string{"test/#", "test/#"}, func(string, types.MessageEnvelope) error {
+		mutex.Lock()
+		calls++
+		mutex.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topics := client.GetSubscribedTopics(); !reflect.DeepEqual(topics, []string{"test/#"}) {
+		t.Fatalf("GetSubscribedTopics() = %v，期望只有一个订阅", topics)
+	}
+	if err := client.Publish("test/a", "x"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(client.ReceivedMessages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if received := len(client.ReceivedMessages()); received != 1 {
+		t.Fatalf("底层收到 %d 条消息，期望 1 条", received)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if calls != 1 {
+		t.Fatalf("处理函数被调用 %d 次，期望 1 次", calls)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/clint456/edgex-messagebus-client/bench"
+)
+
+// 压测报告格式
+const (
+	reportText = "text"
+	reportJSON = "json"
+	reportCSV  = "csv"
+)
+
+// runBench 按参数生成压测流量，统计发布延迟和回环端到端延迟并输出报告
+func runBench(ctx context.Context, args []string) error {
+	fs, cf := newFlagSet("bench", "")
+	var config bench.Config
+	fs.StringVar(&config.Topic, "topic", "", "压测主题，可包含 {n} 占位符，默认为 edgex/bench/<随机 ID>/{n}")
+	fs.IntVar(&config.Topics, "topics", 1, "主题数，消息轮流发布到各主题")
+	fs.IntVar(&config.Count, "count", 1000, "发布的消息总数")
+	fs.DurationVar(&config.Duration, "duration", 0, "最长发布时长，0 表示只受 -count 限制")
+	fs.IntVar(&config.Size, "size", 256, "每条消息的 Payload 字节数")
+	fs.Float64Var(&config.Rate, "rate", 0, "每秒发布的消息数，0 表示不限速")
+	fs.IntVar(&config.Publishers, "publishers", 1, "并发发布者数")
+	fs.BoolVar(&config.NoLoopback, "no-loopback", false, "不订阅压测主题，只统计发布延迟")
+	fs.DurationVar(&config.Wait, "wait", 5*time.Second, "发布完成后等待回环消息的最长时间")
+	format := fs.String("format", reportText, "报告格式 (text, json, csv)")
+	output := fs.String("output", "", "报告输出文件，默认为标准输出；csv 格式追加到已有文件")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch *format {
+	case reportText, reportJSON, reportCSV:
+	default:
+		return fmt.Errorf("不支持的报告格式: %s", *format)
+	}
+	client, err := cf.connect()
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect()
+
+	report, err := bench.Run(ctx, client, config)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = os.Stdout
+	header := true
+	if *output != "" {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if *format == reportCSV {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+			if info, err := os.Stat(*output); err == nil && info.Size() > 0 {
+				header = false
+			}
+		}
+		file, err := os.OpenFile(*output, flags, 0o644)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	switch *format {
+	case reportJSON:
+		return report.WriteJSON(w)
+	case reportCSV:
+		return report.WriteCSV(w, header)
+	}
+	return writeTextReport(w, report)
+}
+
+// writeTextReport 以便于阅读的文本输出报告
+func writeTextReport(w io.Writer, r *bench.Report) error {
+	latency := func(name string, l bench.Latency) string {
+		if l.Samples == 0 {
+			return name + " -\n"
+		}
+		return fmt.Sprintf("%s p50=%s p90=%s p95=%s p99=%s max=%s\n", name,
+			l.P50.Round(time.Microsecond), l.P90.Round(time.Microsecond), l.P95.Round(time.Microsecond),
+			l.P99.Round(time.Microsecond), l.Max.Round(time.Microsecond))
+	}
+	_, err := fmt.Fprintf(w, "主题:      %s (%d 个)\n"+
+		"发布:      %d 条，失败 %d 条，%.0f 条/秒，%.0f 字节/秒\n"+
+		"接收:      %d 条，丢失 %d 条，%.0f 条/秒\n"+
+		"耗时:      %s\n%s%s",
+		r.Topic, r.Topics,
+		r.Published, r.PublishErrors, r.PublishRate, r.Throughput,
+		r.Received, r.Lost, r.ReceiveRate,
+		r.Duration.Round(time.Millisecond),
+		latency("发布延迟:  ", r.PublishLatency), latency("端到端:    ", r.EndToEnd))
+	return err
+}
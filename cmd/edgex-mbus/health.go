@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+)
+
+// runHealth 连接 Broker 并输出与 HealthHandler 相同的健康状态 JSON，不可用时返回错误
+func runHealth(ctx context.Context, args []string) error {
+	fs, cf := newFlagSet("health", "")
+	probeTimeout := fs.Duration("probe-timeout", 2*time.Second, "等待探测消息经 Broker 返回的超时")
+	local := fs.Bool("local", false, "只检查本地连接状态，不经 Broker 往返探测")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	client, err := cf.connect(messagebus.WithHealthProbe(*probeTimeout))
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect()
+
+	path := "/readyz"
+	if *local {
+		path = "/healthz"
+	}
+	request := httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx)
+	recorder := httptest.NewRecorder()
+	client.HealthHandler().ServeHTTP(recorder, request)
+	os.Stdout.Write(recorder.Body.Bytes())
+	if recorder.Code != http.StatusOK {
+		return fmt.Errorf("MessageBus 不可用 (HTTP %d)", recorder.Code)
+	}
+	return nil
+}
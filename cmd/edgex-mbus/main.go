@@ -0,0 +1,220 @@
+// edgex-mbus 是基于 messagebus 包的命令行工具，用于在 Shell 中订阅、发布、请求和检查 EdgeX MessageBus
+//
+// 用法:
+//
+//	edgex-mbus <sub|pub|req|health|bench> [flags] [args]
+//
+// 连接参数可以来自 -config 指定的配置文件、MESSAGEBUS_* 环境变量和命令行参数，命令行参数优先。
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+)
+
+// command 表示一个子命令
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{name: "sub", summary: "订阅主题并打印收到的消息", run: runSub},
+	{name: "pub", summary: "向主题发布消息", run: runPub},
+	{name: "req", summary: "发送请求并打印响应", run: runReq},
+	{name: "health", summary: "检查与 Broker 的连接和往返延迟", run: runHealth},
+	{name: "bench", summary: "发布压测消息并统计吞吐量和延迟", run: runBench},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "-h" || name == "-help" || name == "--help" || name == "help" {
+		usage()
+		return
+	}
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := cmd.run(ctx, os.Args[2:])
+		stop()
+		if err != nil && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "edgex-mbus %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "未知的子命令: %s\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法: edgex-mbus <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr, "\n子命令:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\n使用 edgex-mbus <command> -h 查看子命令的参数")
+}
+
+// clientFlags 表示各子命令共用的连接参数，与 messagebus.Config 对应
+type clientFlags struct {
+	fs             *flag.FlagSet
+	configFile     string
+	host           string
+	port           int
+	protocol       string
+	busType        string
+	clientID       string
+	clientIDPrefix string
+	username       string
+	password       string
+	qos            int
+	clean          bool
+	wsPath         string
+	proxyURL       string
+	certFile       string
+	keyFile        string
+	caFile         string
+	insecure       bool
+	prefix         string
+	logLevel       string
+}
+
+// newFlagSet 创建注册了连接参数的子命令参数集
+func newFlagSet(name, args string) (*flag.FlagSet, *clientFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	f := &clientFlags{fs: fs}
+	fs.StringVar(&f.configFile, "config", "", "配置文件 (.yaml/.yml/.toml/.json)")
+	fs.StringVar(&f.host, "host", "localhost", "Broker 地址")
+	fs.IntVar(&f.port, "port", 1883, "Broker 端口")
+	fs.StringVar(&f.protocol, "protocol", "tcp", "连接协议 (tcp, ssl, tls, ws, wss)")
+	fs.StringVar(&f.busType, "type", messagebus.TypeMQTT, "MessageBus 类型 (mqtt, nats-core, nats-jetstream)")
+	fs.StringVar(&f.clientID, "client-id", "", "客户端 ID，默认按 -client-id-prefix 自动生成")
+	fs.StringVar(&f.clientIDPrefix, "client-id-prefix", "edgex-mbus", "自动生成客户端 ID 的前缀，生成结果为 <前缀>-<主机名>-<随机后缀>")
+	fs.StringVar(&f.username, "username", "", "用户名")
+	fs.StringVar(&f.password, "password", "", "密码")
+	fs.IntVar(&f.qos, "qos", 0, "MQTT QoS (0, 1, 2)")
+	fs.BoolVar(&f.clean, "clean-session", true, "MQTT Clean Session，设为 false 并指定 -client-id 时接收离线期间的 QoS 1/2 消息")
+	fs.StringVar(&f.wsPath, "ws-path", "", "ws/wss 协议的握手路径，例如 /mqtt")
+	fs.StringVar(&f.proxyURL, "proxy", "", "连接 Broker 使用的代理，例如 http://proxy:3128 或 socks5://proxy:1080")
+	fs.StringVar(&f.certFile, "cert", "", "客户端证书文件")
+	fs.StringVar(&f.keyFile, "key", "", "客户端私钥文件")
+	fs.StringVar(&f.caFile, "ca", "", "CA 证书文件")
+	fs.BoolVar(&f.insecure, "insecure", false, "跳过 Broker 证书校验")
+	fs.StringVar(&f.prefix, "topic-prefix", "", "主题命名空间前缀")
+	fs.StringVar(&f.logLevel, "log-level", "ERROR", "日志级别 (TRACE, DEBUG, INFO, WARN, ERROR)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: edgex-mbus %s [flags] %s\n\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs, f
+}
+
+// config 按配置文件、环境变量、命令行参数的顺序合并出客户端配置，只有显式设置的命令行参数会覆盖前两者
+func (f *clientFlags) config() (messagebus.Config, error) {
+	config, err := messagebus.LoadConfig(f.configFile)
+	if err != nil {
+		return config, err
+	}
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "host":
+			config.Host = f.host
+		case "port":
+			config.Port = f.port
+		case "protocol":
+			config.Protocol = f.protocol
+		case "type":
+			config.Type = f.busType
+		case "client-id":
+			config.ClientID = f.clientID
+			config.ClientIDPrefix = ""
+		case "client-id-prefix":
+			config.ClientIDPrefix = f.clientIDPrefix
+		case "username":
+			config.Username = f.username
+		case "password":
+			config.Password = f.password
+		case "qos":
+			config.QoS = f.qos
+		case "clean-session":
+			config.CleanSession = messagebus.CleanSessionFlag(f.clean)
+		case "ws-path":
+			config.WebSocket.Path = f.wsPath
+		case "proxy":
+			config.ProxyURL = f.proxyURL
+		case "cert":
+			config.CertFile = f.certFile
+		case "key":
+			config.KeyFile = f.keyFile
+		case "ca":
+			config.CAFile = f.caFile
+		case "insecure":
+			config.SkipCertVerify = f.insecure
+		case "topic-prefix":
+			config.TopicPrefix = f.prefix
+		}
+	})
+	if config.ClientID == "" && config.ClientIDPrefix == "" {
+		config.ClientIDPrefix = f.clientIDPrefix
+	}
+	return config, nil
+}
+
+// connect 创建客户端并连接到 Broker
+func (f *clientFlags) connect(opts ...messagebus.Option) (*messagebus.Client, error) {
+	config, err := f.config()
+	if err != nil {
+		return nil, err
+	}
+	all := append([]messagebus.Option{
+		messagebus.WithConfig(config),
+		messagebus.WithLogger(logger.NewClient("edgex-mbus", strings.ToUpper(f.logLevel))),
+	}, opts...)
+	client, err := messagebus.NewClientWithOptions(all...)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Connect(); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// headerFlag 收集可重复的 key=value 参数
+type headerFlag map[string]string
+
+func (h headerFlag) String() string {
+	pairs := make([]string, 0, len(h))
+	for k, v := range h {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (h headerFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("参数格式应为 key=value: %s", value)
+	}
+	h[key] = val
+	return nil
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/brokertest"
+)
+
+// parseConfig 按子命令的方式解析连接参数并返回合并后的配置
+func parseConfig(t *testing.T, args ...string) messagebus.Config {
+	t.Helper()
+	fs, cf := newFlagSet("test", "")
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	config, err := cf.config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+// captureStdout 在 fn 执行期间将标准输出重定向到管道，返回写入的内容
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	output := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		output <- buf.String()
+	}()
+	defer func() {
+		os.Stdout = stdout
+	}()
+	fn()
+	_ = w.Close()
+	return <-output
+}
+
+func TestClientFlagsDefaults(t *testing.T) {
+	config := parseConfig(t)
+	if config.Host != "localhost" || config.Port != 1883 || config.Protocol != "tcp" || config.Type != messagebus.TypeMQTT {
+		t.Errorf("默认连接参数不符: %+v", config)
+	}
+	if config.ClientID != "" || config.ClientIDPrefix != "edgex-mbus" {
+		t.Errorf("ClientID=%q ClientIDPrefix=%q，期望按 edgex-mbus 前缀生成", config.ClientID, config.ClientIDPrefix)
+	}
+	if config.CleanSession != nil {
+		t.Errorf("未指定 -clean-session 时不应设置 CleanSession")
+	}
+}
+
+func TestClientFlagsMapping(t *testing.T) {
+	config := parseConfig(t,
+		"-host", "broker", "-port", "8883", "-protocol", "ssl", "-type", messagebus.TypeNatsCore,
+		"-client-id", "svc-1", "-username", "user", "-password", "secret", "-qos", "1",
+		"-clean-session=false", "-ws-path", "/mqtt", "-proxy", "socks5://proxy:1080",
+		"-cert", "c.pem", "-key", "k.pem", "-ca", "ca.pem", "-insecure", "-topic-prefix", "site1",
+	)
+	checks := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"Host", config.Host, "broker"},
+		{"Port", config.Port, 8883},
+		{"Protocol", config.Protocol, "ssl"},
+		{"Type", config.Type, messagebus.TypeNatsCore},
+		{"ClientID", config.ClientID, "svc-1"},
+		{"ClientIDPrefix", config.ClientIDPrefix, ""},
+		{"Username", config.Username, "user"},
+		{"Password", config.Password, "secret"},
+		{"QoS", config.QoS, 1},
+		{"WebSocket.Path", config.WebSocket.Path, "/mqtt"},
+		{"ProxyURL", config.ProxyURL, "socks5://proxy:1080"},
+		{"CertFile", config.CertFile, "c.pem"},
+		{"KeyFile", config.KeyFile, "k.pem"},
+		{"CAFile", config.CAFile, "ca.pem"},
+		{"SkipCertVerify", config.SkipCertVerify, true},
+		{"TopicPrefix", config.TopicPrefix, "site1"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v，期望 %v", c.name, c.got, c.want)
+		}
+	}
+	if config.CleanSession == nil || *config.CleanSession {
+		t.Errorf("-clean-session=false 未映射到 CleanSession")
+	}
+}
+
+func TestClientFlagsPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "host: filehost\nport: 1884\nclientId: file-client\nusername: fileuser\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MESSAGEBUS_PORT", "1885")
+	t.Setenv("MESSAGEBUS_USERNAME", "envuser")
+
+	config := parseConfig(t, "-config", path, "-username", "flaguser")
+	if config.Host != "filehost" {
+		t.Errorf("Host = %q，期望来自配置文件", config.Host)
+	}
+	if config.Port != 1885 {
+		t.Errorf("Port = %d，环境变量应覆盖配置文件", config.Port)
+	}
+	if config.Username != "flaguser" {
+		t.Errorf("Username = %q，命令行参数应覆盖环境变量", config.Username)
+	}
+	if config.ClientID != "file-client" || config.ClientIDPrefix != "" {
+		t.Errorf("ClientID=%q ClientIDPrefix=%q，配置文件指定 ClientID 时不应生成", config.ClientID, config.ClientIDPrefix)
+	}
+
+	config = parseConfig(t, "-config", path, "-client-id-prefix", "tool")
+	if config.ClientIDPrefix != "tool" {
+		t.Errorf("ClientIDPrefix = %q，期望 tool", config.ClientIDPrefix)
+	}
+}
+
+func TestSubcommandArgumentErrors(t *testing.T) {
+	ctx := context.Background()
+	stderr := os.Stderr
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stderr = devNull
+	defer func() { os.Stderr = stderr }()
+
+	cases := []struct {
+		name string
+		run  func(context.Context, []string) error
+		args []string
+	}{
+		{"pub 缺少主题", runPub, nil},
+		{"pub 参数过多", runPub, []string{"a", "b", "c"}},
+		{"sub 缺少主题", runSub, nil},
+		{"sub 输出格式", runSub, []string{"-format", "xml", "test/a"}},
+		{"req 缺少主题", runReq, nil},
+		{"req 输出格式", runReq, []string{"-format", "xml", "test/a"}},
+		{"未知参数", runHealth, []string{"-unknown"}},
+	}
+	for _, c := range cases {
+		if err := c.run(ctx, c.args); err == nil {
+			t.Errorf("%s: 期望返回错误", c.name)
+		}
+	}
+}
+
+func TestPubSubCommands(t *testing.T) {
+	broker := brokertest.StartMQTT(t)
+	conn := []string{"-host", broker.Host, "-port", strconv.Itoa(broker.Port)}
+
+	pubCtx, cancelPub := context.WithCancel(context.Background())
+	defer cancelPub()
+	pubDone := make(chan error, 1)
+	output := captureStdout(t, func() {
+		subDone := make(chan error, 1)
+		go func() {
+			subDone <- runSub(context.Background(), append(conn, "-count", "1", "-timeout", "5s", "test/cli/#"))
+		}()
+		// sub 连接前发布的消息会丢失，因此重复发布直到 sub 收到一条
+		go func() {
+			pubDone <- runPub(pubCtx, append(conn, "-repeat", "250", "-interval", "20ms", "-header", "k=v", "-correlation-id", "c1", "test/cli/a", `{"n":1}`))
+		}()
+		if err := <-subDone; err != nil {
+			t.Errorf("sub 返回错误: %v", err)
+		}
+	})
+	cancelPub()
+	if err := <-pubDone; err != nil {
+		t.Errorf("pub 返回错误: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("sub 输出 %d 行，期望 1 行: %q", len(lines), output)
+	}
+	var printed struct {
+		Topic         string            `json:"topic"`
+		CorrelationID string            `json:"correlationId"`
+		Headers       map[string]string `json:"headers"`
+		Payload       json.RawMessage   `json:"payload"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &printed); err != nil {
+		t.Fatal(err)
+	}
+	if printed.Topic != "test/cli/a" || printed.CorrelationID != "c1" || printed.Headers["k"] != "v" || string(printed.Payload) != `{"n":1}` {
+		t.Errorf("sub 输出不符: %s", lines[0])
+	}
+}
+
+func TestReqCommand(t *testing.T) {
+	broker := brokertest.StartMQTT(t)
+	conn := []string{"-host", broker.Host, "-port", strconv.Itoa(broker.Port)}
+	responder := broker.NewClient(t)
+	if err := responder.RegisterRequestHandler("test/cli/command", func(_ context.Context, request types.MessageEnvelope) (interface{}, error) {
+		return map[string]string{"requestId": request.RequestID}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var runErr error
+	output := captureStdout(t, func() {
+		runErr = runReq(context.Background(), append(conn, "-request-id", "r1", "-timeout", "5s", "-format", "raw", "test/cli/command", `{}`))
+	})
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &payload); err != nil || payload["requestId"] != "r1" {
+		t.Errorf("req 输出 %q，期望响应 Payload 中 requestId 为 r1", output)
+	}
+}
+
+func TestHealthCommand(t *testing.T) {
+	broker := brokertest.StartMQTT(t)
+	conn := []string{"-host", broker.Host, "-port", strconv.Itoa(broker.Port)}
+
+	for _, args := range [][]string{{"-local"}, {"-probe-timeout", "2s"}} {
+		var runErr error
+		output := captureStdout(t, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			runErr = runHealth(ctx, append(conn, args...))
+		})
+		if runErr != nil {
+			t.Errorf("health %v 返回错误: %v", args, runErr)
+		}
+		if !json.Valid([]byte(output)) {
+			t.Errorf("health %v 输出不是 JSON: %q", args, output)
+		}
+	}
+}
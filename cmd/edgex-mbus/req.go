@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+)
+
+// runReq 向请求主题发送请求，等待响应并按输出格式打印，响应 ErrorCode 非 0 时返回错误
+func runReq(_ context.Context, args []string) error {
+	fs, cf := newFlagSet("req", "<request-topic> [payload]")
+	pf := addPayloadFlags(fs)
+	responseTopic := fs.String("response-topic", "", "响应主题，默认为 edgex/response/edgex-mbus/<随机 ID>")
+	requestID := fs.String("request-id", "", "RequestID，默认自动生成")
+	timeout := fs.Duration("timeout", 5*time.Second, "等待响应的超时")
+	format := fs.String("format", formatJSON, "输出格式 (json, raw, record)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 || fs.NArg() > 2 {
+		fs.Usage()
+		return fmt.Errorf("需要请求主题和可选的 Payload")
+	}
+	printer, err := newMessagePrinter(*format)
+	if err != nil {
+		return err
+	}
+	envelope, err := pf.envelope(fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+	if *responseTopic == "" {
+		*responseTopic = "edgex/response/edgex-mbus/" + uuid.NewString()
+	}
+	client, err := cf.connect()
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect()
+
+	response, err := client.RequestWithOptions(envelope, fs.Arg(0), *responseTopic, *timeout, messagebus.RequestOptions{RequestID: *requestID})
+	var requestErr *messagebus.RequestError
+	if err != nil && !errors.As(err, &requestErr) {
+		return err
+	}
+	topic := response.ReceivedTopic
+	if topic == "" {
+		topic = *responseTopic
+	}
+	if printErr := printer.print(topic, *response); printErr != nil {
+		return printErr
+	}
+	return err
+}
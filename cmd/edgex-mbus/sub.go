@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+)
+
+// 消息输出格式
+const (
+	formatJSON   = "json"   // 每行一个 JSON 对象，Payload 为 JSON 时原样嵌入，否则为字符串
+	formatRaw    = "raw"    // 每行一条消息的原始 Payload
+	formatRecord = "record" // 录制文件格式，可直接交给 messagebus.Replayer 回放
+)
+
+// printedMessage 表示 json 格式输出的一条消息
+type printedMessage struct {
+	Time          time.Time         `json:"time"`
+	Topic         string            `json:"topic"`
+	CorrelationID string            `json:"correlationId,omitempty"`
+	RequestID     string            `json:"requestId,omitempty"`
+	ContentType   string            `json:"contentType,omitempty"`
+	ErrorCode     int               `json:"errorCode,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Payload       interface{}       `json:"payload"`
+}
+
+// messagePrinter 按输出格式将消息写到标准输出
+type messagePrinter struct {
+	mutex   sync.Mutex
+	format  string
+	encoder *json.Encoder
+}
+
+func newMessagePrinter(format string) (*messagePrinter, error) {
+	switch format {
+	case formatJSON, formatRaw, formatRecord:
+	default:
+		return nil, fmt.Errorf("不支持的输出格式: %s", format)
+	}
+	return &messagePrinter{format: format, encoder: json.NewEncoder(os.Stdout)}, nil
+}
+
+func (p *messagePrinter) print(topic string, message types.MessageEnvelope) error {
+	payload, err := messagebus.EnvelopePayloadBytes(message)
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	switch p.format {
+	case formatRaw:
+		_, err = fmt.Fprintf(os.Stdout, "%s\n", payload)
+		return err
+	case formatRecord:
+		return p.encoder.Encode(messagebus.RecordedMessage{
+			Time:          time.Now(),
+			Direction:     messagebus.TapInbound,
+			Topic:         topic,
+			CorrelationID: message.CorrelationID,
+			RequestID:     message.RequestID,
+			ContentType:   message.ContentType,
+			Headers:       message.QueryParams,
+			Payload:       payload,
+		})
+	}
+	var body interface{} = string(payload)
+	if json.Valid(payload) {
+		body = json.RawMessage(payload)
+	}
+	return p.encoder.Encode(printedMessage{
+		Time:          time.Now(),
+		Topic:         topic,
+		CorrelationID: message.CorrelationID,
+		RequestID:     message.RequestID,
+		ContentType:   message.ContentType,
+		ErrorCode:     message.ErrorCode,
+		Headers:       message.QueryParams,
+		Payload:       body,
+	})
+}
+
+// runSub 订阅主题并打印收到的消息，收到 -count 条消息、超过 -timeout 或收到中断信号后退出
+func runSub(ctx context.Context, args []string) error {
+	fs, cf := newFlagSet("sub", "<topic>...")
+	count := fs.Int("count", 0, "收到指定数量的消息后退出，0 表示不限制")
+	timeout := fs.Duration("timeout", 0, "运行指定时长后退出，0 表示不限制")
+	format := fs.String("format", formatJSON, "输出格式 (json, raw, record)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("至少需要一个订阅主题")
+	}
+	printer, err := newMessagePrinter(*format)
+	if err != nil {
+		return err
+	}
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	client, err := cf.connect()
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var received sync.Mutex
+	seen := 0
+	err = client.Subscribe(fs.Args(), func(topic string, message types.MessageEnvelope) error {
+		received.Lock()
+		defer received.Unlock()
+		if *count > 0 && seen >= *count {
+			return nil
+		}
+		if err := printer.print(topic, message); err != nil {
+			fmt.Fprintf(os.Stderr, "输出消息失败: %v\n", err)
+		}
+		seen++
+		if *count > 0 && seen >= *count {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return nil
+}
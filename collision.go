@@ -0,0 +1,283 @@
+package messagebus
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+)
+
+// ClientID 冲突检测的默认值
+const (
+	defaultCollisionThreshold = 3
+	defaultCollisionWindow    = time.Minute
+)
+
+// ClientIDCollisionConfig 表示 Broker 端 ClientID 冲突的检测与处理参数
+//
+// MQTT Broker 收到相同 ClientID 的新连接时会断开旧连接（会话接管）；两个实例使用相同 ClientID 且都开启
+// 自动重连时会反复互相踢下线，表现为没有任何错误的断线循环。
+type ClientIDCollisionConfig struct {
+	// Detect 为 true 时统计 Broker 主动断开连接的次数，判定为冲突时记录告警并调用 OnClientIDTakeover 回调
+	Detect bool
+	// Threshold Window 内 Broker 主动断开连接达到该次数时判定为冲突，默认 3；
+	// Broker 明确告知会话被接管（MQTT 5 原因码 0x8E）时立即判定
+	Threshold int
+	// Window 统计断开次数的时间窗口，默认 1 分钟
+	Window time.Duration
+	// Resolve 为 true 时判定为冲突后改用新生成的 ClientID 重新连接，不能与持久会话同时使用
+	Resolve bool
+}
+
+// BrokerDisconnectNotifier 由能够观察到 Broker 主动断开连接的底层客户端实现，
+// 客户端据此检测 ClientID 冲突，并在启用 Reconnect 时按配置的退避参数重连
+type BrokerDisconnectNotifier interface {
+	// NotifyBrokerDisconnect 注册连接被 Broker 断开时的回调，takeover 为 true 表示 Broker 明确告知会话被接管
+	NotifyBrokerDisconnect(handler func(takeover bool))
+}
+
+// ClientIDTakeover 描述一次检测到的 ClientID 冲突
+type ClientIDTakeover struct {
+	ClientID    string // 发生冲突的 ClientID
+	Disconnects int    // 统计窗口内 Broker 主动断开连接的次数
+	Confirmed   bool   // Broker 明确告知会话被接管，而不是按断开次数推断
+	NewClientID string // 启用 Resolve 时改用的 ClientID，否则为空
+}
+
+// ClientIDTakeoverHandler 在检测到 ClientID 冲突后被调用
+type ClientIDTakeoverHandler func(takeover ClientIDTakeover)
+
+// collisionState 记录 Broker 主动断开连接的时间
+type collisionState struct {
+	mutex       sync.Mutex
+	disconnects []time.Time
+	resolving   bool
+}
+
+// GenerateClientID 生成形如 <prefix>-<主机名>-<8 位随机十六进制> 的 ClientID，prefix 为空时省略
+// 主机名取第一段，其中 MQTT 不允许的字符替换为 -
+func GenerateClientID(prefix string) string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	host, _, _ = strings.Cut(host, ".")
+	host = strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '-'
+	}, host)
+	parts := []string{host, randomSuffix()}
+	if prefix != "" {
+		parts = append([]string{prefix}, parts...)
+	}
+	return strings.Join(parts, "-")
+}
+
+// randomSuffix 返回 8 位随机十六进制字符串
+func randomSuffix() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// clientIDOptions 校验 ClientIDPrefix 与冲突检测参数
+func clientIDOptions(config Config) error {
+	if config.ClientIDPrefix != "" {
+		if config.ClientID != "" {
+			return fmt.Errorf("ClientID 与 ClientIDPrefix 不能同时设置")
+		}
+		if strings.EqualFold(config.Type, TypeMQTT) && strings.ContainsAny(config.ClientIDPrefix, "+#/") {
+			return fmt.Errorf("MQTT ClientIDPrefix 不能包含 +、# 或 /")
+		}
+		if config.persistentSession() {
+			return fmt.Errorf("持久会话需要固定的 ClientID，不能使用 ClientIDPrefix 自动生成")
+		}
+	}
+	collision := config.ClientIDCollision
+	if collision.Threshold < 0 || collision.Window < 0 {
+		return fmt.Errorf("ClientIDCollision 的 Threshold 和 Window 不能为负数")
+	}
+	if !collision.Detect {
+		if collision.Resolve {
+			return fmt.Errorf("ClientIDCollision.Resolve 需要同时启用 Detect")
+		}
+		return nil
+	}
+	if !strings.EqualFold(config.Type, TypeMQTT) {
+		return fmt.Errorf("ClientID 冲突检测仅支持 %s 类型", TypeMQTT)
+	}
+	if collision.Resolve && config.persistentSession() {
+		return fmt.Errorf("持久会话按 ClientID 保留，ClientIDCollision.Resolve 不能与 CleanSession=false 同时使用")
+	}
+	return nil
+}
+
+// OnClientIDTakeover 注册检测到 ClientID 冲突后的回调；传入 nil 取消回调
+// 需要启用 ClientIDCollision.Detect
+func (c *Client) OnClientIDTakeover(handler ClientIDTakeoverHandler) {
+	c.callbacks.mutex.Lock()
+	defer c.callbacks.mutex.Unlock()
+	c.callbacks.onTakeover = handler
+}
+
+// ClientID 返回当前连接使用的 ClientID，ClientIDCollision.Resolve 更换后与 Config.ClientID 不同
+func (c *Client) ClientID() string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.busConfig.Optional["ClientId"]
+}
+
+// watchBrokerDisconnects 在启用冲突检测或自动重连时向底层客户端注册 Broker 断开连接的回调；
+// 底层客户端无法报告 Broker 断开连接时，冲突检测不会生效，连接断开只能在发布失败或健康检查时发现
+func (c *Client) watchBrokerDisconnects(client messaging.MessageClient) {
+	if !c.config.ClientIDCollision.Detect && !c.config.Reconnect.Enabled {
+		return
+	}
+	if !notifiesBrokerDisconnect(client) {
+		if c.config.ClientIDCollision.Detect {
+			c.log(LogConnection).Warn("底层客户端未实现BrokerDisconnectNotifier，ClientID冲突检测不会生效", c.logFields("type", fmt.Sprintf("%T", client))...)
+		} else {
+			c.log(LogConnection).Info("底层客户端未实现BrokerDisconnectNotifier，连接断开在发布失败或健康检查时才会重连", c.logFields("type", fmt.Sprintf("%T", client))...)
+		}
+		return
+	}
+	client.(BrokerDisconnectNotifier).NotifyBrokerDisconnect(c.connectionLost)
+}
+
+// connectionLost 在底层连接被 Broker 断开时调用：启用冲突检测时先统计断开次数，
+// 未因冲突更换 ClientID 时按 Config.Reconnect 在后台重连
+func (c *Client) connectionLost(takeover bool) {
+	if c.config.ClientIDCollision.Detect && c.brokerDisconnected(takeover) {
+		return
+	}
+	c.mutex.RLock()
+	active := c.isConnected && !c.stopping
+	c.mutex.RUnlock()
+	if active {
+		c.reconnectInBackground(fmt.Errorf("与Broker的连接已断开"))
+	}
+}
+
+// notifiesBrokerDisconnect 判断底层客户端能否报告 Broker 断开连接；
+// 转换主题的包装只转发注册，需按被包装的客户端判断
+func notifiesBrokerDisconnect(client messaging.MessageClient) bool {
+	if rewriting, ok := client.(*rewritingClient); ok {
+		client = rewriting.MessageClient
+	}
+	_, ok := client.(BrokerDisconnectNotifier)
+	return ok
+}
+
+// brokerDisconnected 记录一次 Broker 主动断开连接，达到阈值或 Broker 明确告知会话被接管时判定为冲突；
+// 返回 true 表示正在改用新的 ClientID 重新连接，无需再按 Config.Reconnect 重连
+func (c *Client) brokerDisconnected(takeover bool) bool {
+	c.mutex.RLock()
+	active := c.isConnected && !c.stopping
+	c.mutex.RUnlock()
+	if !active {
+		return false
+	}
+	cfg := c.config.ClientIDCollision
+	threshold := cfg.Threshold
+	if threshold <= 0 {
+		threshold = defaultCollisionThreshold
+	}
+	window := cfg.Window
+	if window <= 0 {
+		window = defaultCollisionWindow
+	}
+
+	now := time.Now()
+	c.collision.mutex.Lock()
+	recent := c.collision.disconnects[:0]
+	for _, t := range c.collision.disconnects {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	c.collision.disconnects = recent
+	count := len(recent)
+	if (!takeover && count < threshold) || c.collision.resolving {
+		resolving := c.collision.resolving
+		c.collision.mutex.Unlock()
+		return resolving
+	}
+	c.collision.disconnects = nil
+	c.collision.resolving = cfg.Resolve
+	c.collision.mutex.Unlock()
+
+	event := ClientIDTakeover{ClientID: c.ClientID(), Disconnects: count, Confirmed: takeover}
+	if cfg.Resolve {
+		event.NewClientID = c.nextClientID()
+	}
+	err := fmt.Errorf("ClientID %s 疑似被其他连接占用，%s 内被 Broker 断开 %d 次", event.ClientID, window, count)
+	if takeover {
+		err = fmt.Errorf("ClientID %s 的会话已被其他连接接管", event.ClientID)
+	}
+	c.log(LogConnection).Warn("检测到ClientID冲突", c.logFields("disconnects", count, "confirmed", takeover, "newClientId", event.NewClientID)...)
+	c.emitEvent(LifecycleEvent{Type: EventClientIDTakeover, Attempt: count, Err: err})
+	c.callbacks.mutex.Lock()
+	handler := c.callbacks.onTakeover
+	c.callbacks.mutex.Unlock()
+	if handler != nil {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					c.log(LogConnection).Error("ClientID冲突回调发生panic", c.logFields("panic", r)...)
+				}
+			}()
+			handler(event)
+		}()
+	}
+	if cfg.Resolve {
+		c.lifecycle.spawn(stageReconnect, func(<-chan struct{}) {
+			c.resolveClientID(event.NewClientID)
+		})
+	}
+	return cfg.Resolve
+}
+
+// nextClientID 生成替换用的 ClientID：设置了 ClientIDPrefix 时重新生成，否则在 Config.ClientID 后追加随机后缀
+func (c *Client) nextClientID() string {
+	if c.config.ClientIDPrefix != "" {
+		return GenerateClientID(c.config.ClientIDPrefix)
+	}
+	return c.config.ClientID + "-" + randomSuffix()
+}
+
+// resolveClientID 改用新的 ClientID 建立底层连接并恢复订阅，遗嘱连接随之更换
+func (c *Client) resolveClientID(clientID string) {
+	defer func() {
+		c.collision.mutex.Lock()
+		c.collision.resolving = false
+		c.collision.mutex.Unlock()
+	}()
+	c.mutex.RLock()
+	busConfig := c.busConfig
+	c.mutex.RUnlock()
+	optional := make(map[string]string, len(busConfig.Optional))
+	for k, v := range busConfig.Optional {
+		optional[k] = v
+	}
+	optional["ClientId"] = clientID
+	busConfig.Optional = optional
+
+	if err := c.replaceMessageClient(busConfig, nil); err != nil {
+		c.log(LogConnection).Error("更换ClientID失败", c.logFields("newClientId", clientID, "error", err)...)
+		c.reportError("clientIdTakeover", "", err)
+		return
+	}
+	c.disconnectWill()
+	if err := c.connectWill(); err != nil {
+		c.log(LogConnection).Error("更换ClientID后重建遗嘱连接失败", c.logFields("error", err)...)
+	}
+	c.log(LogConnection).Info("已更换ClientID并重新连接", c.logFields("newClientId", clientID)...)
+}
@@ -0,0 +1,72 @@
+package messagebus_test
+
+import (
+	"testing"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// notifyingClient 为内存客户端补充 BrokerDisconnectNotifier
+type notifyingClient struct {
+	messaging.MessageClient
+	registered bool
+}
+
+func (n *notifyingClient) NotifyBrokerDisconnect(func(takeover bool)) {
+	n.registered = true
+}
+
+func TestClientIDCollisionWarnsWithoutNotifier(t *testing.T) {
+	const warning = "底层客户端未实现BrokerDisconnectNotifier，ClientID冲突检测不会生效"
+	broker := messagebustest.NewBroker()
+	cases := []struct {
+		name    string
+		wrap    bool
+		options []messagebus.Option
+	}{
+		{"plain", false, nil},
+		{"topic prefix", false, []messagebus.Option{messagebus.WithTopicPrefix("site1")}},
+		{"notifier", true, nil},
+		{"notifier with topic prefix", true, []messagebus.Option{messagebus.WithTopicPrefix("site1")}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			log := &captureLogger{}
+			var notifier *notifyingClient
+			factory := broker.MessageClientFactory(tc.name)
+			if tc.wrap {
+				inner := factory
+				factory = func(config types.MessageBusConfig) (messaging.MessageClient, error) {
+					client, err := inner(config)
+					if err != nil {
+						return nil, err
+					}
+					notifier = &notifyingClient{MessageClient: client}
+					return notifier, nil
+				}
+			}
+			opts := append([]messagebus.Option{
+				messagebus.WithConfig(testConfig()),
+				messagebus.WithLogger(log),
+				messagebus.WithMessageClientFactory(factory),
+				messagebus.WithClientIDCollision(messagebus.ClientIDCollisionConfig{Detect: true}),
+			}, tc.options...)
+			client, err := messagebus.NewClientWithOptions(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			_, warned := log.find(warning)
+			if warned == tc.wrap {
+				t.Errorf("告警 = %v，期望 %v", warned, !tc.wrap)
+			}
+			if tc.wrap && !notifier.registered {
+				t.Error("未向底层客户端注册 Broker 断开回调")
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package messagebus
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/responses"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// defaultCommandTimeout 是未配置超时时设备命令等待响应的最长时间
+const defaultCommandTimeout = 10 * time.Second
+
+// CommandClientOptions 表示设备命令客户端的可选参数
+type CommandClientOptions struct {
+	// Timeout ctx 未设置截止时间时等待响应的最长时间，默认 10 秒
+	Timeout time.Duration
+	// EnableNameFieldEscape 是否对主题中的设备名和命令名进行 URL 转义，需与 EdgeX 服务的同名配置一致
+	EnableNameFieldEscape bool
+}
+
+// CommandClient 通过 MessageBus 调用 core-command 的设备命令接口，与 EdgeX 的消息版命令客户端一致：
+// 请求发布到 <BaseTopicPrefix>/core/command/request/...，响应发布在 <BaseTopicPrefix>/response/core-command/<RequestID>
+type CommandClient struct {
+	client *Client
+	opts   CommandClientOptions
+}
+
+// NewCommandClient 创建设备命令客户端
+func (c *Client) NewCommandClient(opts CommandClientOptions) *CommandClient {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultCommandTimeout
+	}
+	return &CommandClient{client: c, opts: opts}
+}
+
+// Get 执行设备的 get 命令并返回读取到的事件，不将事件推送到 core-data
+func (cc *CommandClient) Get(ctx context.Context, deviceName, commandName string) (*responses.EventResponse, error) {
+	return cc.GetWithQueryParams(ctx, deviceName, commandName, map[string]string{
+		common.PushEvent:   common.ValueFalse,
+		common.ReturnEvent: common.ValueTrue,
+	})
+}
+
+// GetWithQueryParams 携带查询参数（如 ds-pushevent、ds-returnevent）执行设备的 get 命令
+// ds-returnevent 为 false 时响应不包含事件，返回的 EventResponse 只填充 RequestId 和 StatusCode
+func (cc *CommandClient) GetWithQueryParams(ctx context.Context, deviceName, commandName string, queryParams map[string]string) (*responses.EventResponse, error) {
+	envelope := types.NewMessageEnvelopeForRequest(nil, queryParams)
+	response, err := cc.request(ctx, envelope, cc.commandTopic(deviceName, commandName, "get"))
+	if err != nil {
+		return nil, err
+	}
+	if queryParams[common.ReturnEvent] == common.ValueFalse {
+		res := responses.EventResponse{}
+		res.ApiVersion = common.ApiVersion
+		res.RequestId = response.RequestID
+		res.StatusCode = http.StatusOK
+		return &res, nil
+	}
+	res, err := types.GetMsgPayload[responses.EventResponse](*response)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Set 使用给定参数执行设备的 set 命令
+func (cc *CommandClient) Set(ctx context.Context, deviceName, commandName string, settings map[string]any) (commonDTO.BaseResponse, error) {
+	envelope := types.NewMessageEnvelopeForRequest(settings, nil)
+	response, err := cc.request(ctx, envelope, cc.commandTopic(deviceName, commandName, "set"))
+	if err != nil {
+		return commonDTO.BaseResponse{}, err
+	}
+	return commonDTO.NewBaseResponse(response.RequestID, "", http.StatusOK), nil
+}
+
+// DeviceCommands 查询设备支持的命令
+func (cc *CommandClient) DeviceCommands(ctx context.Context, deviceName string) (responses.DeviceCoreCommandResponse, error) {
+	topic := common.NewPathBuilder().EnableNameFieldEscape(cc.opts.EnableNameFieldEscape).
+		SetPath(cc.client.baseTopic()).SetPath(common.CoreCommandQueryRequestPublishTopic).SetNameFieldPath(deviceName).BuildPath()
+	response, err := cc.request(ctx, types.NewMessageEnvelopeForRequest(nil, nil), topic)
+	if err != nil {
+		return responses.DeviceCoreCommandResponse{}, err
+	}
+	return types.GetMsgPayload[responses.DeviceCoreCommandResponse](*response)
+}
+
+// AllDeviceCommands 分页查询所有设备支持的命令
+func (cc *CommandClient) AllDeviceCommands(ctx context.Context, offset, limit int) (responses.MultiDeviceCoreCommandsResponse, error) {
+	queryParams := map[string]string{common.Offset: strconv.Itoa(offset), common.Limit: strconv.Itoa(limit)}
+	topic := common.BuildTopic(cc.client.baseTopic(), common.CoreCommandQueryRequestPublishTopic, common.All)
+	response, err := cc.request(ctx, types.NewMessageEnvelopeForRequest(nil, queryParams), topic)
+	if err != nil {
+		return responses.MultiDeviceCoreCommandsResponse{}, err
+	}
+	return types.GetMsgPayload[responses.MultiDeviceCoreCommandsResponse](*response)
+}
+
+// commandTopic 返回设备命令的请求主题
+func (cc *CommandClient) commandTopic(deviceName, commandName, method string) string {
+	return common.NewPathBuilder().EnableNameFieldEscape(cc.opts.EnableNameFieldEscape).
+		SetPath(cc.client.baseTopic()).SetPath(common.CoreCommandRequestPublishTopic).
+		SetNameFieldPath(deviceName).SetNameFieldPath(commandName).SetPath(method).BuildPath()
+}
+
+// request 发送命令请求并等待 core-command 的响应，响应 ErrorCode 非 0 时返回 *RequestError
+func (cc *CommandClient) request(ctx context.Context, envelope types.MessageEnvelope, requestTopic string) (*types.MessageEnvelope, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cc.opts.Timeout)
+		defer cancel()
+	}
+	responseTopic := common.BuildTopic(cc.client.baseTopic(), common.ResponseTopic, common.CoreCommandServiceKey)
+	return cc.client.RequestWithContext(ctx, envelope, requestTopic, responseTopic)
+}
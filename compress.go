@@ -0,0 +1,245 @@
+package messagebus
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/klauspost/compress/zstd"
+)
+
+// HeaderContentEncoding 是信封 QueryParams 中记录 Payload 压缩方式的键，ContentType 仍为压缩前的内容类型
+const HeaderContentEncoding = "x-content-encoding"
+
+const (
+	EncodingGzip = "gzip" // gzip 压缩
+	EncodingZstd = "zstd" // zstd 压缩
+
+	defaultCompressionThreshold = 1024             // 默认只压缩不小于 1KB 的 Payload
+	maxDecompressedSize         = 64 * 1024 * 1024 // 解压后的最大字节数，防止压缩炸弹
+)
+
+// CompressionConfig 表示发布时的 Payload 压缩参数
+type CompressionConfig struct {
+	// Encoding 压缩方式 (gzip, zstd 或通过 RegisterCompressor 注册的名称)，为空时不压缩
+	Encoding string
+	// Threshold 只压缩不小于该字节数的 Payload，默认 1024
+	Threshold int
+}
+
+// Compressor 定义 Payload 的压缩方式
+type Compressor interface {
+	// Encoding 返回压缩方式名称，会写入信封的 QueryParams[HeaderContentEncoding]
+	Encoding() string
+	// Compress 压缩数据
+	Compress(data []byte) ([]byte, error)
+	// Decompress 解压数据
+	Decompress(data []byte) ([]byte, error)
+}
+
+// GzipCompressor 使用 compress/gzip 压缩 Payload
+type GzipCompressor struct{}
+
+// Encoding 返回 gzip
+func (GzipCompressor) Encoding() string { return EncodingGzip }
+
+// gzipWriters 复用 gzip.Writer，每个 Writer 内部约占用数百 KB 的压缩状态
+var gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+
+// gzipReaders 复用 gzip.Reader
+var gzipReaders sync.Pool
+
+// Compress 使用 gzip 压缩数据
+func (GzipCompressor) Compress(data []byte) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	w := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(w)
+	w.Reset(buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(buf.Bytes()), nil
+}
+
+// Decompress 解压 gzip 数据
+func (GzipCompressor) Decompress(data []byte) ([]byte, error) {
+	var r *gzip.Reader
+	if pooled, ok := gzipReaders.Get().(*gzip.Reader); ok {
+		if err := pooled.Reset(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		r = pooled
+	} else {
+		created, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		r = created
+	}
+	defer gzipReaders.Put(r)
+	defer r.Close()
+	out, err := io.ReadAll(io.LimitReader(r, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > maxDecompressedSize {
+		return nil, fmt.Errorf("解压后的数据超过 %d 字节", maxDecompressedSize)
+	}
+	return out, nil
+}
+
+// ZstdCompressor 使用 zstd 压缩 Payload，压缩率和速度通常优于 gzip
+type ZstdCompressor struct{}
+
+// zstdCoders 是所有 ZstdCompressor 共享的编解码器，二者均可并发使用
+var zstdCoders struct {
+	once    sync.Once
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+	err     error
+}
+
+// zstdInit 创建共享的 zstd 编解码器
+func zstdInit() error {
+	zstdCoders.once.Do(func() {
+		zstdCoders.encoder, zstdCoders.err = zstd.NewWriter(nil)
+		if zstdCoders.err != nil {
+			return
+		}
+		zstdCoders.decoder, zstdCoders.err = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecompressedSize))
+	})
+	return zstdCoders.err
+}
+
+// Encoding 返回 zstd
+func (ZstdCompressor) Encoding() string { return EncodingZstd }
+
+// Compress 使用 zstd 压缩数据
+func (ZstdCompressor) Compress(data []byte) ([]byte, error) {
+	if err := zstdInit(); err != nil {
+		return nil, err
+	}
+	return zstdCoders.encoder.EncodeAll(data, nil), nil
+}
+
+// Decompress 解压 zstd 数据
+func (ZstdCompressor) Decompress(data []byte) ([]byte, error) {
+	if err := zstdInit(); err != nil {
+		return nil, err
+	}
+	return zstdCoders.decoder.DecodeAll(data, nil)
+}
+
+// compressorRegistry 按压缩方式保存已注册的压缩器，默认包含 gzip 与 zstd
+var compressorRegistry = struct {
+	sync.RWMutex
+	compressors map[string]Compressor
+}{compressors: map[string]Compressor{
+	EncodingGzip: GzipCompressor{},
+	EncodingZstd: ZstdCompressor{},
+}}
+
+// RegisterCompressor 注册压缩器，相同名称的压缩器会被替换
+func RegisterCompressor(compressor Compressor) {
+	if compressor == nil {
+		return
+	}
+	compressorRegistry.Lock()
+	defer compressorRegistry.Unlock()
+	compressorRegistry.compressors[compressor.Encoding()] = compressor
+}
+
+// CompressorFor 返回压缩方式对应的压缩器
+func CompressorFor(encoding string) (Compressor, bool) {
+	compressorRegistry.RLock()
+	defer compressorRegistry.RUnlock()
+	compressor, ok := compressorRegistry.compressors[encoding]
+	return compressor, ok
+}
+
+// compressionInterceptor 压缩不小于阈值的 Payload，并在 QueryParams 中记录压缩方式
+func compressionInterceptor(config CompressionConfig) PublishInterceptor {
+	threshold := config.Threshold
+	if threshold <= 0 {
+		threshold = defaultCompressionThreshold
+	}
+	return func(next PublishHandler) PublishHandler {
+		return func(ctx context.Context, topic string, envelope types.MessageEnvelope) error {
+			if envelope.QueryParams[HeaderContentEncoding] != "" {
+				return next(ctx, topic, envelope)
+			}
+			compressor, ok := CompressorFor(config.Encoding)
+			if !ok {
+				return fmt.Errorf("不支持的压缩方式: %s", config.Encoding)
+			}
+			err := withOutboundPayloadBytes(envelope.Payload, func(data []byte) error {
+				if len(data) < threshold {
+					return nil
+				}
+				compressed, err := compressor.Compress(data)
+				if err != nil {
+					return fmt.Errorf("压缩Payload失败: %w", err)
+				}
+				envelope.Payload = compressed
+				envelope.QueryParams = withParam(envelope.QueryParams, HeaderContentEncoding, compressor.Encoding())
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			return next(ctx, topic, envelope)
+		}
+	}
+}
+
+// decompressPayload 按 QueryParams[HeaderContentEncoding] 解压 Payload，未压缩的信封原样返回
+func decompressPayload(envelope types.MessageEnvelope) (types.MessageEnvelope, error) {
+	encoding := envelope.QueryParams[HeaderContentEncoding]
+	if encoding == "" {
+		return envelope, nil
+	}
+	compressor, ok := CompressorFor(encoding)
+	if !ok {
+		return envelope, fmt.Errorf("不支持的压缩方式: %s", encoding)
+	}
+	data, err := payloadBytes(envelope.Payload)
+	if err != nil {
+		return envelope, err
+	}
+	decompressed, err := compressor.Decompress(data)
+	if err != nil {
+		return envelope, fmt.Errorf("解压Payload失败: %w", err)
+	}
+	envelope.Payload = decompressed
+	envelope.QueryParams = withoutParam(envelope.QueryParams, HeaderContentEncoding)
+	return envelope, nil
+}
+
+// withParam 返回设置了 key 的 QueryParams 副本，不修改原有的映射
+func withParam(params map[string]string, key, value string) map[string]string {
+	copied := make(map[string]string, len(params)+1)
+	for k, v := range params {
+		copied[k] = v
+	}
+	copied[key] = value
+	return copied
+}
+
+// withoutParam 返回去掉 key 的 QueryParams 副本，不修改原有的映射
+func withoutParam(params map[string]string, key string) map[string]string {
+	copied := make(map[string]string, len(params))
+	for k, v := range params {
+		if k != key {
+			copied[k] = v
+		}
+	}
+	return copied
+}
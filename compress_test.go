@@ -0,0 +1,92 @@
+package messagebus_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// subscribeChannel 订阅 topic 并将收到的消息写入返回的通道
+func subscribeChannel(t *testing.T, client *messagebustest.MockClient, topic string) <-chan types.MessageEnvelope {
+	t.Helper()
+	received := make(chan types.MessageEnvelope, 16)
+	if err := client.Subscribe([]string{topic}, func(_ string, msg types.MessageEnvelope) error {
+		received <- msg
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return received
+}
+
+// receiveOne 等待通道中的一条消息
+func receiveOne(t *testing.T, received <-chan types.MessageEnvelope) types.MessageEnvelope {
+	t.Helper()
+	select {
+	case msg := <-received:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("未收到消息")
+		return types.MessageEnvelope{}
+	}
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	for _, encoding := range []string{messagebus.EncodingGzip, messagebus.EncodingZstd} {
+		t.Run(encoding, func(t *testing.T) {
+			broker := messagebustest.NewBroker()
+			publisher, err := messagebustest.NewMockClientWithBroker(broker, messagebus.WithCompression(encoding, 64))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = publisher.Close() })
+			// 订阅方不需要启用压缩，按信封中的标记自动解压
+			subscriber, err := messagebustest.NewMockClientWithBroker(broker)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = subscriber.Close() })
+			received := subscribeChannel(t, subscriber, "test/compress")
+
+			large := bytes.Repeat([]byte("compressible "), 100)
+			if err := publisher.PublishBinary("test/compress", large); err != nil {
+				t.Fatal(err)
+			}
+			if err := publisher.PublishBinary("test/compress", []byte("small")); err != nil {
+				t.Fatal(err)
+			}
+
+			published := broker.Published()
+			if len(published) != 2 {
+				t.Fatalf("发布了 %d 条消息，期望 2", len(published))
+			}
+			if got := published[0].Envelope.QueryParams[messagebus.HeaderContentEncoding]; got != encoding {
+				t.Errorf("大消息的压缩标记 = %q，期望 %q", got, encoding)
+			}
+			if wire, _ := messagebus.EnvelopePayloadBytes(published[0].Envelope); len(wire) >= len(large) {
+				t.Errorf("压缩后 %d 字节，未小于原始的 %d 字节", len(wire), len(large))
+			}
+			if got := published[1].Envelope.QueryParams[messagebus.HeaderContentEncoding]; got != "" {
+				t.Errorf("低于阈值的消息不应压缩，压缩标记 = %q", got)
+			}
+
+			for _, want := range [][]byte{large, []byte("small")} {
+				msg := receiveOne(t, received)
+				data, err := messagebus.EnvelopePayloadBytes(msg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(data, want) {
+					t.Errorf("解压后 %d 字节，期望 %d 字节", len(data), len(want))
+				}
+				if msg.QueryParams[messagebus.HeaderContentEncoding] != "" {
+					t.Error("解压后的消息不应保留压缩标记")
+				}
+			}
+		})
+	}
+}
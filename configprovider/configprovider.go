@@ -0,0 +1,60 @@
+// Package configprovider 从 EdgeX 配置中心（Core Keeper）读取 MessageBus 段创建客户端
+//
+// 该包单独存放，只有导入它的程序才会依赖 github.com/edgexfoundry/go-mod-configuration。
+package configprovider
+
+import (
+	"fmt"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/edgexfoundry/go-mod-configuration/v4/configuration"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+)
+
+// serviceConfig 用于从配置中心读取 MessageBus 段
+type serviceConfig struct {
+	MessageBus messagebus.EdgeXMessageBusInfo
+}
+
+// Source 基于 EdgeX 配置中心实现 messagebus.ConfigSource
+type Source struct {
+	provider configuration.Client
+}
+
+var _ messagebus.ConfigSource = (*Source)(nil)
+
+// NewSource 返回读取 provider 中 MessageBus 段的配置来源，provider 由调用方负责关闭
+func NewSource(provider configuration.Client) *Source {
+	return &Source{provider: provider}
+}
+
+// MessageBusInfo 读取配置中心中的 MessageBus 段
+func (s *Source) MessageBusInfo() (messagebus.EdgeXMessageBusInfo, error) {
+	raw, err := s.provider.GetConfiguration(&serviceConfig{})
+	if err != nil {
+		return messagebus.EdgeXMessageBusInfo{}, fmt.Errorf("读取配置中心的 MessageBus 配置失败: %w", err)
+	}
+	config, ok := raw.(*serviceConfig)
+	if !ok {
+		return messagebus.EdgeXMessageBusInfo{}, fmt.Errorf("配置中心返回了意外的配置类型 %T", raw)
+	}
+	return config.MessageBus, nil
+}
+
+// WatchMessageBusInfo 监听配置中心中 MessageBus 段的变化
+func (s *Source) WatchMessageBusInfo(updates chan<- interface{}, errs chan<- error, watchClient messaging.MessageClient) {
+	s.provider.WatchForChanges(updates, errs, &messagebus.EdgeXMessageBusInfo{}, messagebus.MessageBusSectionKey,
+		func() messaging.MessageClient { return watchClient })
+}
+
+// NewClient 从配置中心读取 MessageBus 段创建客户端，并在该段变化时使用新配置重新连接，见 messagebus.NewClientFromConfigSource
+//
+// base 提供 MessageBus 段之外的参数（如 Credentials、Reconnect、Tags），其中的 Broker 参数会被配置中心的值覆盖。
+// 监听使用独立的底层连接，provider 由调用方负责关闭。
+func NewClient(provider configuration.Client, base messagebus.Config, lc logger.LoggingClient) (*messagebus.Client, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("配置中心客户端不能为空")
+	}
+	return messagebus.NewClientFromConfigSource(NewSource(provider), base, lc)
+}
@@ -0,0 +1,138 @@
+package configprovider_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/configprovider"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-configuration/v4/configuration"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// fakeProvider 以 JSON 形式保存服务配置，模拟配置中心按结构解码配置，并记录监听参数
+type fakeProvider struct {
+	configuration.Client
+	config string
+	err    error
+	raw    interface{} // 非 nil 时 GetConfiguration 原样返回，模拟意外的配置类型
+
+	mutex       sync.Mutex
+	watchKey    string
+	watchClient messaging.MessageClient
+	updates     chan<- interface{}
+}
+
+func (p *fakeProvider) GetConfiguration(configStruct interface{}) (interface{}, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.raw != nil {
+		return p.raw, nil
+	}
+	return configStruct, json.Unmarshal([]byte(p.config), configStruct)
+}
+
+func (p *fakeProvider) WatchForChanges(updates chan<- interface{}, _ chan<- error, _ interface{}, waitKey string, getMsgClientCb func() messaging.MessageClient) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.watchKey, p.watchClient, p.updates = waitKey, getMsgClientCb(), updates
+}
+
+const serviceConfig = `{
+  "Service": {"Host": "app"},
+  "MessageBus": {
+    "Type": "mqtt", "Protocol": "tcp", "Host": "broker-a", "Port": 1883,
+    "AuthMode": "none", "BaseTopicPrefix": "edgex",
+    "Optional": {"ClientId": "from-keeper"}
+  }
+}`
+
+func TestSourceReadsMessageBusSection(t *testing.T) {
+	info, err := configprovider.NewSource(&fakeProvider{config: serviceConfig}).MessageBusInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Type != messagebus.TypeMQTT || info.Host != "broker-a" || info.Port != 1883 || info.BaseTopicPrefix != "edgex" ||
+		info.Optional["ClientId"] != "from-keeper" {
+		t.Errorf("MessageBus 段 = %+v", info)
+	}
+}
+
+func TestSourceReportsProviderErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		provider *fakeProvider
+		problem  string
+	}{
+		{"provider error", &fakeProvider{err: errors.New("keeper 不可用")}, "keeper 不可用"},
+		{"unexpected type", &fakeProvider{raw: map[string]interface{}{}}, "意外的配置类型"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := configprovider.NewSource(tc.provider).MessageBusInfo()
+			if err == nil || !strings.Contains(err.Error(), tc.problem) {
+				t.Errorf("错误 %v 应包含 %q", err, tc.problem)
+			}
+		})
+	}
+	if _, err := configprovider.NewClient(nil, messagebus.Config{}, logger.NewMockClient()); err == nil {
+		t.Error("provider 为空时 NewClient 应返回错误")
+	}
+}
+
+func TestNewClientWatchesMessageBusSection(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	factory := broker.MessageClientFactory("keeper")
+	var mutex sync.Mutex
+	var hosts []string
+	base := messagebus.Config{
+		MessageClientFactory: func(config types.MessageBusConfig) (messaging.MessageClient, error) {
+			mutex.Lock()
+			hosts = append(hosts, config.Broker.Host)
+			mutex.Unlock()
+			return factory(config)
+		},
+	}
+	provider := &fakeProvider{config: serviceConfig}
+	client, err := configprovider.NewClient(provider, base, logger.NewMockClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if id := client.ClientID(); id != "from-keeper" {
+		t.Errorf("ClientID = %q，期望取自 MessageBus.Optional", id)
+	}
+
+	provider.mutex.Lock()
+	key, watchClient, updates := provider.watchKey, provider.watchClient, provider.updates
+	provider.mutex.Unlock()
+	if key != messagebus.MessageBusSectionKey || watchClient == nil || updates == nil {
+		t.Fatalf("监听参数 key=%q client=%v，期望监听 %s 段并提供独立连接", key, watchClient, messagebus.MessageBusSectionKey)
+	}
+
+	updates <- &messagebus.EdgeXMessageBusInfo{Type: messagebus.TypeMQTT, Protocol: "tcp", Host: "broker-b", Port: 1883}
+	deadline := time.Now().Add(time.Second)
+	for {
+		mutex.Lock()
+		last := hosts[len(hosts)-1]
+		mutex.Unlock()
+		if last == "broker-b" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("MessageBus 段变化后未使用新的 Broker 地址重新连接")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
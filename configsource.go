@@ -0,0 +1,174 @@
+package messagebus
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// MessageBusSectionKey 是 EdgeX 配置中 MessageBus 段的键
+const MessageBusSectionKey = "MessageBus"
+
+// EdgeXMessageBusInfo 与 EdgeX 服务配置中的 MessageBus 段结构一致（go-mod-bootstrap 的 MessageBusInfo）
+type EdgeXMessageBusInfo struct {
+	Disabled        bool
+	Type            string
+	Protocol        string
+	Host            string
+	Port            int
+	AuthMode        string
+	SecretName      string
+	BaseTopicPrefix string
+	Optional        map[string]string
+}
+
+// ConfigSource 提供 EdgeX 服务配置中的 MessageBus 段并推送其变化
+// configprovider 子包基于 EdgeX 配置中心（go-mod-configuration）实现，核心包因此不依赖配置中心客户端
+type ConfigSource interface {
+	// MessageBusInfo 返回当前的 MessageBus 段
+	MessageBusInfo() (EdgeXMessageBusInfo, error)
+	// WatchMessageBusInfo 开始监听 MessageBus 段，变化时向 updates 发送 *EdgeXMessageBusInfo，监听出错时向 errs 发送错误；
+	// watchClient 是供监听使用的独立底层连接（配置中心经 MessageBus 推送变化时使用）
+	WatchMessageBusInfo(updates chan<- interface{}, errs chan<- error, watchClient messaging.MessageClient)
+}
+
+// NewClientFromConfigSource 从 source 读取 MessageBus 段创建客户端，并监听该段的变化：
+// Broker 地址、类型或 Optional 参数更新后，客户端会使用新配置重新连接并恢复订阅。
+//
+// base 提供 MessageBus 段之外的参数（如 Credentials、Reconnect、Tags），其中的 Broker 参数会被 source 中的值覆盖。
+// 监听使用的独立连接在 Disconnect 后保持，以便重新连接时继续接收变化，Close 时断开。
+// 使用 EdgeX 配置中心时见 configprovider.NewClient。
+func NewClientFromConfigSource(source ConfigSource, base Config, lc logger.LoggingClient) (*Client, error) {
+	if source == nil {
+		return nil, fmt.Errorf("配置来源不能为空")
+	}
+	info, err := source.MessageBusInfo()
+	if err != nil {
+		return nil, fmt.Errorf("读取 MessageBus 配置失败: %w", err)
+	}
+	if info.Disabled {
+		return nil, fmt.Errorf("配置来源中的 MessageBus 已禁用")
+	}
+	config := base
+	applyMessageBusInfo(&config, info)
+	client, err := NewClient(config, lc)
+	if err != nil {
+		return nil, err
+	}
+
+	watchClient, err := newMessageClient(client.config, client.busConfig)
+	if err == nil {
+		err = watchClient.Connect()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("创建配置监听连接失败: %w", err)
+	}
+	client.configInfo = info
+	client.configWatch = watchClient
+	client.configUpdates = make(chan interface{}, 1)
+	client.configErrors = make(chan error, 1)
+	source.WatchMessageBusInfo(client.configUpdates, client.configErrors, watchClient)
+	return client, nil
+}
+
+// applyMessageBusInfo 将 EdgeX MessageBus 段写入客户端配置
+func applyMessageBusInfo(config *Config, info EdgeXMessageBusInfo) {
+	config.Host = info.Host
+	config.Port = info.Port
+	config.Protocol = info.Protocol
+	config.Type = info.Type
+	if info.BaseTopicPrefix != "" {
+		config.BaseTopicPrefix = info.BaseTopicPrefix
+	}
+	optional := info.Optional
+	if v := optional["ClientId"]; v != "" {
+		config.ClientID = v
+	}
+	if v := optional["Username"]; v != "" {
+		config.Username = v
+	}
+	if v := optional["Password"]; v != "" {
+		config.Password = v
+	}
+	if v, err := strconv.Atoi(optional["Qos"]); err == nil {
+		config.QoS = v
+	}
+	if v := optional["CertFile"]; v != "" {
+		config.CertFile = v
+	}
+	if v := optional["KeyFile"]; v != "" {
+		config.KeyFile = v
+	}
+	if v := optional["CaFile"]; v != "" {
+		config.CAFile = v
+	}
+	if v, err := strconv.ParseBool(optional["SkipCertVerify"]); err == nil {
+		config.SkipCertVerify = v
+	}
+}
+
+// watchConfigProvider 处理配置中心推送的 MessageBus 配置变化
+func (c *Client) watchConfigProvider(stop <-chan struct{}) {
+	for {
+		select {
+		case raw := <-c.configUpdates:
+			info, ok := raw.(*EdgeXMessageBusInfo)
+			if !ok || info == nil {
+				// 监听建立时配置中心会先发送一次 nil
+				continue
+			}
+			// 配置中心每次推送同一个对象，需复制后再使用
+			update := *info
+			update.Optional = make(map[string]string, len(info.Optional))
+			for k, v := range info.Optional {
+				update.Optional[k] = v
+			}
+			if err := c.applyConfigUpdate(update); err != nil {
+				c.log(LogConnection).Error("应用配置中心的 MessageBus 配置失败", c.logFields("error", err)...)
+			}
+		case err := <-c.configErrors:
+			c.log(LogConnection).Warn("监听配置中心的 MessageBus 配置出错", c.logFields("error", err)...)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// applyConfigUpdate 在 Broker 相关配置变化时使用新配置重新连接
+func (c *Client) applyConfigUpdate(info EdgeXMessageBusInfo) error {
+	c.mutex.RLock()
+	current := c.configInfo
+	busConfig := c.busConfig
+	c.mutex.RUnlock()
+	if reflect.DeepEqual(current, info) {
+		return nil
+	}
+	if info.Disabled {
+		return fmt.Errorf("配置中心中的 MessageBus 已禁用，保持当前连接")
+	}
+
+	config := c.config
+	applyMessageBusInfo(&config, info)
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	busConfig.Broker = types.HostInfo{Host: info.Host, Port: info.Port, Protocol: info.Protocol}
+	busConfig.Type = info.Type
+	optional := make(map[string]string, len(busConfig.Optional)+len(info.Optional))
+	for k, v := range busConfig.Optional {
+		optional[k] = v
+	}
+	for k, v := range info.Optional {
+		optional[k] = v
+	}
+	busConfig.Optional = optional
+	if err := c.replaceMessageClient(busConfig, func() { c.configInfo = info }); err != nil {
+		return err
+	}
+	c.log(LogConnection).Info("已按配置中心的新 MessageBus 配置重新连接", c.logFields("host", info.Host, "port", info.Port, "type", info.Type)...)
+	return nil
+}
@@ -0,0 +1,91 @@
+package messagebus_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// fakeConfigSource 返回固定的 MessageBus 段，并保存监听通道供测试推送变化
+type fakeConfigSource struct {
+	info    messagebus.EdgeXMessageBusInfo
+	updates chan<- interface{}
+}
+
+func (s *fakeConfigSource) MessageBusInfo() (messagebus.EdgeXMessageBusInfo, error) {
+	return s.info, nil
+}
+
+func (s *fakeConfigSource) WatchMessageBusInfo(updates chan<- interface{}, _ chan<- error, _ messaging.MessageClient) {
+	s.updates = updates
+}
+
+func TestNewClientFromConfigSource(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	var mutex sync.Mutex
+	var hosts []string
+	factory := broker.MessageClientFactory("config-source")
+	base := messagebus.Config{
+		MessageClientFactory: func(config types.MessageBusConfig) (messaging.MessageClient, error) {
+			mutex.Lock()
+			hosts = append(hosts, config.Broker.Host)
+			mutex.Unlock()
+			return factory(config)
+		},
+	}
+	source := &fakeConfigSource{info: messagebus.EdgeXMessageBusInfo{
+		Type: messagebus.TypeMQTT, Protocol: "tcp", Host: "broker-a", Port: 1883,
+		Optional: map[string]string{"ClientId": "from-config"},
+	}}
+	client, err := messagebus.NewClientFromConfigSource(source, base, logger.NewMockClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if id := client.ClientID(); id != "from-config" {
+		t.Fatalf("ClientID = %q，期望取自 Optional", id)
+	}
+
+	received := make(chan struct{}, 1)
+	err = client.Subscribe([]string{"test/config"}, func(string, types.MessageEnvelope) error {
+		received <- struct{}{}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	update := source.info
+	update.Host = "broker-b"
+	source.updates <- &update
+	deadline := time.Now().Add(time.Second)
+	for {
+		mutex.Lock()
+		last := hosts[len(hosts)-1]
+		mutex.Unlock()
+		if last == "broker-b" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("配置变化后未使用新的 Broker 地址重新连接")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := client.Publish("test/config", "x"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("重新连接后订阅未恢复")
+	}
+}
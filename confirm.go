@@ -0,0 +1,50 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PublishConfirmed 发布消息并等待 Broker 确认，返回投递错误而不是暂存或忽略
+//
+// mqtt 类型至少使用 QoS 1 发布并等待 PUBACK（QoS 2 时等待 PUBCOMP），Config.QoS 为 0 时
+// 经额外连接以 QoS 1 发布；nats-jetstream 类型等待 JetStream 的发布确认；kafka 类型总是等待确认；
+// amqp 类型的确认模式在连接时开启，需要启用 Config.ConfirmPublish；nats-core 没有确认机制，
+// 调用会返回错误。确认等待时间受底层客户端超时和 ctx 限制，消息不会进入离线存储转发队列。
+func (c *Client) PublishConfirmed(ctx context.Context, topic string, data interface{}) error {
+	if err := confirmSupported(c.config.Type); err != nil {
+		return err
+	}
+	if strings.EqualFold(c.config.Type, TypeAMQP) && !c.config.ConfirmPublish {
+		return fmt.Errorf("%s 类型需要启用 ConfirmPublish 才能等待发布确认", TypeAMQP)
+	}
+	payload, contentType, err := c.encodePayload(data)
+	if err != nil {
+		return err
+	}
+	return runWithContext(ctx, func() error {
+		return c.publishEnvelopeWithOptions(ctx, topic, newEnvelope(payload, contentType), c.confirmOptions())
+	})
+}
+
+// confirmOptions 返回确认发布使用的发布选项
+func (c *Client) confirmOptions() *PublishOptions {
+	qos := c.config.QoS
+	if qos < 1 {
+		qos = 1
+	}
+	return &PublishOptions{QoS: qos}
+}
+
+// OptionalConfirmPublish 是启用 ConfirmPublish 时写入底层配置 Optional 的键，
+// 需要在连接时开启确认模式的后端（如 amqp）据此启用 publisher confirms
+const OptionalConfirmPublish = "ConfirmPublish"
+
+// confirmSupported 判断消息总线类型是否支持发布确认
+func confirmSupported(busType string) error {
+	if strings.EqualFold(busType, TypeNatsCore) {
+		return fmt.Errorf("%s 类型不支持发布确认", TypeNatsCore)
+	}
+	return nil
+}
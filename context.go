@@ -0,0 +1,80 @@
+package messagebus
+
+import (
+	"context"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// defaultRequestTimeout 是 ctx 未设置截止时间时请求等待响应的最长时间
+const defaultRequestTimeout = 30 * time.Second
+
+// ConnectWithContext 连接到 MessageBus，ctx 取消或超时时立即返回 ctx 的错误
+func (c *Client) ConnectWithContext(ctx context.Context) error {
+	return runWithContext(ctx, c.Connect)
+}
+
+// PublishWithContext 发布消息到指定主题，ctx 取消或超时时立即返回 ctx 的错误
+// ctx 中的追踪上下文会注入到发布的信封中
+func (c *Client) PublishWithContext(ctx context.Context, topic string, data interface{}) error {
+	payload, err := toPayload(data)
+	if err != nil {
+		return err
+	}
+	err = runWithContext(ctx, func() error {
+		return c.publish(ctx, topic, payload, "application/json")
+	})
+	return publishError(topic, err)
+}
+
+// SubscribeWithContext 订阅多个主题，ctx 取消或超时时立即返回 ctx 的错误
+func (c *Client) SubscribeWithContext(ctx context.Context, topics []string, handler MessageHandler) error {
+	return runWithContext(ctx, func() error {
+		return c.Subscribe(topics, handler)
+	})
+}
+
+// RequestWithContext 发送请求并等待响应，等待时间取自 ctx 的截止时间（未设置时为 30 秒）
+// ctx 中的追踪上下文会注入到请求信封中
+func (c *Client) RequestWithContext(ctx context.Context, envelope types.MessageEnvelope, requestTopic string, responseTopic string) (*types.MessageEnvelope, error) {
+	timeout := defaultRequestTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	type result struct {
+		response *types.MessageEnvelope
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		response, err := c.request(ctx, envelope, requestTopic, responseTopic, timeout, RequestOptions{})
+		done <- result{response: response, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.response, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// runWithContext 执行 fn，ctx 先结束时返回 ctx 的错误，fn 仍会在后台执行完毕
+func runWithContext(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
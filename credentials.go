@@ -0,0 +1,162 @@
+package messagebus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// EdgeX 秘密存储中 MessageBus 凭据使用的键
+const (
+	SecretKeyUsername   = "username"
+	SecretKeyPassword   = "password"
+	SecretKeyClientCert = "clientcert"
+	SecretKeyClientKey  = "clientkey"
+	SecretKeyCACert     = "cacert"
+)
+
+// Credentials 表示连接 Broker 所需的凭据，证书均为 PEM 格式
+type Credentials struct {
+	Username   string
+	Password   string
+	ClientCert string
+	ClientKey  string
+	CACert     string
+}
+
+// CredentialsProvider 提供连接 Broker 的凭据，设置后会覆盖 Config 中的用户名、密码和证书
+type CredentialsProvider interface {
+	Credentials() (Credentials, error)
+}
+
+// SecretGetter 定义按名称读取秘密的方法
+// go-mod-secrets 的 SecretClient 和 go-mod-bootstrap 的 SecretProvider 均满足该接口
+type SecretGetter interface {
+	GetSecret(secretName string, keys ...string) (map[string]string, error)
+}
+
+// SecretStoreCredentialsProvider 从 EdgeX 秘密存储（Vault/OpenBao）读取 MessageBus 凭据
+type SecretStoreCredentialsProvider struct {
+	store      SecretGetter
+	secretName string
+}
+
+// NewSecretStoreCredentialsProvider 创建从指定秘密读取凭据的提供者
+func NewSecretStoreCredentialsProvider(store SecretGetter, secretName string) *SecretStoreCredentialsProvider {
+	return &SecretStoreCredentialsProvider{store: store, secretName: secretName}
+}
+
+// Credentials 读取秘密并转换为凭据，缺失的键保持为空
+func (p *SecretStoreCredentialsProvider) Credentials() (Credentials, error) {
+	if p.store == nil {
+		return Credentials{}, fmt.Errorf("秘密存储客户端不能为空")
+	}
+	secrets, err := p.store.GetSecret(p.secretName)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("读取秘密 %s 失败: %w", p.secretName, err)
+	}
+	return Credentials{
+		Username:   secrets[SecretKeyUsername],
+		Password:   secrets[SecretKeyPassword],
+		ClientCert: secrets[SecretKeyClientCert],
+		ClientKey:  secrets[SecretKeyClientKey],
+		CACert:     secrets[SecretKeyCACert],
+	}, nil
+}
+
+// applyCredentials 将凭据写入 go-mod-messaging 的 Optional 参数
+func applyCredentials(optional map[string]string, creds Credentials) {
+	setOptional(optional, "Username", creds.Username)
+	setOptional(optional, "Password", creds.Password)
+	setOptional(optional, "CertPEMBlock", creds.ClientCert)
+	setOptional(optional, "KeyPEMBlock", creds.ClientKey)
+	setOptional(optional, "CaPEMBlock", creds.CACert)
+}
+
+// setOptional 值非空时设置，否则删除该键
+func setOptional(optional map[string]string, key, value string) {
+	if value == "" {
+		delete(optional, key)
+		return
+	}
+	optional[key] = value
+}
+
+// watchCredentials 定期刷新凭据，凭据变化时使用新凭据重新认证
+func (c *Client) watchCredentials(stop <-chan struct{}) {
+	ticker := time.NewTicker(c.config.CredentialsRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.refreshCredentials(); err != nil {
+				c.log(LogConnection).Error("刷新MessageBus凭据失败", c.logFields("error", err)...)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// refreshCredentials 重新读取凭据，变化时创建新的底层客户端、恢复订阅并替换旧客户端
+func (c *Client) refreshCredentials() error {
+	creds, err := c.config.Credentials.Credentials()
+	if err != nil {
+		return err
+	}
+	c.mutex.RLock()
+	unchanged := creds == c.credentials
+	busConfig := c.busConfig
+	c.mutex.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	optional := make(map[string]string, len(busConfig.Optional))
+	for k, v := range busConfig.Optional {
+		optional[k] = v
+	}
+	applyCredentials(optional, creds)
+	busConfig.Optional = optional
+	if err := c.replaceMessageClient(busConfig, func() { c.credentials = creds }); err != nil {
+		return err
+	}
+	c.log(LogConnection).Info("凭据已轮换，已使用新凭据重新认证", c.logFields()...)
+	return nil
+}
+
+// replaceMessageClient 使用新的底层配置建立连接，替换当前客户端、恢复订阅后断开旧连接
+// onSwap 在持有 c.mutex 时调用，用于同步更新与新客户端相关的状态
+func (c *Client) replaceMessageClient(busConfig types.MessageBusConfig, onSwap func()) error {
+	client, err := newMessageClient(c.config, busConfig)
+	if err != nil {
+		return fmt.Errorf("创建新的底层客户端失败: %w", err)
+	}
+	c.watchBrokerDisconnects(client)
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("使用新配置连接失败: %w", err)
+	}
+
+	c.mutex.Lock()
+	if !c.isConnected || c.stopping {
+		c.mutex.Unlock()
+		return client.Disconnect()
+	}
+	old := c.client
+	c.client = client
+	c.busConfig = busConfig
+	if onSwap != nil {
+		onSwap()
+	}
+	c.mutex.Unlock()
+
+	c.closeVariants()
+	if err := c.resubscribe(); err != nil {
+		c.log(LogConnection).Error("切换底层客户端后恢复订阅失败", c.logFields("error", err)...)
+	}
+	if err := old.Disconnect(); err != nil {
+		c.log(LogConnection).Warn("断开旧连接失败", c.logFields("error", err)...)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package messagebus
+
+import (
+	"context"
+	"time"
+)
+
+// drainPollInterval 是平滑断开连接时检查消息是否处理完毕的间隔
+const drainPollInterval = 10 * time.Millisecond
+
+// acceptedPublishKey 标记在停止接受新发布之前已被接受的发布（异步发布、批量发布器的剩余消息）
+type acceptedPublishKey struct{}
+
+// acceptedPublish 返回标记为已接受发布的 ctx，平滑断开期间仍允许其发布
+func acceptedPublish(ctx context.Context) context.Context {
+	return context.WithValue(ctx, acceptedPublishKey{}, true)
+}
+
+// isAcceptedPublish 判断 ctx 是否标记为已接受的发布
+func isAcceptedPublish(ctx context.Context) bool {
+	accepted, _ := ctx.Value(acceptedPublishKey{}).(bool)
+	return accepted
+}
+
+// DisconnectWithContext 平滑断开与 MessageBus 的连接
+//
+// 依次执行：取消底层订阅以停止接收新消息；等待已缓冲和处理中的消息处理完毕（处理函数此时仍可发布）；
+// 停止接受新的发布（返回 ErrClientClosing），等待进行中的异步发布和暂存消息转发完成；
+// 最后与 Disconnect 相同地停止后台 goroutine 并关闭连接。
+// 与 Unsubscribe 相同，被取消的订阅会从客户端和订阅状态文件中移除，之后的 Connect/Reconnect 不会恢复它们。
+// 各阶段共享 ctx 的截止时间，ctx 结束后跳过剩余的等待直接关闭；ctx 未设置截止时间时
+// 关闭阶段仍以 ShutdownTimeout 为上限。
+func (c *Client) DisconnectWithContext(ctx context.Context) error {
+	c.mutex.Lock()
+	if !c.isConnected || c.client == nil || c.stopping {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.stopping = true
+	subs := make([]*subscription, 0, len(c.subscriptions))
+	for _, sub := range c.subscriptions {
+		subs = append(subs, sub)
+	}
+	c.mutex.Unlock()
+
+	for _, group := range groupByClientVariant(subs) {
+		subscriber, err := c.subscriberFor(group[0].opts)
+		if err == nil {
+			err = subscriber.Unsubscribe(c.wireTopics(group)...)
+		}
+		if err != nil {
+			c.log(LogConnection).Warn("平滑断开时取消底层订阅失败", c.logFields("topics", c.wireTopics(group), "error", err)...)
+		}
+	}
+	if !waitUntil(ctx, func() bool { return c.handling.Load() == 0 && buffersEmpty(subs) }) {
+		c.log(LogConnection).Warn("等待订阅消息处理完毕超时", c.logFields("handling", c.handling.Load())...)
+	}
+	c.forgetDrainedSubscriptions(subs)
+
+	c.closing.Store(true)
+	defer c.closing.Store(false)
+	if !waitUntil(ctx, func() bool { return len(c.asyncSlots) == 0 && !(c.outboxEnabled() && c.outboxPending()) }) {
+		c.log(LogConnection).Warn("等待发布完成超时", c.logFields("asyncPublishes", len(c.asyncSlots))...)
+	}
+
+	timeout := c.shutdownTimeout()
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	return c.closeConnection(timeout)
+}
+
+// forgetDrainedSubscriptions 与 Unsubscribe 相同地移除平滑断开时取消的订阅
+// 等待期间被重新订阅替换的主题保留新的订阅
+func (c *Client) forgetDrainedSubscriptions(subs []*subscription) {
+	if len(subs) == 0 {
+		return
+	}
+	topics := make([]string, 0, len(subs))
+	c.mutex.Lock()
+	for _, sub := range subs {
+		if c.subscriptions[sub.topic] == sub {
+			delete(c.subscriptions, sub.topic)
+			close(sub.done)
+			topics = append(topics, sub.topic)
+		}
+	}
+	c.mutex.Unlock()
+	if len(topics) == 0 {
+		return
+	}
+	c.forgetPendingSubscriptions(topics)
+	c.saveSubscriptionState()
+	c.emitEvent(LifecycleEvent{Type: EventUnsubscribed, Topics: topics})
+}
+
+// Drain 以 ShutdownTimeout 为截止时间平滑断开连接，见 DisconnectWithContext
+func (c *Client) Drain() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.shutdownTimeout())
+	defer cancel()
+	return c.DisconnectWithContext(ctx)
+}
+
+// waitUntil 周期性检查 done，直到其返回 true 或 ctx 结束，返回 done 是否已满足
+func waitUntil(ctx context.Context, done func() bool) bool {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for !done() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return done()
+		}
+	}
+	return true
+}
+
+// buffersEmpty 判断订阅的接收通道是否都已为空
+func buffersEmpty(subs []*subscription) bool {
+	for _, sub := range subs {
+		if len(sub.messages) > 0 || len(sub.priority) > 0 || len(sub.ingress) > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package messagebus_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// testConfig 返回连接内存 Broker 使用的基础配置
+func testConfig() messagebus.Config {
+	return messagebus.Config{Host: "localhost", Port: 1883, Protocol: "tcp", Type: messagebus.TypeMQTT}
+}
+
+func TestDisconnectDrainTimeoutWithBlockedHandler(t *testing.T) {
+	config := testConfig()
+	config.DrainTimeout = 100 * time.Millisecond
+	config.ShutdownTimeout = 10 * time.Second
+	lc := &captureLogger{}
+	client := newMockClient(t, messagebus.WithConfig(config), messagebus.WithLogger(lc))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	block := make(chan struct{})
+	t.Cleanup(func() { close(block) })
+	err := client.Subscribe([]string{"test/drain"}, func(_ string, msg types.MessageEnvelope) error {
+		if payload, _ := messagebus.EnvelopePayloadBytes(msg); string(payload) == "first" {
+			close(started)
+			<-release
+			return nil
+		}
+		<-block
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Publish("test/drain", "first"); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := client.Publish("test/drain", "second"); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	begin := time.Now()
+	go func() { done <- client.Disconnect() }()
+	// 等待断开流程发出停止信号，第二条消息随后在排空阶段处理
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("处理函数阻塞时 Disconnect 未在 DrainTimeout 后返回")
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Fatalf("Disconnect 耗时 %s，期望约为 DrainTimeout", elapsed)
+	}
+	if _, ok := lc.find("排空超时，放弃正在处理的消息"); !ok {
+		t.Fatal("未记录被放弃的消息")
+	}
+}
+
+func TestDisconnectWithContextForgetsSubscriptions(t *testing.T) {
+	config := testConfig()
+	config.SubscriptionState.File = filepath.Join(t.TempDir(), "subscriptions.json")
+	var received, restored atomic.Int32
+	handler := func(string, types.MessageEnvelope) error {
+		received.Add(1)
+		return nil
+	}
+	config.SubscriptionState.Restore = func(string, *messagebus.SubscribeOptions) messagebus.MessageHandler {
+		restored.Add(1)
+		return handler
+	}
+	client := newMockClient(t, messagebus.WithConfig(config))
+	if err := client.Subscribe([]string{"test/drained"}, handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DisconnectWithContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 平滑断开取消的订阅不应被重新连接或订阅状态文件恢复
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Reconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Publish("test/drained", "after"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n, r := received.Load(), restored.Load(); n != 0 || r != 0 {
+		t.Errorf("平滑断开后订阅被恢复：收到 %d 条消息，Restore 调用 %d 次", n, r)
+	}
+}
@@ -0,0 +1,24 @@
+package messagebus
+
+import (
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// HeaderPublisherID 是信封 QueryParams 中记录发布方 ClientID 的键，用于过滤客户端自己发布的消息
+const HeaderPublisherID = "x-publisher-id"
+
+// stampPublisher 在信封中记录发布方的 ClientID，ClientID 为空时不记录
+func (c *Client) stampPublisher(envelope *types.MessageEnvelope) {
+	if c.config.ClientID == "" {
+		return
+	}
+	if envelope.QueryParams == nil {
+		envelope.QueryParams = make(map[string]string)
+	}
+	envelope.QueryParams[HeaderPublisherID] = c.config.ClientID
+}
+
+// isEcho 判断消息是否由本客户端发布
+func (c *Client) isEcho(envelope types.MessageEnvelope) bool {
+	return c.config.ClientID != "" && envelope.QueryParams[HeaderPublisherID] == c.config.ClientID
+}
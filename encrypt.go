@@ -0,0 +1,280 @@
+package messagebus
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+const (
+	// HeaderEncryption 是信封 QueryParams 中记录 Payload 加密算法的键
+	HeaderEncryption = "x-encryption"
+	// HeaderEncryptionKeyID 是信封 QueryParams 中记录加密密钥 ID 的键
+	HeaderEncryptionKeyID = "x-encryption-key-id"
+	// EncryptionAESGCM 表示使用 AES-GCM 加密，Payload 为 12 字节随机 nonce 加密文
+	EncryptionAESGCM = "aes-gcm"
+)
+
+// EdgeX 秘密存储中加密密钥使用的键
+const (
+	SecretKeyEncryptionKey   = "encryptionkey"   // Base64 编码的 AES 密钥
+	SecretKeyEncryptionKeyID = "encryptionkeyid" // 密钥 ID，缺失时使用秘密名称
+)
+
+// defaultKeyRefreshInterval 是 SecretStoreKeyProvider 重新读取密钥的默认间隔
+const defaultKeyRefreshInterval = 5 * time.Minute
+
+// EncryptionConfig 表示端到端 Payload 加密参数
+type EncryptionConfig struct {
+	// KeyProvider 提供 AES 密钥，设置后发布的 Payload 使用 AES-GCM 加密，收到的加密消息自动解密
+	KeyProvider KeyProvider
+	// AllowPlaintext 为 true 时仍接受未加密的消息，默认拒绝以防止经 Broker 注入明文消息
+	AllowPlaintext bool
+}
+
+// KeyProvider 提供 AES 密钥（16、24 或 32 字节），密钥以 ID 区分以便轮换
+type KeyProvider interface {
+	// CurrentKey 返回加密使用的密钥及其 ID
+	CurrentKey() (keyID string, key []byte, err error)
+	// Key 返回指定 ID 的密钥，用于解密
+	Key(keyID string) ([]byte, error)
+}
+
+// StaticKeyProvider 使用固定的密钥，可通过 AddKey 保留轮换前的旧密钥用于解密
+type StaticKeyProvider struct {
+	mutex   sync.RWMutex
+	current string
+	keys    map[string][]byte
+}
+
+// NewStaticKeyProvider 创建使用指定密钥加密的密钥提供者
+func NewStaticKeyProvider(keyID string, key []byte) *StaticKeyProvider {
+	return &StaticKeyProvider{current: keyID, keys: map[string][]byte{keyID: key}}
+}
+
+// AddKey 添加仅用于解密的密钥
+func (p *StaticKeyProvider) AddKey(keyID string, key []byte) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.keys[keyID] = key
+}
+
+// CurrentKey 返回加密使用的密钥
+func (p *StaticKeyProvider) CurrentKey() (string, []byte, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.current, p.keys[p.current], nil
+}
+
+// Key 返回指定 ID 的密钥
+func (p *StaticKeyProvider) Key(keyID string) ([]byte, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("未知的密钥 ID: %s", keyID)
+	}
+	return key, nil
+}
+
+// EnvKeyProvider 从环境变量读取 Base64 编码的密钥，密钥 ID 为环境变量名
+type EnvKeyProvider struct {
+	name string
+}
+
+// NewEnvKeyProvider 创建从指定环境变量读取密钥的提供者
+func NewEnvKeyProvider(name string) *EnvKeyProvider {
+	return &EnvKeyProvider{name: name}
+}
+
+// CurrentKey 读取环境变量中的密钥
+func (p *EnvKeyProvider) CurrentKey() (string, []byte, error) {
+	key, err := p.Key(p.name)
+	return p.name, key, err
+}
+
+// Key 读取环境变量中的密钥，keyID 必须为环境变量名
+func (p *EnvKeyProvider) Key(keyID string) ([]byte, error) {
+	if keyID != p.name {
+		return nil, fmt.Errorf("未知的密钥 ID: %s", keyID)
+	}
+	value, ok := os.LookupEnv(p.name)
+	if !ok {
+		return nil, fmt.Errorf("环境变量 %s 未设置", p.name)
+	}
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("环境变量 %s 不是合法的Base64: %w", p.name, err)
+	}
+	return key, nil
+}
+
+// SecretStoreKeyProvider 从 EdgeX 秘密存储（Vault/OpenBao）读取密钥，并按 RefreshInterval 缓存
+// 轮换后旧密钥仍保留在缓存中用于解密在途消息
+type SecretStoreKeyProvider struct {
+	store      SecretGetter
+	secretName string
+	// RefreshInterval 重新读取秘密的间隔，默认 5 分钟
+	RefreshInterval time.Duration
+
+	mutex    sync.Mutex
+	current  string
+	keys     map[string][]byte
+	loadedAt time.Time
+}
+
+// NewSecretStoreKeyProvider 创建从指定秘密读取密钥的提供者
+func NewSecretStoreKeyProvider(store SecretGetter, secretName string) *SecretStoreKeyProvider {
+	return &SecretStoreKeyProvider{store: store, secretName: secretName, keys: make(map[string][]byte)}
+}
+
+// CurrentKey 返回秘密中当前的密钥
+func (p *SecretStoreKeyProvider) CurrentKey() (string, []byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err := p.refresh(false); err != nil {
+		return "", nil, err
+	}
+	return p.current, p.keys[p.current], nil
+}
+
+// Key 返回指定 ID 的密钥，缓存中没有时重新读取秘密
+func (p *SecretStoreKeyProvider) Key(keyID string) ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err := p.refresh(false); err != nil {
+		return nil, err
+	}
+	if key, ok := p.keys[keyID]; ok {
+		return key, nil
+	}
+	if err := p.refresh(true); err != nil {
+		return nil, err
+	}
+	if key, ok := p.keys[keyID]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("未知的密钥 ID: %s", keyID)
+}
+
+// refresh 缓存过期或 force 为 true 时重新读取秘密，调用方须持有锁
+func (p *SecretStoreKeyProvider) refresh(force bool) error {
+	interval := p.RefreshInterval
+	if interval <= 0 {
+		interval = defaultKeyRefreshInterval
+	}
+	if !force && p.current != "" && time.Since(p.loadedAt) < interval {
+		return nil
+	}
+	if p.store == nil {
+		return fmt.Errorf("秘密存储客户端不能为空")
+	}
+	secrets, err := p.store.GetSecret(p.secretName)
+	if err != nil {
+		return fmt.Errorf("读取秘密 %s 失败: %w", p.secretName, err)
+	}
+	key, err := base64.StdEncoding.DecodeString(secrets[SecretKeyEncryptionKey])
+	if err != nil || len(key) == 0 {
+		return fmt.Errorf("秘密 %s 中的 %s 不是合法的Base64密钥", p.secretName, SecretKeyEncryptionKey)
+	}
+	keyID := secrets[SecretKeyEncryptionKeyID]
+	if keyID == "" {
+		keyID = p.secretName
+	}
+	p.keys[keyID] = key
+	p.current = keyID
+	p.loadedAt = time.Now()
+	return nil
+}
+
+// encryptionAAD 返回与密文绑定的附加认证数据，防止 ContentType 或压缩标记被篡改
+func encryptionAAD(envelope types.MessageEnvelope) []byte {
+	return []byte(envelope.ContentType + "\n" + envelope.QueryParams[HeaderContentEncoding])
+}
+
+// newGCM 使用密钥创建 AES-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建AES密码失败: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptionInterceptor 使用 KeyProvider 的当前密钥以 AES-GCM 加密 Payload
+func encryptionInterceptor(provider KeyProvider) PublishInterceptor {
+	return func(next PublishHandler) PublishHandler {
+		return func(ctx context.Context, topic string, envelope types.MessageEnvelope) error {
+			keyID, key, err := provider.CurrentKey()
+			if err != nil {
+				return fmt.Errorf("获取加密密钥失败: %w", err)
+			}
+			gcm, err := newGCM(key)
+			if err != nil {
+				return err
+			}
+			err = withOutboundPayloadBytes(envelope.Payload, func(data []byte) error {
+				nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(data)+gcm.Overhead())
+				if _, err := rand.Read(nonce); err != nil {
+					return fmt.Errorf("生成nonce失败: %w", err)
+				}
+				envelope.Payload = gcm.Seal(nonce, nonce, data, encryptionAAD(envelope))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			envelope.QueryParams = withParam(envelope.QueryParams, HeaderEncryption, EncryptionAESGCM)
+			envelope.QueryParams[HeaderEncryptionKeyID] = keyID
+			return next(ctx, topic, envelope)
+		}
+	}
+}
+
+// decryptPayload 按 QueryParams 中的加密标记解密 Payload
+func decryptPayload(config EncryptionConfig, envelope types.MessageEnvelope) (types.MessageEnvelope, error) {
+	algorithm := envelope.QueryParams[HeaderEncryption]
+	if algorithm == "" {
+		if config.KeyProvider != nil && !config.AllowPlaintext {
+			return envelope, fmt.Errorf("拒绝未加密的消息")
+		}
+		return envelope, nil
+	}
+	if algorithm != EncryptionAESGCM {
+		return envelope, fmt.Errorf("不支持的加密算法: %s", algorithm)
+	}
+	if config.KeyProvider == nil {
+		return envelope, fmt.Errorf("收到加密消息但未配置 KeyProvider")
+	}
+	key, err := config.KeyProvider.Key(envelope.QueryParams[HeaderEncryptionKeyID])
+	if err != nil {
+		return envelope, fmt.Errorf("获取解密密钥失败: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return envelope, err
+	}
+	data, err := payloadBytes(envelope.Payload)
+	if err != nil {
+		return envelope, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return envelope, fmt.Errorf("密文长度不足")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], encryptionAAD(envelope))
+	if err != nil {
+		return envelope, fmt.Errorf("解密Payload失败: %w", err)
+	}
+	envelope.Payload = plaintext
+	envelope.QueryParams = withoutParam(envelope.QueryParams, HeaderEncryption)
+	delete(envelope.QueryParams, HeaderEncryptionKeyID)
+	return envelope, nil
+}
@@ -0,0 +1,81 @@
+package messagebus_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+)
+
+func TestEncryptionRoundTripAndTamperDetection(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	provider := messagebus.NewStaticKeyProvider("k1", bytes.Repeat([]byte("k"), 32))
+	publisher, err := messagebustest.NewMockClientWithBroker(broker, messagebus.WithEncryption(provider))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = publisher.Close() })
+	subscriber, err := messagebustest.NewMockClientWithBroker(broker, messagebus.WithEncryption(provider))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = subscriber.Close() })
+	received := subscribeChannel(t, subscriber, "test/secret")
+
+	if err := publisher.PublishBinary("test/secret", []byte("top secret")); err != nil {
+		t.Fatal(err)
+	}
+	wire := broker.Published()[0].Envelope
+	if wire.QueryParams[messagebus.HeaderEncryption] != messagebus.EncryptionAESGCM || wire.QueryParams[messagebus.HeaderEncryptionKeyID] != "k1" {
+		t.Errorf("加密标记 = %v", wire.QueryParams)
+	}
+	ciphertext, err := messagebus.EnvelopePayloadBytes(wire)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(ciphertext, []byte("top secret")) {
+		t.Error("Broker 上的 Payload 仍包含明文")
+	}
+	msg := receiveOne(t, received)
+	if data, _ := messagebus.EnvelopePayloadBytes(msg); string(data) != "top secret" {
+		t.Errorf("解密后 = %q", data)
+	}
+	if msg.QueryParams[messagebus.HeaderEncryption] != "" {
+		t.Error("解密后的消息不应保留加密标记")
+	}
+
+	// 经未加密的客户端发布被篡改的密文，订阅方应拒绝并报告解码错误
+	forger, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = forger.Close() })
+	tampered := wire
+	tampered.Payload = append([]byte(nil), ciphertext...)
+	tampered.Payload.([]byte)[len(ciphertext)-1] ^= 0xff
+	if err := forger.PublishEnvelope("test/secret", tampered); err != nil {
+		t.Fatal(err)
+	}
+	if err := forger.PublishBinary("test/secret", []byte("plaintext")); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case busErr := <-subscriber.Errors():
+			var decodeErr *messagebus.DecodeError
+			if !errors.As(busErr.Err, &decodeErr) {
+				t.Errorf("期望 *DecodeError，实际 %T: %v", busErr.Err, busErr.Err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("未报告篡改或未加密消息的解码错误")
+		}
+	}
+	select {
+	case msg := <-received:
+		t.Errorf("被篡改或未加密的消息不应交给处理函数: %v", msg.Payload)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,278 @@
+package messagebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+var (
+	// ErrNotConnected 表示客户端尚未连接或已断开
+	ErrNotConnected = errors.New("MessageBus未连接")
+	// ErrPublishTimeout 表示发布在 Broker 确认或 ctx 截止时间之前未完成
+	ErrPublishTimeout = errors.New("发布超时")
+	// ErrSubscribeFailed 表示底层客户端订阅或重新订阅失败
+	ErrSubscribeFailed = errors.New("订阅失败")
+	// ErrInvalidConfig 表示配置校验未通过，*ConfigError 与之匹配
+	ErrInvalidConfig = errors.New("MessageBus配置无效")
+	// ErrClientClosing 表示客户端正在平滑断开连接，不再接受新的发布
+	ErrClientClosing = errors.New("客户端正在断开连接")
+	// ErrClientNotFound 表示 Manager 中没有指定名称的客户端或匹配主题的路由
+	ErrClientNotFound = errors.New("客户端不存在")
+	// ErrClientClosed 表示客户端已 Close，不能再连接
+	ErrClientClosed = errors.New("客户端已关闭")
+)
+
+// OpError 表示一次操作失败，Kind 为上述哨兵错误之一，Err 为底层原因
+// errors.Is 可同时匹配 Kind 与 Err，例如 ErrPublishTimeout 和 context.DeadlineExceeded
+type OpError struct {
+	Op    string // 操作名称，例如 publish、subscribe
+	Topic string // 相关主题，多个主题以逗号分隔
+	Kind  error  // 错误类别
+	Err   error  // 底层原因，可能为 nil
+}
+
+// Error 实现 error 接口
+func (e *OpError) Error() string {
+	msg := e.Op
+	if e.Topic != "" {
+		msg += " " + e.Topic
+	}
+	msg += ": " + e.Kind.Error()
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap 返回错误类别与底层原因，供 errors.Is/As 使用
+func (e *OpError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// publishError 将超时类的发布错误归类为 ErrPublishTimeout，其余错误原样返回
+func publishError(topic string, err error) error {
+	if err == nil || !isTimeout(err) {
+		return err
+	}
+	return &OpError{Op: "publish", Topic: topic, Kind: ErrPublishTimeout, Err: err}
+}
+
+// subscribeError 将底层订阅错误包装为 ErrSubscribeFailed
+func subscribeError(op string, topics []string, err error) error {
+	return &OpError{Op: op, Topic: strings.Join(topics, ","), Kind: ErrSubscribeFailed, Err: err}
+}
+
+// isTimeout 判断错误是否由超时引起
+// go-mod-messaging 的超时错误类型位于 internal 包中，只能依据错误信息判断
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "timeout")
+}
+
+// ErrorOverflowPolicy 表示错误通道已满时的处理策略
+type ErrorOverflowPolicy string
+
+const (
+	ErrorOverflowDropNewest ErrorOverflowPolicy = "drop-newest" // 丢弃新产生的错误（默认）
+	ErrorOverflowDropOldest ErrorOverflowPolicy = "drop-oldest" // 丢弃通道中最早的错误，为新错误腾出位置
+)
+
+// BusError 表示一次异步错误，记录发生错误的操作、主题和时间
+type BusError struct {
+	Op    string    // 操作名称: receive、decode、handle、reconnect、outbox
+	Topic string    // 相关主题，未知时为空
+	Time  time.Time // 错误发生时间
+	Err   error     // 原始错误
+}
+
+// Error 实现 error 接口
+func (e *BusError) Error() string {
+	if e.Topic == "" {
+		return fmt.Sprintf("%s: %v", e.Op, e.Err)
+	}
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Topic, e.Err)
+}
+
+// Unwrap 返回原始错误
+func (e *BusError) Unwrap() error {
+	return e.Err
+}
+
+// Errors 返回客户端的异步错误通道，每个错误携带操作、主题和时间
+// 通道已满时按 Config.ErrorOverflow 丢弃错误，丢弃数见 Stats().DroppedErrors
+// 调用 GetErrorChannel 后错误改为写入其返回的通道，本通道不再收到新错误；Close 后通道关闭
+func (c *Client) Errors() <-chan BusError {
+	return c.busErrors
+}
+
+// GetErrorChannel 返回客户端的错误通道，通道中的错误为 *BusError
+// 首次调用后错误只写入该通道（Errors 中尚未读取的错误一并移入），溢出策略与 Errors 相同；
+// 二者只应使用其一，新代码建议使用 Errors。Close 后通道关闭
+func (c *Client) GetErrorChannel() <-chan error {
+	c.errorsMu.Lock()
+	defer c.errorsMu.Unlock()
+	if c.legacyErrors != nil {
+		return c.legacyErrors
+	}
+	c.legacyErrors = make(chan error, cap(c.busErrors))
+	if c.errorsClosed {
+		close(c.legacyErrors)
+		return c.legacyErrors
+	}
+	for {
+		select {
+		case busErr := <-c.busErrors:
+			c.legacyErrors <- &busErr
+		default:
+			return c.legacyErrors
+		}
+	}
+}
+
+// reportError 将客户端内部产生的异步错误发送到错误通道，通道已满时按溢出策略丢弃
+func (c *Client) reportError(op string, topic string, err error) {
+	busErr := BusError{Op: op, Topic: topic, Time: time.Now(), Err: err}
+	c.lastError.Store(&busErr)
+	dropOldest := c.config.ErrorOverflow == ErrorOverflowDropOldest
+	c.errorsMu.Lock()
+	var sent bool
+	var dropped uint64
+	switch {
+	case c.errorsClosed:
+		sent = true
+	case c.legacyErrors != nil:
+		sent, dropped = offerError(c.legacyErrors, error(&busErr), dropOldest)
+	default:
+		sent, dropped = offerError(c.busErrors, busErr, dropOldest)
+	}
+	c.errorsMu.Unlock()
+	c.stats.droppedErrors.Add(dropped)
+	if !sent {
+		c.lc.Debug("错误通道已满，丢弃错误", c.logFields("op", op, "topic", topic, "error", err)...)
+	}
+}
+
+// offerError 以非阻塞方式写入错误通道，dropOldest 时先丢弃最早的错误腾出位置
+// 返回是否写入成功及被丢弃的错误数，调用方需持有 errorsMu
+func offerError[T any](ch chan T, v T, dropOldest bool) (bool, uint64) {
+	select {
+	case ch <- v:
+		return true, 0
+	default:
+	}
+	if dropOldest {
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- v:
+			return true, 1
+		default:
+		}
+		return false, 2
+	}
+	return false, 1
+}
+
+// errorQueueDepth 返回当前错误通道中等待读取的错误数量
+func (c *Client) errorQueueDepth() int {
+	c.errorsMu.Lock()
+	defer c.errorsMu.Unlock()
+	if c.legacyErrors != nil {
+		return len(c.legacyErrors)
+	}
+	return len(c.busErrors)
+}
+
+// isClosed 返回客户端是否已 Close
+func (c *Client) isClosed() bool {
+	c.errorsMu.Lock()
+	defer c.errorsMu.Unlock()
+	return c.errorsClosed
+}
+
+// Close 断开连接并关闭 Errors 与 GetErrorChannel 返回的错误通道，之后客户端不能再连接
+// 读取方可据此结束 range 循环，MergeErrorChannels 的合并通道也随之关闭；重复调用无副作用
+func (c *Client) Close() error {
+	err := c.Disconnect()
+	c.errorsMu.Lock()
+	defer c.errorsMu.Unlock()
+	if c.errorsClosed {
+		return err
+	}
+	c.errorsClosed = true
+	if c.configWatch != nil {
+		if watchErr := c.configWatch.Disconnect(); watchErr != nil && err == nil {
+			err = fmt.Errorf("断开配置监听连接失败: %w", watchErr)
+		}
+	}
+	close(c.busErrors)
+	if c.legacyErrors != nil {
+		close(c.legacyErrors)
+	}
+	return err
+}
+
+// forwardErrors 将底层客户端报告的错误转发到错误通道，避免底层客户端因通道写满而阻塞
+func (c *Client) forwardErrors(stop <-chan struct{}) {
+	for {
+		select {
+		case err := <-c.errorChan:
+			c.reportError("receive", "", err)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// MergeErrorChannels 将多个错误通道合并为一个，所有输入通道关闭后合并通道随之关闭
+func MergeErrorChannels(chans ...<-chan error) <-chan error {
+	merged := make(chan error, len(chans))
+	var wg sync.WaitGroup
+	for _, ch := range chans {
+		if ch == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(ch <-chan error) {
+			defer wg.Done()
+			for err := range ch {
+				merged <- err
+			}
+		}(ch)
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+	return merged
+}
+
+// RequestError 表示响应信封携带了非 0 的 ErrorCode
+type RequestError struct {
+	ErrorCode int                    // 响应中的错误码
+	Message   string                 // 响应 Payload 中的错误信息
+	Response  *types.MessageEnvelope // 原始响应信封
+}
+
+// Error 实现 error 接口
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("请求返回错误(ErrorCode=%d): %s", e.ErrorCode, e.Message)
+}
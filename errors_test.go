@@ -0,0 +1,97 @@
+package messagebus_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+func TestMergeErrorChannels(t *testing.T) {
+	a := make(chan error, 3)
+	b := make(chan error, 3)
+	want := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		errA, errB := fmt.Errorf("a%d", i), fmt.Errorf("b%d", i)
+		a <- errA
+		b <- errB
+		want[errA.Error()] = true
+		want[errB.Error()] = true
+	}
+	close(a)
+	close(b)
+
+	merged := messagebus.MergeErrorChannels(a, b, nil)
+	got := map[string]bool{}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case err, ok := <-merged:
+			if !ok {
+				if len(got) != len(want) {
+					t.Fatalf("收到 %d 个错误，期望 %d 个: %v", len(got), len(want), got)
+				}
+				return
+			}
+			if !want[err.Error()] {
+				t.Fatalf("收到意外的错误 %v", err)
+			}
+			got[err.Error()] = true
+		case <-timeout:
+			t.Fatalf("合并通道未关闭，已收到 %v", got)
+		}
+	}
+}
+
+func TestMergeErrorChannelsClosesAfterClientClose(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	clientA, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientB, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := messagebus.MergeErrorChannels(clientA.GetErrorChannel(), clientB.GetErrorChannel())
+
+	handlerErr := errors.New("处理失败")
+	err = clientA.Subscribe([]string{"test/errors"}, func(string, types.MessageEnvelope) error { return handlerErr })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clientB.Publish("test/errors", "x"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-merged:
+		var busErr *messagebus.BusError
+		if !errors.As(err, &busErr) || !errors.Is(err, handlerErr) {
+			t.Fatalf("期望 *BusError 包装处理错误，实际 %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("未收到处理函数返回的错误")
+	}
+
+	if err := clientA.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := clientB.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case _, ok := <-merged:
+		if ok {
+			t.Fatal("Close 后不应再收到错误")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("客户端 Close 后合并通道未关闭")
+	}
+	if err := clientA.Connect(); !errors.Is(err, messagebus.ErrClientClosed) {
+		t.Fatalf("Close 后 Connect 应返回 ErrClientClosed，实际 %v", err)
+	}
+}
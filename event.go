@@ -0,0 +1,83 @@
+package messagebus
+
+import (
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/requests"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+)
+
+// EventHandler 定义接收 EdgeX Event 的处理函数
+type EventHandler func(topic string, event dtos.Event) error
+
+// baseTopic 返回配置的主题前缀，默认为 edgex
+func (c *Client) baseTopic() string {
+	if c.config.BaseTopicPrefix != "" {
+		return c.config.BaseTopicPrefix
+	}
+	return common.DefaultBaseTopic
+}
+
+// serviceName 返回构造事件主题使用的服务名，默认为 ClientID
+func (c *Client) serviceName() string {
+	if c.config.ServiceName != "" {
+		return c.config.ServiceName
+	}
+	return c.config.ClientID
+}
+
+// EventTopic 返回设备服务发布事件的标准主题
+// <BaseTopicPrefix>/events/device/<ServiceName>/<profileName>/<deviceName>/<sourceName>
+func (c *Client) EventTopic(profileName, deviceName, sourceName string) string {
+	return common.BuildTopic(c.baseTopic(), common.EventsPublishTopic, common.Device,
+		c.serviceName(), profileName, deviceName, sourceName)
+}
+
+// PublishEvent 使用读数构造 EdgeX Event，封装为 AddEventRequest 后发布到 EventTopic 返回的主题
+// 读数中未设置的 Id、DeviceName、ProfileName 和 Origin 取自事件
+func (c *Client) PublishEvent(profileName, deviceName, sourceName string, readings ...dtos.BaseReading) error {
+	if profileName == "" || deviceName == "" || sourceName == "" {
+		return fmt.Errorf("profileName、deviceName 和 sourceName 不能为空")
+	}
+	if len(readings) == 0 {
+		return fmt.Errorf("事件至少需要一个读数")
+	}
+	event := dtos.NewEvent(profileName, deviceName, sourceName)
+	event.Readings = make([]dtos.BaseReading, len(readings))
+	for i, reading := range readings {
+		if reading.Id == "" {
+			reading.Id = uuid.NewString()
+		}
+		if reading.DeviceName == "" {
+			reading.DeviceName = deviceName
+		}
+		if reading.ProfileName == "" {
+			reading.ProfileName = profileName
+		}
+		if reading.Origin == 0 {
+			reading.Origin = event.Origin
+		}
+		event.Readings[i] = reading
+	}
+	return c.Publish(c.EventTopic(profileName, deviceName, sourceName), requests.NewAddEventRequest(event))
+}
+
+// SubscribeEvents 订阅所有设备服务发布的事件，将 AddEventRequest 解码后把其中的 Event 交给处理函数
+// 解码失败的消息不会交给处理函数，而是以 *DecodeError 发送到错误通道
+func (c *Client) SubscribeEvents(handler EventHandler) error {
+	if handler == nil {
+		return fmt.Errorf("消息处理函数不能为空")
+	}
+	topic := common.BuildTopic(c.baseTopic(), common.CoreDataEventSubscribeTopic)
+	return c.Subscribe([]string{topic}, func(topic string, message types.MessageEnvelope) error {
+		var request requests.AddEventRequest
+		if err := DecodePayload(message, &request); err != nil {
+			c.reportError("decode", topic, &DecodeError{Topic: topic, CorrelationID: message.CorrelationID, Err: err})
+			return nil
+		}
+		return handler(topic, request.Event)
+	})
+}
@@ -0,0 +1,126 @@
+package messagebus
+
+import (
+	"sync"
+	"time"
+)
+
+// lifecycleEventBuffer 是生命周期事件通道的缓冲大小
+const lifecycleEventBuffer = 64
+
+// LifecycleEventType 表示生命周期事件的类型
+type LifecycleEventType string
+
+const (
+	EventConnected        LifecycleEventType = "connected"        // 已连接
+	EventDisconnected     LifecycleEventType = "disconnected"     // 已断开连接
+	EventReconnecting     LifecycleEventType = "reconnecting"     // 开始一次重连尝试
+	EventReconnected      LifecycleEventType = "reconnected"      // 重连成功
+	EventReconnectFailed  LifecycleEventType = "reconnectFailed"  // 重连最终失败
+	EventSubscribed       LifecycleEventType = "subscribed"       // 订阅成功
+	EventUnsubscribed     LifecycleEventType = "unsubscribed"     // 取消订阅
+	EventUnhealthy        LifecycleEventType = "unhealthy"        // 健康检查连续失败达到阈值
+	EventCircuitOpen      LifecycleEventType = "circuitOpen"      // 发布熔断器打开
+	EventCircuitHalfOpen  LifecycleEventType = "circuitHalfOpen"  // 发布熔断器进入半开状态
+	EventCircuitClosed    LifecycleEventType = "circuitClosed"    // 发布熔断器关闭
+	EventClientIDTakeover LifecycleEventType = "clientIdTakeover" // 检测到 ClientID 冲突
+)
+
+// LifecycleEvent 描述客户端生命周期中的一次重要事件，不同类型使用其中不同的字段
+type LifecycleEvent struct {
+	Type    LifecycleEventType // 事件类型
+	Time    time.Time          // 事件发生时间
+	Topics  []string           // 相关主题 (subscribed, unsubscribed)
+	Attempt int                // 重连尝试次数 (reconnecting, reconnected, reconnectFailed)、连续失败次数 (unhealthy, circuitOpen) 或断开次数 (clientIdTakeover)
+	Err     error              // 相关错误 (reconnectFailed, 重连用尽后的 disconnected, unhealthy, circuitOpen, clientIdTakeover)
+}
+
+// ConnectHandler 在连接建立（Connect 成功）后被调用
+type ConnectHandler func()
+
+// DisconnectHandler 在主动断开连接或重连次数用尽后被调用
+type DisconnectHandler func()
+
+// ReconnectHandler 在自动或手动重连成功、订阅恢复后被调用，attempt 为成功时的尝试次数
+type ReconnectHandler func(attempt int)
+
+// callbackState 保存连接状态变化回调
+type callbackState struct {
+	mutex        sync.Mutex
+	onConnect    ConnectHandler
+	onDisconnect DisconnectHandler
+	onReconnect  ReconnectHandler
+	onPanic      PanicHandler
+
+	onSlowHandler SlowHandlerHandler
+	onQueueLag    QueueLagHandler
+	onTakeover    ClientIDTakeoverHandler
+}
+
+// OnConnect 注册连接建立后的回调，可用于发布上线（birth）消息；传入 nil 取消回调
+// 回调在调用 Connect 的 goroutine 中、释放客户端锁之后执行，可以安全地调用 Publish
+func (c *Client) OnConnect(handler ConnectHandler) {
+	c.callbacks.mutex.Lock()
+	defer c.callbacks.mutex.Unlock()
+	c.callbacks.onConnect = handler
+}
+
+// OnDisconnect 注册主动断开连接后的回调；传入 nil 取消回调
+func (c *Client) OnDisconnect(handler DisconnectHandler) {
+	c.callbacks.mutex.Lock()
+	defer c.callbacks.mutex.Unlock()
+	c.callbacks.onDisconnect = handler
+}
+
+// OnReconnect 注册重连成功后的回调，此时订阅已恢复；传入 nil 取消回调
+func (c *Client) OnReconnect(handler ReconnectHandler) {
+	c.callbacks.mutex.Lock()
+	defer c.callbacks.mutex.Unlock()
+	c.callbacks.onReconnect = handler
+}
+
+// runCallbacks 调用事件对应的回调，回调中的 panic 会被记录而不会影响客户端
+func (c *Client) runCallbacks(event LifecycleEvent) {
+	c.callbacks.mutex.Lock()
+	onConnect, onDisconnect, onReconnect := c.callbacks.onConnect, c.callbacks.onDisconnect, c.callbacks.onReconnect
+	c.callbacks.mutex.Unlock()
+	var fn func()
+	switch {
+	case event.Type == EventConnected && onConnect != nil:
+		fn = onConnect
+	case event.Type == EventDisconnected && onDisconnect != nil:
+		fn = onDisconnect
+	case event.Type == EventReconnected && onReconnect != nil:
+		fn = func() { onReconnect(event.Attempt) }
+	default:
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			c.log(LogConnection).Error("生命周期回调发生panic", c.logFields("event", string(event.Type), "panic", r)...)
+		}
+	}()
+	fn()
+}
+
+// LifecycleEvents 返回生命周期事件通道，可与各类回调同时使用
+//
+// 通道带有固定大小的缓冲且永不关闭；消费过慢导致缓冲已满时，新事件会被丢弃并计入
+// Stats().DroppedLifecycleEvents，不会阻塞客户端。
+func (c *Client) LifecycleEvents() <-chan LifecycleEvent {
+	return c.events
+}
+
+// emitEvent 以非阻塞方式发送生命周期事件，并同步调用对应的回调
+// 调用方不能持有 c.mutex，否则回调中调用客户端方法会死锁
+func (c *Client) emitEvent(event LifecycleEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	select {
+	case c.events <- event:
+	default:
+		c.stats.droppedEvents.Add(1)
+	}
+	c.runCallbacks(event)
+}
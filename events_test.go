@@ -0,0 +1,73 @@
+package messagebus_test
+
+import (
+	"reflect"
+	"testing"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+func TestLifecycleEventSequence(t *testing.T) {
+	client, err := messagebus.NewClientWithOptions(
+		messagebus.WithLogger(logger.NewMockClient()),
+		messagebus.WithMessageClientFactory(messagebustest.NewBroker().MessageClientFactory("events")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	var callbacks []string
+	client.OnConnect(func() { callbacks = append(callbacks, "connect") })
+	client.OnDisconnect(func() { callbacks = append(callbacks, "disconnect") })
+
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	handler := func(string, types.MessageEnvelope) error { return nil }
+	if err := client.Subscribe([]string{"test/a", "test/b"}, handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Unsubscribe("test/a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+
+	type event struct {
+		Type   messagebus.LifecycleEventType
+		Topics []string
+	}
+	want := []event{
+		{messagebus.EventConnected, nil},
+		{messagebus.EventSubscribed, []string{"test/a", "test/b"}},
+		{messagebus.EventUnsubscribed, []string{"test/a"}},
+		{messagebus.EventDisconnected, nil},
+	}
+	var got []event
+	for len(got) < len(want) {
+		select {
+		case e := <-client.LifecycleEvents():
+			if e.Time.IsZero() {
+				t.Errorf("%s 事件缺少时间", e.Type)
+			}
+			got = append(got, event{e.Type, e.Topics})
+		default:
+			t.Fatalf("事件序列 %v，期望 %v", got, want)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("事件序列 %v，期望 %v", got, want)
+	}
+	select {
+	case e := <-client.LifecycleEvents():
+		t.Fatalf("多余的事件 %s", e.Type)
+	default:
+	}
+	if !reflect.DeepEqual(callbacks, []string{"connect", "disconnect"}) {
+		t.Fatalf("回调顺序 %v", callbacks)
+	}
+}
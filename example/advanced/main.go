@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -49,16 +48,16 @@ func main() {
 	// Create logger with structured logging
 	lc := logger.NewClient("AdvancedMessageBusExample", "DEBUG")
 
-	// Configuration with environment variable support
-	config := messagebus.Config{
-		Host:     getEnvOrDefault("MESSAGEBUS_HOST", "localhost"),
-		Port:     getEnvIntOrDefault("MESSAGEBUS_PORT", 1883),
-		Protocol: getEnvOrDefault("MESSAGEBUS_PROTOCOL", "tcp"),
-		Type:     getEnvOrDefault("MESSAGEBUS_TYPE", "mqtt"),
-		ClientID: getEnvOrDefault("MESSAGEBUS_CLIENT_ID", "advanced-example-client"),
-		Username: os.Getenv("MESSAGEBUS_USERNAME"),
-		Password: os.Getenv("MESSAGEBUS_PASSWORD"),
-		QoS:      getEnvIntOrDefault("MESSAGEBUS_QOS", 1),
+	// Configuration from an optional file plus MESSAGEBUS_* environment variables
+	config, err := messagebus.LoadConfig(os.Getenv("MESSAGEBUS_CONFIG_FILE"))
+	if err != nil {
+		log.Fatalf("Invalid MessageBus configuration: %v", err)
+	}
+	if config.ClientID == "" {
+		config.ClientID = "advanced-example-client"
+	}
+	if config.QoS == 0 {
+		config.QoS = 1
 	}
 
 	// Create client
@@ -170,38 +169,46 @@ func monitorHealth(ctx context.Context, client *messagebus.Client, lc logger.Log
 	}
 }
 
-// publishSensorData simulates publishing sensor data
+// publishSensorData simulates publishing sensor data with one periodic publisher per sensor,
+// plus a heartbeat; publishers follow the client's connection and stop when ctx is done
 func publishSensorData(ctx context.Context, client *messagebus.Client, lc logger.LoggingClient) {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-
 	sensors := []string{"temperature", "humidity", "pressure"}
 	locations := []string{"room1", "room2", "outdoor"}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			for i, sensorType := range sensors {
-				data := SensorData{
-					DeviceID:   fmt.Sprintf("sensor-%s-%d", sensorType, i+1),
-					SensorType: sensorType,
-					Value:      20.0 + float64(i*5) + (float64(time.Now().Unix()%10) - 5),
-					Unit:       getUnitForSensor(sensorType),
-					Timestamp:  time.Now(),
-					Location:   locations[i%len(locations)],
-					Quality:    95 + (int(time.Now().Unix()) % 5),
-				}
-
-				topic := fmt.Sprintf("edgex/events/device/%s", data.DeviceID)
-				if err := client.Publish(topic, data); err != nil {
-					lc.Errorf("Failed to publish sensor data: %v", err)
-				} else {
-					lc.Debugf("Published sensor data: %s = %.2f %s", data.DeviceID, data.Value, data.Unit)
-				}
-			}
+	var publishers []*messagebus.PeriodicPublisher
+	for i, sensorType := range sensors {
+		deviceID := fmt.Sprintf("sensor-%s-%d", sensorType, i+1)
+		topic := fmt.Sprintf("edgex/events/device/%s", deviceID)
+		publisher, err := client.NewPeriodicPublisher(topic, 5*time.Second, func() (interface{}, error) {
+			return SensorData{
+				DeviceID:   deviceID,
+				SensorType: sensorType,
+				Value:      20.0 + float64(i*5) + (float64(time.Now().Unix()%10) - 5),
+				Unit:       getUnitForSensor(sensorType),
+				Timestamp:  time.Now(),
+				Location:   locations[i%len(locations)],
+				Quality:    95 + (int(time.Now().Unix()) % 5),
+			}, nil
+		})
+		if err != nil {
+			lc.Errorf("Failed to create sensor publisher: %v", err)
+			continue
 		}
+		publishers = append(publishers, publisher)
+	}
+
+	heartbeat, err := client.NewHeartbeat("edgex/status/advanced-example", messagebus.HeartbeatOptions{
+		Interval: 10 * time.Second,
+	})
+	if err != nil {
+		lc.Errorf("Failed to create heartbeat: %v", err)
+	} else {
+		publishers = append(publishers, heartbeat)
+	}
+
+	<-ctx.Done()
+	for _, publisher := range publishers {
+		publisher.Close()
 	}
 }
 
@@ -210,8 +217,8 @@ func handleCommands(ctx context.Context, client *messagebus.Client, lc logger.Lo
 	handler := func(topic string, message types.MessageEnvelope) error {
 		lc.Debugf("Received command on topic: %s", topic)
 
-		var cmd CommandRequest
-		if err := json.Unmarshal(message.Payload.([]byte), &cmd); err != nil {
+		cmd, err := messagebus.DecodeJSON[CommandRequest](message)
+		if err != nil {
 			lc.Errorf("Failed to parse command: %v", err)
 			return err
 		}
@@ -260,17 +267,17 @@ func subscribeToEvents(ctx context.Context, client *messagebus.Client, lc logger
 		lc.Debugf("Received event on topic: %s, CorrelationID: %s", topic, message.CorrelationID)
 
 		// Parse and process different types of events
-		var eventData map[string]interface{}
-		if err := json.Unmarshal(message.Payload.([]byte), &eventData); err != nil {
+		eventData, err := messagebus.DecodeJSON[map[string]interface{}](message)
+		if err != nil {
 			lc.Errorf("Failed to parse event data: %v", err)
 			return err
 		}
 
 		// Process based on topic pattern
 		switch {
-		case contains(topic, "events/device"):
+		case messagebus.TopicMatches("edgex/events/device/#", topic):
 			lc.Infof("Device event: %+v", eventData)
-		case contains(topic, "events/system"):
+		case messagebus.TopicMatches("edgex/events/system/#", topic):
 			lc.Infof("System event: %+v", eventData)
 		default:
 			lc.Infof("Other event: %+v", eventData)
@@ -341,22 +348,6 @@ func requestResponseExample(ctx context.Context, client *messagebus.Client, lc l
 }
 
 // Helper functions
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-func getEnvIntOrDefault(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := fmt.Sscanf(value, "%d", &defaultValue); err == nil && intValue == 1 {
-			return defaultValue
-		}
-	}
-	return defaultValue
-}
-
 func getUnitForSensor(sensorType string) string {
 	switch sensorType {
 	case "temperature":
@@ -369,8 +360,3 @@ func getUnitForSensor(sensorType string) string {
 		return ""
 	}
 }
-
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr ||
-		(len(s) > len(substr) && (s[:len(substr)] == substr || s[len(s)-len(substr):] == substr)))
-}
@@ -46,14 +46,12 @@ func main() {
 		fmt.Printf("   实际主题: %s\n", topic)
 		fmt.Printf("   CorrelationID: %s\n", message.CorrelationID)
 
-		// 安全地处理 Payload
-		var payloadStr string
-		if payload, ok := message.Payload.([]byte); ok {
-			payloadStr = string(payload)
-		} else {
-			payloadStr = fmt.Sprintf("%v", message.Payload)
+		// Payload 可能是 []byte、base64 字符串或已解码的对象，统一转换为字节
+		payload, err := messagebus.EnvelopePayloadBytes(message)
+		if err != nil {
+			return err
 		}
-		fmt.Printf("   内容: %s\n", payloadStr)
+		fmt.Printf("   内容: %s\n", payload)
 		fmt.Println("   ---")
 		return nil
 	}
@@ -65,14 +65,12 @@ func main() {
 		}
 		fmt.Printf("   事件类型: %s\n", messageType)
 
-		// 安全地处理 Payload
-		var payloadStr string
-		if payload, ok := message.Payload.([]byte); ok {
-			payloadStr = string(payload)
-		} else {
-			payloadStr = fmt.Sprintf("%v", message.Payload)
+		// Payload 可能是 []byte、base64 字符串或已解码的对象，统一转换为字节
+		payload, err := messagebus.EnvelopePayloadBytes(message)
+		if err != nil {
+			return err
 		}
-		fmt.Printf("   消息内容: %s\n", payloadStr)
+		fmt.Printf("   消息内容: %s\n", payload)
 		fmt.Println("   " + strings.Repeat("-", 50))
 		return nil
 	}
@@ -0,0 +1,251 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+)
+
+// HeaderFanOutID 是信封 QueryParams 中记录多主题发布分组 ID 的键，同一次 PublishFanOut/PublishMulti 发出的信封取值相同
+const HeaderFanOutID = "x-fanout-id"
+
+// FanOutMode 表示多主题发布在部分主题失败时的处理方式
+type FanOutMode int
+
+const (
+	// FanOutBestEffort 并发发布所有主题，返回全部失败的主题
+	FanOutBestEffort FanOutMode = iota
+	// FanOutAllOrNothing 先完成所有主题的编码和校验并检查连接与熔断状态，任一项不通过时不发送任何消息
+	// （连接或熔断检查不通过时直接返回对应错误）；
+	// 随后按主题顺序逐个发送，某个主题发送失败时不再发送剩余主题
+	//
+	// MQTT/NATS 没有跨主题事务，已发送的消息无法撤回，因此只能保证发送前的检查全部通过
+	FanOutAllOrNothing
+)
+
+// FanOutOptions 表示多主题发布参数
+type FanOutOptions struct {
+	Mode        FanOutMode // 部分失败时的处理方式，默认 FanOutBestEffort
+	Concurrency int        // FanOutBestEffort 时并发发布的主题数，默认 8
+}
+
+// MultiError 汇总多主题发布的结果，仅在至少一个主题失败或未发送时返回
+type MultiError struct {
+	Errors    map[string]error // 失败的主题及原因
+	Published []string         // 已成功发布的主题
+	Skipped   []string         // FanOutAllOrNothing 时因其他主题校验或发送失败而未发送的主题
+}
+
+// Error 实现 error 接口
+func (e *MultiError) Error() string {
+	topics := e.failedTopics()
+	parts := make([]string, len(topics))
+	for i, topic := range topics {
+		parts[i] = fmt.Sprintf("%q: %v", topic, e.Errors[topic])
+	}
+	msg := fmt.Sprintf("%d 个主题发布失败: %s", len(topics), strings.Join(parts, "; "))
+	if len(e.Skipped) > 0 {
+		msg += fmt.Sprintf("，%d 个主题未发送", len(e.Skipped))
+	}
+	return msg
+}
+
+// Unwrap 按主题顺序返回各主题的失败原因，供 errors.Is/As 使用
+func (e *MultiError) Unwrap() []error {
+	topics := e.failedTopics()
+	errs := make([]error, len(topics))
+	for i, topic := range topics {
+		errs[i] = e.Errors[topic]
+	}
+	return errs
+}
+
+// failedTopics 返回排序后的失败主题
+func (e *MultiError) failedTopics() []string {
+	topics := make([]string, 0, len(e.Errors))
+	for topic := range e.Errors {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// PublishFanOut 将同一份数据分别发布到多个主题，采用 FanOutBestEffort 语义
+// 数据只编码一次；部分主题失败时返回 *MultiError
+func (c *Client) PublishFanOut(data interface{}, topics ...string) error {
+	messages := make(map[string]interface{}, len(topics))
+	for _, topic := range topics {
+		messages[topic] = data
+	}
+	return c.PublishMulti(messages, FanOutOptions{})
+}
+
+// PublishMulti 将每个主题对应的数据作为一组发布，数据可以是任意可编码的值或 types.MessageEnvelope
+// 同组信封的 QueryParams 中写入相同的 HeaderFanOutID；部分主题失败或未发送时返回 *MultiError
+func (c *Client) PublishMulti(messages map[string]interface{}, opts FanOutOptions) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	topics := make([]string, 0, len(messages))
+	for topic := range messages {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	result := &MultiError{Errors: make(map[string]error)}
+	groupID := uuid.NewString()
+	envelopes := make(map[string]types.MessageEnvelope, len(messages))
+	encoded := make(map[interface{}]encodedPayload)
+	for _, topic := range topics {
+		envelope, err := c.fanOutEnvelope(messages[topic], encoded)
+		if err == nil {
+			err = validatePublishTopic(topic)
+		}
+		if err != nil {
+			result.Errors[topic] = err
+			continue
+		}
+		envelope.QueryParams[HeaderFanOutID] = groupID
+		envelopes[topic] = envelope
+	}
+
+	if opts.Mode == FanOutAllOrNothing {
+		if len(result.Errors) == 0 {
+			if err := c.fanOutPreflight(); err != nil {
+				return err
+			}
+		}
+		for i, topic := range topics {
+			if len(result.Errors) > 0 {
+				for _, rest := range topics[i:] {
+					if _, failed := result.Errors[rest]; !failed {
+						result.Skipped = append(result.Skipped, rest)
+					}
+				}
+				break
+			}
+			if err := c.publishEnvelope(context.Background(), topic, envelopes[topic]); err != nil {
+				result.Errors[topic] = err
+				continue
+			}
+			result.Published = append(result.Published, topic)
+		}
+	} else {
+		c.publishFanOut(envelopes, opts.Concurrency, result)
+	}
+	if len(result.Errors) == 0 && len(result.Skipped) == 0 {
+		return nil
+	}
+	return result
+}
+
+// encodedPayload 缓存同一数据的编码结果，PublishFanOut 中多个主题共享一次编码
+type encodedPayload struct {
+	payload     interface{}
+	contentType string
+}
+
+// fanOutEnvelope 按 Publish 的规则为一个主题构造信封
+func (c *Client) fanOutEnvelope(data interface{}, encoded map[interface{}]encodedPayload) (types.MessageEnvelope, error) {
+	switch envelope := data.(type) {
+	case types.MessageEnvelope:
+		return fanOutCopy(envelope), nil
+	case *types.MessageEnvelope:
+		if envelope == nil {
+			return types.MessageEnvelope{}, fmt.Errorf("信封不能为空")
+		}
+		return fanOutCopy(*envelope), nil
+	}
+	key, cacheable := cacheKey(data)
+	if cacheable {
+		if cached, ok := encoded[key]; ok {
+			return fanOutCopy(newEnvelope(cached.payload, cached.contentType)), nil
+		}
+	}
+	payload, contentType, err := c.encodePayload(data)
+	if err != nil {
+		return types.MessageEnvelope{}, err
+	}
+	if cacheable {
+		encoded[key] = encodedPayload{payload: payload, contentType: contentType}
+	}
+	return fanOutCopy(newEnvelope(payload, contentType)), nil
+}
+
+// cacheKey 返回可作为映射键的数据，不可比较的值（如切片、映射及接口字段中保存了这类值的结构体）不缓存
+func cacheKey(data interface{}) (interface{}, bool) {
+	if !reflect.ValueOf(data).Comparable() {
+		return nil, false
+	}
+	return data, true
+}
+
+// fanOutCopy 为信封生成独立的 CorrelationID 和 QueryParams
+func fanOutCopy(envelope types.MessageEnvelope) types.MessageEnvelope {
+	if envelope.CorrelationID == "" {
+		envelope.CorrelationID = uuid.NewString()
+	}
+	envelope.QueryParams = copyQueryParams(envelope.QueryParams)
+	return envelope
+}
+
+// validatePublishTopic 校验发布主题不为空且不含通配符
+func validatePublishTopic(topic string) error {
+	if topic == "" {
+		return fmt.Errorf("发布主题不能为空")
+	}
+	if strings.ContainsAny(topic, "+#*>") {
+		return fmt.Errorf("发布主题不能包含通配符: %s", topic)
+	}
+	return nil
+}
+
+// fanOutPreflight 在 FanOutAllOrNothing 发送前检查客户端能否发布
+func (c *Client) fanOutPreflight() error {
+	if c.closing.Load() {
+		return ErrClientClosing
+	}
+	if !c.IsConnected() && (c.config.ConfirmPublish || !c.outboxEnabled()) {
+		return ErrNotConnected
+	}
+	if c.CircuitState() == CircuitOpen {
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// publishFanOut 以有限并发发布各主题的信封，将结果写入 result
+func (c *Client) publishFanOut(envelopes map[string]types.MessageEnvelope, concurrency int, result *MultiError) {
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	var mutex sync.Mutex
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for topic, envelope := range envelopes {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			err := c.publishEnvelope(context.Background(), topic, envelope)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				result.Errors[topic] = err
+				return
+			}
+			result.Published = append(result.Published, topic)
+		}()
+	}
+	wg.Wait()
+	sort.Strings(result.Published)
+}
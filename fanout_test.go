@@ -0,0 +1,140 @@
+package messagebus_test
+
+import (
+	"errors"
+	"testing"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// topicFailingPublisher 发布到 failTopic 时失败
+type topicFailingPublisher struct {
+	messaging.MessageClient
+	failTopic string
+}
+
+func (c *topicFailingPublisher) Publish(message types.MessageEnvelope, topic string) error {
+	if topic == c.failTopic {
+		return errors.New("broker 拒绝发布")
+	}
+	return c.MessageClient.Publish(message, topic)
+}
+
+// reading 的 Value 字段保存切片时不可作为映射键
+type reading struct {
+	Name  string
+	Value interface{}
+}
+
+func TestPublishFanOutSharesGroupID(t *testing.T) {
+	client, err := messagebustest.NewMockClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	// 接口字段中的切片使数据不可比较，不能因此 panic
+	data := reading{Name: "temp", Value: []int{1, 2}}
+	if err := client.PublishFanOut(data, "test/fanout/a", "test/fanout/b", "test/fanout/c"); err != nil {
+		t.Fatal(err)
+	}
+	published := client.Published()
+	if len(published) != 3 {
+		t.Fatalf("发布了 %d 条消息，期望 3", len(published))
+	}
+	groupID := published[0].Envelope.QueryParams[messagebus.HeaderFanOutID]
+	for _, msg := range published {
+		if groupID == "" || msg.Envelope.QueryParams[messagebus.HeaderFanOutID] != groupID {
+			t.Errorf("%s 的分组 ID = %q，期望同组相同", msg.Topic, msg.Envelope.QueryParams[messagebus.HeaderFanOutID])
+		}
+		if payload, _ := messagebus.EnvelopePayloadBytes(msg.Envelope); string(payload) != `{"Name":"temp","Value":[1,2]}` {
+			t.Errorf("%s 的 Payload = %s", msg.Topic, payload)
+		}
+	}
+	if published[0].Envelope.CorrelationID == published[1].Envelope.CorrelationID {
+		t.Error("同组信封应使用各自的 CorrelationID")
+	}
+}
+
+func TestFanOutAllOrNothingSkipsRemainingTopics(t *testing.T) {
+	t.Run("validation", func(t *testing.T) {
+		client, err := messagebustest.NewMockClient()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+		err = client.PublishMulti(map[string]interface{}{
+			"test/a":   "x",
+			"test/b/#": "y",
+			"test/c":   "z",
+		}, messagebus.FanOutOptions{Mode: messagebus.FanOutAllOrNothing})
+		var multi *messagebus.MultiError
+		if !errors.As(err, &multi) {
+			t.Fatalf("期望 *MultiError，实际 %v", err)
+		}
+		if len(multi.Errors) != 1 || multi.Errors["test/b/#"] == nil {
+			t.Errorf("Errors = %v，期望只有通配符主题失败", multi.Errors)
+		}
+		if len(multi.Published) != 0 || len(multi.Skipped) != 2 {
+			t.Errorf("Published = %v, Skipped = %v，期望全部未发送", multi.Published, multi.Skipped)
+		}
+		if n := len(client.Published()); n != 0 {
+			t.Errorf("校验失败时不应发送任何消息，实际发送 %d 条", n)
+		}
+	})
+
+	t.Run("send failure", func(t *testing.T) {
+		broker := messagebustest.NewBroker()
+		factory := broker.MessageClientFactory("fanout")
+		client, err := messagebus.NewClientWithOptions(
+			messagebus.WithConfig(testConfig()),
+			messagebus.WithLogger(logger.NewMockClient()),
+			messagebus.WithMessageClientFactory(func(busConfig types.MessageBusConfig) (messaging.MessageClient, error) {
+				inner, err := factory(busConfig)
+				if err != nil {
+					return nil, err
+				}
+				return &topicFailingPublisher{MessageClient: inner, failTopic: "test/b"}, nil
+			}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+		err = client.PublishMulti(map[string]interface{}{"test/a": "x", "test/b": "y", "test/c": "z"},
+			messagebus.FanOutOptions{Mode: messagebus.FanOutAllOrNothing})
+		var multi *messagebus.MultiError
+		if !errors.As(err, &multi) {
+			t.Fatalf("期望 *MultiError，实际 %v", err)
+		}
+		if len(multi.Published) != 1 || multi.Published[0] != "test/a" || multi.Errors["test/b"] == nil ||
+			len(multi.Skipped) != 1 || multi.Skipped[0] != "test/c" {
+			t.Errorf("结果 = %+v，期望 test/a 已发送、test/b 失败、test/c 未发送", multi)
+		}
+		if published := broker.Published(); len(published) != 1 || published[0].Topic != "test/a" {
+			t.Errorf("Broker 收到 %v，期望只有 test/a", published)
+		}
+	})
+
+	t.Run("disconnected", func(t *testing.T) {
+		client, err := messagebustest.NewMockClient()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+		if err := client.Disconnect(); err != nil {
+			t.Fatal(err)
+		}
+		err = client.PublishMulti(map[string]interface{}{"test/a": "x", "test/b": "y"},
+			messagebus.FanOutOptions{Mode: messagebus.FanOutAllOrNothing})
+		if !errors.Is(err, messagebus.ErrNotConnected) {
+			t.Fatalf("未连接时应返回 ErrNotConnected，实际 %v", err)
+		}
+	})
+}
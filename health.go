@@ -0,0 +1,108 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// UnhealthyHandler 在连续健康检查失败次数达到阈值时被调用
+type UnhealthyHandler func(consecutiveFailures int, err error)
+
+// healthState 记录连续健康检查失败情况
+type healthState struct {
+	mutex     sync.Mutex
+	failures  int              // 连续失败次数
+	escalated bool             // 本轮失败是否已触发升级
+	onFailure UnhealthyHandler // 升级回调
+}
+
+// HealthCheck 检查客户端健康状态，并按 HealthFailureThreshold 进行失败升级
+// 设置 HealthProbeTimeout 时会经 Broker 往返探测，见 DeepHealthCheck
+func (c *Client) HealthCheck() error {
+	if c.config.HealthProbeTimeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), c.config.HealthProbeTimeout)
+		defer cancel()
+		_, err := c.DeepHealthCheck(ctx)
+		return err
+	}
+	err := c.checkHealth()
+	c.recordHealth(err)
+	return err
+}
+
+// DeepHealthCheck 在本地状态正常时向本客户端私有的临时主题发布探测消息，等待其经 Broker 返回，
+// 返回往返耗时；ctx 结束前未收到探测消息即判定为失败，结果同样计入 HealthFailureThreshold
+//
+// MQTT 的 PINGREQ 由底层客户端按 KeepAlive 自动发送且不对外暴露，往返探测还能发现 Broker 接受连接但不再转发消息的情况
+func (c *Client) DeepHealthCheck(ctx context.Context) (time.Duration, error) {
+	err := c.checkHealth()
+	var rtt time.Duration
+	if err == nil {
+		rtt, err = c.pingBroker(ctx)
+	}
+	c.recordHealth(err)
+	if err != nil {
+		return 0, err
+	}
+	c.lastRoundTrip.Store(int64(rtt))
+	return rtt, nil
+}
+
+// LastRoundTrip 返回最近一次成功的 DeepHealthCheck 测得的往返耗时，尚未探测时返回 0
+func (c *Client) LastRoundTrip() time.Duration {
+	return time.Duration(c.lastRoundTrip.Load())
+}
+
+// OnUnhealthy 注册连续健康检查失败达到阈值时的回调
+func (c *Client) OnUnhealthy(handler UnhealthyHandler) {
+	c.health.mutex.Lock()
+	defer c.health.mutex.Unlock()
+	c.health.onFailure = handler
+}
+
+// checkHealth 检查本地连接状态
+func (c *Client) checkHealth() error {
+	if !c.IsConnected() {
+		return ErrNotConnected
+	}
+	if c.IsReconnecting() {
+		return fmt.Errorf("MessageBus正在重连")
+	}
+	return nil
+}
+
+// recordHealth 记录一次健康检查结果，成功时清零计数，连续失败达到阈值时触发一次升级
+func (c *Client) recordHealth(err error) {
+	c.health.mutex.Lock()
+	if err == nil {
+		c.health.failures = 0
+		c.health.escalated = false
+		c.health.mutex.Unlock()
+		return
+	}
+	c.health.failures++
+	threshold := c.config.HealthFailureThreshold
+	if threshold <= 0 || c.health.failures < threshold || c.health.escalated {
+		c.health.mutex.Unlock()
+		return
+	}
+	c.health.escalated = true
+	failures := c.health.failures
+	handler := c.health.onFailure
+	c.health.mutex.Unlock()
+
+	c.log(LogConnection).Warn("健康检查连续失败，判定为不健康", c.logFields("failures", failures, "error", err)...)
+	c.emitEvent(LifecycleEvent{Type: EventUnhealthy, Attempt: failures, Err: err})
+	if handler != nil {
+		handler(failures, err)
+	}
+	if c.config.ReconnectOnUnhealthy {
+		c.lifecycle.spawn(stageReconnect, func(<-chan struct{}) {
+			if err := c.Reconnect(); err != nil {
+				c.log(LogConnection).Error("健康检查触发的重连失败", c.logFields("error", err)...)
+			}
+		})
+	}
+}
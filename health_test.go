@@ -0,0 +1,71 @@
+package messagebus_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+)
+
+func TestHealthCheckEscalation(t *testing.T) {
+	config := testConfig()
+	config.HealthFailureThreshold = 3
+	client := newMockClient(t, messagebus.WithConfig(config))
+	var mutex sync.Mutex
+	var escalations []int
+	client.OnUnhealthy(func(failures int, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if !errors.Is(err, messagebus.ErrNotConnected) {
+			t.Errorf("升级回调收到的错误为 %v", err)
+		}
+		escalations = append(escalations, failures)
+	})
+
+	// 断开连接时健康检查失败，重新连接后通过
+	steps := []struct {
+		healthy bool
+		want    []int // 该步之后累计的升级记录
+	}{
+		{false, nil},
+		{false, nil},
+		{true, nil}, // 未达到阈值前恢复，计数清零
+		{false, nil},
+		{false, nil},
+		{false, []int{3}}, // 连续第 3 次失败，触发升级
+		{false, []int{3}}, // 同一轮失败只升级一次
+		{true, []int{3}},
+		{false, []int{3}},
+		{false, []int{3}},
+		{false, []int{3, 3}}, // 恢复后再次连续失败，重新升级
+	}
+	for i, step := range steps {
+		if step.healthy != client.IsConnected() {
+			var err error
+			if step.healthy {
+				err = client.Connect()
+			} else {
+				err = client.Disconnect()
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := client.HealthCheck()
+		if step.healthy != (err == nil) {
+			t.Fatalf("第 %d 步: HealthCheck() = %v，期望健康: %v", i, err, step.healthy)
+		}
+		mutex.Lock()
+		got := append([]int(nil), escalations...)
+		mutex.Unlock()
+		if len(got) != len(step.want) {
+			t.Fatalf("第 %d 步: 升级记录 %v，期望 %v", i, got, step.want)
+		}
+		for j := range got {
+			if got[j] != step.want[j] {
+				t.Fatalf("第 %d 步: 升级记录 %v，期望 %v", i, got, step.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package messagebus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+)
+
+// defaultProbeTimeout 是健康探测等待自身消息返回的默认时长
+const defaultProbeTimeout = 2 * time.Second
+
+// HealthStatus 表示 HealthHandler 返回的 JSON 内容
+type HealthStatus struct {
+	Status        string       `json:"status"` // ok 或 unavailable
+	ClientID      string       `json:"clientId"`
+	Connected     bool         `json:"connected"`
+	Reconnecting  bool         `json:"reconnecting"`
+	Subscriptions int          `json:"subscriptions"`
+	RoundTripMs   float64      `json:"roundTripMs,omitempty"` // 探测消息经 Broker 返回的耗时，仅 /readyz 探测成功时返回
+	LastError     *HealthError `json:"lastError,omitempty"`
+	Error         string       `json:"error,omitempty"` // 判定为不可用的原因
+}
+
+// HealthError 表示最近一次报告到错误通道的错误
+type HealthError struct {
+	Op      string    `json:"op"`
+	Topic   string    `json:"topic,omitempty"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// HealthHandler 返回提供 /healthz 与 /readyz 的 http.Handler，挂载到其他路由前缀时使用 http.StripPrefix
+//
+// /healthz 只检查本地状态，连续健康检查失败达到 HealthFailureThreshold 时返回 503，适合作为存活探针；
+// /readyz 在未连接、正在重连或探测消息未能在 HealthProbeTimeout（默认 2 秒）内经 Broker 返回时返回 503，适合作为就绪探针。
+func (c *Client) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		status := c.healthStatus()
+		var err error
+		if c.unhealthy() {
+			err = errors.New("健康检查连续失败")
+		}
+		writeHealth(w, status, err)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		status := c.healthStatus()
+		err := c.checkHealth()
+		if err == nil {
+			ctx, cancel := context.WithTimeout(r.Context(), c.probeTimeout())
+			var rtt time.Duration
+			rtt, err = c.pingBroker(ctx)
+			cancel()
+			if err == nil {
+				c.lastRoundTrip.Store(int64(rtt))
+				status.RoundTripMs = float64(rtt.Microseconds()) / 1000
+			}
+		}
+		writeHealth(w, status, err)
+	})
+	return mux
+}
+
+// probeTimeout 返回 /readyz 等待探测消息的时长
+func (c *Client) probeTimeout() time.Duration {
+	if c.config.HealthProbeTimeout > 0 {
+		return c.config.HealthProbeTimeout
+	}
+	return defaultProbeTimeout
+}
+
+// writeHealth 按检查结果写入状态码和 JSON
+func writeHealth(w http.ResponseWriter, status HealthStatus, err error) {
+	code := http.StatusOK
+	status.Status = "ok"
+	if err != nil {
+		code = http.StatusServiceUnavailable
+		status.Status = "unavailable"
+		status.Error = err.Error()
+	}
+	w.Header().Set("Content-Type", common.ContentTypeJSON)
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(status)
+}
+
+// healthStatus 汇总本地连接状态和最近一次错误
+func (c *Client) healthStatus() HealthStatus {
+	c.mutex.RLock()
+	status := HealthStatus{
+		ClientID:      c.config.ClientID,
+		Connected:     c.isConnected,
+		Reconnecting:  c.reconnect.active,
+		Subscriptions: len(c.subscriptions),
+	}
+	c.mutex.RUnlock()
+	if last := c.lastError.Load(); last != nil {
+		status.LastError = &HealthError{Op: last.Op, Topic: last.Topic, Message: last.Err.Error(), Time: last.Time}
+	}
+	return status
+}
+
+// unhealthy 判断连续健康检查失败是否已达到阈值
+func (c *Client) unhealthy() bool {
+	c.health.mutex.Lock()
+	defer c.health.mutex.Unlock()
+	return c.health.escalated
+}
+
+// pingBroker 向本客户端私有的临时主题发布一条消息并等待其经 Broker 返回，返回往返耗时
+func (c *Client) pingBroker(ctx context.Context) (time.Duration, error) {
+	id := uuid.NewString()
+	topic := common.BuildTopic(c.baseTopic(), "messagebus", "ping", c.config.ClientID, id)
+	messages := make(chan types.MessageEnvelope, 1)
+	client := c.messageClient()
+	if err := client.Subscribe([]types.TopicChannel{{Topic: topic, Messages: messages}}, make(chan error, 1)); err != nil {
+		return 0, subscribeError("ping", []string{topic}, err)
+	}
+	defer func() { _ = client.Unsubscribe(topic) }()
+
+	start := time.Now()
+	ping := types.MessageEnvelope{CorrelationID: id, Payload: []byte("ping"), ContentType: common.ContentTypeText}
+	if err := client.Publish(ping, topic); err != nil {
+		return 0, publishError(topic, err)
+	}
+	for {
+		select {
+		case msg := <-messages:
+			if msg.CorrelationID == id {
+				return time.Since(start), nil
+			}
+		case <-ctx.Done():
+			return 0, fmt.Errorf("等待探测消息返回超时: %w", ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package messagebus
+
+import (
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// publishInterceptors 返回 Config.PublishInterceptors 以及按配置启用的内置拦截器，内置拦截器位于最内层
+func (c *Client) publishInterceptors() []PublishInterceptor {
+	interceptors := c.config.PublishInterceptors
+	interceptors = interceptors[:len(interceptors):len(interceptors)]
+	if c.config.Schemas != nil {
+		// Schema 校验针对原始 Payload，须在压缩和加密之前
+		interceptors = append(interceptors, schemaInterceptor(c.config.Schemas))
+	}
+	if c.config.Compression.Encoding != "" {
+		interceptors = append(interceptors, compressionInterceptor(c.config.Compression))
+	}
+	if c.config.Encryption.KeyProvider != nil {
+		// 先压缩后加密，密文几乎不可压缩
+		interceptors = append(interceptors, encryptionInterceptor(c.config.Encryption.KeyProvider))
+	}
+	if c.config.Chunking.MaxPayloadSize > 0 {
+		// 分块针对最终发出的字节，须在压缩和加密之后
+		interceptors = append(interceptors, chunkingInterceptor(c.config.Chunking))
+	}
+	return interceptors
+}
+
+// decodeInbound 还原收到的信封：按 QueryParams 中的标记依次解密、解压 Payload
+// 处理函数、请求响应和流式响应收到的都是还原后的信封
+func (c *Client) decodeInbound(envelope types.MessageEnvelope) (types.MessageEnvelope, error) {
+	envelope, err := decryptPayload(c.config.Encryption, envelope)
+	if err != nil {
+		return envelope, err
+	}
+	return decompressPayload(envelope)
+}
@@ -0,0 +1,68 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// PublishHandler 发布一条信封，是发布拦截器链中的一环
+type PublishHandler func(ctx context.Context, topic string, envelope types.MessageEnvelope) error
+
+// PublishInterceptor 包装 PublishHandler，用于在信封发出前统一做压缩、加密、校验或补充头部等变换
+// 拦截器可以修改信封后调用 next，也可以返回错误拒绝发布
+type PublishInterceptor func(next PublishHandler) PublishHandler
+
+// ChainPublish 按顺序使用拦截器包装 handler，第一个拦截器位于最外层、最先处理信封
+func ChainPublish(handler PublishHandler, interceptors ...PublishInterceptor) PublishHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		if interceptors[i] != nil {
+			handler = interceptors[i](handler)
+		}
+	}
+	return handler
+}
+
+// interceptPublish 让信封依次经过 Config.PublishInterceptors 和内置拦截器（如压缩）后交给 send 发出
+func (c *Client) interceptPublish(ctx context.Context, topic string, envelope types.MessageEnvelope, send PublishHandler) error {
+	interceptors := c.publishInterceptors()
+	if len(interceptors) == 0 {
+		return send(ctx, topic, envelope)
+	}
+	return ChainPublish(send, interceptors...)(ctx, topic, envelope)
+}
+
+// sendDirect 使用主连接直接发出信封，用于请求等不经过存储转发的发布
+func (c *Client) sendDirect(_ context.Context, topic string, envelope types.MessageEnvelope) error {
+	return c.messageClient().Publish(envelope, topic)
+}
+
+// HeaderInterceptor 为每条发出的信封补充固定的 QueryParams，信封中已有的同名参数不会被覆盖
+func HeaderInterceptor(headers map[string]string) PublishInterceptor {
+	return func(next PublishHandler) PublishHandler {
+		return func(ctx context.Context, topic string, envelope types.MessageEnvelope) error {
+			params := make(map[string]string, len(envelope.QueryParams)+len(headers))
+			for k, v := range headers {
+				params[k] = v
+			}
+			for k, v := range envelope.QueryParams {
+				params[k] = v
+			}
+			envelope.QueryParams = params
+			return next(ctx, topic, envelope)
+		}
+	}
+}
+
+// PublishValidationInterceptor 在发出前校验信封，校验失败时拒绝发布并返回校验错误
+func PublishValidationInterceptor(validate func(topic string, envelope types.MessageEnvelope) error) PublishInterceptor {
+	return func(next PublishHandler) PublishHandler {
+		return func(ctx context.Context, topic string, envelope types.MessageEnvelope) error {
+			if err := validate(topic, envelope); err != nil {
+				return fmt.Errorf("发布校验失败: %w", err)
+			}
+			return next(ctx, topic, envelope)
+		}
+	}
+}
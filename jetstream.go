@@ -0,0 +1,102 @@
+package messagebus
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+)
+
+// 支持的消息总线类型，NATS 类型需要使用 include_nats_messaging 构建标签编译，
+// kafka、amqp 类型需要使用同名子包提供的 MessageClientFactory
+const (
+	TypeMQTT          = messaging.MQTT
+	TypeNatsCore      = messaging.NatsCore
+	TypeNatsJetStream = messaging.NatsJetStream
+	TypeKafka         = "kafka"
+	TypeAMQP          = "amqp"
+)
+
+// JetStream 消费者的投递策略
+const (
+	DeliverAll            = "all"
+	DeliverLast           = "last"
+	DeliverLastPerSubject = "lastpersubject"
+	DeliverNew            = "new"
+)
+
+// AckExplicit 是 JetStream 消费者唯一支持的确认策略
+const AckExplicit = "explicit"
+
+// JetStreamConfig 表示 NATS JetStream 专用参数，仅在 Type 为 nats-jetstream 时生效
+// 消费者固定使用显式确认（AckExplicit），消息进入接收通道时即被确认
+type JetStreamConfig struct {
+	// Subject 流覆盖的主题（EdgeX 格式，如 edgex/#），未设置 Durable 时订阅会绑定到由该主题派生的流
+	Subject string
+	// Durable 持久化消费者名称，设置后客户端重启可从上次确认的位置继续消费；自动创建流时也用作流名称
+	Durable string
+	// AutoProvision 连接时若流不存在则自动创建
+	AutoProvision bool
+	// Deliver 新消费者的投递起点 (all, last, lastpersubject, new)，默认 new
+	Deliver string
+	// QueueGroup 队列组名称，同组消费者分摊消息
+	QueueGroup string
+	// PublishRetryAttempts 发布未收到 JetStream 确认时的重试次数
+	PublishRetryAttempts int
+	// ExactlyOnce 启用发布去重，以 CorrelationID 作为消息 ID
+	ExactlyOnce bool
+	// AckPolicy 消费者的确认策略，仅支持 explicit；go-mod-messaging 固定以显式确认订阅，设置其他值会在校验时报错
+	AckPolicy string
+	// MaxDeliver 服务端最大投递次数，暂不支持：go-mod-messaging 在消息进入接收通道时即确认，服务端不会重投，
+	// 设置后会在校验时报错，需要限制重投次数时使用 SubscribeOptions.Redelivery.MaxAttempts
+	MaxDeliver int
+}
+
+// isZero 判断是否未设置任何 JetStream 参数
+func (j JetStreamConfig) isZero() bool {
+	return j == JetStreamConfig{}
+}
+
+// jetStreamOptions 校验 JetStream 参数并转换为 go-mod-messaging 的 Optional 参数
+func jetStreamOptions(config Config) (map[string]string, error) {
+	js := config.JetStream
+	if js.isZero() {
+		return nil, nil
+	}
+	if !strings.EqualFold(config.Type, TypeNatsJetStream) {
+		return nil, fmt.Errorf("JetStream 参数仅适用于 %s 类型，当前类型为 %s", TypeNatsJetStream, config.Type)
+	}
+	switch js.Deliver {
+	case "", DeliverAll, DeliverLast, DeliverLastPerSubject, DeliverNew:
+	default:
+		return nil, fmt.Errorf("不支持的 JetStream 投递策略: %s", js.Deliver)
+	}
+	if js.AutoProvision && js.Subject == "" {
+		return nil, fmt.Errorf("自动创建流时必须设置 Subject")
+	}
+	if js.AckPolicy != "" && !strings.EqualFold(js.AckPolicy, AckExplicit) {
+		return nil, fmt.Errorf("不支持的 JetStream 确认策略: %s，底层客户端固定使用 %s", js.AckPolicy, AckExplicit)
+	}
+	if js.MaxDeliver != 0 {
+		return nil, fmt.Errorf("不支持 JetStream MaxDeliver：底层客户端收到消息即确认，服务端不会重投，请改用 SubscribeOptions.Redelivery.MaxAttempts")
+	}
+	if js.PublishRetryAttempts < 0 {
+		return nil, fmt.Errorf("PublishRetryAttempts 不能为负数")
+	}
+	opts := make(map[string]string)
+	setOptional(opts, "Subject", js.Subject)
+	setOptional(opts, "Durable", js.Durable)
+	setOptional(opts, "Deliver", js.Deliver)
+	setOptional(opts, "QueueGroup", js.QueueGroup)
+	if js.AutoProvision {
+		opts["AutoProvision"] = "true"
+	}
+	if js.PublishRetryAttempts > 0 {
+		opts["DefaultPubRetryAttempts"] = strconv.Itoa(js.PublishRetryAttempts)
+	}
+	if js.ExactlyOnce {
+		opts["ExactlyOnce"] = "true"
+	}
+	return opts, nil
+}
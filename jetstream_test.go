@@ -0,0 +1,46 @@
+package messagebus_test
+
+import (
+	"strings"
+	"testing"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+)
+
+func TestJetStreamConfigValidation(t *testing.T) {
+	base := messagebus.Config{Host: "localhost", Port: 4222, Protocol: "tcp", Type: messagebus.TypeNatsJetStream}
+	cases := []struct {
+		name      string
+		jetStream messagebus.JetStreamConfig
+		problem   string
+	}{
+		{"durable", messagebus.JetStreamConfig{Durable: "app", Deliver: messagebus.DeliverAll}, ""},
+		{"explicit ack", messagebus.JetStreamConfig{Durable: "app", AckPolicy: messagebus.AckExplicit}, ""},
+		{"ack none", messagebus.JetStreamConfig{Durable: "app", AckPolicy: "none"}, "确认策略"},
+		{"max deliver", messagebus.JetStreamConfig{Durable: "app", MaxDeliver: 3}, "MaxDeliver"},
+		{"unknown deliver", messagebus.JetStreamConfig{Deliver: "first"}, "投递策略"},
+		{"auto provision without subject", messagebus.JetStreamConfig{AutoProvision: true}, "Subject"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := base
+			config.JetStream = tc.jetStream
+			err := config.Validate()
+			if tc.problem == "" {
+				if err != nil {
+					t.Errorf("期望通过校验，实际 %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.problem) {
+				t.Errorf("错误 %v 应包含 %q", err, tc.problem)
+			}
+		})
+	}
+
+	config := messagebus.Config{Host: "localhost", Port: 1883, Protocol: "tcp", Type: messagebus.TypeMQTT}
+	config.JetStream = messagebus.JetStreamConfig{Durable: "app"}
+	if err := config.Validate(); err == nil {
+		t.Error("非 nats-jetstream 类型设置 JetStream 参数应校验失败")
+	}
+}
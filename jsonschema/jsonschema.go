@@ -0,0 +1,68 @@
+// Package jsonschema 提供基于 JSON Schema 的 messagebus.SchemaValidator
+//
+// 该包单独存放，只有导入它的程序才会依赖 github.com/santhosh-tekuri/jsonschema。
+// 支持 draft-04 至 2020-12，未声明 $schema 时按 2020-12 处理。
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// schemaURL 是编译时 Schema 文档使用的资源地址，避免被解析为当前目录下的文件
+const schemaURL = "mem:///schema.json"
+
+// Validator 使用编译好的 JSON Schema 校验 Payload
+type Validator struct {
+	schema *jsonschema.Schema
+}
+
+var _ messagebus.SchemaValidator = (*Validator)(nil)
+
+// Compile 编译 JSON Schema 文档
+func Compile(schema []byte) (*Validator, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	if err := compiler.AddResource(schemaURL, bytes.NewReader(schema)); err != nil {
+		return nil, fmt.Errorf("加载JSON Schema失败: %w", err)
+	}
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("编译JSON Schema失败: %w", err)
+	}
+	return &Validator{schema: compiled}, nil
+}
+
+// CompileFile 编译文件中的 JSON Schema 文档
+func CompileFile(path string) (*Validator, error) {
+	schema, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取JSON Schema文件失败: %w", err)
+	}
+	return Compile(schema)
+}
+
+// MustCompile 与 Compile 相同，编译失败时 panic，适用于程序内置的 Schema
+func MustCompile(schema []byte) *Validator {
+	validator, err := Compile(schema)
+	if err != nil {
+		panic(err)
+	}
+	return validator
+}
+
+// Validate 校验 JSON Payload
+func (v *Validator) Validate(payload []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return fmt.Errorf("Payload不是合法的JSON: %w", err)
+	}
+	return v.schema.Validate(document)
+}
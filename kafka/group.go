@@ -0,0 +1,520 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// uncommittedOffset 是消费者组中没有已提交位置的分区的重置位置，AdjustFetchOffsetsFn 据此将其改为从订阅时刻开始
+var uncommittedOffset = kgo.NewOffset().AtEnd()
+
+// topicPartition 表示 Kafka 主题的一个分区
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// subPartition 表示一个订阅在某个分区上的消费进度，对应一个消费位置键
+type subPartition struct {
+	topic string
+	tp    topicPartition
+}
+
+// groupSubscription 表示消费者组内的一个订阅
+type groupSubscription struct {
+	topic        types.TopicChannel
+	errors       chan error
+	binary       bool
+	pattern      *regexp.Regexp // 匹配订阅对应的 Kafka 主题
+	subscribedAt time.Time
+}
+
+// pendingRecord 表示已投递、尚有订阅未处理完的记录
+type pendingRecord struct {
+	offset  int64
+	epoch   int32
+	waiting map[string]bool // 尚未处理完该记录的订阅主题
+}
+
+// partitionWindow 按位置顺序记录一个分区已投递、尚未标记提交的记录
+type partitionWindow struct {
+	next    int64 // 下一条待投递记录的位置，重建消费者后不再重复投递之前的记录
+	pending []*pendingRecord
+}
+
+// consumerGroup 是消费者组内所有订阅共享的 Kafka 消费者：订阅集合变化时以全部订阅的主题重建消费者，
+// 记录在所有匹配的订阅都处理完（CommitOffset）后才标记提交，不会提交仍在通道或处理函数中的记录
+type consumerGroup struct {
+	owner *MessageClient
+	name  string
+
+	restarting sync.Mutex // 串行化消费者的重建
+	mutex      sync.Mutex
+	subs       map[string]*groupSubscription
+	current    *consumer
+	assigned   map[topicPartition]bool
+	windows    map[topicPartition]*partitionWindow
+	keys       map[string]subPartition
+	skip       map[subPartition]int64 // 订阅在分区上已处理到的位置，不再投递该位置及之前的记录
+	after      map[subPartition]int64 // 分区没有已提交位置时，只投递订阅之后（毫秒时间戳）发布的记录
+}
+
+// newConsumerGroup 创建消费者组，第一次订阅时才加入
+func newConsumerGroup(owner *MessageClient, name string) *consumerGroup {
+	g := &consumerGroup{owner: owner, name: name, subs: make(map[string]*groupSubscription)}
+	g.reset()
+	return g
+}
+
+// reset 清除所有分区的投递进度，调用方须持有 mutex 或确保没有并发访问
+func (g *consumerGroup) reset() {
+	g.assigned = make(map[topicPartition]bool)
+	g.windows = make(map[topicPartition]*partitionWindow)
+	g.keys = make(map[string]subPartition)
+	g.skip = make(map[subPartition]int64)
+	g.after = make(map[subPartition]int64)
+}
+
+// subscribe 将主题加入消费者组并重建消费者，失败时恢复原有订阅
+func (g *consumerGroup) subscribe(topics []types.TopicChannel, messageErrors chan error, binary bool) error {
+	added := make([]*groupSubscription, 0, len(topics))
+	for _, topic := range topics {
+		literal, pattern := kafkaSubscription(topic.Topic, g.owner.config.TopicLevels)
+		if pattern == "" {
+			pattern = "^" + regexp.QuoteMeta(literal) + "$"
+		}
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("订阅主题 %s 无效: %w", topic.Topic, err)
+		}
+		added = append(added, &groupSubscription{topic: topic, errors: messageErrors, binary: binary, pattern: compiled, subscribedAt: time.Now()})
+	}
+
+	g.restarting.Lock()
+	defer g.restarting.Unlock()
+	g.mutex.Lock()
+	previous := make(map[string]*groupSubscription, len(g.subs))
+	for topic, sub := range g.subs {
+		previous[topic] = sub
+	}
+	for _, sub := range added {
+		g.subs[sub.topic.Topic] = sub
+	}
+	g.mutex.Unlock()
+	if err := g.restart(); err != nil {
+		g.mutex.Lock()
+		g.subs = previous
+		g.mutex.Unlock()
+		_ = g.restart()
+		return err
+	}
+	return nil
+}
+
+// unsubscribe 将主题移出消费者组，订阅集合有变化时重建消费者
+func (g *consumerGroup) unsubscribe(topics ...string) {
+	g.restarting.Lock()
+	defer g.restarting.Unlock()
+	g.mutex.Lock()
+	removed := false
+	for _, topic := range topics {
+		if _, ok := g.subs[topic]; ok {
+			delete(g.subs, topic)
+			g.forgetSubscription(topic)
+			removed = true
+		}
+	}
+	g.mutex.Unlock()
+	if removed {
+		// 重建前的消费者离开消费者组时提交 forgetSubscription 标记的位置
+		_ = g.restart()
+	}
+}
+
+// close 停止消费者并清除所有订阅，消费者离开消费者组前提交已标记的位置
+func (g *consumerGroup) close() {
+	g.restarting.Lock()
+	defer g.restarting.Unlock()
+	g.mutex.Lock()
+	g.subs = make(map[string]*groupSubscription)
+	g.mutex.Unlock()
+	_ = g.restart()
+}
+
+// forgetSubscription 不再等待已退订的订阅处理记录，并标记因此处理完的位置，调用方须持有 mutex
+func (g *consumerGroup) forgetSubscription(topic string) {
+	for key, target := range g.keys {
+		if target.topic == topic {
+			delete(g.keys, key)
+		}
+	}
+	for target := range g.skip {
+		if target.topic == topic {
+			delete(g.skip, target)
+		}
+	}
+	for target := range g.after {
+		if target.topic == topic {
+			delete(g.after, target)
+		}
+	}
+	for tp, window := range g.windows {
+		for _, entry := range window.pending {
+			delete(entry.waiting, topic)
+		}
+		if mark := g.advance(tp, window); mark != nil && g.current != nil {
+			g.current.client.MarkCommitOffsets(mark)
+		}
+	}
+}
+
+// restart 停止当前消费者，并以全部订阅的主题创建新的消费者，调用方须持有 restarting
+func (g *consumerGroup) restart() error {
+	g.mutex.Lock()
+	old := g.current
+	g.current = nil
+	g.mutex.Unlock()
+	if old != nil {
+		old.stop()
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.assigned = make(map[topicPartition]bool)
+	if len(g.subs) == 0 {
+		// 没有订阅时不保留投递进度，重新订阅后从已提交的位置继续
+		g.reset()
+		return nil
+	}
+	patterns := make([]string, 0, len(g.subs))
+	for _, sub := range g.subs {
+		patterns = append(patterns, sub.pattern.String())
+	}
+	cons := &consumer{done: make(chan struct{})}
+	opts := append([]kgo.Opt(nil), g.owner.baseOpts...)
+	opts = append(opts,
+		kgo.ConsumerGroup(g.name),
+		kgo.ConsumeRegex(),
+		kgo.ConsumeTopics(patterns...),
+		kgo.ConsumeResetOffset(uncommittedOffset),
+		// 只提交 CommitOffset 确认处理完的记录，拉取后尚未处理的记录不会被自动提交
+		kgo.AutoCommitMarks(),
+		kgo.AdjustFetchOffsetsFn(g.adjustOffsets),
+		kgo.OnPartitionsAssigned(func(_ context.Context, _ *kgo.Client, assigned map[string][]int32) {
+			g.assign(cons, assigned)
+		}),
+		kgo.OnPartitionsRevoked(func(ctx context.Context, client *kgo.Client, revoked map[string][]int32) {
+			_ = client.CommitMarkedOffsets(ctx)
+			g.revoke(cons, revoked)
+		}),
+		kgo.OnPartitionsLost(func(_ context.Context, _ *kgo.Client, lost map[string][]int32) {
+			g.revoke(cons, lost)
+		}),
+	)
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return fmt.Errorf("创建Kafka消费者失败: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cons.client = client
+	cons.cancel = cancel
+	g.current = cons
+	go func() {
+		defer close(cons.done)
+		g.consume(ctx, cons)
+	}()
+	return nil
+}
+
+// adjustOffsets 决定分配到的分区从哪里开始消费：没有已提交位置的分区从最早的订阅时刻开始，
+// 配置 OffsetStore 时从各订阅记录的位置中最小的一个之后开始，并记录各订阅已处理到的位置以免重复投递
+func (g *consumerGroup) adjustOffsets(_ context.Context, assigned map[string]map[int32]kgo.Offset) (map[string]map[int32]kgo.Offset, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	for kafkaTopic, partitions := range assigned {
+		var matching []*groupSubscription
+		for _, sub := range g.subs {
+			if sub.pattern.MatchString(kafkaTopic) {
+				matching = append(matching, sub)
+			}
+		}
+		for partition, offset := range partitions {
+			tp := topicPartition{topic: kafkaTopic, partition: partition}
+			uncommitted := offset == uncommittedOffset
+			committed := offset.EpochOffset().Offset
+			start := int64(-1)
+			var earliest time.Time
+			for _, sub := range matching {
+				target := subPartition{topic: sub.topic.Topic, tp: tp}
+				delete(g.skip, target)
+				delete(g.after, target)
+				stored, ok := int64(0), false
+				if store := g.owner.offsets; store != nil {
+					var err error
+					stored, ok, err = store.Load(offsetKey(g.name, sub.topic.Topic, kafkaTopic, partition))
+					if err != nil {
+						return nil, fmt.Errorf("读取Kafka主题 %s 分区 %d 的消费位置失败: %w", kafkaTopic, partition, err)
+					}
+				}
+				switch {
+				case ok:
+					g.skip[target] = stored
+					if start < 0 || stored+1 < start {
+						start = stored + 1
+					}
+				case uncommitted:
+					g.after[target] = sub.subscribedAt.UnixMilli()
+					if earliest.IsZero() || sub.subscribedAt.Before(earliest) {
+						earliest = sub.subscribedAt
+					}
+				default:
+					g.skip[target] = committed - 1
+				}
+			}
+			switch {
+			case start >= 0 && (uncommitted || start < committed):
+				partitions[partition] = kgo.NewOffset().At(start).WithEpoch(-1)
+			case uncommitted && !earliest.IsZero():
+				partitions[partition] = kgo.NewOffset().AfterMilli(earliest.UnixMilli())
+			case uncommitted:
+				partitions[partition] = kgo.NewOffset().AfterMilli(time.Now().UnixMilli())
+			}
+		}
+	}
+	return assigned, nil
+}
+
+// assign 记录当前消费者分配到的分区，只有这些分区的位置会被标记提交
+func (g *consumerGroup) assign(cons *consumer, assigned map[string][]int32) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.current != cons {
+		return
+	}
+	for kafkaTopic, partitions := range assigned {
+		for _, partition := range partitions {
+			g.assigned[topicPartition{topic: kafkaTopic, partition: partition}] = true
+		}
+	}
+}
+
+// revoke 清除失去的分区的投递进度，重新分配后从已提交的位置继续；重建消费者时保留进度
+func (g *consumerGroup) revoke(cons *consumer, revoked map[string][]int32) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.current != cons {
+		return
+	}
+	for kafkaTopic, partitions := range revoked {
+		for _, partition := range partitions {
+			tp := topicPartition{topic: kafkaTopic, partition: partition}
+			delete(g.assigned, tp)
+			delete(g.windows, tp)
+			for target := range g.skip {
+				if target.tp == tp {
+					delete(g.skip, target)
+				}
+			}
+			for target := range g.after {
+				if target.tp == tp {
+					delete(g.after, target)
+				}
+			}
+		}
+	}
+}
+
+// consume 拉取记录并投递到匹配的订阅通道
+func (g *consumerGroup) consume(ctx context.Context, cons *consumer) {
+	for {
+		fetches := cons.client.PollFetches(ctx)
+		if fetches.IsClientClosed() || ctx.Err() != nil {
+			return
+		}
+		fetches.EachError(func(kafkaTopic string, partition int32, err error) {
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			g.sendError(fmt.Errorf("消费Kafka主题 %s 分区 %d 失败: %w", kafkaTopic, partition, err))
+		})
+		fetches.EachRecord(func(record *kgo.Record) {
+			if ctx.Err() == nil {
+				g.dispatch(ctx, cons, record)
+			}
+		})
+	}
+}
+
+// sendError 将错误发送到所有订阅的错误通道
+func (g *consumerGroup) sendError(err error) {
+	g.mutex.Lock()
+	channels := make(map[chan error]bool)
+	for _, sub := range g.subs {
+		channels[sub.errors] = true
+	}
+	g.mutex.Unlock()
+	for messageErrors := range channels {
+		sendError(messageErrors, err)
+	}
+}
+
+// dispatch 将记录投递到所有匹配且尚未处理过该记录的订阅，并登记到分区的待提交窗口
+func (g *consumerGroup) dispatch(ctx context.Context, cons *consumer, record *kgo.Record) {
+	tp := topicPartition{topic: record.Topic, partition: record.Partition}
+	busTopic := recordTopic(record)
+	g.mutex.Lock()
+	if g.current != cons {
+		g.mutex.Unlock()
+		return
+	}
+	window, ok := g.windows[tp]
+	if !ok {
+		window = &partitionWindow{next: record.Offset}
+		g.windows[tp] = window
+	}
+	if record.Offset < window.next {
+		g.mutex.Unlock()
+		return
+	}
+	entry := &pendingRecord{offset: record.Offset, epoch: record.LeaderEpoch, waiting: make(map[string]bool)}
+	var targets []*groupSubscription
+	for _, sub := range g.subs {
+		if !messagebus.TopicMatches(sub.topic.Topic, busTopic) {
+			continue
+		}
+		target := subPartition{topic: sub.topic.Topic, tp: tp}
+		if skip, ok := g.skip[target]; ok && record.Offset <= skip {
+			continue
+		}
+		if after, ok := g.after[target]; ok && record.Timestamp.UnixMilli() < after {
+			continue
+		}
+		g.keys[offsetKey(g.name, sub.topic.Topic, record.Topic, record.Partition)] = target
+		entry.waiting[sub.topic.Topic] = true
+		targets = append(targets, sub)
+	}
+	window.pending = append(window.pending, entry)
+	window.next = record.Offset + 1
+	mark := g.advance(tp, window)
+	g.mutex.Unlock()
+	g.mark(cons, mark)
+
+	for _, sub := range targets {
+		var envelope types.MessageEnvelope
+		if sub.binary {
+			envelope.Payload = record.Value
+		} else if err := json.Unmarshal(record.Value, &envelope); err != nil {
+			sendError(sub.errors, fmt.Errorf("解码主题 %s 的信封失败: %w", busTopic, err))
+			g.settle(cons, tp, record.Offset, sub.topic.Topic)
+			continue
+		}
+		envelope.ReceivedTopic = busTopic
+		if envelope.QueryParams == nil {
+			envelope.QueryParams = make(map[string]string)
+		}
+		envelope.QueryParams[messagebus.HeaderOffsetKey] = offsetKey(g.name, sub.topic.Topic, record.Topic, record.Partition)
+		envelope.QueryParams[messagebus.HeaderOffset] = strconv.FormatInt(record.Offset, 10)
+		select {
+		case sub.topic.Messages <- envelope:
+		case <-ctx.Done():
+			g.rewind(tp, record.Offset)
+			return
+		}
+	}
+}
+
+// settle 标记订阅已处理完单条记录（如无法解码的记录）
+func (g *consumerGroup) settle(cons *consumer, tp topicPartition, offset int64, topic string) {
+	g.mutex.Lock()
+	window, ok := g.windows[tp]
+	if !ok {
+		g.mutex.Unlock()
+		return
+	}
+	for _, entry := range window.pending {
+		if entry.offset == offset {
+			delete(entry.waiting, topic)
+			break
+		}
+	}
+	mark := g.advance(tp, window)
+	g.mutex.Unlock()
+	g.mark(cons, mark)
+}
+
+// rewind 在投递被停止消费者打断时撤销该记录及之后的登记，重建后的消费者从该记录重新投递
+func (g *consumerGroup) rewind(tp topicPartition, offset int64) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	window, ok := g.windows[tp]
+	if !ok {
+		return
+	}
+	for i, entry := range window.pending {
+		if entry.offset >= offset {
+			window.pending = window.pending[:i]
+			break
+		}
+	}
+	if offset < window.next {
+		window.next = offset
+	}
+}
+
+// commit 确认订阅已处理完 offset（含）之前的记录，实现 MessageClient.CommitOffset
+func (g *consumerGroup) commit(key string, offset int64) {
+	g.mutex.Lock()
+	target, ok := g.keys[key]
+	if !ok {
+		g.mutex.Unlock()
+		return
+	}
+	window, ok := g.windows[target.tp]
+	if !ok {
+		g.mutex.Unlock()
+		return
+	}
+	for _, entry := range window.pending {
+		if entry.offset > offset {
+			break
+		}
+		delete(entry.waiting, target.topic)
+	}
+	mark := g.advance(target.tp, window)
+	cons := g.current
+	g.mutex.Unlock()
+	g.mark(cons, mark)
+}
+
+// advance 移出窗口头部所有订阅都已处理完的记录，返回需要标记提交的位置，调用方须持有 mutex
+func (g *consumerGroup) advance(tp topicPartition, window *partitionWindow) map[string]map[int32]kgo.EpochOffset {
+	var last *pendingRecord
+	for len(window.pending) > 0 && len(window.pending[0].waiting) == 0 {
+		last = window.pending[0]
+		window.pending = window.pending[1:]
+	}
+	if last == nil || !g.assigned[tp] {
+		return nil
+	}
+	return map[string]map[int32]kgo.EpochOffset{
+		tp.topic: {tp.partition: {Epoch: last.epoch, Offset: last.offset + 1}},
+	}
+}
+
+// mark 在持有 mutex 之外标记提交位置，由 AutoCommitMarks 定期及失去分区时提交
+func (g *consumerGroup) mark(cons *consumer, offsets map[string]map[int32]kgo.EpochOffset) {
+	if cons == nil || offsets == nil {
+		return
+	}
+	cons.client.MarkCommitOffsets(offsets)
+}
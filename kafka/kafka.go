@@ -0,0 +1,520 @@
+// Package kafka 提供 Kafka 消息总线后端，实现与 MQTT、NATS 相同的发布、订阅和请求-响应语义
+//
+// 该包单独存放，只有导入它的程序才会依赖 github.com/twmb/franz-go。使用时将 Type 设置为
+// messagebus.TypeKafka，并通过 Factory 设置 MessageClientFactory：
+//
+//	client, err := messagebus.NewClientWithOptions(
+//	    messagebus.WithBroker("kafka.local", 9092, "tcp", messagebus.TypeKafka),
+//	    messagebus.WithMessageClientFactory(kafka.Factory(kafka.Config{GroupID: "app-service"})),
+//	)
+//
+// Kafka 主题名不能包含 /，MessageBus 主题的前 TopicLevels 层以 . 连接后作为 Kafka 主题，
+// 完整主题保存在记录头 HeaderTopic 中，订阅时据此还原 ReceivedTopic 并按通配符过滤。
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+)
+
+// HeaderTopic 是 Kafka 记录头中保存完整 MessageBus 主题的键
+const HeaderTopic = "messagebus-topic"
+
+const (
+	defaultTopicLevels    = 3
+	defaultPublishTimeout = 10 * time.Second
+	defaultMetadataMaxAge = 10 * time.Second
+	metadataMinAge        = 500 * time.Millisecond
+	connectTimeout        = 10 * time.Second
+)
+
+// KeyExtractor 返回记录的分区键，相同键的记录进入同一分区并保持顺序
+type KeyExtractor func(topic string, envelope types.MessageEnvelope) []byte
+
+// TopicLevelKey 返回使用主题第 index 层（从 0 开始）作为分区键的 KeyExtractor，
+// 例如对 edgex/events/device/{service}/{profile}/{device}/{source} 使用 5 可按设备分区；
+// 主题层级不足时使用完整主题
+func TopicLevelKey(index int) KeyExtractor {
+	return func(topic string, _ types.MessageEnvelope) []byte {
+		levels := strings.Split(topic, "/")
+		if index < 0 || index >= len(levels) {
+			return []byte(topic)
+		}
+		return []byte(levels[index])
+	}
+}
+
+// Config 表示 Kafka 后端专用参数
+type Config struct {
+	// Brokers 额外的种子 Broker 地址 (host:port)，MessageBus 配置中的 Host:Port 总会作为第一个地址
+	Brokers []string
+	// GroupID 消费者组，同组的客户端分摊订阅的分区，重启后从已提交的位置继续消费；
+	// 客户端的所有订阅共享一个组内消费者，记录在处理完（见 CommitOffset）后才提交。
+	// 为空时每个订阅独立消费全部分区，只接收订阅之后发布的消息
+	GroupID string
+	// TopicLevels MessageBus 主题中用于构成 Kafka 主题的层数，默认 3（如 edgex.events.device）
+	TopicLevels int
+	// KeyExtractor 分区键提取函数，默认使用完整的 MessageBus 主题
+	KeyExtractor KeyExtractor
+	// PublishTimeout 等待 Broker 确认记录的最长时间，默认 10 秒
+	PublishTimeout time.Duration
+	// MetadataMaxAge 通配符订阅发现新主题的间隔，默认 10 秒
+	MetadataMaxAge time.Duration
+	// Options 追加的 franz-go 客户端选项
+	Options []kgo.Opt
+}
+
+// Factory 返回创建 Kafka 底层客户端的 MessageClientFactory
+func Factory(config Config) messagebus.MessageClientFactory {
+	return func(busConfig types.MessageBusConfig) (messaging.MessageClient, error) {
+		return NewMessageClient(busConfig, config)
+	}
+}
+
+// consumer 表示一个 Kafka 消费者：未设置 GroupID 时每个订阅一个，设置时消费者组内的订阅共享一个
+type consumer struct {
+	client *kgo.Client
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// MessageClient 使用 franz-go 实现 messaging.MessageClient
+type MessageClient struct {
+	config     Config
+	clientID   string
+	baseOpts   []kgo.Opt
+	mutex      sync.Mutex
+	producer   *kgo.Client
+	consumers  map[string]*consumer
+	group      *consumerGroup
+	disconnect bool
+	offsets    messagebus.OffsetStore
+}
+
+var (
+	_ messaging.MessageClient    = (*MessageClient)(nil)
+	_ messagebus.OffsetResumer   = (*MessageClient)(nil)
+	_ messagebus.OffsetCommitter = (*MessageClient)(nil)
+)
+
+// NewMessageClient 按 MessageBus 底层配置创建 Kafka 客户端，Connect 时才建立连接
+func NewMessageClient(busConfig types.MessageBusConfig, config Config) (*MessageClient, error) {
+	if config.TopicLevels <= 0 {
+		config.TopicLevels = defaultTopicLevels
+	}
+	if config.KeyExtractor == nil {
+		config.KeyExtractor = func(topic string, _ types.MessageEnvelope) []byte { return []byte(topic) }
+	}
+	if config.PublishTimeout <= 0 {
+		config.PublishTimeout = defaultPublishTimeout
+	}
+	if config.MetadataMaxAge <= 0 {
+		config.MetadataMaxAge = defaultMetadataMaxAge
+	}
+	seeds := []string{net.JoinHostPort(busConfig.Broker.Host, strconv.Itoa(busConfig.Broker.Port))}
+	seeds = append(seeds, config.Brokers...)
+	clientID := busConfig.Optional["ClientId"]
+	// 订阅尚不存在的主题（如首次请求的响应主题）时，尽快通过元数据刷新发现自动创建的主题
+	opts := []kgo.Opt{kgo.SeedBrokers(seeds...), kgo.MetadataMaxAge(config.MetadataMaxAge), kgo.MetadataMinAge(metadataMinAge)}
+	if clientID != "" {
+		opts = append(opts, kgo.ClientID(clientID))
+	}
+	if username := busConfig.Optional["Username"]; username != "" {
+		opts = append(opts, kgo.SASL(plain.Auth{User: username, Pass: busConfig.Optional["Password"]}.AsMechanism()))
+	}
+	switch strings.ToLower(busConfig.Broker.Protocol) {
+	case "tls", "ssl":
+		tlsConfig, err := messagebus.TLSConfigFromOptions(busConfig.Optional)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, kgo.DialTLSConfig(tlsConfig))
+	}
+	opts = append(opts, config.Options...)
+	client := &MessageClient{
+		config:    config,
+		clientID:  clientID,
+		baseOpts:  opts,
+		consumers: make(map[string]*consumer),
+	}
+	if config.GroupID != "" {
+		client.group = newConsumerGroup(client, config.GroupID)
+	}
+	return client, nil
+}
+
+// Connect 创建生产者并确认至少一个 Broker 可达
+func (c *MessageClient) Connect() error {
+	producer, err := kgo.NewClient(append(c.baseOpts, kgo.AllowAutoTopicCreation())...)
+	if err != nil {
+		return fmt.Errorf("创建Kafka客户端失败: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	if err := producer.Ping(ctx); err != nil {
+		producer.Close()
+		return fmt.Errorf("连接Kafka失败: %w", err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.producer != nil {
+		c.producer.Close()
+	}
+	c.producer = producer
+	c.disconnect = false
+	return nil
+}
+
+// ResumeFrom 实现 messagebus.OffsetResumer：消费者组分配到分区时从 store 中记录的位置之后继续消费，
+// store 中没有记录的分区仍按消费者组已提交的位置或订阅时刻开始；收到的信封携带分区和位置，须设置 GroupID
+func (c *MessageClient) ResumeFrom(store messagebus.OffsetStore) error {
+	if c.config.GroupID == "" {
+		return fmt.Errorf("使用消费位置存储时必须设置 GroupID")
+	}
+	c.offsets = store
+	return nil
+}
+
+// CommitOffset 实现 messagebus.OffsetCommitter：确认 key（信封中的 HeaderOffsetKey）对应的订阅已处理完
+// offset（含）之前的记录，分区上的记录被所有匹配的订阅处理完后才提交到消费者组。
+// 经 messagebus.Client 订阅时由其自动调用，直接使用 MessageClient 订阅消费者组时须在处理完每条消息后调用，
+// 否则消费者组的位置不会前进
+func (c *MessageClient) CommitOffset(key string, offset int64) error {
+	if c.group != nil {
+		c.group.commit(key, offset)
+	}
+	return nil
+}
+
+// Publish 将信封编码为 JSON 后发布
+func (c *MessageClient) Publish(message types.MessageEnvelope, topic string) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("编码信封失败: %w", err)
+	}
+	return c.produce(topic, c.config.KeyExtractor(topic, message), data)
+}
+
+// PublishWithSizeLimit 在编码后的信封不超过 limit KB 时发布，limit 不大于 0 表示不限制
+func (c *MessageClient) PublishWithSizeLimit(message types.MessageEnvelope, topic string, limit int64) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("编码信封失败: %w", err)
+	}
+	if limit > 0 && int64(len(data)) > limit*1024 {
+		return fmt.Errorf("消息大小 %d 字节超过限制 %d KB", len(data), limit)
+	}
+	return c.produce(topic, c.config.KeyExtractor(topic, message), data)
+}
+
+// PublishBinaryData 直接发布原始数据
+func (c *MessageClient) PublishBinaryData(data []byte, topic string) error {
+	return c.produce(topic, c.config.KeyExtractor(topic, types.MessageEnvelope{Payload: data}), data)
+}
+
+// produce 同步发布一条记录，等待 Broker 确认
+func (c *MessageClient) produce(topic string, key, value []byte) error {
+	c.mutex.Lock()
+	producer := c.producer
+	c.mutex.Unlock()
+	if producer == nil {
+		return fmt.Errorf("Kafka客户端未连接")
+	}
+	record := &kgo.Record{
+		Topic:   kafkaTopic(topic, c.config.TopicLevels),
+		Key:     key,
+		Value:   value,
+		Headers: []kgo.RecordHeader{{Key: HeaderTopic, Value: []byte(topic)}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.PublishTimeout)
+	defer cancel()
+	if err := producer.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return fmt.Errorf("发布到Kafka主题 %s 失败: %w", record.Topic, err)
+	}
+	return nil
+}
+
+// Subscribe 订阅主题，收到的信封发送到对应的通道；设置 GroupID 时信封携带消费位置（见 CommitOffset）
+func (c *MessageClient) Subscribe(topics []types.TopicChannel, messageErrors chan error) error {
+	return c.subscribe(topics, messageErrors, false, c.group)
+}
+
+// SubscribeBinaryData 与 Subscribe 相同，但将原始数据包装为信封的 Payload
+func (c *MessageClient) SubscribeBinaryData(topics []types.TopicChannel, messageErrors chan error) error {
+	return c.subscribe(topics, messageErrors, true, c.group)
+}
+
+// subscribe 将主题加入消费者组，group 为 nil 时为每个主题启动独立的消费者，任一主题失败时停止本次已启动的消费者
+func (c *MessageClient) subscribe(topics []types.TopicChannel, messageErrors chan error, binary bool, group *consumerGroup) error {
+	c.mutex.Lock()
+	disconnected := c.disconnect
+	c.mutex.Unlock()
+	if disconnected {
+		return fmt.Errorf("Kafka客户端已断开")
+	}
+	if group != nil {
+		return group.subscribe(topics, messageErrors, binary)
+	}
+	var started []string
+	for _, topic := range topics {
+		if err := c.startConsumer(topic, messageErrors, binary); err != nil {
+			_ = c.Unsubscribe(started...)
+			return err
+		}
+		started = append(started, topic.Topic)
+	}
+	return nil
+}
+
+// startConsumer 创建独立消费 topic 对应 Kafka 主题全部分区的客户端并启动拉取循环
+func (c *MessageClient) startConsumer(topic types.TopicChannel, messageErrors chan error, binary bool) error {
+	opts := append([]kgo.Opt(nil), c.baseOpts...)
+	literal, pattern := kafkaSubscription(topic.Topic, c.config.TopicLevels)
+	if pattern != "" {
+		opts = append(opts, kgo.ConsumeRegex(), kgo.ConsumeTopics(pattern))
+	} else {
+		opts = append(opts, kgo.ConsumeTopics(literal))
+	}
+	// 从订阅时刻开始消费，避免分区分配完成前发布的消息丢失
+	opts = append(opts, kgo.ConsumeResetOffset(kgo.NewOffset().AfterMilli(time.Now().UnixMilli())))
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return fmt.Errorf("创建Kafka消费者失败: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &consumer{client: client, cancel: cancel, done: make(chan struct{})}
+
+	c.mutex.Lock()
+	if c.disconnect {
+		c.mutex.Unlock()
+		cancel()
+		client.Close()
+		return fmt.Errorf("Kafka客户端已断开")
+	}
+	if existing, ok := c.consumers[topic.Topic]; ok {
+		defer existing.stop()
+	}
+	c.consumers[topic.Topic] = sub
+	c.mutex.Unlock()
+
+	go func() {
+		defer close(sub.done)
+		c.consume(ctx, client, topic, messageErrors, binary)
+	}()
+	return nil
+}
+
+// offsetKey 返回消费者组内一个订阅在某个分区上的消费位置键
+func offsetKey(group, topic, kafkaTopic string, partition int32) string {
+	return fmt.Sprintf("kafka|%s|%s|%s|%d", group, topic, kafkaTopic, partition)
+}
+
+// consume 拉取记录并按通配符过滤后投递到订阅通道
+func (c *MessageClient) consume(ctx context.Context, client *kgo.Client, topic types.TopicChannel, messageErrors chan error, binary bool) {
+	for {
+		fetches := client.PollFetches(ctx)
+		if fetches.IsClientClosed() || ctx.Err() != nil {
+			return
+		}
+		fetches.EachError(func(kafkaTopic string, partition int32, err error) {
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			sendError(messageErrors, fmt.Errorf("消费Kafka主题 %s 分区 %d 失败: %w", kafkaTopic, partition, err))
+		})
+		fetches.EachRecord(func(record *kgo.Record) {
+			busTopic := recordTopic(record)
+			if !messagebus.TopicMatches(topic.Topic, busTopic) {
+				return
+			}
+			var envelope types.MessageEnvelope
+			if binary {
+				envelope.Payload = record.Value
+			} else if err := json.Unmarshal(record.Value, &envelope); err != nil {
+				sendError(messageErrors, fmt.Errorf("解码主题 %s 的信封失败: %w", busTopic, err))
+				return
+			}
+			envelope.ReceivedTopic = busTopic
+			select {
+			case topic.Messages <- envelope:
+			case <-ctx.Done():
+			}
+		})
+	}
+}
+
+// sendError 以非阻塞方式发送错误，通道为空或已满时丢弃
+func sendError(messageErrors chan error, err error) {
+	if messageErrors == nil {
+		return
+	}
+	select {
+	case messageErrors <- err:
+	default:
+	}
+}
+
+// stop 停止消费者并等待拉取循环退出
+func (s *consumer) stop() {
+	s.cancel()
+	s.client.Close()
+	<-s.done
+}
+
+// Request 发布请求并等待 responseTopicPrefix/RequestID 上的响应
+func (c *MessageClient) Request(message types.MessageEnvelope, requestTopic string, responseTopicPrefix string, timeout time.Duration) (*types.MessageEnvelope, error) {
+	if message.RequestID == "" {
+		message.RequestID = uuid.NewString()
+	}
+	responseTopic := strings.TrimSuffix(responseTopicPrefix, "/") + "/" + message.RequestID
+	responses := make(chan types.MessageEnvelope, 1)
+	// 响应只应由本次请求接收，不加入消费者组
+	if err := c.subscribe([]types.TopicChannel{{Topic: responseTopic, Messages: responses}}, nil, false, nil); err != nil {
+		return nil, err
+	}
+	defer func() { _ = c.Unsubscribe(responseTopic) }()
+	if err := c.Publish(message, requestTopic); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case response := <-responses:
+		return &response, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("等待 %s 的响应超时", responseTopic)
+	}
+}
+
+// Unsubscribe 停止指定主题的消费者，或将其移出消费者组
+func (c *MessageClient) Unsubscribe(topics ...string) error {
+	c.mutex.Lock()
+	var stopping []*consumer
+	var grouped []string
+	for _, topic := range topics {
+		if sub, ok := c.consumers[topic]; ok {
+			stopping = append(stopping, sub)
+			delete(c.consumers, topic)
+		} else {
+			grouped = append(grouped, topic)
+		}
+	}
+	c.mutex.Unlock()
+	for _, sub := range stopping {
+		sub.stop()
+	}
+	if c.group != nil && len(grouped) > 0 {
+		c.group.unsubscribe(grouped...)
+	}
+	return nil
+}
+
+// Disconnect 停止所有消费者并关闭生产者，消费者组会在离开前提交已处理完的位置
+func (c *MessageClient) Disconnect() error {
+	c.mutex.Lock()
+	consumers := c.consumers
+	c.consumers = make(map[string]*consumer)
+	producer := c.producer
+	c.producer = nil
+	c.disconnect = true
+	c.mutex.Unlock()
+	for _, sub := range consumers {
+		sub.stop()
+	}
+	if c.group != nil {
+		c.group.close()
+	}
+	if producer != nil {
+		producer.Close()
+	}
+	return nil
+}
+
+// invalidTopicChars 匹配 Kafka 主题名不允许的字符
+var invalidTopicChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// topicLevel 将 MessageBus 主题层级转换为 Kafka 主题名的一段，. 作为层级分隔符也被替换
+func topicLevel(level string) string {
+	return invalidTopicChars.ReplaceAllString(level, "_")
+}
+
+// kafkaTopic 返回 MessageBus 主题对应的 Kafka 主题：前 levels 层以 . 连接
+func kafkaTopic(topic string, levels int) string {
+	parts := strings.Split(topic, "/")
+	if len(parts) > levels {
+		parts = parts[:levels]
+	}
+	for i, part := range parts {
+		parts[i] = topicLevel(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// kafkaSubscription 返回订阅主题对应的 Kafka 主题；前 levels 层包含通配符时返回匹配 Kafka 主题的正则表达式
+func kafkaSubscription(topic string, levels int) (literal string, pattern string) {
+	parts := strings.Split(topic, "/")
+	if len(parts) > levels {
+		parts = parts[:levels]
+	}
+	wildcard := false
+	for _, part := range parts {
+		if part == "+" || part == "*" || part == "#" || part == ">" {
+			wildcard = true
+			break
+		}
+	}
+	if !wildcard {
+		return kafkaTopic(topic, levels), ""
+	}
+	var builder strings.Builder
+	builder.WriteString("^")
+	for i, part := range parts {
+		switch part {
+		case "#", ">":
+			if i == 0 {
+				builder.WriteString(".+")
+			} else {
+				builder.WriteString(`(\..+)?`)
+			}
+			builder.WriteString("$")
+			return "", builder.String()
+		case "+", "*":
+			if i > 0 {
+				builder.WriteString(`\.`)
+			}
+			builder.WriteString(`[^.]+`)
+		default:
+			if i > 0 {
+				builder.WriteString(`\.`)
+			}
+			builder.WriteString(regexp.QuoteMeta(topicLevel(part)))
+		}
+	}
+	builder.WriteString("$")
+	return "", builder.String()
+}
+
+// recordTopic 返回记录的完整 MessageBus 主题，缺少 HeaderTopic 时由 Kafka 主题还原
+func recordTopic(record *kgo.Record) string {
+	for _, header := range record.Headers {
+		if header.Key == HeaderTopic {
+			return string(header.Value)
+		}
+	}
+	return strings.ReplaceAll(record.Topic, ".", "/")
+}
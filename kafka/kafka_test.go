@@ -0,0 +1,407 @@
+package kafka_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/kafka"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// startCluster 启动内存中的 Kafka 集群，topics 为预先创建的 Kafka 主题
+func startCluster(t *testing.T, partitions int32, topics ...string) (string, int) {
+	t.Helper()
+	opts := []kfake.Opt{kfake.NumBrokers(1), kfake.AllowAutoTopicCreation()}
+	if len(topics) > 0 {
+		opts = append(opts, kfake.SeedTopics(partitions, topics...))
+	}
+	cluster, err := kfake.NewCluster(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cluster.Close)
+	host, port, err := net.SplitHostPort(cluster.ListenAddrs()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, _ := strconv.Atoi(port)
+	return host, portNumber
+}
+
+// busConfig 返回连接到测试集群的 MessageBus 底层配置
+func busConfig(host string, port int) types.MessageBusConfig {
+	return types.MessageBusConfig{
+		Broker:   types.HostInfo{Host: host, Port: port, Protocol: "tcp"},
+		Type:     messagebus.TypeKafka,
+		Optional: map[string]string{"ClientId": "kafka-" + uuid.NewString()},
+	}
+}
+
+// newClient 创建并连接 Kafka 底层客户端，测试结束时断开
+func newClient(t *testing.T, host string, port int, config kafka.Config) *kafka.MessageClient {
+	t.Helper()
+	config.MetadataMaxAge = time.Second
+	config.Options = append(config.Options, kgo.FetchMaxWait(100*time.Millisecond), kgo.HeartbeatInterval(100*time.Millisecond))
+	client, err := kafka.NewMessageClient(busConfig(host, port), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Disconnect() })
+	return client
+}
+
+// subscribe 订阅主题并返回接收信封的通道
+func subscribe(t *testing.T, client *kafka.MessageClient, topic string) chan types.MessageEnvelope {
+	t.Helper()
+	messages := make(chan types.MessageEnvelope, 64)
+	if err := client.Subscribe([]types.TopicChannel{{Topic: topic, Messages: messages}}, make(chan error, 16)); err != nil {
+		t.Fatal(err)
+	}
+	return messages
+}
+
+// receive 在超时时间内读取一条信封
+func receive(t *testing.T, messages chan types.MessageEnvelope) types.MessageEnvelope {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(10 * time.Second):
+		t.Fatal("未收到消息")
+		return types.MessageEnvelope{}
+	}
+}
+
+// publish 发布以 payload 为内容的信封
+func publish(t *testing.T, client *kafka.MessageClient, topic, payload string) {
+	t.Helper()
+	if err := client.Publish(types.MessageEnvelope{CorrelationID: uuid.NewString(), Payload: []byte(payload)}, topic); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// commit 确认信封已处理完
+func commit(t *testing.T, client *kafka.MessageClient, msg types.MessageEnvelope) {
+	t.Helper()
+	offset, err := strconv.ParseInt(msg.QueryParams[messagebus.HeaderOffset], 10, 64)
+	if err != nil {
+		t.Fatalf("信封不含消费位置: %v", msg.QueryParams)
+	}
+	if err := client.CommitOffset(msg.QueryParams[messagebus.HeaderOffsetKey], offset); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func payload(t *testing.T, msg types.MessageEnvelope) string {
+	t.Helper()
+	data, err := messagebus.EnvelopePayloadBytes(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTopicLevelKeyPartitioning(t *testing.T) {
+	host, port := startCluster(t, 4, "test.key.device")
+	producer := newClient(t, host, port, kafka.Config{KeyExtractor: kafka.TopicLevelKey(3)})
+	devices := []string{"d1", "d2", "d3", "d4", "d5", "d6"}
+	for i := 0; i < 5; i++ {
+		for _, device := range devices {
+			publish(t, producer, "test/key/device/"+device, fmt.Sprintf("%s-%d", device, i))
+		}
+	}
+
+	consumer, err := kgo.NewClient(kgo.SeedBrokers(net.JoinHostPort(host, strconv.Itoa(port))),
+		kgo.ConsumeTopics("test.key.device"), kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+	partitions := make(map[string]int32)
+	lastOffsets := make(map[string]int64)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for received := 0; received < 5*len(devices); {
+		fetches := consumer.PollFetches(ctx)
+		if ctx.Err() != nil {
+			t.Fatalf("只读取到 %d 条记录", received)
+		}
+		fetches.EachRecord(func(record *kgo.Record) {
+			received++
+			key := string(record.Key)
+			if partition, ok := partitions[key]; ok && partition != record.Partition {
+				t.Errorf("键 %s 的记录分布在分区 %d 和 %d", key, partition, record.Partition)
+			}
+			partitions[key] = record.Partition
+			if last, ok := lastOffsets[key]; ok && record.Offset <= last {
+				t.Errorf("键 %s 的记录顺序错乱", key)
+			}
+			lastOffsets[key] = record.Offset
+		})
+	}
+	if len(partitions) != len(devices) {
+		t.Fatalf("分区键 = %v，期望按设备名分区", partitions)
+	}
+}
+
+func TestConsumerGroupCommitsOnlyHandledRecords(t *testing.T) {
+	host, port := startCluster(t, 1, "test.group.commit")
+	producer := newClient(t, host, port, kafka.Config{})
+	config := kafka.Config{GroupID: "commit-" + uuid.NewString()}
+
+	first := newClient(t, host, port, config)
+	messages := subscribe(t, first, "test/group/commit")
+	publish(t, producer, "test/group/commit", "m0")
+	commit(t, first, receive(t, messages))
+	// m1 已投递到通道但没有确认，离开消费者组时不能提交
+	publish(t, producer, "test/group/commit", "m1")
+	if got := payload(t, receive(t, messages)); got != "m1" {
+		t.Fatalf("收到 %q，期望 m1", got)
+	}
+	if err := first.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+
+	second := newClient(t, host, port, config)
+	messages = subscribe(t, second, "test/group/commit")
+	msg := receive(t, messages)
+	if got := payload(t, msg); got != "m1" {
+		t.Fatalf("重新加入后收到 %q，期望重新投递未确认的 m1", got)
+	}
+	commit(t, second, msg)
+	if err := second.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+
+	third := newClient(t, host, port, config)
+	messages = subscribe(t, third, "test/group/commit")
+	publish(t, producer, "test/group/commit", "m2")
+	if got := payload(t, receive(t, messages)); got != "m2" {
+		t.Fatalf("收到 %q，已确认的 m1 不应重新投递", got)
+	}
+}
+
+func TestConsumerGroupSharesPartitions(t *testing.T) {
+	host, port := startCluster(t, 4, "test.group.share")
+	producer := newClient(t, host, port, kafka.Config{})
+	config := kafka.Config{GroupID: "share-" + uuid.NewString()}
+	clients := []*kafka.MessageClient{newClient(t, host, port, config), newClient(t, host, port, config)}
+
+	var mutex sync.Mutex
+	counts := make(map[string]int)
+	receivedBy := make([]map[string]bool, len(clients))
+	for i, client := range clients {
+		i, client := i, client
+		receivedBy[i] = make(map[string]bool)
+		messages := subscribe(t, client, "test/group/share/#")
+		go func() {
+			for msg := range messages {
+				data, _ := messagebus.EnvelopePayloadBytes(msg)
+				text := string(data)
+				mutex.Lock()
+				counts[text]++
+				receivedBy[i][text] = true
+				mutex.Unlock()
+				offset, _ := strconv.ParseInt(msg.QueryParams[messagebus.HeaderOffset], 10, 64)
+				_ = client.CommitOffset(msg.QueryParams[messagebus.HeaderOffsetKey], offset)
+			}
+		}()
+	}
+	received := func(text string) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return counts[text] > 0
+	}
+	joined := func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(receivedBy[0]) > 0 && len(receivedBy[1]) > 0
+	}
+
+	// 以不同分区键反复发布探测消息，直到两个客户端都分到分区
+	deadline := time.Now().Add(15 * time.Second)
+	for probe := 0; !joined(); probe++ {
+		if time.Now().After(deadline) {
+			t.Fatal("两个客户端未能分摊分区")
+		}
+		text := fmt.Sprintf("probe-%d", probe)
+		publish(t, producer, "test/group/share/"+text, text)
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	for i := 0; i < 40; i++ {
+		text := fmt.Sprintf("m%d", i)
+		publish(t, producer, "test/group/share/"+text, text)
+	}
+	waitFor(t, func() bool {
+		for i := 0; i < 40; i++ {
+			if !received(fmt.Sprintf("m%d", i)) {
+				return false
+			}
+		}
+		return true
+	})
+	mutex.Lock()
+	defer mutex.Unlock()
+	for i := 0; i < 40; i++ {
+		if count := counts[fmt.Sprintf("m%d", i)]; count != 1 {
+			t.Errorf("m%d 被投递 %d 次，同组客户端应只有一个收到", i, count)
+		}
+	}
+}
+
+func TestConsumerGroupSubscriptionsShareConsumer(t *testing.T) {
+	host, port := startCluster(t, 1, "test.group.a", "test.group.b")
+	producer := newClient(t, host, port, kafka.Config{})
+	client := newClient(t, host, port, kafka.Config{GroupID: "subs-" + uuid.NewString()})
+	a := subscribe(t, client, "test/group/a")
+	b := subscribe(t, client, "test/group/b")
+
+	publish(t, producer, "test/group/a", "a1")
+	publish(t, producer, "test/group/b", "b1")
+	if got := payload(t, receive(t, a)); got != "a1" {
+		t.Fatalf("a 收到 %q", got)
+	}
+	if got := payload(t, receive(t, b)); got != "b1" {
+		t.Fatalf("b 收到 %q", got)
+	}
+
+	// 退订一个主题后重建的消费者继续为另一个订阅投递，且不重复投递已投递过的记录
+	if err := client.Unsubscribe("test/group/a"); err != nil {
+		t.Fatal(err)
+	}
+	publish(t, producer, "test/group/b", "b2")
+	if got := payload(t, receive(t, b)); got != "b2" {
+		t.Fatalf("退订 a 后 b 收到 %q，期望 b2", got)
+	}
+	publish(t, producer, "test/group/a", "a2")
+	select {
+	case msg := <-a:
+		t.Fatalf("退订后仍收到 %q", payload(t, msg))
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestOffsetStoreResume(t *testing.T) {
+	host, port := startCluster(t, 1, "test.resume.data")
+	producer := newClient(t, host, port, kafka.Config{})
+	store := messagebus.NewMemoryOffsetStore()
+	group := "resume-" + uuid.NewString()
+	newBusClient := func() (*messagebus.Client, chan string) {
+		client, err := messagebus.NewClientWithOptions(
+			messagebus.WithBroker(host, port, "tcp", messagebus.TypeKafka),
+			messagebus.WithMessageClientFactory(kafka.Factory(kafka.Config{
+				GroupID:        group,
+				MetadataMaxAge: time.Second,
+				Options:        []kgo.Opt{kgo.FetchMaxWait(100 * time.Millisecond)},
+			})),
+			messagebus.WithOffsetStore(store),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = client.Disconnect() })
+		received := make(chan string, 16)
+		err = client.Subscribe([]string{"test/resume/data"}, func(_ string, msg types.MessageEnvelope) error {
+			data, err := messagebus.EnvelopePayloadBytes(msg)
+			if err != nil {
+				return err
+			}
+			received <- string(data)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client, received
+	}
+
+	client, received := newBusClient()
+	publish(t, producer, "test/resume/data", "m0")
+	publish(t, producer, "test/resume/data", "m1")
+	for _, want := range []string{"m0", "m1"} {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Fatalf("收到 %q，期望 %q", got, want)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("未收到 %s", want)
+		}
+	}
+	key := fmt.Sprintf("kafka|%s|test/resume/data|test.resume.data|0", group)
+	waitFor(t, func() bool {
+		offset, ok, _ := store.Load(key)
+		return ok && offset == 1
+	})
+	if err := client.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 存储中的位置早于消费者组已提交的位置时，从存储的位置之后重新消费
+	if err := store.Save(key, 0); err != nil {
+		t.Fatal(err)
+	}
+	_, received = newBusClient()
+	select {
+	case got := <-received:
+		if got != "m1" {
+			t.Fatalf("恢复后收到 %q，期望从 m1 继续", got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("未按存储的位置恢复消费")
+	}
+}
+
+func TestRequest(t *testing.T) {
+	host, port := startCluster(t, 1, "test.request.ping")
+	requester := newClient(t, host, port, kafka.Config{})
+	responder := newClient(t, host, port, kafka.Config{GroupID: "responder-" + uuid.NewString()})
+	requests := subscribe(t, responder, "test/request/ping")
+
+	errs := make(chan error, 1)
+	go func() {
+		request := <-requests
+		response := types.MessageEnvelope{RequestID: request.RequestID, CorrelationID: request.CorrelationID, Payload: []byte("pong")}
+		errs <- responder.Publish(response, "test/response/"+request.RequestID)
+	}()
+
+	request := types.MessageEnvelope{RequestID: uuid.NewString(), CorrelationID: uuid.NewString(), Payload: []byte("ping")}
+	response, err := requester.Request(request, "test/request/ping", "test/response", 15*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if response.RequestID != request.RequestID || payload(t, *response) != "pong" {
+		t.Fatalf("响应 = %+v", response)
+	}
+}
+
+// waitFor 等待条件成立
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("等待超时")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
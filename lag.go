@@ -0,0 +1,152 @@
+package messagebus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// 积压检测的默认值
+const (
+	defaultQueueLagDuration = 10 * time.Second
+	defaultLagCheckInterval = time.Second
+)
+
+// LagConfig 表示慢处理函数与订阅通道积压检测参数
+type LagConfig struct {
+	// SlowHandlerThreshold 处理函数单次执行超过该时长时记录告警并调用 OnSlowHandler 回调，0 表示不检测
+	SlowHandlerThreshold time.Duration
+	// QueueHighWater 订阅接收通道占用比例的高水位 (0~1)，如 0.8 表示超过 80%，0 表示不检测
+	QueueHighWater float64
+	// QueueLagDuration 通道占用持续高于高水位多久后告警，默认 10 秒；回落到高水位以下后重新计时
+	QueueLagDuration time.Duration
+	// CheckInterval 检查通道占用的间隔，默认 1 秒
+	CheckInterval time.Duration
+}
+
+// validate 校验积压检测参数
+func (l LagConfig) validate() error {
+	if l.SlowHandlerThreshold < 0 || l.QueueLagDuration < 0 || l.CheckInterval < 0 {
+		return fmt.Errorf("SlowHandlerThreshold、QueueLagDuration 和 CheckInterval 不能为负数")
+	}
+	if l.QueueHighWater < 0 || l.QueueHighWater > 1 {
+		return fmt.Errorf("QueueHighWater 必须在 0~1 之间")
+	}
+	return nil
+}
+
+// SlowHandlerHandler 在处理函数单次执行超过 SlowHandlerThreshold 后被调用
+type SlowHandlerHandler func(topic string, message types.MessageEnvelope, elapsed time.Duration)
+
+// QueueLagHandler 在订阅通道占用持续高于 QueueHighWater 达到 QueueLagDuration 后被调用，
+// topic 为订阅主题，duration 为已持续的时长
+type QueueLagHandler func(topic string, depth, capacity int, duration time.Duration)
+
+// OnSlowHandler 注册慢处理函数回调；传入 nil 取消回调
+func (c *Client) OnSlowHandler(handler SlowHandlerHandler) {
+	c.callbacks.mutex.Lock()
+	defer c.callbacks.mutex.Unlock()
+	c.callbacks.onSlowHandler = handler
+}
+
+// OnQueueLag 注册订阅通道积压回调；传入 nil 取消回调
+func (c *Client) OnQueueLag(handler QueueLagHandler) {
+	c.callbacks.mutex.Lock()
+	defer c.callbacks.mutex.Unlock()
+	c.callbacks.onQueueLag = handler
+}
+
+// observeHandlerLatency 在处理函数执行超过阈值时记录告警并调用回调
+func (c *Client) observeHandlerLatency(topic string, message types.MessageEnvelope, elapsed time.Duration) {
+	threshold := c.config.Lag.SlowHandlerThreshold
+	if threshold <= 0 || elapsed <= threshold {
+		return
+	}
+	c.stats.slowHandlers.Add(1)
+	c.log(LogSubscribe).Warn("处理函数执行缓慢", c.logFields("topic", topic, "correlationId", message.CorrelationID, "elapsed", elapsed, "threshold", threshold)...)
+	c.callbacks.mutex.Lock()
+	handler := c.callbacks.onSlowHandler
+	c.callbacks.mutex.Unlock()
+	if handler != nil {
+		handler(topic, message, elapsed)
+	}
+}
+
+// watchQueueLag 定期检查各订阅接收通道的占用，持续高于高水位时告警一次，回落后重新计时
+func (c *Client) watchQueueLag(stop <-chan struct{}) {
+	cfg := c.config.Lag
+	lagDuration := cfg.QueueLagDuration
+	if lagDuration <= 0 {
+		lagDuration = defaultQueueLagDuration
+	}
+	interval := cfg.CheckInterval
+	if interval <= 0 {
+		interval = defaultLagCheckInterval
+	}
+	type lagState struct {
+		since   time.Time
+		alerted bool
+	}
+	states := make(map[*subscription]*lagState)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+		now := time.Now()
+		active := make(map[*subscription]*lagState)
+		for _, sub := range c.subscriptionList() {
+			depth, capacity := len(sub.messages), cap(sub.messages)
+			if capacity == 0 || float64(depth) < cfg.QueueHighWater*float64(capacity) {
+				continue
+			}
+			state, ok := states[sub]
+			if !ok {
+				state = &lagState{since: now}
+			}
+			active[sub] = state
+			if lagged := now.Sub(state.since); !state.alerted && lagged >= lagDuration {
+				state.alerted = true
+				c.queueLag(sub.topic, depth, capacity, lagged)
+			}
+		}
+		states = active
+	}
+}
+
+// queueLag 记录订阅通道积压告警并调用回调
+func (c *Client) queueLag(topic string, depth, capacity int, duration time.Duration) {
+	c.stats.queueLagAlerts.Add(1)
+	c.log(LogSubscribe).Warn("订阅接收通道持续积压", c.logFields("topic", topic, "depth", depth, "capacity", capacity, "duration", duration)...)
+	c.callbacks.mutex.Lock()
+	handler := c.callbacks.onQueueLag
+	c.callbacks.mutex.Unlock()
+	if handler != nil {
+		handler(topic, depth, capacity, duration)
+	}
+}
+
+// subscriptionList 返回当前所有订阅的快照
+func (c *Client) subscriptionList() []*subscription {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	subs := make([]*subscription, 0, len(c.subscriptions))
+	for _, sub := range c.subscriptions {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// queueDepths 返回各订阅接收通道中排队的消息数
+func (c *Client) queueDepths() map[string]int {
+	subs := c.subscriptionList()
+	depths := make(map[string]int, len(subs))
+	for _, sub := range subs {
+		depths[sub.topic] = len(sub.messages) + len(sub.priority)
+	}
+	return depths
+}
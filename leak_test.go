@@ -0,0 +1,199 @@
+package messagebus_test
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// staticCredentials 每次刷新都返回相同的凭据
+type staticCredentials struct{}
+
+func (staticCredentials) Credentials() (messagebus.Credentials, error) {
+	return messagebus.Credentials{Username: "user", Password: "secret"}, nil
+}
+
+// 建议以 go test -race 运行，同时检查后台 goroutine 的数据竞争
+func TestCloseDoesNotLeakGoroutines(t *testing.T) {
+	// 配置监听使用的独立连接在 Disconnect 后保持，只在 Close 时断开，因此只在 Close 时启用配置监听
+	shutdowns := []struct {
+		name        string
+		watchConfig bool
+		shutdown    func(*messagebus.Client) error
+	}{
+		{"Close", true, (*messagebus.Client).Close},
+		{"Disconnect", false, (*messagebus.Client).Disconnect},
+		{"DisconnectWithContext", false, func(client *messagebus.Client) error {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return client.DisconnectWithContext(ctx)
+		}},
+	}
+	for _, tc := range shutdowns {
+		t.Run(tc.name, func(t *testing.T) {
+			baseline := runtime.NumGoroutine()
+			broker := messagebustest.NewBroker()
+			// 依次经 WebSocket 中继、代理中继和直连，覆盖各种中继的启停
+			relays := []func(*messagebus.Config){
+				func(config *messagebus.Config) { config.Protocol = "ws" },
+				func(config *messagebus.Config) { config.ProxyURL = "http://127.0.0.1:1" },
+				func(*messagebus.Config) {},
+			}
+			for i, relay := range relays {
+				responder, err := messagebustest.NewMockClientWithBroker(broker)
+				if err != nil {
+					t.Fatal(err)
+				}
+				client := newFullFeaturedClient(t, broker, relay, tc.watchConfig)
+				exerciseClient(t, client, responder.Client)
+				exerciseBackgroundFeatures(t, client)
+				if err := tc.shutdown(client); err != nil {
+					t.Fatalf("第 %d 个客户端关闭失败: %v", i+1, err)
+				}
+				t.Cleanup(func() { _ = client.Close() })
+				if err := responder.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expectNoLeakedGoroutines(t, baseline, tc.name)
+		})
+	}
+}
+
+// newFullFeaturedClient 创建启用所有后台功能的客户端：自动重连、存储转发、健康检查升级、凭据刷新、
+// 积压检测、分块重组，watchConfig 为 true 时经配置来源创建并监听配置变化；
+// 连接前先发布一条消息使其进入存储转发队列，连接后由后台转发
+func newFullFeaturedClient(t *testing.T, broker *messagebustest.Broker, relay func(*messagebus.Config), watchConfig bool) *messagebus.Client {
+	t.Helper()
+	base := messagebus.Config{
+		MessageClientFactory:       broker.MessageClientFactory("leak"),
+		Reconnect:                  messagebus.ReconnectConfig{Enabled: true, InitialDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond},
+		Outbox:                     messagebus.OutboxConfig{Store: messagebus.NewMemoryOutbox(16)},
+		HealthFailureThreshold:     1,
+		ReconnectOnUnhealthy:       true,
+		HealthProbeTimeout:         time.Millisecond,
+		Credentials:                staticCredentials{},
+		CredentialsRefreshInterval: 10 * time.Millisecond,
+		Lag:                        messagebus.LagConfig{QueueHighWater: 0.5, CheckInterval: 10 * time.Millisecond},
+		Chunking:                   messagebus.ChunkingConfig{MaxPayloadSize: 1024, ReassemblyTimeout: 50 * time.Millisecond},
+	}
+	relay(&base)
+	info := messagebus.EdgeXMessageBusInfo{Type: messagebus.TypeMQTT, Protocol: "tcp", Host: "localhost", Port: 1883}
+	if base.Protocol != "" {
+		info.Protocol = base.Protocol
+	}
+	source := &fakeConfigSource{info: info}
+	var client *messagebus.Client
+	var err error
+	if watchConfig {
+		client, err = messagebus.NewClientFromConfigSource(source, base, logger.NewMockClient())
+	} else {
+		base.Type, base.Protocol, base.Host, base.Port = info.Type, info.Protocol, info.Host, info.Port
+		client, err = messagebus.NewClient(base, logger.NewMockClient())
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Publish("test/leak/outbox", "queued"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if watchConfig {
+		// 配置中心推送未变化的配置，由监听 goroutine 处理
+		source.updates <- &source.info
+	}
+	return client
+}
+
+// exerciseBackgroundFeatures 触发健康检查升级后的重连、分块重组和主动重连
+func exerciseBackgroundFeatures(t *testing.T, client *messagebus.Client) {
+	t.Helper()
+	received := make(chan struct{}, 1)
+	err := client.Subscribe([]string{"test/leak/chunk"}, func(string, types.MessageEnvelope) error {
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.PublishBinary("test/leak/chunk", bytes.Repeat([]byte("x"), 4096)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("未收到分块重组后的消息")
+	}
+	// 探测超时使健康检查失败并触发升级重连
+	_ = client.HealthCheck()
+	if err := client.Reconnect(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+}
+
+// expectNoLeakedGoroutines 等待 goroutine 数回落到 baseline，超时后输出所有 goroutine 的调用栈
+func expectNoLeakedGoroutines(t *testing.T, baseline int, shutdown string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > baseline {
+		buf := make([]byte, 1<<16)
+		buf = buf[:runtime.Stack(buf, true)]
+		t.Fatalf("%s 后仍有 %d 个多余的 goroutine:\n%s", shutdown, n-baseline, buf)
+	}
+}
+
+// exerciseClient 使用会启动后台 goroutine 的各类功能
+func exerciseClient(t *testing.T, client, responder *messagebus.Client) {
+	t.Helper()
+	handler := func(string, types.MessageEnvelope) error { return nil }
+	if err := client.SubscribeWithOptions([]string{"test/leak/#"}, handler, messagebus.SubscribeOptions{Workers: 4, PriorityLanes: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Subscribe([]string{"test/other"}, handler); err != nil {
+		t.Fatal(err)
+	}
+	if result := <-client.PublishAsync("test/leak/a", "x"); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if err := client.PublishWithPriority("test/leak/b", "y", messagebus.PriorityHigh); err != nil {
+		t.Fatal(err)
+	}
+	periodic, err := client.NewPeriodicPublisher("test/leak/periodic", 10*time.Millisecond, func() (interface{}, error) { return "tick", nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer periodic.Close()
+
+	err = responder.RegisterRequestHandler("test/request", func(context.Context, types.MessageEnvelope) (interface{}, error) {
+		return "pong", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := client.CreateMessageEnvelope("ping", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Request(request, "test/request", "test/response", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Unsubscribe("test/other"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,132 @@
+package messagebus
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultShutdownTimeout 是未配置 ShutdownTimeout 时断开连接等待后台 goroutine 退出的总时长
+const defaultShutdownTimeout = 30 * time.Second
+
+// lifecycleStage 表示后台 goroutine 所属的停止阶段，按定义顺序依次停止
+type lifecycleStage int
+
+const (
+	stageReconnect lifecycleStage = iota // 重连与健康升级，最先停止以免关闭过程中再次建立连接
+	stagePublish                         // 发布侧后台任务，在订阅之前停止以便完成已排队的发布
+	stageSubscribe                       // 订阅处理与响应流，最后停止并排空缓冲消息
+	stageCount
+)
+
+var stageNames = [stageCount]string{"reconnect", "publish", "subscribe"}
+
+// stageGroup 记录一个阶段的停止信号和运行中的 goroutine
+type stageGroup struct {
+	stop    chan struct{}
+	running int           // 运行中的 goroutine 数，由 lifecycle.mutex 保护
+	idle    chan struct{} // running 降为 0 时关闭，按需创建
+}
+
+// lifecycle 统一管理客户端的后台 goroutine，断开连接时按阶段顺序停止
+type lifecycle struct {
+	mutex  sync.Mutex
+	groups [stageCount]*stageGroup
+}
+
+func newLifecycle() *lifecycle {
+	l := &lifecycle{}
+	l.reset()
+	return l
+}
+
+// reset 为所有阶段创建新的停止信号，必须在持有锁或初始化时调用
+func (l *lifecycle) reset() {
+	for i := range l.groups {
+		l.groups[i] = &stageGroup{stop: make(chan struct{})}
+	}
+}
+
+// spawn 在指定阶段启动后台 goroutine，fn 应在 stop 关闭后尽快返回
+// shutdown 进行中启动的 goroutine 归入正在停止的阶段，会收到已关闭的 stop 并被一同等待
+func (l *lifecycle) spawn(stage lifecycleStage, fn func(stop <-chan struct{})) {
+	l.mutex.Lock()
+	group := l.groups[stage]
+	group.running++
+	l.mutex.Unlock()
+	go func() {
+		defer l.done(group)
+		fn(group.stop)
+	}()
+}
+
+// done 记录 group 中的一个 goroutine 已退出
+func (l *lifecycle) done(group *stageGroup) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	group.running--
+	if group.running == 0 && group.idle != nil {
+		close(group.idle)
+		group.idle = nil
+	}
+}
+
+// idle 返回在 group 中没有运行中的 goroutine 时关闭的通道
+func (l *lifecycle) idle(group *stageGroup) <-chan struct{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if group.running == 0 {
+		closed := make(chan struct{})
+		close(closed)
+		return closed
+	}
+	if group.idle == nil {
+		group.idle = make(chan struct{})
+	}
+	return group.idle
+}
+
+// stopChan 返回指定阶段当前的停止信号
+func (l *lifecycle) stopChan(stage lifecycleStage) <-chan struct{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.groups[stage].stop
+}
+
+// shutdown 按阶段顺序发出停止信号并等待 goroutine 退出，所有阶段共享 timeout
+// 返回未能在超时内退出的阶段名称；等待结束后才换用新的停止信号，
+// 因此停止过程中启动的 goroutine（如触发的后台重连）同样会收到停止信号并被等待
+func (l *lifecycle) shutdown(timeout time.Duration) []string {
+	l.mutex.Lock()
+	groups := l.groups
+	l.mutex.Unlock()
+	defer func() {
+		l.mutex.Lock()
+		l.reset()
+		l.mutex.Unlock()
+	}()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	// 第一遍按阶段顺序发出停止信号并等待；第二遍复查，覆盖等待后续阶段期间在已停止阶段中新启动的 goroutine
+	for pass := 0; pass < 2; pass++ {
+		for i, group := range groups {
+			if pass == 0 {
+				close(group.stop)
+			}
+			select {
+			case <-l.idle(group):
+			case <-deadline.C:
+				// 超时后其余阶段只发出停止信号，不再等待
+				var stuck []string
+				for j := i; j < len(groups); j++ {
+					if pass == 0 && j > i {
+						close(groups[j].stop)
+					}
+					stuck = append(stuck, stageNames[j])
+				}
+				return stuck
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package messagebus
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLifecycleShutdownStopsGoroutinesSpawnedDuringShutdown(t *testing.T) {
+	l := newLifecycle()
+	var running atomic.Int32
+	track := func(stop <-chan struct{}) {
+		defer running.Add(-1)
+		<-stop
+	}
+	spawn := func(stage lifecycleStage, fn func(<-chan struct{})) {
+		running.Add(1)
+		l.spawn(stage, fn)
+	}
+
+	// 订阅阶段的 goroutine 在停止时触发后台重连，此时重连阶段已停止
+	spawn(stageSubscribe, func(stop <-chan struct{}) {
+		defer running.Add(-1)
+		<-stop
+		spawn(stageReconnect, track)
+		spawn(stageSubscribe, track)
+	})
+	if stuck := l.shutdown(time.Second); len(stuck) > 0 {
+		t.Fatalf("阶段 %v 未能退出", stuck)
+	}
+	if n := running.Load(); n != 0 {
+		t.Fatalf("shutdown 返回后仍有 %d 个 goroutine 在运行", n)
+	}
+
+	// shutdown 之后启动的 goroutine 使用新的停止信号
+	spawn(stageSubscribe, track)
+	select {
+	case <-l.stopChan(stageSubscribe):
+		t.Fatal("新的停止信号不应已关闭")
+	default:
+	}
+	if stuck := l.shutdown(time.Second); len(stuck) > 0 || running.Load() != 0 {
+		t.Fatalf("第二次 shutdown 未停止 goroutine: stuck=%v running=%d", stuck, running.Load())
+	}
+}
+
+func TestLifecycleShutdownTimeout(t *testing.T) {
+	l := newLifecycle()
+	release := make(chan struct{})
+	defer close(release)
+	l.spawn(stagePublish, func(<-chan struct{}) { <-release })
+	stopped := make(chan struct{})
+	l.spawn(stageSubscribe, func(stop <-chan struct{}) {
+		<-stop
+		close(stopped)
+	})
+	stuck := l.shutdown(50 * time.Millisecond)
+	if len(stuck) != 2 || stuck[0] != "publish" || stuck[1] != "subscribe" {
+		t.Fatalf("stuck = %v，期望 [publish subscribe]", stuck)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("超时后其余阶段也应收到停止信号")
+	}
+}
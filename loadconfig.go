@@ -0,0 +1,247 @@
+package messagebus
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix 是覆盖配置文件的环境变量前缀
+const EnvPrefix = "MESSAGEBUS_"
+
+// defaultConfig 返回未指定参数时使用的默认配置
+func defaultConfig() Config {
+	return Config{
+		Host:     "localhost",
+		Port:     1883,
+		Protocol: "tcp",
+		Type:     TypeMQTT,
+	}
+}
+
+// duration 支持以 "5s"、"1m30s" 形式在配置文件中书写时间间隔
+type duration time.Duration
+
+func (d *duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// fileConfig 是配置文件的结构，字段均为可选，未出现的字段保留默认值
+type fileConfig struct {
+	Host                   *string           `json:"host" yaml:"host" toml:"host"`
+	Port                   *int              `json:"port" yaml:"port" toml:"port"`
+	Protocol               *string           `json:"protocol" yaml:"protocol" toml:"protocol"`
+	Type                   *string           `json:"type" yaml:"type" toml:"type"`
+	ClientID               *string           `json:"clientId" yaml:"clientId" toml:"clientId"`
+	ClientIDPrefix         *string           `json:"clientIdPrefix" yaml:"clientIdPrefix" toml:"clientIdPrefix"`
+	Username               *string           `json:"username" yaml:"username" toml:"username"`
+	Password               *string           `json:"password" yaml:"password" toml:"password"`
+	QoS                    *int              `json:"qos" yaml:"qos" toml:"qos"`
+	CleanSession           *bool             `json:"cleanSession" yaml:"cleanSession" toml:"cleanSession"`
+	SessionExpiryInterval  *duration         `json:"sessionExpiryInterval" yaml:"sessionExpiryInterval" toml:"sessionExpiryInterval"`
+	CertFile               *string           `json:"certFile" yaml:"certFile" toml:"certFile"`
+	KeyFile                *string           `json:"keyFile" yaml:"keyFile" toml:"keyFile"`
+	CAFile                 *string           `json:"caFile" yaml:"caFile" toml:"caFile"`
+	SkipCertVerify         *bool             `json:"skipCertVerify" yaml:"skipCertVerify" toml:"skipCertVerify"`
+	ProxyURL               *string           `json:"proxyUrl" yaml:"proxyUrl" toml:"proxyUrl"`
+	Tags                   map[string]string `json:"tags" yaml:"tags" toml:"tags"`
+	ContractVersion        *string           `json:"contractVersion" yaml:"contractVersion" toml:"contractVersion"`
+	MaxInFlightBytes       *int64            `json:"maxInFlightBytes" yaml:"maxInFlightBytes" toml:"maxInFlightBytes"`
+	ResponseTopicPrefix    *string           `json:"responseTopicPrefix" yaml:"responseTopicPrefix" toml:"responseTopicPrefix"`
+	HealthFailureThreshold *int              `json:"healthFailureThreshold" yaml:"healthFailureThreshold" toml:"healthFailureThreshold"`
+	ReconnectOnUnhealthy   *bool             `json:"reconnectOnUnhealthy" yaml:"reconnectOnUnhealthy" toml:"reconnectOnUnhealthy"`
+	HealthProbeTimeout     *duration         `json:"healthProbeTimeout" yaml:"healthProbeTimeout" toml:"healthProbeTimeout"`
+	ShutdownTimeout        *duration         `json:"shutdownTimeout" yaml:"shutdownTimeout" toml:"shutdownTimeout"`
+	DrainTimeout           *duration         `json:"drainTimeout" yaml:"drainTimeout" toml:"drainTimeout"`
+	WebSocket              *struct {
+		Path    *string             `json:"path" yaml:"path" toml:"path"`
+		Headers map[string][]string `json:"headers" yaml:"headers" toml:"headers"`
+	} `json:"webSocket" yaml:"webSocket" toml:"webSocket"`
+	ClientIDCollision *struct {
+		Detect    *bool     `json:"detect" yaml:"detect" toml:"detect"`
+		Threshold *int      `json:"threshold" yaml:"threshold" toml:"threshold"`
+		Window    *duration `json:"window" yaml:"window" toml:"window"`
+		Resolve   *bool     `json:"resolve" yaml:"resolve" toml:"resolve"`
+	} `json:"clientIdCollision" yaml:"clientIdCollision" toml:"clientIdCollision"`
+	Reconnect *struct {
+		Enabled      *bool     `json:"enabled" yaml:"enabled" toml:"enabled"`
+		InitialDelay *duration `json:"initialDelay" yaml:"initialDelay" toml:"initialDelay"`
+		MaxDelay     *duration `json:"maxDelay" yaml:"maxDelay" toml:"maxDelay"`
+		Jitter       *float64  `json:"jitter" yaml:"jitter" toml:"jitter"`
+		MaxAttempts  *int      `json:"maxAttempts" yaml:"maxAttempts" toml:"maxAttempts"`
+	} `json:"reconnect" yaml:"reconnect" toml:"reconnect"`
+}
+
+// LoadConfig 从配置文件和环境变量加载客户端配置，并在返回前调用 Validate
+//
+// 文件格式按扩展名识别（.yaml/.yml、.toml、.json），path 为空时只使用默认值和环境变量。
+// 环境变量优先于配置文件，支持 MESSAGEBUS_HOST、MESSAGEBUS_PORT、MESSAGEBUS_PROTOCOL、
+// MESSAGEBUS_TYPE、MESSAGEBUS_CLIENT_ID、MESSAGEBUS_CLIENT_ID_PREFIX、MESSAGEBUS_USERNAME、MESSAGEBUS_PASSWORD、
+// MESSAGEBUS_QOS、MESSAGEBUS_CERT_FILE、MESSAGEBUS_KEY_FILE、MESSAGEBUS_CA_FILE、
+// MESSAGEBUS_SKIP_CERT_VERIFY 和 MESSAGEBUS_PROXY_URL。
+func LoadConfig(path string) (Config, error) {
+	config := defaultConfig()
+	if path != "" {
+		if err := loadConfigFile(path, &config); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := applyEnvOverrides(&config); err != nil {
+		return Config{}, err
+	}
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// loadConfigFile 解析配置文件并合并到 config 中
+func loadConfigFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	var fc fileConfig
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fc)
+	case ".toml":
+		err = toml.Unmarshal(data, &fc)
+	case ".json":
+		err = json.Unmarshal(data, &fc)
+	default:
+		return fmt.Errorf("不支持的配置文件格式: %s", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+	}
+	fc.apply(config)
+	return nil
+}
+
+// apply 将文件中出现的字段写入 config
+func (fc fileConfig) apply(config *Config) {
+	setIf(&config.Host, fc.Host)
+	setIf(&config.Port, fc.Port)
+	setIf(&config.Protocol, fc.Protocol)
+	setIf(&config.Type, fc.Type)
+	setIf(&config.ClientID, fc.ClientID)
+	setIf(&config.ClientIDPrefix, fc.ClientIDPrefix)
+	setIf(&config.Username, fc.Username)
+	setIf(&config.Password, fc.Password)
+	setIf(&config.QoS, fc.QoS)
+	if fc.CleanSession != nil {
+		config.CleanSession = CleanSessionFlag(*fc.CleanSession)
+	}
+	setDurationIf(&config.SessionExpiryInterval, fc.SessionExpiryInterval)
+	setIf(&config.CertFile, fc.CertFile)
+	setIf(&config.KeyFile, fc.KeyFile)
+	setIf(&config.CAFile, fc.CAFile)
+	setIf(&config.SkipCertVerify, fc.SkipCertVerify)
+	setIf(&config.ProxyURL, fc.ProxyURL)
+	if fc.Tags != nil {
+		config.Tags = fc.Tags
+	}
+	if fc.ContractVersion != nil {
+		config.ContractVersion = ContractVersion(*fc.ContractVersion)
+	}
+	setIf(&config.MaxInFlightBytes, fc.MaxInFlightBytes)
+	setIf(&config.ResponseTopicPrefix, fc.ResponseTopicPrefix)
+	setIf(&config.HealthFailureThreshold, fc.HealthFailureThreshold)
+	setIf(&config.ReconnectOnUnhealthy, fc.ReconnectOnUnhealthy)
+	setDurationIf(&config.HealthProbeTimeout, fc.HealthProbeTimeout)
+	setDurationIf(&config.ShutdownTimeout, fc.ShutdownTimeout)
+	setDurationIf(&config.DrainTimeout, fc.DrainTimeout)
+	if ws := fc.WebSocket; ws != nil {
+		setIf(&config.WebSocket.Path, ws.Path)
+		if ws.Headers != nil {
+			config.WebSocket.Headers = make(http.Header, len(ws.Headers))
+			for name, values := range ws.Headers {
+				for _, value := range values {
+					config.WebSocket.Headers.Add(name, value)
+				}
+			}
+		}
+	}
+	if cc := fc.ClientIDCollision; cc != nil {
+		setIf(&config.ClientIDCollision.Detect, cc.Detect)
+		setIf(&config.ClientIDCollision.Threshold, cc.Threshold)
+		setDurationIf(&config.ClientIDCollision.Window, cc.Window)
+		setIf(&config.ClientIDCollision.Resolve, cc.Resolve)
+	}
+	if r := fc.Reconnect; r != nil {
+		setIf(&config.Reconnect.Enabled, r.Enabled)
+		setDurationIf(&config.Reconnect.InitialDelay, r.InitialDelay)
+		setDurationIf(&config.Reconnect.MaxDelay, r.MaxDelay)
+		setIf(&config.Reconnect.Jitter, r.Jitter)
+		setIf(&config.Reconnect.MaxAttempts, r.MaxAttempts)
+	}
+}
+
+func setIf[T any](dst *T, src *T) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setDurationIf(dst *time.Duration, src *duration) {
+	if src != nil {
+		*dst = time.Duration(*src)
+	}
+}
+
+// applyEnvOverrides 使用 MESSAGEBUS_ 前缀的环境变量覆盖配置
+func applyEnvOverrides(config *Config) error {
+	strs := map[string]*string{
+		"HOST":             &config.Host,
+		"PROTOCOL":         &config.Protocol,
+		"TYPE":             &config.Type,
+		"CLIENT_ID":        &config.ClientID,
+		"CLIENT_ID_PREFIX": &config.ClientIDPrefix,
+		"USERNAME":         &config.Username,
+		"PASSWORD":         &config.Password,
+		"CERT_FILE":        &config.CertFile,
+		"KEY_FILE":         &config.KeyFile,
+		"CA_FILE":          &config.CAFile,
+		"PROXY_URL":        &config.ProxyURL,
+	}
+	for name, dst := range strs {
+		if value, ok := os.LookupEnv(EnvPrefix + name); ok {
+			*dst = value
+		}
+	}
+	ints := map[string]*int{
+		"PORT": &config.Port,
+		"QOS":  &config.QoS,
+	}
+	for name, dst := range ints {
+		if value, ok := os.LookupEnv(EnvPrefix + name); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("环境变量 %s%s 不是整数: %q", EnvPrefix, name, value)
+			}
+			*dst = n
+		}
+	}
+	if value, ok := os.LookupEnv(EnvPrefix + "SKIP_CERT_VERIFY"); ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("环境变量 %sSKIP_CERT_VERIFY 不是布尔值: %q", EnvPrefix, value)
+		}
+		config.SkipCertVerify = b
+	}
+	return nil
+}
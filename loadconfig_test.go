@@ -0,0 +1,138 @@
+package messagebus_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+)
+
+func TestLoadConfigFileFormats(t *testing.T) {
+	files := map[string]string{
+		"bus.yaml": `
+host: broker.local
+port: 8883
+protocol: tls
+clientId: gateway
+qos: 1
+healthProbeTimeout: 3s
+tags:
+  site: s42
+reconnect:
+  enabled: true
+  initialDelay: 500ms
+  maxAttempts: 5
+`,
+		"bus.toml": `
+host = "broker.local"
+port = 8883
+protocol = "tls"
+clientId = "gateway"
+qos = 1
+healthProbeTimeout = "3s"
+[tags]
+site = "s42"
+[reconnect]
+enabled = true
+initialDelay = "500ms"
+maxAttempts = 5
+`,
+		"bus.json": `{
+  "host": "broker.local", "port": 8883, "protocol": "tls", "clientId": "gateway", "qos": 1,
+  "healthProbeTimeout": "3s", "tags": {"site": "s42"},
+  "reconnect": {"enabled": true, "initialDelay": "500ms", "maxAttempts": 5}
+}`,
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			config, err := messagebus.LoadConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Host != "broker.local" || config.Port != 8883 || config.Protocol != "tls" || config.ClientID != "gateway" || config.QoS != 1 {
+				t.Errorf("连接参数 = %s:%d %s %s QoS %d", config.Host, config.Port, config.Protocol, config.ClientID, config.QoS)
+			}
+			// 文件中未出现的字段保留默认值
+			if config.Type != messagebus.TypeMQTT {
+				t.Errorf("Type = %q，期望默认的 mqtt", config.Type)
+			}
+			if config.HealthProbeTimeout != 3*time.Second || config.Tags["site"] != "s42" {
+				t.Errorf("HealthProbeTimeout = %s, Tags = %v", config.HealthProbeTimeout, config.Tags)
+			}
+			if r := config.Reconnect; !r.Enabled || r.InitialDelay != 500*time.Millisecond || r.MaxAttempts != 5 {
+				t.Errorf("Reconnect = %+v", r)
+			}
+		})
+	}
+}
+
+func TestLoadConfigEnvOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bus.yaml")
+	if err := os.WriteFile(path, []byte("host: file.local\nport: 1883\nclientId: from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MESSAGEBUS_HOST", "env.local")
+	t.Setenv("MESSAGEBUS_PORT", "11883")
+	t.Setenv("MESSAGEBUS_QOS", "2")
+	config, err := messagebus.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "env.local" || config.Port != 11883 || config.QoS != 2 {
+		t.Errorf("环境变量应覆盖配置文件，实际 %s:%d QoS %d", config.Host, config.Port, config.QoS)
+	}
+	if config.ClientID != "from-file" {
+		t.Errorf("未被环境变量覆盖的字段应保留文件中的值，实际 ClientID = %q", config.ClientID)
+	}
+
+	// 只使用默认值和环境变量
+	config, err = messagebus.LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "env.local" || config.Protocol != "tcp" {
+		t.Errorf("无配置文件时 = %s %s", config.Host, config.Protocol)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	cases := []struct {
+		name    string
+		path    string
+		env     string
+		problem string
+	}{
+		{"unsupported format", write("bus.ini", "host=x"), "", "不支持的配置文件格式"},
+		{"malformed", write("bad.json", "{"), "", "解析配置文件"},
+		{"missing file", filepath.Join(dir, "missing.yaml"), "", "读取配置文件失败"},
+		{"bad env", "", "not-a-number", "不是整数"},
+		{"invalid config", write("invalid.yaml", "port: 70000\n"), "", "Port"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.env != "" {
+				t.Setenv("MESSAGEBUS_PORT", tc.env)
+			}
+			_, err := messagebus.LoadConfig(tc.path)
+			if err == nil || !strings.Contains(err.Error(), tc.problem) {
+				t.Errorf("错误 %v 应包含 %q", err, tc.problem)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package messagebus
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// LogComponent 表示可以单独设置日志级别的客户端组件
+type LogComponent string
+
+const (
+	LogConnection LogComponent = "connection" // 连接、断开、重连、凭据与健康检查
+	LogPublish    LogComponent = "publish"    // 发布、请求、离线转发与熔断
+	LogSubscribe  LogComponent = "subscribe"  // 订阅、消息处理、重试与重投
+	LogPayload    LogComponent = "payload"    // 收发消息的 Payload 转储，级别为 DEBUG 或 TRACE 时启用
+)
+
+// defaultLogPayloadBytes 是 Payload 转储未设置 PayloadMaxBytes 时保留的字节数
+const defaultLogPayloadBytes = 256
+
+// redactedValue 是脱敏字段替换后的值
+const redactedValue = "***"
+
+// logLevels 按严重程度从低到高排列的 EdgeX 日志级别
+var logLevels = []string{models.TraceLog, models.DebugLog, models.InfoLog, models.WarnLog, models.ErrorLog}
+
+// LogConfig 表示按组件的日志级别和 Payload 转储参数
+type LogConfig struct {
+	// Levels 各组件的最低日志级别 (TRACE, DEBUG, INFO, WARN, ERROR)，在日志客户端自身级别之上再次过滤，
+	// 未设置的组件只按日志客户端级别输出；LogPayload 未设置时不转储 Payload
+	Levels map[LogComponent]string
+	// PayloadMaxBytes Payload 转储保留的最大字节数，超出部分截断，默认 256
+	PayloadMaxBytes int
+	// RedactFields JSON Payload 中需要脱敏的字段名（任意层级），转储时值替换为 ***
+	RedactFields []string
+	// Redact 自定义脱敏函数，在 RedactFields 之后、截断之前调用
+	Redact func(topic string, payload []byte) []byte
+}
+
+// validate 校验日志参数
+func (l LogConfig) validate() error {
+	for component, level := range l.Levels {
+		switch component {
+		case LogConnection, LogPublish, LogSubscribe, LogPayload:
+		default:
+			return fmt.Errorf("不支持的日志组件: %s", component)
+		}
+		if levelRankOf(level) < 0 {
+			return fmt.Errorf("组件 %s 的日志级别 %q 无效", component, level)
+		}
+	}
+	if l.PayloadMaxBytes < 0 {
+		return fmt.Errorf("PayloadMaxBytes 不能为负数")
+	}
+	return nil
+}
+
+// levelRankOf 返回日志级别的严重程度序号，无效级别返回 -1
+func levelRankOf(level string) int {
+	for i, l := range logLevels {
+		if strings.EqualFold(level, l) {
+			return i
+		}
+	}
+	return -1
+}
+
+// componentLogger 为日志附加 component 字段，并按组件级别过滤
+type componentLogger struct {
+	base      logger.LoggingClient
+	component LogComponent
+	level     atomic.Int32 // 最低输出级别序号，-1 表示不额外过滤
+}
+
+// newComponentLoggers 为每个组件创建日志客户端
+func newComponentLoggers(base logger.LoggingClient, config LogConfig) map[LogComponent]logger.LoggingClient {
+	loggers := make(map[LogComponent]logger.LoggingClient)
+	for _, component := range []LogComponent{LogConnection, LogPublish, LogSubscribe, LogPayload} {
+		level := -1
+		if l, ok := config.Levels[component]; ok {
+			level = levelRankOf(l)
+		}
+		l := &componentLogger{base: base, component: component}
+		l.level.Store(int32(level))
+		loggers[component] = l
+	}
+	return loggers
+}
+
+func (l *componentLogger) enabled(level string) bool {
+	threshold := int(l.level.Load())
+	return threshold < 0 || levelRankOf(level) >= threshold
+}
+
+func (l *componentLogger) fields(args []interface{}) []interface{} {
+	return append(args, "component", string(l.component))
+}
+
+// SetLogLevel 设置组件的最低日志级别
+func (l *componentLogger) SetLogLevel(level string) error {
+	rank := levelRankOf(level)
+	if rank < 0 {
+		return fmt.Errorf("日志级别 %q 无效", level)
+	}
+	l.level.Store(int32(rank))
+	return nil
+}
+
+// LogLevel 返回组件的日志级别，未单独设置时返回日志客户端的级别
+func (l *componentLogger) LogLevel() string {
+	threshold := l.level.Load()
+	if threshold < 0 {
+		return l.base.LogLevel()
+	}
+	return logLevels[threshold]
+}
+
+func (l *componentLogger) Trace(msg string, args ...interface{}) {
+	if l.enabled(models.TraceLog) {
+		l.base.Trace(msg, l.fields(args)...)
+	}
+}
+
+func (l *componentLogger) Debug(msg string, args ...interface{}) {
+	if l.enabled(models.DebugLog) {
+		l.base.Debug(msg, l.fields(args)...)
+	}
+}
+
+func (l *componentLogger) Info(msg string, args ...interface{}) {
+	if l.enabled(models.InfoLog) {
+		l.base.Info(msg, l.fields(args)...)
+	}
+}
+
+func (l *componentLogger) Warn(msg string, args ...interface{}) {
+	if l.enabled(models.WarnLog) {
+		l.base.Warn(msg, l.fields(args)...)
+	}
+}
+
+func (l *componentLogger) Error(msg string, args ...interface{}) {
+	if l.enabled(models.ErrorLog) {
+		l.base.Error(msg, l.fields(args)...)
+	}
+}
+
+func (l *componentLogger) Tracef(msg string, args ...interface{}) {
+	if l.enabled(models.TraceLog) {
+		l.base.Tracef(msg, args...)
+	}
+}
+
+func (l *componentLogger) Debugf(msg string, args ...interface{}) {
+	if l.enabled(models.DebugLog) {
+		l.base.Debugf(msg, args...)
+	}
+}
+
+func (l *componentLogger) Infof(msg string, args ...interface{}) {
+	if l.enabled(models.InfoLog) {
+		l.base.Infof(msg, args...)
+	}
+}
+
+func (l *componentLogger) Warnf(msg string, args ...interface{}) {
+	if l.enabled(models.WarnLog) {
+		l.base.Warnf(msg, args...)
+	}
+}
+
+func (l *componentLogger) Errorf(msg string, args ...interface{}) {
+	if l.enabled(models.ErrorLog) {
+		l.base.Errorf(msg, args...)
+	}
+}
+
+// log 返回组件的日志客户端
+func (c *Client) log(component LogComponent) logger.LoggingClient {
+	if l, ok := c.loggers[component]; ok {
+		return l
+	}
+	return c.lc
+}
+
+// payloadDumpEnabled 判断是否需要转储 Payload，仅在 LogPayload 级别显式设置为 DEBUG 或 TRACE 时启用
+func (c *Client) payloadDumpEnabled() bool {
+	level, ok := c.config.Logging.Levels[LogPayload]
+	return ok && levelRankOf(level) <= levelRankOf(models.DebugLog)
+}
+
+// dumpPayload 按脱敏和截断规则以 DEBUG 级别记录收发消息的 Payload，direction 为 publish 或 receive
+func (c *Client) dumpPayload(direction, topic string, envelope types.MessageEnvelope) {
+	if !c.payloadDumpEnabled() {
+		return
+	}
+	read := withPayloadBytes
+	if direction == "publish" {
+		read = withOutboundPayloadBytes
+	}
+	_ = read(envelope.Payload, func(data []byte) error {
+		c.log(LogPayload).Debug("消息内容", c.logFields("op", direction, "topic", topic, "correlationId", envelope.CorrelationID,
+			"contentType", envelope.ContentType, "size", len(data), "payload", c.redactPayload(topic, data))...)
+		return nil
+	})
+}
+
+// redactPayload 对 Payload 脱敏并截断，返回可记录的字符串
+func (c *Client) redactPayload(topic string, data []byte) string {
+	cfg := c.config.Logging
+	if len(cfg.RedactFields) > 0 {
+		data = redactJSON(data, cfg.RedactFields)
+	}
+	if cfg.Redact != nil {
+		data = cfg.Redact(topic, data)
+	}
+	limit := cfg.PayloadMaxBytes
+	if limit == 0 {
+		limit = defaultLogPayloadBytes
+	}
+	if len(data) > limit {
+		return fmt.Sprintf("%s...(已截断 %d 字节)", data[:limit], len(data)-limit)
+	}
+	return string(data)
+}
+
+// redactJSON 将 JSON 中任意层级的指定字段值替换为 ***，非 JSON 数据原样返回
+func redactJSON(data []byte, fields []string) []byte {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return data
+	}
+	names := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		names[field] = struct{}{}
+	}
+	redacted, err := json.Marshal(redactValue(value, names))
+	if err != nil {
+		return data
+	}
+	return redacted
+}
+
+func redactValue(value interface{}, names map[string]struct{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if _, ok := names[key]; ok {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactValue(child, names)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactValue(child, names)
+		}
+	}
+	return value
+}
@@ -0,0 +1,238 @@
+package messagebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Manager 管理多个具名客户端（例如 internal、cloud、legacy），统一连接、断开和健康检查，并按名称或主题路由发布
+// 适用于同时对接多条消息总线的网关；Manager 只持有客户端，不修改它们的配置
+type Manager struct {
+	mutex   sync.RWMutex
+	clients map[string]*Client
+	names   []string       // 添加顺序，ConnectAll 按此顺序连接，DisconnectAll 逆序断开
+	routes  []managerRoute // 按添加顺序匹配的主题路由
+}
+
+// managerRoute 表示主题模式到客户端名称的路由
+type managerRoute struct {
+	pattern string
+	name    string
+}
+
+// NewManager 创建一个空的客户端管理器
+func NewManager() *Manager {
+	return &Manager{clients: make(map[string]*Client)}
+}
+
+// Add 以 name 登记已创建的客户端，名称重复时返回错误
+func (m *Manager) Add(name string, client *Client) error {
+	if name == "" {
+		return fmt.Errorf("客户端名称不能为空")
+	}
+	if client == nil {
+		return fmt.Errorf("客户端 %s 不能为空", name)
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.clients[name]; ok {
+		return fmt.Errorf("客户端 %s 已存在", name)
+	}
+	m.clients[name] = client
+	m.names = append(m.names, name)
+	return nil
+}
+
+// Create 使用函数式选项创建客户端并以 name 登记，不建立连接
+func (m *Manager) Create(name string, opts ...Option) (*Client, error) {
+	client, err := NewClientWithOptions(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("创建客户端 %s 失败: %w", name, err)
+	}
+	if err := m.Add(name, client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// Remove 移除客户端及指向它的路由并返回该客户端，不会断开其连接
+func (m *Manager) Remove(name string) (*Client, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	client, ok := m.clients[name]
+	if !ok {
+		return nil, false
+	}
+	delete(m.clients, name)
+	for i, n := range m.names {
+		if n == name {
+			m.names = append(m.names[:i:i], m.names[i+1:]...)
+			break
+		}
+	}
+	routes := m.routes[:0:0]
+	for _, route := range m.routes {
+		if route.name != name {
+			routes = append(routes, route)
+		}
+	}
+	m.routes = routes
+	return client, true
+}
+
+// Client 返回名为 name 的客户端，不存在时返回 ErrClientNotFound
+func (m *Manager) Client(name string) (*Client, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	client, ok := m.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrClientNotFound, name)
+	}
+	return client, nil
+}
+
+// Names 按添加顺序返回所有客户端名称
+func (m *Manager) Names() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return append([]string(nil), m.names...)
+}
+
+// snapshot 按添加顺序返回名称和客户端的快照，遍历时不持有锁
+func (m *Manager) snapshot() ([]string, []*Client) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	names := append([]string(nil), m.names...)
+	clients := make([]*Client, len(names))
+	for i, name := range names {
+		clients[i] = m.clients[name]
+	}
+	return names, clients
+}
+
+// ConnectAll 按添加顺序连接所有客户端；某个客户端连接失败时继续连接其余客户端，
+// 返回汇总了各客户端失败原因的错误，已连接的客户端保持连接
+func (m *Manager) ConnectAll() error {
+	names, clients := m.snapshot()
+	var errs []error
+	for i, client := range clients {
+		if err := client.Connect(); err != nil {
+			errs = append(errs, fmt.Errorf("连接客户端 %s 失败: %w", names[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DisconnectAll 按添加顺序的逆序断开所有客户端，返回汇总了各客户端失败原因的错误
+func (m *Manager) DisconnectAll() error {
+	return m.disconnectAll(func(client *Client) error { return client.Disconnect() })
+}
+
+// DisconnectAllWithContext 按添加顺序的逆序平滑断开所有客户端（见 Client.DisconnectWithContext），各客户端共享 ctx 的截止时间
+func (m *Manager) DisconnectAllWithContext(ctx context.Context) error {
+	return m.disconnectAll(func(client *Client) error { return client.DisconnectWithContext(ctx) })
+}
+
+// CloseAll 按添加顺序的逆序关闭所有客户端（见 Client.Close），各客户端的错误通道随之关闭
+func (m *Manager) CloseAll() error {
+	return m.disconnectAll(func(client *Client) error { return client.Close() })
+}
+
+// disconnectAll 按添加顺序的逆序对每个客户端调用 disconnect
+func (m *Manager) disconnectAll(disconnect func(*Client) error) error {
+	names, clients := m.snapshot()
+	var errs []error
+	for i := len(clients) - 1; i >= 0; i-- {
+		if err := disconnect(clients[i]); err != nil {
+			errs = append(errs, fmt.Errorf("断开客户端 %s 失败: %w", names[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HealthCheckAll 并发调用各客户端的 HealthCheck，返回名称到检查结果的映射，健康的客户端对应 nil
+func (m *Manager) HealthCheckAll() map[string]error {
+	names, clients := m.snapshot()
+	results := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func(i int, client *Client) {
+			defer wg.Done()
+			results[i] = client.HealthCheck()
+		}(i, client)
+	}
+	wg.Wait()
+	health := make(map[string]error, len(names))
+	for i, name := range names {
+		health[name] = results[i]
+	}
+	return health
+}
+
+// Healthy 在所有客户端健康检查均通过时返回 nil，否则返回汇总了失败客户端的错误
+func (m *Manager) Healthy() error {
+	health := m.HealthCheckAll()
+	var errs []error
+	for _, name := range m.Names() {
+		if err := health[name]; err != nil {
+			errs = append(errs, fmt.Errorf("客户端 %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Publish 经名为 name 的客户端发布消息
+func (m *Manager) Publish(name, topic string, data interface{}) error {
+	client, err := m.Client(name)
+	if err != nil {
+		return err
+	}
+	return client.Publish(topic, data)
+}
+
+// PublishWithContext 经名为 name 的客户端发布消息，ctx 控制等待确认的时长
+func (m *Manager) PublishWithContext(ctx context.Context, name, topic string, data interface{}) error {
+	client, err := m.Client(name)
+	if err != nil {
+		return err
+	}
+	return client.PublishWithContext(ctx, topic, data)
+}
+
+// Route 将匹配 pattern 的主题路由到名为 name 的客户端，pattern 支持通配符；路由按添加顺序匹配
+func (m *Manager) Route(pattern, name string) error {
+	if pattern == "" {
+		return fmt.Errorf("主题模式不能为空")
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.clients[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrClientNotFound, name)
+	}
+	m.routes = append(m.routes, managerRoute{pattern: pattern, name: name})
+	return nil
+}
+
+// ClientFor 返回第一条匹配 topic 的路由指向的客户端及其名称，没有匹配的路由时返回 ErrClientNotFound
+func (m *Manager) ClientFor(topic string) (string, *Client, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for _, route := range m.routes {
+		if TopicMatches(route.pattern, topic) {
+			return route.name, m.clients[route.name], nil
+		}
+	}
+	return "", nil, fmt.Errorf("%w: 没有匹配主题 %s 的路由", ErrClientNotFound, topic)
+}
+
+// PublishRouted 按 Route 登记的路由选择客户端发布消息
+func (m *Manager) PublishRouted(topic string, data interface{}) error {
+	_, client, err := m.ClientFor(topic)
+	if err != nil {
+		return err
+	}
+	return client.Publish(topic, data)
+}
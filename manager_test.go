@@ -0,0 +1,128 @@
+package messagebus_test
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+func TestManagerRoutesPublishesByTopic(t *testing.T) {
+	internal, cloud := messagebustest.NewBroker(), messagebustest.NewBroker()
+	manager := messagebus.NewManager()
+	t.Cleanup(func() { _ = manager.CloseAll() })
+	for name, broker := range map[string]*messagebustest.Broker{"internal": internal, "cloud": cloud} {
+		_, err := manager.Create(name, messagebus.WithConfig(testConfig()), messagebus.WithLogger(logger.NewMockClient()),
+			messagebus.WithMessageClientFactory(broker.MessageClientFactory(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := manager.ConnectAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Route("edgex/#", "internal"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Route("#", "cloud"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Route("other/#", "missing"); !errors.Is(err, messagebus.ErrClientNotFound) {
+		t.Errorf("路由到不存在的客户端应返回 ErrClientNotFound，实际 %v", err)
+	}
+
+	if err := manager.PublishRouted("edgex/events/d1", "local"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.PublishRouted("site42/events/d1", "remote"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Publish("cloud", "edgex/events/d2", "direct"); err != nil {
+		t.Fatal(err)
+	}
+	if got := publishedTopics(internal); got != "edgex/events/d1" {
+		t.Errorf("internal 收到 %s", got)
+	}
+	if got := publishedTopics(cloud); got != "site42/events/d1,edgex/events/d2" {
+		t.Errorf("cloud 收到 %s", got)
+	}
+	for name, err := range manager.HealthCheckAll() {
+		if err != nil {
+			t.Errorf("%s 健康检查失败: %v", name, err)
+		}
+	}
+
+	// 移除客户端时一并移除指向它的路由，按添加顺序匹配下一条路由
+	removed, ok := manager.Remove("internal")
+	if !ok {
+		t.Fatal("Remove 应返回已登记的客户端")
+	}
+	t.Cleanup(func() { _ = removed.Close() })
+	if name, _, err := manager.ClientFor("edgex/events/d1"); err != nil || name != "cloud" {
+		t.Errorf("移除后 ClientFor = %q, %v，期望 cloud", name, err)
+	}
+	if _, err := manager.Client("internal"); !errors.Is(err, messagebus.ErrClientNotFound) {
+		t.Errorf("移除后 Client 应返回 ErrClientNotFound，实际 %v", err)
+	}
+
+	if err := manager.DisconnectAll(); err != nil {
+		t.Fatal(err)
+	}
+	if client, _ := manager.Client("cloud"); client.IsConnected() {
+		t.Error("DisconnectAll 后客户端仍处于连接状态")
+	}
+}
+
+func TestManagerConnectAllContinuesAfterFailure(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	manager := messagebus.NewManager()
+	t.Cleanup(func() { _ = manager.CloseAll() })
+	var failures atomic.Int32
+	failures.Store(-1)
+	factory := broker.MessageClientFactory("down")
+	_, err := manager.Create("down", messagebus.WithConfig(testConfig()), messagebus.WithLogger(logger.NewMockClient()),
+		messagebus.WithMessageClientFactory(func(busConfig types.MessageBusConfig) (messaging.MessageClient, error) {
+			inner, err := factory(busConfig)
+			if err != nil {
+				return nil, err
+			}
+			return &flakyClient{MessageClient: inner, failures: &failures}, nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	up, err := manager.Create("up", messagebus.WithConfig(testConfig()), messagebus.WithLogger(logger.NewMockClient()),
+		messagebus.WithMessageClientFactory(broker.MessageClientFactory("up")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Add("up", up); err == nil || !strings.Contains(err.Error(), "已存在") {
+		t.Errorf("名称重复时 Add 应失败，实际 %v", err)
+	}
+
+	err = manager.ConnectAll()
+	if err == nil || !strings.Contains(err.Error(), "down") {
+		t.Fatalf("ConnectAll 应返回 down 的连接错误，实际 %v", err)
+	}
+	if !up.IsConnected() {
+		t.Error("其他客户端应继续连接")
+	}
+	if err := manager.Healthy(); err == nil || !strings.Contains(err.Error(), "down") {
+		t.Errorf("Healthy 应报告 down 不健康，实际 %v", err)
+	}
+}
+
+// publishedTopics 按发布顺序返回 Broker 上的主题，以逗号分隔
+func publishedTopics(broker *messagebustest.Broker) string {
+	var topics []string
+	for _, msg := range broker.Published() {
+		topics = append(topics, msg.Topic)
+	}
+	return strings.Join(topics, ",")
+}
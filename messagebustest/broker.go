@@ -0,0 +1,290 @@
+// Package messagebustest 提供无需 Broker 的内存 MessageBus 实现，用于单元测试发布、订阅和请求-响应路径
+package messagebustest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+)
+
+// Message 表示经内存 Broker 传递的一条消息
+type Message struct {
+	Topic    string                // 发布的主题
+	Envelope types.MessageEnvelope // 订阅方收到的信封（已按 JSON 往返，与真实 Broker 一致）
+	Binary   bool                  // 是否通过 PublishBinaryData 发布
+}
+
+// Broker 是内存消息代理，支持 MQTT (+ #) 与 NATS (* >) 通配符订阅
+// 多个客户端共享同一个 Broker 时可以互相收发消息；$share/<group>/<filter> 共享订阅与设置了 QueueGroup 的 NATS
+// 队列组订阅按轮询方式只投递给同组的一个订阅
+type Broker struct {
+	mutex      sync.Mutex
+	clients    map[*memoryClient]struct{}
+	published  []Message
+	deliveries []delivery
+	shared     map[string]int // 按共享订阅主题或队列组记录已投递的消息数，用于轮询
+}
+
+// NewBroker 创建内存 Broker
+func NewBroker() *Broker {
+	return &Broker{clients: make(map[*memoryClient]struct{}), shared: make(map[string]int)}
+}
+
+// MessageClientFactory 返回创建连接到该 Broker 的底层客户端的工厂，可用于 messagebus.Config.MessageClientFactory
+func (b *Broker) MessageClientFactory(owner string) func(types.MessageBusConfig) (messaging.MessageClient, error) {
+	return func(config types.MessageBusConfig) (messaging.MessageClient, error) {
+		return &memoryClient{broker: b, owner: owner, queueGroup: config.Optional["QueueGroup"], subscriptions: make(map[string]memorySubscription)}, nil
+	}
+}
+
+// Published 返回所有已发布的消息，按发布顺序排列
+func (b *Broker) Published() []Message {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]Message(nil), b.published...)
+}
+
+// Reset 清空已发布和已投递的消息记录
+func (b *Broker) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.published = nil
+	b.deliveries = nil
+}
+
+// publish 记录消息并投递给所有匹配的订阅
+func (b *Broker) publish(topic string, data []byte, binary bool) error {
+	var envelope types.MessageEnvelope
+	if binary {
+		envelope = types.MessageEnvelope{Payload: data}
+	} else if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("解码信封失败: %w", err)
+	}
+	envelope.ReceivedTopic = topic
+
+	b.mutex.Lock()
+	b.published = append(b.published, Message{Topic: topic, Envelope: envelope, Binary: binary})
+	var targets []memorySubscription
+	for client := range b.clients {
+		targets = append(targets, client.matching(topic)...)
+	}
+	targets = b.pickShared(targets)
+	b.mutex.Unlock()
+
+	for _, sub := range targets {
+		msg := envelope
+		if sub.binary && !binary {
+			msg = types.MessageEnvelope{Payload: data, ReceivedTopic: topic}
+		}
+		sub.client.recordReceived(msg)
+		sub.messages <- msg
+	}
+	return nil
+}
+
+// pickShared 对同一共享订阅主题的多个订阅只保留一个，按轮询选择；调用方需持有 b.mutex
+func (b *Broker) pickShared(targets []memorySubscription) []memorySubscription {
+	groups := make(map[string][]memorySubscription)
+	var picked []memorySubscription
+	for _, sub := range targets {
+		switch {
+		case strings.HasPrefix(sub.topic, "$share/"):
+			groups[sub.topic] = append(groups[sub.topic], sub)
+		case sub.client.queueGroup != "":
+			key := "queue:" + sub.client.queueGroup + "/" + sub.topic
+			groups[key] = append(groups[key], sub)
+		default:
+			picked = append(picked, sub)
+		}
+	}
+	for topic, subs := range groups {
+		// 按客户端所有者排序，使轮询顺序与订阅收集顺序无关
+		sort.Slice(subs, func(i, j int) bool { return subs[i].client.owner < subs[j].client.owner })
+		picked = append(picked, subs[b.shared[topic]%len(subs)])
+		b.shared[topic]++
+	}
+	return picked
+}
+
+// register 将客户端加入 Broker
+func (b *Broker) register(client *memoryClient, connected bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if connected {
+		b.clients[client] = struct{}{}
+	} else {
+		delete(b.clients, client)
+	}
+}
+
+// received 返回指定所有者的客户端收到的消息
+func (b *Broker) received(owner string) []types.MessageEnvelope {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	var messages []types.MessageEnvelope
+	for _, m := range b.deliveries {
+		if m.owner == owner {
+			messages = append(messages, m.envelope)
+		}
+	}
+	return messages
+}
+
+// memorySubscription 表示一个主题订阅
+type memorySubscription struct {
+	client   *memoryClient
+	topic    string
+	messages chan<- types.MessageEnvelope
+	binary   bool
+}
+
+// memoryClient 是连接到内存 Broker 的 messaging.MessageClient 实现
+type memoryClient struct {
+	broker        *Broker
+	owner         string
+	queueGroup    string // 底层配置的 NATS QueueGroup，同组订阅分摊消息
+	mutex         sync.Mutex
+	connected     bool
+	subscriptions map[string]memorySubscription
+}
+
+func (c *memoryClient) Connect() error {
+	c.mutex.Lock()
+	c.connected = true
+	c.mutex.Unlock()
+	c.broker.register(c, true)
+	return nil
+}
+
+func (c *memoryClient) Disconnect() error {
+	c.mutex.Lock()
+	c.connected = false
+	c.subscriptions = make(map[string]memorySubscription)
+	c.mutex.Unlock()
+	c.broker.register(c, false)
+	return nil
+}
+
+func (c *memoryClient) Publish(message types.MessageEnvelope, topic string) error {
+	return c.PublishWithSizeLimit(message, topic, 0)
+}
+
+func (c *memoryClient) PublishWithSizeLimit(message types.MessageEnvelope, topic string, limit int64) error {
+	if err := c.checkConnected(); err != nil {
+		return err
+	}
+	if topic == "" {
+		return fmt.Errorf("发布主题不能为空")
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("编码信封失败: %w", err)
+	}
+	if limit > 0 && int64(len(data)) > limit*1024 {
+		return fmt.Errorf("消息大小 %d 字节超过限制 %d KB", len(data), limit)
+	}
+	return c.broker.publish(topic, data, false)
+}
+
+func (c *memoryClient) PublishBinaryData(data []byte, topic string) error {
+	if err := c.checkConnected(); err != nil {
+		return err
+	}
+	return c.broker.publish(topic, data, true)
+}
+
+func (c *memoryClient) Subscribe(topics []types.TopicChannel, messageErrors chan error) error {
+	return c.subscribe(topics, false)
+}
+
+func (c *memoryClient) SubscribeBinaryData(topics []types.TopicChannel, messageErrors chan error) error {
+	return c.subscribe(topics, true)
+}
+
+func (c *memoryClient) subscribe(topics []types.TopicChannel, binary bool) error {
+	if err := c.checkConnected(); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, tc := range topics {
+		c.subscriptions[tc.Topic] = memorySubscription{client: c, topic: tc.Topic, messages: tc.Messages, binary: binary}
+	}
+	return nil
+}
+
+func (c *memoryClient) Unsubscribe(topics ...string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, topic := range topics {
+		delete(c.subscriptions, topic)
+	}
+	return nil
+}
+
+// Request 订阅 responseTopicPrefix/<RequestID>，发布请求并等待响应
+func (c *memoryClient) Request(message types.MessageEnvelope, requestTopic string, responseTopicPrefix string, timeout time.Duration) (*types.MessageEnvelope, error) {
+	if message.RequestID == "" {
+		message.RequestID = uuid.NewString()
+	}
+	responseTopic := strings.TrimSuffix(responseTopicPrefix, "/") + "/" + message.RequestID
+	responses := make(chan types.MessageEnvelope, 1)
+	if err := c.Subscribe([]types.TopicChannel{{Topic: responseTopic, Messages: responses}}, nil); err != nil {
+		return nil, err
+	}
+	defer func() { _ = c.Unsubscribe(responseTopic) }()
+	if err := c.Publish(message, requestTopic); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case response := <-responses:
+		return &response, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("等待 %s 的响应超时", responseTopic)
+	}
+}
+
+// matching 返回匹配主题的订阅
+func (c *memoryClient) matching(topic string) []memorySubscription {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var subs []memorySubscription
+	for pattern, sub := range c.subscriptions {
+		if messagebus.TopicMatches(pattern, topic) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// recordReceived 记录客户端收到的消息
+func (c *memoryClient) recordReceived(envelope types.MessageEnvelope) {
+	c.broker.mutex.Lock()
+	defer c.broker.mutex.Unlock()
+	c.broker.deliveries = append(c.broker.deliveries, delivery{owner: c.owner, envelope: envelope})
+}
+
+func (c *memoryClient) checkConnected() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.connected {
+		return fmt.Errorf("内存客户端未连接")
+	}
+	return nil
+}
+
+// delivery 记录一次投递
+type delivery struct {
+	owner    string
+	envelope types.MessageEnvelope
+}
@@ -0,0 +1,94 @@
+package messagebustest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+)
+
+// defaultExpectTimeout 是 ExpectPublished 等待消息的默认时长
+const defaultExpectTimeout = time.Second
+
+// MockClient 是连接到内存 Broker 的 messagebus.Client，可直接替换生产代码中的客户端
+type MockClient struct {
+	*messagebus.Client
+	broker *Broker
+	owner  string
+}
+
+// NewMockClient 创建使用独立内存 Broker 的客户端并完成连接
+// opts 与 messagebus.NewClientWithOptions 相同，底层客户端工厂和默认日志会被替换为内存实现
+func NewMockClient(opts ...messagebus.Option) (*MockClient, error) {
+	return NewMockClientWithBroker(NewBroker(), opts...)
+}
+
+// NewMockClientWithBroker 创建连接到指定内存 Broker 的客户端，多个客户端共享 Broker 时可以互相收发消息
+func NewMockClientWithBroker(broker *Broker, opts ...messagebus.Option) (*MockClient, error) {
+	owner := uuid.NewString()
+	all := append([]messagebus.Option{messagebus.WithLogger(logger.NewMockClient())}, opts...)
+	all = append(all, messagebus.WithMessageClientFactory(broker.MessageClientFactory(owner)))
+	client, err := messagebus.NewClientWithOptions(all...)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Connect(); err != nil {
+		return nil, err
+	}
+	return &MockClient{Client: client, broker: broker, owner: owner}, nil
+}
+
+// Broker 返回客户端连接的内存 Broker
+func (m *MockClient) Broker() *Broker {
+	return m.broker
+}
+
+// Published 返回 Broker 上所有已发布的消息
+func (m *MockClient) Published() []Message {
+	return m.broker.Published()
+}
+
+// ReceivedMessages 返回该客户端的订阅收到的所有消息，按投递顺序排列
+func (m *MockClient) ReceivedMessages() []types.MessageEnvelope {
+	return m.broker.received(m.owner)
+}
+
+// ExpectPublished 等待匹配 topic（可含通配符）的消息被发布并返回最早的一条，1 秒内未发布时使测试失败
+func (m *MockClient) ExpectPublished(t testing.TB, topic string) types.MessageEnvelope {
+	t.Helper()
+	msg, err := m.WaitForPublished(topic, defaultExpectTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+// ExpectNotPublished 断言没有匹配 topic 的消息被发布
+func (m *MockClient) ExpectNotPublished(t testing.TB, topic string) {
+	t.Helper()
+	for _, msg := range m.broker.Published() {
+		if messagebus.TopicMatches(topic, msg.Topic) {
+			t.Fatalf("主题 %s 不应有消息发布，但收到了发往 %s 的消息", topic, msg.Topic)
+		}
+	}
+}
+
+// WaitForPublished 等待匹配 topic 的消息被发布并返回最早的一条，超时返回错误
+func (m *MockClient) WaitForPublished(topic string, timeout time.Duration) (types.MessageEnvelope, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		for _, msg := range m.broker.Published() {
+			if messagebus.TopicMatches(topic, msg.Topic) {
+				return msg.Envelope, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return types.MessageEnvelope{}, fmt.Errorf("%s 内没有发往 %s 的消息", timeout, topic)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
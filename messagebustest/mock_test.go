@@ -0,0 +1,148 @@
+package messagebustest_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+func newMockClient(t *testing.T, broker *messagebustest.Broker) *messagebustest.MockClient {
+	t.Helper()
+	client, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestMockPublishSubscribe(t *testing.T) {
+	client := newMockClient(t, messagebustest.NewBroker())
+
+	received := make(chan types.MessageEnvelope, 1)
+	if err := client.Subscribe([]string{"test/a"}, func(_ string, msg types.MessageEnvelope) error {
+		received <- msg
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Publish("test/a", map[string]string{"k": "v"}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-received:
+		if msg.ReceivedTopic != "test/a" {
+			t.Errorf("ReceivedTopic = %q，期望 test/a", msg.ReceivedTopic)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("订阅未收到消息")
+	}
+
+	published := client.ExpectPublished(t, "test/a")
+	data, err := messagebus.EnvelopePayloadBytes(published)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal(data, &payload); err != nil || payload["k"] != "v" {
+		t.Errorf("已发布的 Payload = %s，期望 {\"k\":\"v\"}", data)
+	}
+	if got := client.ReceivedMessages(); len(got) != 1 {
+		t.Errorf("ReceivedMessages 返回 %d 条，期望 1", len(got))
+	}
+	client.ExpectNotPublished(t, "test/b")
+}
+
+func TestMockWildcardSubscribe(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	subscriber := newMockClient(t, broker)
+	publisher := newMockClient(t, broker)
+
+	received := make(chan string, 4)
+	handler := func(topic string, _ types.MessageEnvelope) error {
+		received <- topic
+		return nil
+	}
+	if err := subscriber.Subscribe([]string{"edgex/events/#"}, handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := subscriber.Subscribe([]string{"edgex/+/status"}, handler); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, topic := range []string{"edgex/events/device/svc/d1", "edgex/core/status", "edgex/core/other", "other/events/x"} {
+		if err := publisher.Publish(topic, "x"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := map[string]bool{}
+	timeout := time.After(time.Second)
+	for len(got) < 2 {
+		select {
+		case topic := <-received:
+			got[topic] = true
+		case <-timeout:
+			t.Fatalf("只收到 %v，期望 edgex/events/device/svc/d1 和 edgex/core/status", got)
+		}
+	}
+	select {
+	case topic := <-received:
+		t.Errorf("不应收到主题 %s", topic)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if !got["edgex/events/device/svc/d1"] || !got["edgex/core/status"] {
+		t.Errorf("收到 %v，期望 edgex/events/device/svc/d1 和 edgex/core/status", got)
+	}
+	if n := len(subscriber.ReceivedMessages()); n != 2 {
+		t.Errorf("ReceivedMessages 返回 %d 条，期望 2", n)
+	}
+	if n := len(publisher.ReceivedMessages()); n != 0 {
+		t.Errorf("发布方 ReceivedMessages 返回 %d 条，期望 0", n)
+	}
+}
+
+func TestMockRequest(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	responder := newMockClient(t, broker)
+	requester := newMockClient(t, broker)
+
+	if err := responder.RegisterRequestHandler("test/command", func(_ context.Context, request types.MessageEnvelope) (interface{}, error) {
+		return map[string]string{"echo": request.RequestID}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	envelope, err := requester.CreateMessageEnvelope(map[string]string{"cmd": "ping"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope.RequestID = "req-1"
+	response, err := requester.Request(envelope, "test/command", "test/response", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.RequestID != "req-1" {
+		t.Errorf("响应 RequestID = %q，期望 req-1", response.RequestID)
+	}
+	requester.ExpectPublished(t, "test/command")
+	responder.ExpectPublished(t, "test/response/#")
+}
+
+func TestMockBrokerReset(t *testing.T) {
+	client := newMockClient(t, messagebustest.NewBroker())
+	if err := client.Publish("test/a", "x"); err != nil {
+		t.Fatal(err)
+	}
+	client.ExpectPublished(t, "test/a")
+	client.Broker().Reset()
+	if n := len(client.Published()); n != 0 {
+		t.Errorf("Reset 后 Published 返回 %d 条，期望 0", n)
+	}
+}
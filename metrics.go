@@ -0,0 +1,43 @@
+package messagebus
+
+import "time"
+
+// MetricsRecorder 接收客户端的发布/订阅活动，用于导出指标；prommetrics 子包提供 Prometheus 实现
+// 方法在发布和消息处理路径上同步调用，实现应避免阻塞
+type MetricsRecorder interface {
+	// ObservePublish 记录一次成功的发布（含离线转发）
+	ObservePublish()
+	// ObserveHandler 记录一次处理函数调用，topic 为订阅主题
+	ObserveHandler(topic string, elapsed time.Duration, err error)
+	// ObserveOverflowDrop 记录一条因缓冲区已满被丢弃的消息
+	ObserveOverflowDrop(topic string)
+	// ObserveReconnect 记录一次成功的重连
+	ObserveReconnect()
+}
+
+// MetricsInfo 是创建 MetricsRecorder 时可用的客户端信息
+type MetricsInfo struct {
+	ClientID        string            // 客户端 ID
+	Tags            map[string]string // 客户端标签
+	ErrorQueueDepth func() int        // 返回错误通道中等待读取的错误数量
+}
+
+// MetricsFactory 在创建客户端时按客户端信息创建 MetricsRecorder，返回错误时客户端创建失败
+type MetricsFactory func(info MetricsInfo) (MetricsRecorder, error)
+
+// nopMetrics 是未配置 Config.Metrics 时使用的空实现
+type nopMetrics struct{}
+
+func (nopMetrics) ObservePublish()                             {}
+func (nopMetrics) ObserveHandler(string, time.Duration, error) {}
+func (nopMetrics) ObserveOverflowDrop(string)                  {}
+func (nopMetrics) ObserveReconnect()                           {}
+
+// Metrics 返回客户端的 MetricsRecorder，未配置 Config.Metrics 时返回 nil
+// 使用 prommetrics 时可通过 prommetrics.Collector 取得可注册的 prometheus.Collector
+func (c *Client) Metrics() MetricsRecorder {
+	if _, ok := c.metrics.(nopMetrics); ok {
+		return nil
+	}
+	return c.metrics
+}
@@ -0,0 +1,82 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HandlerMiddleware 包装 MessageHandler，用于在处理函数前后加入日志、指标、校验等通用逻辑
+type HandlerMiddleware func(next MessageHandler) MessageHandler
+
+// Use 注册订阅中间件，只作用于之后注册的订阅
+// 多次调用时按注册顺序排列，先注册的中间件位于最外层
+func (c *Client) Use(middleware ...HandlerMiddleware) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, mw := range middleware {
+		if mw != nil {
+			c.middleware = append(c.middleware, mw)
+		}
+	}
+}
+
+// applyMiddleware 使用已注册的中间件包装处理函数
+func (c *Client) applyMiddleware(handler MessageHandler) MessageHandler {
+	c.mutex.RLock()
+	middleware := c.middleware
+	c.mutex.RUnlock()
+	return Chain(handler, middleware...)
+}
+
+// Chain 按顺序使用中间件包装 handler，第一个中间件位于最外层
+func Chain(handler MessageHandler, middleware ...HandlerMiddleware) MessageHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if middleware[i] != nil {
+			handler = middleware[i](handler)
+		}
+	}
+	return handler
+}
+
+// LoggingMiddleware 以 Debug 级别记录每条消息的主题、CorrelationID、处理耗时和错误
+func LoggingMiddleware(lc logger.LoggingClient) HandlerMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(topic string, message types.MessageEnvelope) error {
+			start := time.Now()
+			err := next(topic, message)
+			lc.Debug("消息处理完成", "topic", topic, "correlationId", message.CorrelationID, "duration", time.Since(start), "error", err)
+			return err
+		}
+	}
+}
+
+// ValidationMiddleware 在处理函数之前校验消息，校验失败时不调用处理函数并返回校验错误
+func ValidationMiddleware(validate func(topic string, message types.MessageEnvelope) error) HandlerMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(topic string, message types.MessageEnvelope) error {
+			if err := validate(topic, message); err != nil {
+				return fmt.Errorf("消息校验失败: %w", err)
+			}
+			return next(topic, message)
+		}
+	}
+}
+
+// TracingMiddleware 为每条消息创建以发布方 Span 为父 Span 的消费 Span，未配置 TracerProvider 时不创建
+// 处理函数需要获取追踪上下文时请使用 TracedHandler
+func (c *Client) TracingMiddleware() HandlerMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(topic string, message types.MessageEnvelope) error {
+			ctx := c.ContextFromEnvelope(context.Background(), message)
+			_, span := c.startSpan(ctx, "process", topic, trace.SpanKindConsumer)
+			err := next(topic, message)
+			c.endSpan(span, err)
+			return err
+		}
+	}
+}
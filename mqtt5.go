@@ -0,0 +1,153 @@
+package messagebus
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// ErrMQTT5Unsupported 表示底层客户端不支持 MQTT 5 属性，需使用 mqtt5 子包提供的 MessageClientFactory
+var ErrMQTT5Unsupported = errors.New("底层客户端不支持 MQTT 5 属性")
+
+// 支持 MQTT 5 的底层客户端将收到的 PUBLISH 属性写入信封 QueryParams 时使用的键
+const (
+	// HeaderMQTT5UserPropertyPrefix 用户属性键的前缀，例如用户属性 site 保存为 mqtt5-user-site
+	HeaderMQTT5UserPropertyPrefix = "mqtt5-user-"
+	// HeaderMQTT5MessageExpiry 剩余的消息过期时间（秒）
+	HeaderMQTT5MessageExpiry = "mqtt5-message-expiry"
+	// HeaderMQTT5ResponseTopic 响应主题
+	HeaderMQTT5ResponseTopic = "mqtt5-response-topic"
+	// HeaderMQTT5CorrelationData base64 编码的关联数据
+	HeaderMQTT5CorrelationData = "mqtt5-correlation-data"
+	// HeaderMQTT5ContentType PUBLISH 报文的内容类型
+	HeaderMQTT5ContentType = "mqtt5-content-type"
+)
+
+// MQTT5Properties 表示 MQTT 5 PUBLISH 报文属性
+type MQTT5Properties struct {
+	// UserProperties 用户属性，以键值对随报文传递，不修改信封
+	UserProperties map[string]string
+	// MessageExpiry 消息过期时间（整秒），Broker 在此时间内未投递的消息会被丢弃，0 表示不过期
+	MessageExpiry time.Duration
+	// ResponseTopic 响应主题，用于 MQTT 5 原生请求-响应
+	ResponseTopic string
+	// CorrelationData 关联数据，响应方原样带回以便请求方匹配响应
+	CorrelationData []byte
+	// ContentType 报文的内容类型，为空时不设置
+	ContentType string
+	// TopicAlias 是否为该主题申请主题别名，之后发往同一主题的报文只携带别名以减少带宽，
+	// Broker 未开放主题别名或别名已用完时照常携带完整主题
+	TopicAlias bool
+}
+
+// validate 校验 MQTT 5 属性
+func (p MQTT5Properties) validate() error {
+	if p.MessageExpiry < 0 || p.MessageExpiry%time.Second != 0 {
+		return fmt.Errorf("MessageExpiry 必须为非负的整秒")
+	}
+	if strings.ContainsAny(p.ResponseTopic, "+#*>") {
+		return fmt.Errorf("ResponseTopic 不能包含通配符: %s", p.ResponseTopic)
+	}
+	for key := range p.UserProperties {
+		if key == "" {
+			return fmt.Errorf("用户属性的键不能为空")
+		}
+	}
+	return nil
+}
+
+// MQTT5Publisher 由支持 MQTT 5 的底层客户端实现，客户端据此检测是否可以按报文设置属性、QoS 和保留标志
+type MQTT5Publisher interface {
+	// SupportsMQTT5 返回当前连接是否使用 MQTT 5
+	SupportsMQTT5() bool
+	// PublishWithProperties 以指定 QoS、保留标志和属性发布信封
+	PublishWithProperties(message types.MessageEnvelope, topic string, qos int, retain bool, props MQTT5Properties) error
+}
+
+// mqtt5Publisher 返回支持 MQTT 5 的主连接，不支持时 ok 为 false
+func (c *Client) mqtt5Publisher() (MQTT5Publisher, bool) {
+	publisher, ok := c.messageClient().(MQTT5Publisher)
+	if !ok || !publisher.SupportsMQTT5() {
+		return nil, false
+	}
+	return publisher, true
+}
+
+// SupportsMQTT5 返回底层连接是否支持 MQTT 5 属性
+// 支持时 PublishWithOptions 直接按报文设置 QoS 和保留标志，不再建立额外连接
+func (c *Client) SupportsMQTT5() bool {
+	_, ok := c.mqtt5Publisher()
+	return ok
+}
+
+// publishTo 按发布选项选择底层客户端发布信封
+func (c *Client) publishTo(topic string, envelope types.MessageEnvelope, opts *PublishOptions) error {
+	if opts != nil {
+		if publisher, ok := c.mqtt5Publisher(); ok {
+			var props MQTT5Properties
+			if opts.Properties != nil {
+				props = *opts.Properties
+			}
+			return publisher.PublishWithProperties(envelope, topic, opts.QoS, opts.Retain, props)
+		}
+		if opts.Properties != nil {
+			return ErrMQTT5Unsupported
+		}
+	}
+	publisher, err := c.publisherFor(opts)
+	if err != nil {
+		return err
+	}
+	return publisher.Publish(envelope, topic)
+}
+
+// MQTT5PropertiesFromEnvelope 从收到的信封中读取底层客户端写入的 MQTT 5 属性，
+// 消息不是经 MQTT 5 连接收到或没有携带属性时 ok 为 false
+func MQTT5PropertiesFromEnvelope(envelope types.MessageEnvelope) (props MQTT5Properties, ok bool) {
+	for key, value := range envelope.QueryParams {
+		switch {
+		case strings.HasPrefix(key, HeaderMQTT5UserPropertyPrefix):
+			if props.UserProperties == nil {
+				props.UserProperties = make(map[string]string)
+			}
+			props.UserProperties[strings.TrimPrefix(key, HeaderMQTT5UserPropertyPrefix)] = value
+		case key == HeaderMQTT5MessageExpiry:
+			if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+				props.MessageExpiry = time.Duration(seconds) * time.Second
+			}
+		case key == HeaderMQTT5ResponseTopic:
+			props.ResponseTopic = value
+		case key == HeaderMQTT5CorrelationData:
+			if data, err := base64.StdEncoding.DecodeString(value); err == nil {
+				props.CorrelationData = data
+			}
+		case key == HeaderMQTT5ContentType:
+			props.ContentType = value
+		default:
+			continue
+		}
+		ok = true
+	}
+	return props, ok
+}
+
+// RespondMQTT5 按 MQTT 5 原生请求-响应约定回复请求：发布到请求的响应主题，并带回请求的关联数据
+// 响应信封按 NewSuccessResponseEnvelope 构造；请求没有携带响应主题时返回错误
+func (c *Client) RespondMQTT5(request types.MessageEnvelope, data interface{}) error {
+	props, _ := MQTT5PropertiesFromEnvelope(request)
+	if props.ResponseTopic == "" {
+		return fmt.Errorf("请求未携带 MQTT 5 响应主题")
+	}
+	response := NewSuccessResponseEnvelope(request, data)
+	opts := &PublishOptions{
+		QoS:        c.config.QoS,
+		Properties: &MQTT5Properties{CorrelationData: props.CorrelationData},
+	}
+	return c.publishEnvelopeWithOptions(context.Background(), props.ResponseTopic, response, opts)
+}
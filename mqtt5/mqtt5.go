@@ -0,0 +1,740 @@
+// Package mqtt5 提供基于 MQTT 5 的消息总线后端，在 MQTT 语义之外支持用户属性、消息过期时间、
+// 响应主题与关联数据（原生请求-响应）以及主题别名
+//
+// 该包单独存放，只有导入它的程序才会依赖 github.com/eclipse/paho.golang。Type 保持 messagebus.TypeMQTT，
+// 通过 Factory 设置 MessageClientFactory 后，客户端会检测到 MQTT 5 支持，PublishOptions.Properties 随之生效：
+//
+//	client, err := messagebus.NewClientWithOptions(
+//	    messagebus.WithBroker("localhost", 1883, "tcp", messagebus.TypeMQTT),
+//	    messagebus.WithMessageClientFactory(mqtt5.Factory(mqtt5.Config{})),
+//	)
+//
+// 收到消息的 PUBLISH 属性写入信封 QueryParams（键见 messagebus.HeaderMQTT5* 常量），
+// 可通过 messagebus.MQTT5PropertiesFromEnvelope 读取。支持 tcp、tls 与 ssl 协议，ws/wss 和代理连接由 messagebus 客户端经本地中继建立。
+package mqtt5
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultConnectTimeout    = 10 * time.Second
+	defaultPacketTimeout     = 10 * time.Second
+	defaultReconnectInterval = time.Second
+	defaultKeepAlive         = 30
+	maxPendingMessages       = 1024
+)
+
+// Config 表示 MQTT 5 后端专用参数
+type Config struct {
+	// TopicAliasMaximum 允许 Broker 向本客户端发送消息时使用的主题别名数量，0 表示不接受；
+	// 发布时可用的别名数量由 Broker 在 CONNACK 中决定
+	TopicAliasMaximum uint16
+	// ConnectTimeout 建立连接的最长时间，默认 10 秒
+	ConnectTimeout time.Duration
+	// PacketTimeout 等待 Broker 确认发布、订阅和取消订阅的最长时间，默认 10 秒
+	PacketTimeout time.Duration
+	// ReconnectInterval 开启自动重连时两次重连之间的等待时间，默认 1 秒
+	ReconnectInterval time.Duration
+}
+
+// Factory 返回创建 MQTT 5 底层客户端的 MessageClientFactory
+func Factory(config Config) messagebus.MessageClientFactory {
+	return func(busConfig types.MessageBusConfig) (messaging.MessageClient, error) {
+		return NewMessageClient(busConfig, config)
+	}
+}
+
+// subscription 表示一个订阅主题的投递目标
+type subscription struct {
+	messages chan<- types.MessageEnvelope
+	errors   chan error
+	binary   bool
+	qos      byte
+}
+
+// connection 表示一次 MQTT 5 连接及其主题别名状态，重连时整体替换
+type connection struct {
+	client   *paho.Client
+	aliasMax uint16
+	closed   chan struct{} // 主动断开时关闭，使阻塞的投递退出，paho 才能完成关闭
+	takeover atomic.Bool   // Broker 以会话被接管为由断开了连接
+
+	mutex   sync.Mutex
+	aliases map[string]uint16 // 发布主题已分配的别名
+	ready   map[string]bool   // 已随完整主题发出、可以只携带别名的主题
+	inbound map[uint16]string // Broker 发来的别名与主题的对应关系
+}
+
+// MessageClient 使用 paho.golang 实现 messaging.MessageClient 与 messagebus.MQTT5Publisher
+type MessageClient struct {
+	config        Config
+	broker        types.HostInfo
+	optional      map[string]string
+	clientID      string
+	qos           byte
+	retained      bool
+	keepAlive     uint16
+	cleanStart    bool
+	sessionExpiry *uint32
+	autoReconnect bool
+
+	mutex         sync.Mutex
+	conn          *connection
+	subscriptions map[string]subscription
+	pending       []pendingMessage // 持久会话中尚无订阅接收的消息
+	disconnect    bool
+	onDisconnect  func(takeover bool) // Broker 断开连接时的回调，见 NotifyBrokerDisconnect
+}
+
+// pendingMessage 表示持久会话中先于订阅到达的消息
+// paho.golang 会确认所有收到的消息，Broker 不会重新投递，因此暂存到订阅恢复后再投递
+type pendingMessage struct {
+	topic  string
+	packet *paho.Publish
+}
+
+var (
+	_ messaging.MessageClient   = (*MessageClient)(nil)
+	_ messagebus.MQTT5Publisher = (*MessageClient)(nil)
+
+	_ messagebus.BrokerDisconnectNotifier = (*MessageClient)(nil)
+)
+
+// NewMessageClient 按 MessageBus 底层配置创建 MQTT 5 客户端，Connect 时才建立连接
+func NewMessageClient(busConfig types.MessageBusConfig, config Config) (*MessageClient, error) {
+	if config.ConnectTimeout <= 0 {
+		config.ConnectTimeout = defaultConnectTimeout
+	}
+	if config.PacketTimeout <= 0 {
+		config.PacketTimeout = defaultPacketTimeout
+	}
+	if config.ReconnectInterval <= 0 {
+		config.ReconnectInterval = defaultReconnectInterval
+	}
+	switch strings.ToLower(busConfig.Broker.Protocol) {
+	case "", "tcp", "tls", "ssl":
+	default:
+		return nil, fmt.Errorf("MQTT 5 客户端不支持协议 %s", busConfig.Broker.Protocol)
+	}
+	optional := busConfig.Optional
+	c := &MessageClient{
+		config:        config,
+		broker:        busConfig.Broker,
+		optional:      optional,
+		clientID:      optional["ClientId"],
+		keepAlive:     defaultKeepAlive,
+		cleanStart:    true,
+		subscriptions: make(map[string]subscription),
+	}
+	if value := optional["Qos"]; value != "" {
+		qos, err := strconv.ParseUint(value, 10, 8)
+		if err != nil || qos > 2 {
+			return nil, fmt.Errorf("无效的 Qos: %s", value)
+		}
+		c.qos = byte(qos)
+	}
+	if value := optional["Retained"]; value != "" {
+		retained, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 Retained: %s", value)
+		}
+		c.retained = retained
+	}
+	if value := optional["KeepAlive"]; value != "" {
+		keepAlive, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 KeepAlive: %s", value)
+		}
+		c.keepAlive = uint16(keepAlive)
+	}
+	if value := optional["CleanSession"]; value != "" {
+		clean, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 CleanSession: %s", value)
+		}
+		c.cleanStart = clean
+	}
+	if value := optional[messagebus.OptionalSessionExpiryInterval]; value != "" {
+		expiry, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 %s: %s", messagebus.OptionalSessionExpiryInterval, value)
+		}
+		interval := uint32(expiry)
+		c.sessionExpiry = &interval
+	}
+	c.autoReconnect, _ = strconv.ParseBool(optional["AutoReconnect"])
+	return c, nil
+}
+
+// Connect 建立 MQTT 5 连接并重新订阅 Disconnect 前的主题，重新订阅失败时关闭新建立的连接
+func (c *MessageClient) Connect() error {
+	conn, err := c.dial()
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	previous := c.conn
+	c.conn = conn
+	c.disconnect = false
+	subscriptions := make(map[string]subscription, len(c.subscriptions))
+	for topic, sub := range c.subscriptions {
+		subscriptions[topic] = sub
+	}
+	c.mutex.Unlock()
+	if previous != nil {
+		_ = previous.close()
+	}
+	if len(subscriptions) > 0 {
+		if err := c.sendSubscribe(conn, subscriptions); err != nil {
+			c.mutex.Lock()
+			if c.conn == conn {
+				c.conn = nil
+			}
+			c.mutex.Unlock()
+			_ = conn.close()
+			return err
+		}
+	}
+	go c.watch(conn)
+	return nil
+}
+
+// dial 建立网络连接并完成 MQTT 5 握手
+func (c *MessageClient) dial() (*connection, error) {
+	address := net.JoinHostPort(c.broker.Host, strconv.Itoa(c.broker.Port))
+	dialer := &net.Dialer{Timeout: c.config.ConnectTimeout}
+	var netConn net.Conn
+	var err error
+	switch strings.ToLower(c.broker.Protocol) {
+	case "tls", "ssl":
+		tlsConfig, tlsErr := messagebus.TLSConfigFromOptions(c.optional)
+		if tlsErr != nil {
+			return nil, tlsErr
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = c.broker.Host
+		}
+		netConn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+		if err == nil {
+			// tls.Conn 不支持并发写，paho 要求包装为线程安全的连接
+			netConn = packets.NewThreadSafeConn(netConn)
+		}
+	default:
+		netConn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("连接MQTT Broker %s 失败: %w", address, err)
+	}
+
+	conn := &connection{
+		closed:  make(chan struct{}),
+		aliases: make(map[string]uint16),
+		ready:   make(map[string]bool),
+		inbound: make(map[uint16]string),
+	}
+	conn.client = paho.NewClient(paho.ClientConfig{
+		ClientID:      c.clientID,
+		Conn:          netConn,
+		PacketTimeout: c.config.PacketTimeout,
+		OnPublishReceived: []func(paho.PublishReceived) (bool, error){
+			func(received paho.PublishReceived) (bool, error) {
+				c.route(conn, received.Packet)
+				return true, nil
+			},
+		},
+		OnServerDisconnect: func(disconnect *paho.Disconnect) {
+			if disconnect.ReasonCode == packets.DisconnectSessionTakenOver && !conn.takeover.Swap(true) {
+				c.brokerDisconnected(true)
+			}
+		},
+	})
+
+	connect := &paho.Connect{
+		ClientID:   c.clientID,
+		KeepAlive:  c.keepAlive,
+		CleanStart: c.cleanStart,
+		// Request Problem Information 为 0 时部分 Broker 会去掉转发报文中的用户属性，按协议默认值设为 1
+		Properties: &paho.ConnectProperties{SessionExpiryInterval: c.sessionExpiry, RequestProblemInfo: true},
+	}
+	if username := c.optional["Username"]; username != "" {
+		connect.Username = username
+		connect.UsernameFlag = true
+	}
+	if password := c.optional["Password"]; password != "" {
+		connect.Password = []byte(password)
+		connect.PasswordFlag = true
+	}
+	if c.config.TopicAliasMaximum > 0 {
+		connect.Properties.TopicAliasMaximum = paho.Uint16(c.config.TopicAliasMaximum)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.ConnectTimeout)
+	defer cancel()
+	connack, err := conn.client.Connect(ctx, connect)
+	if err != nil {
+		if connack != nil && connack.Properties != nil && connack.Properties.ReasonString != "" {
+			err = fmt.Errorf("%w: %s", err, connack.Properties.ReasonString)
+		}
+		return nil, fmt.Errorf("连接MQTT Broker %s 失败: %w", address, err)
+	}
+	if connack.Properties != nil && connack.Properties.TopicAliasMaximum != nil {
+		conn.aliasMax = *connack.Properties.TopicAliasMaximum
+	}
+	return conn, nil
+}
+
+// watch 在连接意外断开且开启了自动重连时，按 ReconnectInterval 重试直到成功或主动断开
+func (c *MessageClient) watch(conn *connection) {
+	<-conn.client.Done()
+	c.mutex.Lock()
+	current := c.conn == conn && !c.disconnect
+	c.mutex.Unlock()
+	if !current {
+		return
+	}
+	if !conn.takeover.Load() {
+		c.brokerDisconnected(false)
+	}
+	c.notifyError(fmt.Errorf("与MQTT Broker的连接已断开"))
+	if !c.autoReconnect {
+		return
+	}
+	for {
+		time.Sleep(c.config.ReconnectInterval)
+		c.mutex.Lock()
+		// 重新订阅失败的 Connect 会把 c.conn 置空，此时继续重试；已由其他调用重新连接或主动断开时退出
+		stale := (c.conn != conn && c.conn != nil) || c.disconnect
+		c.mutex.Unlock()
+		if stale {
+			return
+		}
+		if err := c.Connect(); err == nil {
+			return
+		}
+	}
+}
+
+// NotifyBrokerDisconnect 注册连接意外断开时的回调，Broker 以会话被接管（原因码 0x8E）为由断开时 takeover 为 true
+func (c *MessageClient) NotifyBrokerDisconnect(handler func(takeover bool)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.onDisconnect = handler
+}
+
+// brokerDisconnected 调用 NotifyBrokerDisconnect 注册的回调
+func (c *MessageClient) brokerDisconnected(takeover bool) {
+	c.mutex.Lock()
+	handler := c.onDisconnect
+	c.mutex.Unlock()
+	if handler != nil {
+		handler(takeover)
+	}
+}
+
+// current 返回当前连接，未连接时返回错误
+func (c *MessageClient) current() (*connection, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.conn == nil || c.disconnect {
+		return nil, fmt.Errorf("MQTT 5 客户端未连接")
+	}
+	return c.conn, nil
+}
+
+// SupportsMQTT5 在已建立 MQTT 5 连接时返回 true
+func (c *MessageClient) SupportsMQTT5() bool {
+	_, err := c.current()
+	return err == nil
+}
+
+// Publish 将信封编码为 JSON 后按默认 QoS 与保留标志发布
+func (c *MessageClient) Publish(message types.MessageEnvelope, topic string) error {
+	return c.PublishWithProperties(message, topic, int(c.qos), c.retained, messagebus.MQTT5Properties{})
+}
+
+// PublishWithSizeLimit 在编码后的信封不超过 limit KB 时发布，limit 不大于 0 表示不限制
+func (c *MessageClient) PublishWithSizeLimit(message types.MessageEnvelope, topic string, limit int64) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("编码信封失败: %w", err)
+	}
+	if limit > 0 && int64(len(data)) > limit*1024 {
+		return fmt.Errorf("消息大小 %d 字节超过限制 %d KB", len(data), limit)
+	}
+	return c.publish(topic, data, c.qos, c.retained, messagebus.MQTT5Properties{})
+}
+
+// PublishBinaryData 直接发布原始数据
+func (c *MessageClient) PublishBinaryData(data []byte, topic string) error {
+	return c.publish(topic, data, c.qos, c.retained, messagebus.MQTT5Properties{})
+}
+
+// PublishWithProperties 将信封编码为 JSON 后以指定 QoS、保留标志和 MQTT 5 属性发布
+func (c *MessageClient) PublishWithProperties(message types.MessageEnvelope, topic string, qos int, retain bool, props messagebus.MQTT5Properties) error {
+	if qos < 0 || qos > 2 {
+		return fmt.Errorf("QoS 必须为 0、1 或 2")
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("编码信封失败: %w", err)
+	}
+	return c.publish(topic, data, byte(qos), retain, props)
+}
+
+// publish 发布一条 PUBLISH 报文，QoS 1、2 时等待 Broker 确认
+func (c *MessageClient) publish(topic string, payload []byte, qos byte, retain bool, props messagebus.MQTT5Properties) error {
+	conn, err := c.current()
+	if err != nil {
+		return err
+	}
+	packet := &paho.Publish{
+		QoS:     qos,
+		Retain:  retain,
+		Topic:   topic,
+		Payload: payload,
+		Properties: &paho.PublishProperties{
+			ResponseTopic:   props.ResponseTopic,
+			CorrelationData: props.CorrelationData,
+			ContentType:     props.ContentType,
+		},
+	}
+	if props.MessageExpiry > 0 {
+		expiry := messageExpirySeconds(props.MessageExpiry)
+		packet.Properties.MessageExpiry = &expiry
+	}
+	for key, value := range props.UserProperties {
+		packet.Properties.User = append(packet.Properties.User, paho.UserProperty{Key: key, Value: value})
+	}
+	alias, established := uint16(0), false
+	if props.TopicAlias {
+		alias, established = conn.outboundAlias(topic)
+		if alias != 0 {
+			packet.Properties.TopicAlias = paho.Uint16(alias)
+			if established {
+				packet.Topic = ""
+			}
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.PacketTimeout)
+	defer cancel()
+	response, err := conn.client.Publish(ctx, packet)
+	if err != nil {
+		return fmt.Errorf("发布到主题 %s 失败: %w", topic, err)
+	}
+	if response != nil && response.ReasonCode >= 0x80 {
+		return fmt.Errorf("发布到主题 %s 失败，原因码 0x%02x", topic, response.ReasonCode)
+	}
+	if alias != 0 && !established {
+		conn.markAlias(topic)
+	}
+	return nil
+}
+
+// messageExpirySeconds 将消息过期时间向上取整为秒，不足 1 秒按 1 秒发送，避免消息在 Broker 上立即过期
+func messageExpirySeconds(expiry time.Duration) uint32 {
+	seconds := (expiry + time.Second - 1) / time.Second
+	if seconds > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(seconds)
+}
+
+// outboundAlias 返回主题的发布别名及是否已可只携带别名发送；别名已用完时返回 0
+// 携带完整主题的报文成功发出后才标记为可用，避免并发发布时只携带别名的报文先于别名建立到达
+func (conn *connection) outboundAlias(topic string) (uint16, bool) {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	if alias, ok := conn.aliases[topic]; ok {
+		return alias, conn.ready[topic]
+	}
+	if len(conn.aliases) >= int(conn.aliasMax) {
+		return 0, false
+	}
+	alias := uint16(len(conn.aliases) + 1)
+	conn.aliases[topic] = alias
+	return alias, false
+}
+
+// markAlias 标记主题的别名已由 Broker 记录
+func (conn *connection) markAlias(topic string) {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	conn.ready[topic] = true
+}
+
+// inboundTopic 按 Broker 发来的别名还原主题，携带完整主题时记录别名
+func (conn *connection) inboundTopic(packet *paho.Publish) string {
+	if packet.Properties == nil || packet.Properties.TopicAlias == nil {
+		return packet.Topic
+	}
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	alias := *packet.Properties.TopicAlias
+	if packet.Topic != "" {
+		conn.inbound[alias] = packet.Topic
+		return packet.Topic
+	}
+	return conn.inbound[alias]
+}
+
+// Subscribe 订阅主题，收到的信封发送到对应的通道
+func (c *MessageClient) Subscribe(topics []types.TopicChannel, messageErrors chan error) error {
+	return c.subscribe(topics, messageErrors, false)
+}
+
+// SubscribeBinaryData 与 Subscribe 相同，但将原始数据包装为信封的 Payload
+func (c *MessageClient) SubscribeBinaryData(topics []types.TopicChannel, messageErrors chan error) error {
+	return c.subscribe(topics, messageErrors, true)
+}
+
+// subscribe 向 Broker 订阅主题并记录投递目标，重新连接时自动恢复
+func (c *MessageClient) subscribe(topics []types.TopicChannel, messageErrors chan error, binary bool) error {
+	conn, err := c.current()
+	if err != nil {
+		return err
+	}
+	subscriptions := make(map[string]subscription, len(topics))
+	for _, topic := range topics {
+		subscriptions[topic.Topic] = subscription{messages: topic.Messages, errors: messageErrors, binary: binary, qos: c.qos}
+	}
+	// 先记录投递目标，订阅确认前到达的保留消息才不会被丢弃
+	c.mutex.Lock()
+	for topic, sub := range subscriptions {
+		c.subscriptions[topic] = sub
+	}
+	c.mutex.Unlock()
+	if err := c.sendSubscribe(conn, subscriptions); err != nil {
+		c.mutex.Lock()
+		for topic := range subscriptions {
+			delete(c.subscriptions, topic)
+		}
+		c.mutex.Unlock()
+		return err
+	}
+	c.deliverPending(conn, subscriptions)
+	return nil
+}
+
+// deliverPending 将暂存的消息投递给新订阅，仍无订阅接收的消息继续暂存
+func (c *MessageClient) deliverPending(conn *connection, subscriptions map[string]subscription) {
+	c.mutex.Lock()
+	var matched []pendingMessage
+	remaining := c.pending[:0]
+	for _, message := range c.pending {
+		if matchesAny(subscriptions, message.topic) {
+			matched = append(matched, message)
+		} else {
+			remaining = append(remaining, message)
+		}
+	}
+	c.pending = remaining
+	c.mutex.Unlock()
+	for _, message := range matched {
+		c.deliver(conn, message.topic, message.packet, subscriptions)
+	}
+}
+
+// matchesAny 判断主题是否匹配任一订阅
+func matchesAny(subscriptions map[string]subscription, topic string) bool {
+	for pattern := range subscriptions {
+		if messagebus.TopicMatches(pattern, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// sendSubscribe 发送 SUBSCRIBE 报文并检查每个主题的原因码
+func (c *MessageClient) sendSubscribe(conn *connection, subscriptions map[string]subscription) error {
+	packet := &paho.Subscribe{}
+	for topic, sub := range subscriptions {
+		packet.Subscriptions = append(packet.Subscriptions, paho.SubscribeOptions{Topic: topic, QoS: sub.qos})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.PacketTimeout)
+	defer cancel()
+	suback, err := conn.client.Subscribe(ctx, packet)
+	if err != nil {
+		return fmt.Errorf("订阅失败: %w", err)
+	}
+	for i, code := range suback.Reasons {
+		if code >= 0x80 && i < len(packet.Subscriptions) {
+			return fmt.Errorf("订阅主题 %s 失败，原因码 0x%02x", packet.Subscriptions[i].Topic, code)
+		}
+	}
+	return nil
+}
+
+// route 将收到的 PUBLISH 报文投递到所有匹配的订阅，持久会话中没有匹配的订阅时暂存
+func (c *MessageClient) route(conn *connection, packet *paho.Publish) {
+	topic := conn.inboundTopic(packet)
+	c.mutex.Lock()
+	if !c.cleanStart && !matchesAny(c.subscriptions, topic) {
+		if len(c.pending) >= maxPendingMessages {
+			c.pending = c.pending[1:]
+		}
+		c.pending = append(c.pending, pendingMessage{topic: topic, packet: packet})
+		c.mutex.Unlock()
+		return
+	}
+	subscriptions := make(map[string]subscription, len(c.subscriptions))
+	for pattern, sub := range c.subscriptions {
+		subscriptions[pattern] = sub
+	}
+	c.mutex.Unlock()
+	c.deliver(conn, topic, packet, subscriptions)
+}
+
+// deliver 将报文解码为信封，投递到 subscriptions 中所有匹配的订阅
+func (c *MessageClient) deliver(conn *connection, topic string, packet *paho.Publish, subscriptions map[string]subscription) {
+	for pattern, sub := range subscriptions {
+		if !messagebus.TopicMatches(pattern, topic) {
+			continue
+		}
+		var envelope types.MessageEnvelope
+		if sub.binary {
+			envelope.Payload = packet.Payload
+		} else if err := json.Unmarshal(packet.Payload, &envelope); err != nil {
+			sendError(sub.errors, fmt.Errorf("解码主题 %s 的信封失败: %w", topic, err))
+			continue
+		}
+		envelope.ReceivedTopic = topic
+		envelope.QueryParams = withProperties(envelope.QueryParams, packet.Properties)
+		select {
+		case sub.messages <- envelope:
+		case <-conn.closed:
+			return
+		}
+	}
+}
+
+// withProperties 将 PUBLISH 属性写入信封的 QueryParams
+func withProperties(params map[string]string, props *paho.PublishProperties) map[string]string {
+	if params == nil {
+		params = make(map[string]string)
+	}
+	if props == nil {
+		return params
+	}
+	for _, user := range props.User {
+		params[messagebus.HeaderMQTT5UserPropertyPrefix+user.Key] = user.Value
+	}
+	if props.MessageExpiry != nil {
+		params[messagebus.HeaderMQTT5MessageExpiry] = strconv.FormatUint(uint64(*props.MessageExpiry), 10)
+	}
+	if props.ResponseTopic != "" {
+		params[messagebus.HeaderMQTT5ResponseTopic] = props.ResponseTopic
+	}
+	if len(props.CorrelationData) > 0 {
+		params[messagebus.HeaderMQTT5CorrelationData] = base64.StdEncoding.EncodeToString(props.CorrelationData)
+	}
+	if props.ContentType != "" {
+		params[messagebus.HeaderMQTT5ContentType] = props.ContentType
+	}
+	return params
+}
+
+// sendError 以非阻塞方式发送错误，通道为空或已满时丢弃
+func sendError(messageErrors chan error, err error) {
+	if messageErrors == nil {
+		return
+	}
+	select {
+	case messageErrors <- err:
+	default:
+	}
+}
+
+// notifyError 将连接错误发送到所有订阅的错误通道
+func (c *MessageClient) notifyError(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	notified := make(map[chan error]bool)
+	for _, sub := range c.subscriptions {
+		if sub.errors != nil && !notified[sub.errors] {
+			notified[sub.errors] = true
+			sendError(sub.errors, err)
+		}
+	}
+}
+
+// Request 以 MQTT 5 响应主题与关联数据发布请求，并等待 responseTopicPrefix/RequestID 上的响应
+func (c *MessageClient) Request(message types.MessageEnvelope, requestTopic string, responseTopicPrefix string, timeout time.Duration) (*types.MessageEnvelope, error) {
+	if message.RequestID == "" {
+		message.RequestID = uuid.NewString()
+	}
+	responseTopic := strings.TrimSuffix(responseTopicPrefix, "/") + "/" + message.RequestID
+	responses := make(chan types.MessageEnvelope, 1)
+	if err := c.Subscribe([]types.TopicChannel{{Topic: responseTopic, Messages: responses}}, nil); err != nil {
+		return nil, err
+	}
+	defer func() { _ = c.Unsubscribe(responseTopic) }()
+	props := messagebus.MQTT5Properties{ResponseTopic: responseTopic, CorrelationData: []byte(message.RequestID)}
+	if err := c.PublishWithProperties(message, requestTopic, int(c.qos), false, props); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case response := <-responses:
+		return &response, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("等待 %s 的响应超时", responseTopic)
+	}
+}
+
+// Unsubscribe 取消订阅主题，之后重新连接时不再恢复
+func (c *MessageClient) Unsubscribe(topics ...string) error {
+	c.mutex.Lock()
+	for _, topic := range topics {
+		delete(c.subscriptions, topic)
+	}
+	conn := c.conn
+	disconnected := c.disconnect
+	c.mutex.Unlock()
+	if conn == nil || disconnected || len(topics) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.PacketTimeout)
+	defer cancel()
+	if _, err := conn.client.Unsubscribe(ctx, &paho.Unsubscribe{Topics: topics}); err != nil {
+		return fmt.Errorf("取消订阅失败: %w", err)
+	}
+	return nil
+}
+
+// Disconnect 断开连接，保留订阅以便再次 Connect 时恢复
+func (c *MessageClient) Disconnect() error {
+	c.mutex.Lock()
+	conn := c.conn
+	c.conn = nil
+	c.disconnect = true
+	c.mutex.Unlock()
+	if conn == nil {
+		return nil
+	}
+	return conn.close()
+}
+
+// close 停止投递并发送 DISCONNECT
+func (conn *connection) close() error {
+	close(conn.closed)
+	return conn.client.Disconnect(&paho.Disconnect{ReasonCode: 0})
+}
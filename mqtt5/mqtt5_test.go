@@ -0,0 +1,250 @@
+package mqtt5_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/brokertest"
+	"github.com/clint456/edgex-messagebus-client/mqtt5"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+)
+
+// newClient 创建并连接到嵌入式 Broker 的 MQTT 5 客户端，测试结束时断开
+func newClient(t *testing.T, broker *brokertest.MQTTBroker, config mqtt5.Config, optional map[string]string) (*mqtt5.MessageClient, string) {
+	t.Helper()
+	clientID := "mqtt5-" + uuid.NewString()
+	opts := map[string]string{"ClientId": clientID}
+	for k, v := range optional {
+		opts[k] = v
+	}
+	client, err := mqtt5.NewMessageClient(types.MessageBusConfig{
+		Broker:   types.HostInfo{Host: broker.Host, Port: broker.Port, Protocol: "tcp"},
+		Type:     messagebus.TypeMQTT,
+		Optional: opts,
+	}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Disconnect() })
+	return client, clientID
+}
+
+// subscribe 订阅主题并返回接收信封的通道
+func subscribe(t *testing.T, client *mqtt5.MessageClient, topic string) chan types.MessageEnvelope {
+	t.Helper()
+	messages := make(chan types.MessageEnvelope, 16)
+	if err := client.Subscribe([]types.TopicChannel{{Topic: topic, Messages: messages}}, make(chan error, 16)); err != nil {
+		t.Fatal(err)
+	}
+	return messages
+}
+
+// receive 在超时时间内读取一条信封
+func receive(t *testing.T, messages chan types.MessageEnvelope) types.MessageEnvelope {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("未收到消息")
+		return types.MessageEnvelope{}
+	}
+}
+
+func TestPublishWithProperties(t *testing.T) {
+	broker := brokertest.StartMQTT(t)
+	publisher, _ := newClient(t, broker, mqtt5.Config{}, nil)
+	subscriber, _ := newClient(t, broker, mqtt5.Config{}, nil)
+	messages := subscribe(t, subscriber, "test/props")
+
+	props := messagebus.MQTT5Properties{
+		UserProperties:  map[string]string{"site": "s1", "line": "2"},
+		MessageExpiry:   500 * time.Millisecond,
+		ResponseTopic:   "test/props/reply",
+		CorrelationData: []byte("corr-1"),
+		ContentType:     "application/json",
+	}
+	envelope := types.MessageEnvelope{CorrelationID: uuid.NewString(), Payload: []byte(`{"a":1}`), ContentType: "application/json"}
+	if err := publisher.PublishWithProperties(envelope, "test/props", 1, false, props); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := receive(t, messages)
+	if msg.ReceivedTopic != "test/props" || msg.CorrelationID != envelope.CorrelationID {
+		t.Fatalf("收到的信封 = %+v", msg)
+	}
+	got, ok := messagebus.MQTT5PropertiesFromEnvelope(msg)
+	if !ok {
+		t.Fatal("信封中没有 MQTT 5 属性")
+	}
+	if got.UserProperties["site"] != "s1" || got.UserProperties["line"] != "2" {
+		t.Errorf("UserProperties = %v", got.UserProperties)
+	}
+	// 不足 1 秒的过期时间向上取整，不能发送 0 使消息立即过期
+	if got.MessageExpiry != time.Second {
+		t.Errorf("MessageExpiry = %s，期望 1s", got.MessageExpiry)
+	}
+	if got.ResponseTopic != props.ResponseTopic || string(got.CorrelationData) != "corr-1" || got.ContentType != props.ContentType {
+		t.Errorf("属性 = %+v", got)
+	}
+}
+
+func TestTopicAliasReuse(t *testing.T) {
+	broker := brokertest.StartMQTT(t)
+	publisher, _ := newClient(t, broker, mqtt5.Config{}, nil)
+	// 订阅方同样接受主题别名，覆盖收到只携带别名的报文时还原主题
+	subscriber, _ := newClient(t, broker, mqtt5.Config{TopicAliasMaximum: 10}, nil)
+	messages := subscribe(t, subscriber, "test/alias/#")
+
+	topics := []string{"test/alias/a", "test/alias/a", "test/alias/b", "test/alias/a", "test/alias/b"}
+	for i, topic := range topics {
+		envelope := types.MessageEnvelope{CorrelationID: uuid.NewString(), Payload: []byte{byte(i)}}
+		if err := publisher.PublishWithProperties(envelope, topic, 1, false, messagebus.MQTT5Properties{TopicAlias: true}); err != nil {
+			t.Fatalf("第 %d 次发布失败: %v", i+1, err)
+		}
+	}
+	for i, topic := range topics {
+		if msg := receive(t, messages); msg.ReceivedTopic != topic {
+			t.Errorf("第 %d 条消息的主题 = %q，期望 %q", i+1, msg.ReceivedTopic, topic)
+		}
+	}
+}
+
+func TestRequestUsesResponseTopicAndCorrelationData(t *testing.T) {
+	broker := brokertest.StartMQTT(t)
+	requester, _ := newClient(t, broker, mqtt5.Config{}, nil)
+	responder, _ := newClient(t, broker, mqtt5.Config{}, nil)
+	requests := subscribe(t, responder, "test/request")
+
+	errs := make(chan error, 1)
+	go func() {
+		request := <-requests
+		props, _ := messagebus.MQTT5PropertiesFromEnvelope(request)
+		if string(props.CorrelationData) != request.RequestID {
+			errs <- fmt.Errorf("请求的关联数据 = %q，期望 %q", props.CorrelationData, request.RequestID)
+			return
+		}
+		response := types.MessageEnvelope{RequestID: request.RequestID, CorrelationID: request.CorrelationID, Payload: []byte("pong")}
+		errs <- responder.PublishWithProperties(response, props.ResponseTopic, 0, false, messagebus.MQTT5Properties{CorrelationData: props.CorrelationData})
+	}()
+
+	request := types.MessageEnvelope{RequestID: uuid.NewString(), CorrelationID: uuid.NewString(), Payload: []byte("ping")}
+	response, err := requester.Request(request, "test/request", "test/response", 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if response.RequestID != request.RequestID || response.ReceivedTopic != "test/response/"+request.RequestID {
+		t.Fatalf("响应 = %+v", response)
+	}
+	props, ok := messagebus.MQTT5PropertiesFromEnvelope(*response)
+	if !ok || string(props.CorrelationData) != request.RequestID {
+		t.Fatalf("响应未带回关联数据: %+v", props)
+	}
+}
+
+func TestAutoReconnectResubscribes(t *testing.T) {
+	broker := brokertest.StartMQTT(t)
+	publisher, _ := newClient(t, broker, mqtt5.Config{}, nil)
+	subscriber, clientID := newClient(t, broker, mqtt5.Config{ReconnectInterval: 20 * time.Millisecond}, map[string]string{"AutoReconnect": "true"})
+	messages := subscribe(t, subscriber, "test/reconnect")
+
+	dropped := make(chan bool, 1)
+	subscriber.NotifyBrokerDisconnect(func(takeover bool) { dropped <- takeover })
+	if !broker.DropClient(clientID) {
+		t.Fatal("Broker 上没有订阅方的连接")
+	}
+	select {
+	case takeover := <-dropped:
+		if takeover {
+			t.Error("Broker 关闭连接不应判定为会话被接管")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("未报告连接断开")
+	}
+	publishUntilReceived(t, publisher, messages, "test/reconnect")
+
+	// 主动断开后重新 Connect 同样恢复订阅，先丢弃重试发布时多收到的消息
+	for len(messages) > 0 {
+		<-messages
+	}
+	if err := subscriber.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := subscriber.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	publishUntilReceived(t, publisher, messages, "test/reconnect")
+}
+
+func TestClientReconnectsThroughNotifier(t *testing.T) {
+	broker := brokertest.StartMQTT(t)
+	client := broker.NewClient(t,
+		messagebus.WithMessageClientFactory(mqtt5.Factory(mqtt5.Config{})),
+		messagebus.WithReconnect(messagebus.ReconnectConfig{InitialDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}),
+	)
+	received := make(chan struct{}, 1)
+	err := client.Subscribe([]string{"test/client"}, func(string, types.MessageEnvelope) error {
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !broker.DropClient(client.ClientID()) {
+		t.Fatal("Broker 上没有客户端的连接")
+	}
+	timeout := time.After(2 * time.Second)
+	for reconnected := false; !reconnected; {
+		select {
+		case event := <-client.LifecycleEvents():
+			reconnected = event.Type == messagebus.EventReconnected
+		case <-timeout:
+			t.Fatal("未收到 reconnected 事件")
+		}
+	}
+
+	publisher := broker.NewClient(t)
+	deadline := time.After(2 * time.Second)
+	for {
+		if err := publisher.Publish("test/client", "after"); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-received:
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("重连后订阅未恢复")
+		}
+	}
+}
+
+// publishUntilReceived 反复发布直到订阅方收到消息，等待重新订阅完成
+func publishUntilReceived(t *testing.T, publisher *mqtt5.MessageClient, messages chan types.MessageEnvelope, topic string) {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for {
+		if err := publisher.Publish(types.MessageEnvelope{CorrelationID: uuid.NewString(), Payload: []byte("x")}, topic); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-messages:
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf("重新连接后未收到 %s 的消息", topic)
+		}
+	}
+}
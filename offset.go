@@ -0,0 +1,249 @@
+package messagebus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// 支持消费位置跟踪的底层客户端在收到的信封 QueryParams 中使用的键
+const (
+	HeaderOffsetKey = "x-offset-key" // 消费位置的键，例如消费者组、主题与分区的组合
+	HeaderOffset    = "x-offset"     // 消息在该键下的位置（十进制整数）
+)
+
+// OffsetStore 保存每个位置键已处理的最后一条消息的位置
+type OffsetStore interface {
+	// Load 返回 key 已处理的最后位置，没有记录时 ok 为 false
+	Load(key string) (offset int64, ok bool, err error)
+	// Save 记录 key 已处理到 offset（含）
+	Save(key string, offset int64) error
+}
+
+// OffsetResumer 由支持按位置恢复消费的底层客户端实现（如 kafka 子包），配置 OffsetStore 时底层客户端必须实现该接口
+type OffsetResumer interface {
+	// ResumeFrom 设置消费位置存储：此后建立的订阅从存储中记录的位置之后开始消费，
+	// 收到的信封在 QueryParams 中携带 HeaderOffsetKey 与 HeaderOffset
+	ResumeFrom(store OffsetStore) error
+}
+
+// OffsetCommitter 由需要在消息处理完后才确认消费位置的底层客户端实现（如 kafka 子包的消费者组）：
+// 客户端按 HeaderOffsetKey 与 HeaderOffset 跟踪处理进度，并将连续处理完的最后位置交给 CommitOffset，
+// 可以与 OffsetStore 同时使用
+type OffsetCommitter interface {
+	// CommitOffset 确认 key 下 offset（含）之前的消息都已处理完
+	CommitOffset(key string, offset int64) error
+}
+
+// MemoryOffsetStore 是基于内存的消费位置存储，进程退出后位置丢失，用于测试
+type MemoryOffsetStore struct {
+	mutex   sync.Mutex
+	offsets map[string]int64
+}
+
+// NewMemoryOffsetStore 创建内存消费位置存储
+func NewMemoryOffsetStore() *MemoryOffsetStore {
+	return &MemoryOffsetStore{offsets: make(map[string]int64)}
+}
+
+// Load 实现 OffsetStore
+func (m *MemoryOffsetStore) Load(key string) (int64, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	offset, ok := m.offsets[key]
+	return offset, ok, nil
+}
+
+// Save 实现 OffsetStore
+func (m *MemoryOffsetStore) Save(key string, offset int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.offsets[key] = offset
+	return nil
+}
+
+// FileOffsetStore 将消费位置以 JSON 保存在单个文件中，每次 Save 先写临时文件再重命名，进程崩溃时不会留下不完整的文件
+type FileOffsetStore struct {
+	path    string
+	mutex   sync.Mutex
+	offsets map[string]int64
+}
+
+// NewFileOffsetStore 打开 path 处的消费位置文件，文件不存在时在第一次 Save 时创建
+func NewFileOffsetStore(path string) (*FileOffsetStore, error) {
+	offsets := make(map[string]int64)
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("读取消费位置文件失败: %w", err)
+	default:
+		if err := json.Unmarshal(data, &offsets); err != nil {
+			return nil, fmt.Errorf("解析消费位置文件 %s 失败: %w", path, err)
+		}
+	}
+	return &FileOffsetStore{path: path, offsets: offsets}, nil
+}
+
+// Load 实现 OffsetStore
+func (f *FileOffsetStore) Load(key string) (int64, bool, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	offset, ok := f.offsets[key]
+	return offset, ok, nil
+}
+
+// Save 实现 OffsetStore，写入失败时保留原有记录
+func (f *FileOffsetStore) Save(key string, offset int64) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if current, ok := f.offsets[key]; ok && current == offset {
+		return nil
+	}
+	previous, existed := f.offsets[key]
+	f.offsets[key] = offset
+	data, err := json.MarshalIndent(f.offsets, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(f.path, append(data, '\n'))
+	}
+	if err != nil {
+		if existed {
+			f.offsets[key] = previous
+		} else {
+			delete(f.offsets, key)
+		}
+		return fmt.Errorf("写入消费位置文件失败: %w", err)
+	}
+	return nil
+}
+
+// offsetWindow 按收到顺序记录一个位置键下已开始处理、尚未提交的消息
+type offsetWindow struct {
+	offsets []int64
+	done    map[int64]bool
+}
+
+// offsetTracker 跟踪各位置键的处理进度，只提交之前的消息都已处理完的位置，
+// 多个 Worker 并发处理时也不会越过仍在处理中的消息
+type offsetTracker struct {
+	mutex   sync.Mutex
+	windows map[string]*offsetWindow
+}
+
+// messageOffset 读取信封中的消费位置，不含位置时 ok 为 false
+func messageOffset(msg types.MessageEnvelope) (key string, offset int64, ok bool) {
+	key = msg.QueryParams[HeaderOffsetKey]
+	if key == "" {
+		return "", 0, false
+	}
+	offset, err := strconv.ParseInt(msg.QueryParams[HeaderOffset], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return key, offset, true
+}
+
+// offsetCommitter 返回实现 OffsetCommitter 的底层客户端，没有时返回 nil
+func (c *Client) offsetCommitter() OffsetCommitter {
+	client := c.messageClient()
+	if rewriting, ok := client.(*rewritingClient); ok {
+		client = rewriting.MessageClient
+	}
+	if relay, ok := client.(*relayClient); ok {
+		client = relay.MessageClient
+	}
+	committer, _ := client.(OffsetCommitter)
+	return committer
+}
+
+// tracksOffsets 判断是否需要跟踪消费位置：配置了 OffsetStore 或底层客户端实现 OffsetCommitter
+func (c *Client) tracksOffsets() bool {
+	return c.config.OffsetStore != nil || c.offsetCommitter() != nil
+}
+
+// trackOffset 在消息开始处理前按收到顺序登记其位置，无需跟踪消费位置或信封不含位置时不做任何事
+func (c *Client) trackOffset(msg types.MessageEnvelope) {
+	if !c.tracksOffsets() {
+		return
+	}
+	key, offset, ok := messageOffset(msg)
+	if !ok {
+		return
+	}
+	t := &c.offsets
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.windows == nil {
+		t.windows = make(map[string]*offsetWindow)
+	}
+	window, ok := t.windows[key]
+	if !ok {
+		window = &offsetWindow{done: make(map[int64]bool)}
+		t.windows[key] = window
+	}
+	window.offsets = append(window.offsets, offset)
+}
+
+// commitOffset 标记消息已处理完（包括处理失败、被采样或过期丢弃），并将连续处理完的最后位置
+// 写入 OffsetStore、交给实现 OffsetCommitter 的底层客户端
+func (c *Client) commitOffset(topic string, msg types.MessageEnvelope) {
+	if !c.tracksOffsets() {
+		return
+	}
+	key, offset, ok := messageOffset(msg)
+	if !ok {
+		return
+	}
+	t := &c.offsets
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	window, ok := t.windows[key]
+	if !ok {
+		return
+	}
+	window.done[offset] = true
+	committed := -1
+	for committed+1 < len(window.offsets) && window.done[window.offsets[committed+1]] {
+		committed++
+	}
+	if committed < 0 {
+		return
+	}
+	last := window.offsets[committed]
+	for _, o := range window.offsets[:committed+1] {
+		delete(window.done, o)
+	}
+	window.offsets = window.offsets[committed+1:]
+	if len(window.offsets) == 0 {
+		delete(t.windows, key)
+	}
+	if c.config.OffsetStore != nil {
+		if err := c.config.OffsetStore.Save(key, last); err != nil {
+			c.log(LogSubscribe).Warn("保存消费位置失败", c.logFields("topic", topic, "key", key, "offset", last, "error", err)...)
+			c.reportError("offset", topic, err)
+		}
+	}
+	if committer := c.offsetCommitter(); committer != nil {
+		if err := committer.CommitOffset(key, last); err != nil {
+			c.log(LogSubscribe).Warn("提交消费位置失败", c.logFields("topic", topic, "key", key, "offset", last, "error", err)...)
+			c.reportError("offset", topic, err)
+		}
+	}
+}
+
+// resumeFrom 将 OffsetStore 交给底层客户端，底层客户端不支持按位置恢复消费时返回错误
+func resumeFrom(config Config, client interface{}) error {
+	if config.OffsetStore == nil {
+		return nil
+	}
+	resumer, ok := client.(OffsetResumer)
+	if !ok {
+		return fmt.Errorf("%s 类型的底层客户端不支持消费位置跟踪", config.Type)
+	}
+	return resumer.ResumeFrom(config.OffsetStore)
+}
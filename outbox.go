@@ -0,0 +1,202 @@
+package messagebus
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// defaultOutboxCapacity 是内存环形缓冲区未指定容量时保留的消息数
+const defaultOutboxCapacity = 1000
+
+// QueuedMessage 表示离线期间暂存、等待转发的消息
+type QueuedMessage struct {
+	Topic    string
+	Envelope types.MessageEnvelope
+	QueuedAt time.Time
+}
+
+// OutboxStore 定义离线消息的存储后端，消息必须按写入顺序取出
+type OutboxStore interface {
+	// Append 在队尾追加消息，超出保留上限时由实现决定丢弃最旧的消息
+	Append(message QueuedMessage) error
+	// Peek 返回队首消息，队列为空时 ok 为 false
+	Peek() (message QueuedMessage, ok bool, err error)
+	// Remove 删除队首消息
+	Remove() error
+	// Len 返回队列中的消息数
+	Len() (int, error)
+}
+
+// OutboxConfig 表示存储转发参数
+type OutboxConfig struct {
+	Store  OutboxStore   // 存储后端，为空时不启用存储转发
+	MaxAge time.Duration // 消息最长保留时间，转发时丢弃超时消息，0 表示不限制
+}
+
+// MemoryOutbox 是基于内存环形缓冲区的存储后端，写满时丢弃最旧的消息，进程退出后消息丢失
+type MemoryOutbox struct {
+	mutex    sync.Mutex
+	messages []QueuedMessage
+	head     int
+	size     int
+	dropped  uint64
+}
+
+// NewMemoryOutbox 创建最多保留 capacity 条消息的内存存储后端，capacity <= 0 时为 1000
+func NewMemoryOutbox(capacity int) *MemoryOutbox {
+	if capacity <= 0 {
+		capacity = defaultOutboxCapacity
+	}
+	return &MemoryOutbox{messages: make([]QueuedMessage, capacity)}
+}
+
+// Append 实现 OutboxStore
+func (m *MemoryOutbox) Append(message QueuedMessage) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.size == len(m.messages) {
+		m.messages[m.head] = QueuedMessage{}
+		m.head = (m.head + 1) % len(m.messages)
+		m.size--
+		m.dropped++
+	}
+	m.messages[(m.head+m.size)%len(m.messages)] = message
+	m.size++
+	return nil
+}
+
+// Peek 实现 OutboxStore
+func (m *MemoryOutbox) Peek() (QueuedMessage, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.size == 0 {
+		return QueuedMessage{}, false, nil
+	}
+	return m.messages[m.head], true, nil
+}
+
+// Remove 实现 OutboxStore
+func (m *MemoryOutbox) Remove() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.size == 0 {
+		return nil
+	}
+	m.messages[m.head] = QueuedMessage{}
+	m.head = (m.head + 1) % len(m.messages)
+	m.size--
+	return nil
+}
+
+// Len 实现 OutboxStore
+func (m *MemoryOutbox) Len() (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.size, nil
+}
+
+// Dropped 返回因缓冲区写满而丢弃的消息数
+func (m *MemoryOutbox) Dropped() uint64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.dropped
+}
+
+// outboxEnabled 判断是否配置了存储转发
+func (c *Client) outboxEnabled() bool {
+	return c.config.Outbox.Store != nil
+}
+
+// outboxPending 判断存储转发队列中是否仍有待转发消息
+func (c *Client) outboxPending() bool {
+	n, err := c.config.Outbox.Store.Len()
+	return err == nil && n > 0
+}
+
+// enqueue 将无法立即发布的消息写入存储转发队列
+func (c *Client) enqueue(topic string, envelope types.MessageEnvelope) error {
+	err := c.config.Outbox.Store.Append(QueuedMessage{Topic: topic, Envelope: envelope, QueuedAt: time.Now()})
+	if err != nil {
+		return fmt.Errorf("写入存储转发队列失败: %w", err)
+	}
+	c.log(LogPublish).Debug("消息已暂存，等待连接恢复后转发", c.logFields("topic", topic, "correlationId", envelope.CorrelationID)...)
+	return nil
+}
+
+// startOutboxDrain 在后台按顺序转发队列中的消息，同一时间只运行一个转发任务
+func (c *Client) startOutboxDrain() {
+	if !c.outboxEnabled() || !c.outboxPending() {
+		return
+	}
+	if !c.outboxDraining.CompareAndSwap(false, true) {
+		return
+	}
+	c.lifecycle.spawn(stagePublish, func(stop <-chan struct{}) {
+		for {
+			err := c.drainOutbox(stop)
+			c.outboxDraining.Store(false)
+			if err != nil {
+				c.log(LogPublish).Warn("转发暂存消息中断", c.logFields("error", err)...)
+				c.reportError("outbox", "", err)
+				return
+			}
+			// 转发结束到清除标记之间写入的消息，其发布方看到转发仍在进行而未启动新的转发，清除标记后复查
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if !c.IsConnected() || !c.outboxPending() || !c.outboxDraining.CompareAndSwap(false, true) {
+				return
+			}
+		}
+	})
+}
+
+// drainOutbox 按写入顺序转发暂存消息，发布失败时停止并保留剩余消息
+func (c *Client) drainOutbox(stop <-chan struct{}) error {
+	store := c.config.Outbox.Store
+	forwarded := 0
+	for {
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		message, ok, err := store.Peek()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if forwarded > 0 {
+				c.log(LogPublish).Info("暂存消息已全部转发", c.logFields("count", forwarded)...)
+			}
+			return nil
+		}
+		if maxAge := c.config.Outbox.MaxAge; maxAge > 0 && time.Since(message.QueuedAt) > maxAge {
+			c.log(LogPublish).Warn("丢弃超过保留时间的暂存消息", c.logFields("topic", message.Topic, "correlationId", message.Envelope.CorrelationID)...)
+			if err := store.Remove(); err != nil {
+				return err
+			}
+			continue
+		}
+		if !c.IsConnected() {
+			return ErrNotConnected
+		}
+		if err := c.messageClient().Publish(message.Envelope, message.Topic); err != nil {
+			c.stats.publishErrors.Add(1)
+			c.reconnectInBackground(err)
+			return err
+		}
+		c.metrics.ObservePublish()
+		c.stats.recordPublish(message.Topic, payloadSize(message.Envelope.Payload))
+		c.tap(TapOutbound, message.Topic, message.Envelope)
+		if err := store.Remove(); err != nil {
+			return err
+		}
+		forwarded++
+	}
+}
@@ -0,0 +1,179 @@
+package messagebus_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// newOutboxClient 创建启用存储转发、尚未连接的客户端
+func newOutboxClient(t *testing.T, broker *messagebustest.Broker, outbox messagebus.OutboxConfig) *messagebus.Client {
+	t.Helper()
+	config := testConfig()
+	config.MessageClientFactory = broker.MessageClientFactory("outbox")
+	config.Outbox = outbox
+	client, err := messagebus.NewClient(config, logger.NewMockClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// publishedPayloads 返回 Broker 上 topic 的消息内容，按发布顺序排列
+func publishedPayloads(t *testing.T, broker *messagebustest.Broker, topic string) []string {
+	t.Helper()
+	var payloads []string
+	for _, msg := range broker.Published() {
+		if msg.Topic != topic {
+			continue
+		}
+		data, err := messagebus.EnvelopePayloadBytes(msg.Envelope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payloads = append(payloads, string(data))
+	}
+	return payloads
+}
+
+func TestMemoryOutboxOverwritesOldest(t *testing.T) {
+	outbox := messagebus.NewMemoryOutbox(3)
+	for i := 0; i < 5; i++ {
+		if err := outbox.Append(messagebus.QueuedMessage{Topic: fmt.Sprintf("m%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, _ := outbox.Len(); n != 3 {
+		t.Fatalf("Len = %d，期望 3", n)
+	}
+	if dropped := outbox.Dropped(); dropped != 2 {
+		t.Errorf("Dropped = %d，期望 2", dropped)
+	}
+	for _, want := range []string{"m2", "m3", "m4"} {
+		msg, ok, err := outbox.Peek()
+		if err != nil || !ok || msg.Topic != want {
+			t.Fatalf("Peek = %q, %v, %v，期望 %q", msg.Topic, ok, err, want)
+		}
+		if err := outbox.Remove(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok, _ := outbox.Peek(); ok {
+		t.Error("取出所有消息后队列应为空")
+	}
+}
+
+func TestOutboxDropsMessagesOlderThanMaxAge(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	outbox := messagebus.NewMemoryOutbox(16)
+	client := newOutboxClient(t, broker, messagebus.OutboxConfig{Store: outbox, MaxAge: 50 * time.Millisecond})
+	if err := client.Publish("test/outbox", "stale"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := client.Publish("test/outbox", "fresh"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	waitForOutbox(t, outbox)
+	if got := publishedPayloads(t, broker, "test/outbox"); len(got) != 1 || got[0] != "fresh" {
+		t.Fatalf("转发的消息 = %v，期望只转发未超时的 fresh", got)
+	}
+}
+
+func TestOutboxDrainsInOrderAfterReconnect(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	outbox := messagebus.NewMemoryOutbox(1000)
+	client := newOutboxClient(t, broker, messagebus.OutboxConfig{Store: outbox})
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for i := 0; i < 10; i++ {
+		text := fmt.Sprintf("offline-%d", i)
+		want = append(want, text)
+		if err := client.Publish("test/outbox", text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, _ := outbox.Len(); n != 10 {
+		t.Fatalf("断开期间暂存 %d 条消息，期望 10", n)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	// 转发期间继续发布的消息排在暂存消息之后
+	for i := 0; i < 5; i++ {
+		text := fmt.Sprintf("online-%d", i)
+		want = append(want, text)
+		if err := client.Publish("test/outbox", text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitForOutbox(t, outbox)
+	got := publishedPayloads(t, broker, "test/outbox")
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("转发顺序 = %v\n期望 %v", got, want)
+	}
+}
+
+func TestOutboxDrainPicksUpConcurrentPublishes(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		broker := messagebustest.NewBroker()
+		outbox := messagebus.NewMemoryOutbox(1000)
+		client := newOutboxClient(t, broker, messagebus.OutboxConfig{Store: outbox})
+		if err := client.Publish("test/outbox", "queued"); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		// 转发结束的同时发布的消息进入队列，不能因转发任务正在退出而滞留
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 25; j++ {
+					_ = client.Publish("test/outbox", fmt.Sprintf("%d-%d", i, j))
+				}
+			}(i)
+		}
+		wg.Wait()
+		waitForOutbox(t, outbox)
+		if got := len(publishedPayloads(t, broker, "test/outbox")); got != 101 {
+			t.Fatalf("第 %d 轮转发了 %d 条消息，期望 101", round, got)
+		}
+		_ = client.Close()
+	}
+}
+
+// waitForOutbox 等待存储转发队列清空
+func waitForOutbox(t *testing.T, outbox messagebus.OutboxStore) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		n, err := outbox.Len()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("存储转发队列仍有 %d 条消息未转发", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,67 @@
+package messagebus
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// PanicHandler 是处理函数发生 panic 后的回调，recovered 为 recover() 的返回值，stack 为发生 panic 时的调用栈
+type PanicHandler func(topic string, message types.MessageEnvelope, recovered interface{}, stack []byte)
+
+// PanicError 表示处理函数发生 panic，作为该消息的处理错误计入统计并发送到错误通道
+type PanicError struct {
+	Value interface{} // recover() 的返回值
+	Stack []byte      // 发生 panic 时的调用栈
+}
+
+// Error 实现 error 接口
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("处理函数发生panic: %v", e.Value)
+}
+
+// OnPanic 注册处理函数发生 panic 后的回调；传入 nil 取消回调
+// 无论是否注册回调，panic 都会被恢复并记录调用栈，订阅继续处理后续消息
+func (c *Client) OnPanic(handler PanicHandler) {
+	c.callbacks.mutex.Lock()
+	defer c.callbacks.mutex.Unlock()
+	c.callbacks.onPanic = handler
+}
+
+// callHandler 调用订阅的处理函数，将 panic 恢复为 *PanicError，使处理 goroutine 继续处理后续消息
+func (c *Client) callHandler(sub *subscription, topic string, message types.MessageEnvelope) (err error) {
+	start := time.Now()
+	defer func() {
+		c.observeHandlerLatency(topic, message, time.Since(start))
+	}()
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		stack := debug.Stack()
+		err = &PanicError{Value: r, Stack: stack}
+		c.stats.handlerPanics.Add(1)
+		c.log(LogSubscribe).Error("处理函数发生panic，已恢复", c.logFields("topic", topic, "correlationId", message.CorrelationID, "panic", r, "stack", string(stack))...)
+		c.notifyPanic(topic, message, r, stack)
+	}()
+	return sub.handler(topic, message)
+}
+
+// notifyPanic 调用 OnPanic 回调，回调自身的 panic 只记录日志
+func (c *Client) notifyPanic(topic string, message types.MessageEnvelope, recovered interface{}, stack []byte) {
+	c.callbacks.mutex.Lock()
+	onPanic := c.callbacks.onPanic
+	c.callbacks.mutex.Unlock()
+	if onPanic == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			c.log(LogSubscribe).Error("panic回调发生panic", c.logFields("topic", topic, "panic", r)...)
+		}
+	}()
+	onPanic(topic, message, recovered, stack)
+}
@@ -0,0 +1,69 @@
+package messagebus_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// 发布方设置的字符串 Payload 恰好是合法的 base64 时，压缩、加密和分块都应按原文处理
+func TestOutboundStringPayloadKeepsText(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+	cases := []struct {
+		name string
+		opts []messagebus.Option
+	}{
+		{"compression", []messagebus.Option{messagebus.WithCompression("gzip", 16)}},
+		{"encryption", []messagebus.Option{messagebus.WithEncryption(messagebus.NewStaticKeyProvider("k1", key))}},
+		{"chunking", []messagebus.Option{messagebus.WithChunking(512)}},
+	}
+	text := strings.Repeat("abcd", 500)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			broker := messagebustest.NewBroker()
+			publisher, err := messagebustest.NewMockClientWithBroker(broker, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = publisher.Close() })
+			subscriber, err := messagebustest.NewMockClientWithBroker(broker, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = subscriber.Close() })
+			received := make(chan types.MessageEnvelope, 1)
+			if err := subscriber.Subscribe([]string{"test/payload"}, func(_ string, msg types.MessageEnvelope) error {
+				received <- msg
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			envelope, err := publisher.CreateMessageEnvelope(nil, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			envelope.Payload = text
+			if err := publisher.PublishEnvelope("test/payload", envelope); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case msg := <-received:
+				data, err := messagebus.EnvelopePayloadBytes(msg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != text {
+					t.Errorf("收到 %d 字节 %.16q...，期望原文 %d 字节", len(data), data, len(text))
+				}
+			case <-time.After(time.Second):
+				t.Fatal("未收到消息")
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package messagebus
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultHeartbeatInterval 是未配置 Interval 时发布心跳的间隔
+const defaultHeartbeatInterval = 30 * time.Second
+
+// HeartbeatStatusUp 是未设置 HeartbeatOptions.Status 时心跳携带的状态
+const HeartbeatStatusUp = "up"
+
+// PayloadFunc 在每次定时发布时生成 Payload，返回错误时跳过本次发布
+type PayloadFunc func() (interface{}, error)
+
+// PeriodicPublisher 按固定间隔向主题发布 Payload
+// 发布器随客户端连接启动、随断开连接停止，重新连接后自动恢复，调用 Close 后不再发布
+type PeriodicPublisher struct {
+	client   *Client
+	topic    string
+	interval time.Duration
+	payload  PayloadFunc
+	done     chan struct{}
+	closed   sync.Once
+	running  <-chan struct{} // 当前运行所在的发布阶段停止信号，避免同一连接周期内重复启动
+}
+
+// NewPeriodicPublisher 创建定时发布器，客户端已连接时立即开始发布
+func (c *Client) NewPeriodicPublisher(topic string, interval time.Duration, payload PayloadFunc) (*PeriodicPublisher, error) {
+	if topic == "" {
+		return nil, fmt.Errorf("发布主题不能为空")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("发布间隔必须大于 0")
+	}
+	if payload == nil {
+		return nil, fmt.Errorf("Payload 生成函数不能为空")
+	}
+	p := &PeriodicPublisher{
+		client:   c,
+		topic:    topic,
+		interval: interval,
+		payload:  payload,
+		done:     make(chan struct{}),
+	}
+	c.periodicMu.Lock()
+	c.periodic[p] = struct{}{}
+	c.periodicMu.Unlock()
+	if c.IsConnected() {
+		c.startPeriodic()
+	}
+	return p, nil
+}
+
+// Publish 立即生成并发布一次 Payload
+func (p *PeriodicPublisher) Publish() error {
+	payload, err := p.payload()
+	if err != nil {
+		return fmt.Errorf("生成 Payload 失败: %w", err)
+	}
+	return p.client.Publish(p.topic, payload)
+}
+
+// Close 停止定时发布，可重复调用
+func (p *PeriodicPublisher) Close() {
+	p.closed.Do(func() {
+		close(p.done)
+		p.client.periodicMu.Lock()
+		delete(p.client.periodic, p)
+		p.client.periodicMu.Unlock()
+	})
+}
+
+// run 启动后立即发布一次，之后按间隔发布，客户端停止发布阶段或 Close 时退出
+func (p *PeriodicPublisher) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Publish(); err != nil {
+			p.client.log(LogPublish).Warn("定时发布失败", p.client.logFields("topic", p.topic, "error", err)...)
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// startPeriodic 为尚未在当前连接周期内运行的定时发布器启动发布 goroutine
+func (c *Client) startPeriodic() {
+	stop := c.lifecycle.stopChan(stagePublish)
+	c.periodicMu.Lock()
+	defer c.periodicMu.Unlock()
+	for p := range c.periodic {
+		if p.running == stop {
+			continue
+		}
+		p.running = stop
+		c.lifecycle.spawn(stagePublish, p.run)
+	}
+}
+
+// HeartbeatOptions 表示心跳发布器的可选参数
+type HeartbeatOptions struct {
+	// Interval 发布间隔，默认 30 秒
+	Interval time.Duration
+	// Status 返回心跳携带的状态，默认为 up
+	Status func() string
+	// Template 根据默认心跳内容生成最终 Payload，为空时直接发布 Heartbeat
+	Template func(Heartbeat) interface{}
+}
+
+// Heartbeat 表示默认的心跳内容
+type Heartbeat struct {
+	ServiceName   string            `json:"serviceName"`
+	ClientID      string            `json:"clientId"`
+	Status        string            `json:"status"`
+	UptimeSeconds float64           `json:"uptimeSeconds"` // 自心跳发布器创建起的秒数
+	Timestamp     int64             `json:"timestamp"`     // 发布时间，Unix 纳秒
+	Sequence      uint64            `json:"sequence"`      // 从 1 开始递增的序号
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+// NewHeartbeat 创建按间隔向 topic 发布心跳的定时发布器
+func (c *Client) NewHeartbeat(topic string, opts HeartbeatOptions) (*PeriodicPublisher, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultHeartbeatInterval
+	}
+	started := time.Now()
+	var sequence atomic.Uint64
+	return c.NewPeriodicPublisher(topic, opts.Interval, func() (interface{}, error) {
+		status := HeartbeatStatusUp
+		if opts.Status != nil {
+			status = opts.Status()
+		}
+		now := time.Now()
+		heartbeat := Heartbeat{
+			ServiceName:   c.serviceName(),
+			ClientID:      c.config.ClientID,
+			Status:        status,
+			UptimeSeconds: now.Sub(started).Seconds(),
+			Timestamp:     now.UnixNano(),
+			Sequence:      sequence.Add(1),
+			Tags:          c.config.Tags,
+		}
+		if opts.Template != nil {
+			return opts.Template(heartbeat), nil
+		}
+		return heartbeat, nil
+	})
+}
@@ -0,0 +1,65 @@
+package messagebus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// maxPooledBufferSize 超过该容量的缓冲区不放回池中，避免偶发的大消息长期占用内存
+const maxPooledBufferSize = 64 * 1024
+
+// bufferPool 复用编码和压缩 Payload 时使用的临时缓冲区
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// getBuffer 从池中取出已清空的缓冲区
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer 将缓冲区放回池中，过大的缓冲区直接丢弃
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBufferSize {
+		bufferPool.Put(buf)
+	}
+}
+
+// withPayloadBytes 以字节形式将 Payload 交给 fn，用于只在发布或接收流程中临时读取 Payload 的场景
+// []byte 直接传递不复制，字符串按 payloadBytes 还原 base64；其他对象编码为 JSON 写入池化缓冲区，
+// fn 返回后缓冲区被复用，fn 不能保留传入的切片
+func withPayloadBytes(payload interface{}, fn func(data []byte) error) error {
+	switch payload.(type) {
+	case nil, []byte, string:
+		data, err := payloadBytes(payload)
+		if err != nil {
+			return err
+		}
+		return fn(data)
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := json.NewEncoder(buf).Encode(payload); err != nil {
+		return fmt.Errorf("编码Payload失败: %w", err)
+	}
+	// 去掉 Encoder 追加的换行，与 json.Marshal 的结果一致
+	return fn(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+// withOutboundPayloadBytes 与 withPayloadBytes 相同，用于发布流程，字符串 Payload 按原始文本传递
+func withOutboundPayloadBytes(payload interface{}, fn func(data []byte) error) error {
+	if s, ok := payload.(string); ok {
+		return fn([]byte(s))
+	}
+	return withPayloadBytes(payload, fn)
+}
+
+// byteCounter 只统计写入字节数的 io.Writer，用于不分配内存地计算对象编码后的大小
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
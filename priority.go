@@ -0,0 +1,79 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// HeaderPriority 是信封 QueryParams 中记录消息优先级的键，高优先级消息取值为 "high"
+const HeaderPriority = "x-priority"
+
+// Priority 表示消息优先级，订阅方设置 SubscribeOptions.PriorityLanes 后优先处理高优先级消息
+type Priority int
+
+const (
+	PriorityNormal Priority = iota // 普通优先级（默认），不写入 HeaderPriority
+	PriorityHigh                   // 高优先级，用于告警、命令等不应排在遥测积压之后的消息
+)
+
+// priorityHigh 是高优先级在 HeaderPriority 中的取值
+const priorityHigh = "high"
+
+// validate 校验优先级取值
+func (p Priority) validate() error {
+	if p != PriorityNormal && p != PriorityHigh {
+		return fmt.Errorf("不支持的消息优先级: %d", p)
+	}
+	return nil
+}
+
+// SetPriority 在信封 QueryParams 中写入优先级，PriorityNormal 时移除标记
+func SetPriority(envelope *types.MessageEnvelope, priority Priority) {
+	if priority == PriorityNormal {
+		delete(envelope.QueryParams, HeaderPriority)
+		return
+	}
+	if envelope.QueryParams == nil {
+		envelope.QueryParams = make(map[string]string)
+	}
+	envelope.QueryParams[HeaderPriority] = priorityHigh
+}
+
+// MessagePriority 返回信封的优先级，未标记时为 PriorityNormal
+func MessagePriority(envelope types.MessageEnvelope) Priority {
+	if strings.EqualFold(envelope.QueryParams[HeaderPriority], priorityHigh) {
+		return PriorityHigh
+	}
+	return PriorityNormal
+}
+
+// PublishWithPriority 以指定优先级发布消息，除优先级标记外与 Publish 相同
+func (c *Client) PublishWithPriority(topic string, data interface{}, priority Priority) error {
+	if err := priority.validate(); err != nil {
+		return err
+	}
+	payload, contentType, err := c.encodePayload(data)
+	if err != nil {
+		return err
+	}
+	envelope := newEnvelope(payload, contentType)
+	SetPriority(&envelope, priority)
+	return c.publishEnvelope(context.Background(), topic, envelope)
+}
+
+// urgent 判断消息是否应进入订阅的高优先级队列
+func (s *subscription) urgent(msg types.MessageEnvelope) bool {
+	return s.priority != nil && MessagePriority(msg) == PriorityHigh
+}
+
+// offerUrgent 将高优先级消息放入高优先级队列，队列已满时阻塞而不丢弃，stop 或 done 关闭时放弃
+func (c *Client) offerUrgent(sub *subscription, msg types.MessageEnvelope, stop <-chan struct{}) {
+	select {
+	case sub.priority <- msg:
+	case <-stop:
+	case <-sub.done:
+	}
+}
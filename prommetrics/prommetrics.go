@@ -0,0 +1,164 @@
+// Package prommetrics 以 Prometheus 格式导出客户端的发布/订阅指标
+//
+// 该包单独存放，只有导入它的程序才会依赖 github.com/prometheus/client_golang：
+//
+//	client, err := messagebus.NewClientWithOptions(
+//	    messagebus.WithMetrics(prommetrics.New()),
+//	)
+//	prometheus.MustRegister(prommetrics.Collector(client))
+package prommetrics
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Namespace 是指标命名空间，所有指标名称以 edgex_messagebus_ 开头
+const Namespace = "edgex_messagebus"
+
+// labelNamePattern Prometheus 标签名称的合法格式
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Metrics 记录发布/订阅活动的 Prometheus 指标，实现 messagebus.MetricsRecorder 和 prometheus.Collector
+type Metrics struct {
+	published       prometheus.Counter
+	received        *prometheus.CounterVec
+	handlerErrors   *prometheus.CounterVec
+	handlerDuration *prometheus.HistogramVec
+	overflowDropped *prometheus.CounterVec
+	reconnects      prometheus.Counter
+	errorChanDepth  *prometheus.Desc
+	errorDepth      func() int
+}
+
+var (
+	_ messagebus.MetricsRecorder = (*Metrics)(nil)
+	_ prometheus.Collector       = (*Metrics)(nil)
+)
+
+// New 返回用于 Config.Metrics 的工厂，客户端 ID 和标签作为常量标签附加到每个指标
+// 标签名需符合 Prometheus 命名规则，且不能为 client_id 或 topic，否则创建客户端失败
+func New() messagebus.MetricsFactory {
+	return func(info messagebus.MetricsInfo) (messagebus.MetricsRecorder, error) {
+		return NewMetrics(info)
+	}
+}
+
+// NewMetrics 按客户端信息创建指标集合
+func NewMetrics(info messagebus.MetricsInfo) (*Metrics, error) {
+	labels := prometheus.Labels{"client_id": info.ClientID}
+	for k, v := range info.Tags {
+		if !labelNamePattern.MatchString(k) || k == "client_id" || k == "topic" {
+			return nil, fmt.Errorf("标签 %q 不能用作指标标签", k)
+		}
+		labels[k] = v
+	}
+	errorDepth := info.ErrorQueueDepth
+	if errorDepth == nil {
+		errorDepth = func() int { return 0 }
+	}
+	return &Metrics{
+		published: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   Namespace,
+			Name:        "messages_published_total",
+			Help:        "已成功发布的消息数量",
+			ConstLabels: labels,
+		}),
+		received: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   Namespace,
+			Name:        "messages_received_total",
+			Help:        "按订阅主题统计的已接收消息数量",
+			ConstLabels: labels,
+		}, []string{"topic"}),
+		handlerErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   Namespace,
+			Name:        "handler_errors_total",
+			Help:        "按订阅主题统计的消息处理函数返回错误次数",
+			ConstLabels: labels,
+		}, []string{"topic"}),
+		handlerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   Namespace,
+			Name:        "handler_duration_seconds",
+			Help:        "按订阅主题统计的消息处理耗时",
+			ConstLabels: labels,
+			Buckets:     prometheus.DefBuckets,
+		}, []string{"topic"}),
+		overflowDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   Namespace,
+			Name:        "messages_overflow_dropped_total",
+			Help:        "按订阅主题统计的因缓冲区已满被丢弃的消息数量",
+			ConstLabels: labels,
+		}, []string{"topic"}),
+		reconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   Namespace,
+			Name:        "reconnects_total",
+			Help:        "成功重连的次数",
+			ConstLabels: labels,
+		}),
+		errorChanDepth: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "error_channel_depth"),
+			"错误通道中等待读取的错误数量",
+			nil, labels,
+		),
+		errorDepth: errorDepth,
+	}, nil
+}
+
+// Collector 返回客户端的 Prometheus 指标收集器，客户端未使用本包的 New 创建指标时返回 nil
+// 返回值可直接注册到 prometheus.Registerer
+func Collector(client *messagebus.Client) prometheus.Collector {
+	if metrics, ok := client.Metrics().(*Metrics); ok {
+		return metrics
+	}
+	return nil
+}
+
+// Describe 实现 prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.published.Describe(ch)
+	m.received.Describe(ch)
+	m.handlerErrors.Describe(ch)
+	m.handlerDuration.Describe(ch)
+	m.overflowDropped.Describe(ch)
+	m.reconnects.Describe(ch)
+	ch <- m.errorChanDepth
+}
+
+// Collect 实现 prometheus.Collector
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.published.Collect(ch)
+	m.received.Collect(ch)
+	m.handlerErrors.Collect(ch)
+	m.handlerDuration.Collect(ch)
+	m.overflowDropped.Collect(ch)
+	m.reconnects.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(m.errorChanDepth, prometheus.GaugeValue, float64(m.errorDepth()))
+}
+
+// ObservePublish 实现 messagebus.MetricsRecorder
+func (m *Metrics) ObservePublish() {
+	m.published.Inc()
+}
+
+// ObserveHandler 实现 messagebus.MetricsRecorder
+func (m *Metrics) ObserveHandler(topic string, elapsed time.Duration, err error) {
+	m.received.WithLabelValues(topic).Inc()
+	m.handlerDuration.WithLabelValues(topic).Observe(elapsed.Seconds())
+	if err != nil {
+		m.handlerErrors.WithLabelValues(topic).Inc()
+	}
+}
+
+// ObserveOverflowDrop 实现 messagebus.MetricsRecorder
+func (m *Metrics) ObserveOverflowDrop(topic string) {
+	m.overflowDropped.WithLabelValues(topic).Inc()
+}
+
+// ObserveReconnect 实现 messagebus.MetricsRecorder
+func (m *Metrics) ObserveReconnect() {
+	m.reconnects.Inc()
+}
@@ -0,0 +1,67 @@
+package prommetrics_test
+
+import (
+	"strings"
+	"testing"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/clint456/edgex-messagebus-client/prommetrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollectorExportsPublishMetrics(t *testing.T) {
+	client, err := messagebustest.NewMockClient(
+		messagebus.WithMetrics(prommetrics.New()),
+		messagebus.WithTags(map[string]string{"tenant": "acme"}),
+	)
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Publish("test/metrics", map[string]string{"k": "v"}); err != nil {
+		t.Fatalf("发布失败: %v", err)
+	}
+
+	collector := prommetrics.Collector(client.Client)
+	if collector == nil {
+		t.Fatal("Collector 返回 nil")
+	}
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("注册收集器失败: %v", err)
+	}
+	expected := `
+# HELP edgex_messagebus_messages_published_total 已成功发布的消息数量
+# TYPE edgex_messagebus_messages_published_total counter
+edgex_messagebus_messages_published_total{client_id="` + client.ClientID() + `",tenant="acme"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "edgex_messagebus_messages_published_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorNilWithoutMetrics(t *testing.T) {
+	client, err := messagebustest.NewMockClient()
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	defer client.Close()
+	if collector := prommetrics.Collector(client.Client); collector != nil {
+		t.Errorf("未配置指标时 Collector 返回 %v，期望 nil", collector)
+	}
+}
+
+func TestNewRejectsReservedTagNames(t *testing.T) {
+	for _, tag := range []string{"client_id", "topic", "bad-name"} {
+		_, err := messagebus.NewClientWithOptions(
+			messagebus.WithMetrics(prommetrics.New()),
+			messagebus.WithTags(map[string]string{tag: "x"}),
+		)
+		if err == nil {
+			t.Errorf("标签 %q 应导致创建客户端失败", tag)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package protobuf 提供 protobuf 格式的 Payload 序列化器
+//
+// 该包单独存放，只有导入它的程序才会依赖 google.golang.org/protobuf。
+package protobuf
+
+import (
+	"fmt"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentTypeProtobuf 是 protobuf Payload 的内容类型
+const ContentTypeProtobuf = "application/x-protobuf"
+
+// Serializer 实现 messagebus.Codec，要求数据为 proto.Message
+type Serializer struct{}
+
+var _ messagebus.Codec = Serializer{}
+
+// 导入本包时自动注册 protobuf 编解码器，使 messagebus.DecodePayload 可以按 ContentType 解码
+func init() {
+	messagebus.RegisterCodec(Serializer{})
+}
+
+// ContentType 返回 protobuf 内容类型
+func (Serializer) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+// MarshalPayload 将 proto.Message 编码为字节切片
+func (Serializer) MarshalPayload(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("数据类型 %T 不是 proto.Message", v)
+	}
+	return proto.Marshal(msg)
+}
+
+// UnmarshalPayload 将字节切片解码到 proto.Message 中
+func (Serializer) UnmarshalPayload(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("目标类型 %T 不是 proto.Message", v)
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// Handler 定义处理已解码 protobuf 消息的函数类型
+type Handler func(topic string, msg proto.Message, message types.MessageEnvelope) error
+
+// NewMessageHandler 返回一个 messagebus.MessageHandler，使用 factory 创建的消息对象解码 Payload 后交给 handler
+func NewMessageHandler(factory func() proto.Message, handler Handler) messagebus.MessageHandler {
+	if factory == nil || handler == nil {
+		return nil
+	}
+	return func(topic string, message types.MessageEnvelope) error {
+		data, err := messagebus.EnvelopePayloadBytes(message)
+		if err != nil {
+			return err
+		}
+		msg := factory()
+		if err := proto.Unmarshal(data, msg); err != nil {
+			return fmt.Errorf("解码主题 %s 的protobuf消息失败: %w", topic, err)
+		}
+		return handler(topic, msg, message)
+	}
+}
@@ -0,0 +1,76 @@
+package protobuf_test
+
+import (
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/clint456/edgex-messagebus-client/protobuf"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestSerializerRoundTrip(t *testing.T) {
+	want, err := structpb.NewStruct(map[string]interface{}{"device": "dev1", "value": 21.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := protobuf.Serializer{}.MarshalPayload(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &structpb.Struct{}
+	if err := (protobuf.Serializer{}).UnmarshalPayload(data, got); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(got, want) {
+		t.Fatalf("解码结果 %v，期望 %v", got, want)
+	}
+	if _, err := (protobuf.Serializer{}).MarshalPayload(map[string]string{}); err == nil {
+		t.Fatal("非 proto.Message 应编码失败")
+	}
+}
+
+func TestPublishAndSubscribeProtobuf(t *testing.T) {
+	client, err := messagebustest.NewMockClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	received := make(chan *wrapperspb.StringValue, 1)
+	handler := protobuf.NewMessageHandler(
+		func() proto.Message { return &wrapperspb.StringValue{} },
+		func(_ string, msg proto.Message, envelope types.MessageEnvelope) error {
+			if envelope.ContentType != protobuf.ContentTypeProtobuf {
+				t.Errorf("ContentType = %q", envelope.ContentType)
+			}
+			received <- msg.(*wrapperspb.StringValue)
+			return nil
+		},
+	)
+	if err := client.Subscribe([]string{"test/proto"}, handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.PublishWithSerializer("test/proto", wrapperspb.String("hello"), protobuf.Serializer{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-received:
+		if msg.GetValue() != "hello" {
+			t.Fatalf("收到 %q，期望 hello", msg.GetValue())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("未收到 protobuf 消息")
+	}
+
+	// 导入本包后 DecodePayload 按 ContentType 选择 protobuf 编解码器
+	envelope := client.ExpectPublished(t, "test/proto")
+	decoded := &wrapperspb.StringValue{}
+	if err := messagebus.DecodePayload(envelope, decoded); err != nil || decoded.GetValue() != "hello" {
+		t.Fatalf("DecodePayload 结果 %q, %v", decoded.GetValue(), err)
+	}
+}
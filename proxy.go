@@ -0,0 +1,145 @@
+package messagebus
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"golang.org/x/net/proxy"
+)
+
+// netDialer 按网络类型和地址建立连接
+type netDialer func(ctx context.Context, network, address string) (net.Conn, error)
+
+// parseProxyURL 解析并校验代理地址
+func parseProxyURL(proxyURL string) (*url.URL, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("ProxyURL 无效: %w", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("ProxyURL 仅支持 http、socks5 和 socks5h，当前为 %q", u.Scheme)
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return nil, fmt.Errorf("ProxyURL 必须包含主机和端口: %s", proxyURL)
+	}
+	return u, nil
+}
+
+// proxyOptions 校验代理参数
+func proxyOptions(config Config) error {
+	if config.ProxyURL == "" {
+		return nil
+	}
+	if _, err := parseProxyURL(config.ProxyURL); err != nil {
+		return err
+	}
+	switch msgType := strings.ToLower(config.Type); {
+	case msgType == TypeKafka:
+		return fmt.Errorf("Kafka 客户端按元数据直接连接各分区的 Broker，不支持 ProxyURL")
+	case strings.HasPrefix(msgType, "nats") && strings.EqualFold(config.Protocol, "tls"):
+		return fmt.Errorf("NATS 在收到 INFO 后才升级 TLS，tls 协议不支持 ProxyURL，请使用 wss")
+	}
+	return nil
+}
+
+// proxyDialer 返回经代理建立连接的 netDialer
+func proxyDialer(proxyURL string) (netDialer, error) {
+	u, err := parseProxyURL(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	direct := &net.Dialer{Timeout: relayDialTimeout}
+	if strings.EqualFold(u.Scheme, "http") {
+		return func(ctx context.Context, network, address string) (net.Conn, error) {
+			return httpConnect(ctx, direct, u, address)
+		}, nil
+	}
+	var auth *proxy.Auth
+	if u.User != nil {
+		password, _ := u.User.Password()
+		auth = &proxy.Auth{User: u.User.Username(), Password: password}
+	}
+	socks, err := proxy.SOCKS5("tcp", u.Host, auth, direct)
+	if err != nil {
+		return nil, fmt.Errorf("创建 SOCKS5 代理失败: %w", err)
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := socks.(proxy.ContextDialer).DialContext(ctx, network, address)
+		if err != nil {
+			return nil, fmt.Errorf("经 SOCKS5 代理 %s 连接 %s 失败: %w", u.Host, address, err)
+		}
+		return conn, nil
+	}, nil
+}
+
+// httpConnect 通过 HTTP CONNECT 建立到 address 的隧道
+func httpConnect(ctx context.Context, direct *net.Dialer, proxyURL *url.URL, address string) (net.Conn, error) {
+	conn, err := direct.DialContext(ctx, "tcp", proxyURL.Host)
+	if err != nil {
+		return nil, fmt.Errorf("连接 HTTP 代理 %s 失败: %w", proxyURL.Host, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("发送 CONNECT 请求失败: %w", err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("读取 CONNECT 响应失败: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("HTTP 代理 %s 拒绝连接 %s: %s", proxyURL.Host, address, resp.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	if reader.Buffered() > 0 {
+		// NATS 等协议的服务端会先发送数据，已读入缓冲区的部分需要先交给调用方
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn 先返回缓冲区中的数据，再从连接读取
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// proxiedDialer 返回经代理连接 Broker 的 brokerDialer，启用 TLS 的协议在隧道内完成 TLS 握手
+func proxiedDialer(config Config, busConfig types.MessageBusConfig) (brokerDialer, error) {
+	dial, err := proxyDialer(config.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	return tcpDialer(dial, busConfig)
+}
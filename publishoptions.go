@@ -0,0 +1,65 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+)
+
+// PublishOptions 表示单次发布的可选参数
+type PublishOptions struct {
+	QoS    int  // 本次发布使用的 QoS (0, 1, 2)，直接生效，不沿用 Config.QoS
+	Retain bool // 是否作为保留消息发布，Broker 会将最后一条保留消息投递给之后的订阅者，仅 mqtt 支持
+	// Properties MQTT 5 报文属性，底层客户端不支持 MQTT 5 时发布返回 ErrMQTT5Unsupported
+	Properties *MQTT5Properties
+	// Priority 消息优先级，写入信封 QueryParams，所有类型均支持
+	Priority Priority
+}
+
+// validate 校验发布选项
+func (o PublishOptions) validate(busType string) error {
+	if o.QoS < 0 || o.QoS > 2 {
+		return fmt.Errorf("QoS 必须为 0、1 或 2，当前为 %d", o.QoS)
+	}
+	if o.Retain && !strings.EqualFold(busType, TypeMQTT) {
+		return fmt.Errorf("保留消息仅支持 %s 类型", TypeMQTT)
+	}
+	if err := o.Priority.validate(); err != nil {
+		return err
+	}
+	if o.Properties != nil {
+		if !strings.EqualFold(busType, TypeMQTT) {
+			return fmt.Errorf("MQTT 5 属性仅支持 %s 类型", TypeMQTT)
+		}
+		return o.Properties.validate()
+	}
+	return nil
+}
+
+// PublishWithOptions 按发布选项发布消息到指定主题
+//
+// 底层客户端的 QoS 与保留标志在创建时固定，与 Config 不同的选项组合会按需建立一条额外的连接
+// （ClientID 追加 -q<QoS> 及 -retain 后缀），断开连接时一并关闭；底层客户端支持 MQTT 5 时按报文设置，不建立额外连接。
+// 按选项发布的消息不会进入离线存储转发队列，未连接时直接返回错误。
+func (c *Client) PublishWithOptions(topic string, data interface{}, opts PublishOptions) error {
+	if err := opts.validate(c.config.Type); err != nil {
+		return err
+	}
+	payload, contentType, err := c.encodePayload(data)
+	if err != nil {
+		return err
+	}
+	envelope := newEnvelope(payload, contentType)
+	SetPriority(&envelope, opts.Priority)
+	return c.publishEnvelopeWithOptions(context.Background(), topic, envelope, &opts)
+}
+
+// publisherFor 返回按发布选项发布使用的底层客户端，选项与客户端配置一致时返回主连接
+func (c *Client) publisherFor(opts *PublishOptions) (messaging.MessageClient, error) {
+	if opts == nil || !strings.EqualFold(c.config.Type, TypeMQTT) || (opts.QoS == c.config.QoS && !opts.Retain) {
+		return c.messageClient(), nil
+	}
+	return c.variantClient(clientVariant{qos: opts.QoS, retain: opts.Retain})
+}
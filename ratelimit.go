@@ -0,0 +1,143 @@
+package messagebus
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited 表示发布速率超出限制，仅在 RateLimitReject 模式下返回
+var ErrRateLimited = errors.New("发布速率超出限制")
+
+// RateLimitMode 表示发布速率超出限制时的处理方式
+type RateLimitMode string
+
+const (
+	RateLimitBlock  RateLimitMode = "block"  // 阻塞等待令牌，等待受 ctx 限制（默认）
+	RateLimitReject RateLimitMode = "reject" // 立即返回 ErrRateLimited
+)
+
+// RateLimitConfig 表示发布限速参数，采用令牌桶算法，突发容量为 1 秒的配额
+type RateLimitConfig struct {
+	// MessagesPerSecond 每秒最多发布的消息数，0 表示不限制
+	MessagesPerSecond float64
+	// BytesPerSecond 每秒最多发布的 Payload 字节数，0 表示不限制
+	// 单条消息超过 1 秒配额时，在令牌桶满时放行并透支后续配额
+	BytesPerSecond float64
+	// PerTopic 为 true 时每个主题单独限速，否则所有主题共享同一配额
+	PerTopic bool
+	// Mode 超出限制时的处理方式，默认 RateLimitBlock
+	Mode RateLimitMode
+}
+
+// enabled 判断是否配置了限速
+func (r RateLimitConfig) enabled() bool {
+	return r.MessagesPerSecond > 0 || r.BytesPerSecond > 0
+}
+
+// tokenBucket 是一个令牌桶，rate 为 0 时不限制
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, now time.Time) *tokenBucket {
+	burst := math.Max(rate, 1)
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+// delay 返回取得 n 个令牌前需要等待的时间，0 表示可以立即取得
+func (b *tokenBucket) delay(n float64, now time.Time) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= n || b.tokens >= b.burst {
+		return 0
+	}
+	need := math.Min(n, b.burst) - b.tokens
+	return time.Duration(need / b.rate * float64(time.Second))
+}
+
+// take 扣除 n 个令牌，允许透支
+func (b *tokenBucket) take(n float64) {
+	if b.rate > 0 {
+		b.tokens -= n
+	}
+}
+
+// rateBuckets 是一组消息数与字节数令牌桶
+type rateBuckets struct {
+	messages *tokenBucket
+	bytes    *tokenBucket
+}
+
+// publishLimiter 记录发布限速状态
+type publishLimiter struct {
+	mutex  sync.Mutex
+	global *rateBuckets
+	topics map[string]*rateBuckets
+}
+
+// buckets 返回主题使用的令牌桶，必须在持有锁时调用
+func (l *publishLimiter) buckets(cfg RateLimitConfig, topic string, now time.Time) *rateBuckets {
+	newBuckets := func() *rateBuckets {
+		return &rateBuckets{
+			messages: newTokenBucket(cfg.MessagesPerSecond, now),
+			bytes:    newTokenBucket(cfg.BytesPerSecond, now),
+		}
+	}
+	if !cfg.PerTopic {
+		if l.global == nil {
+			l.global = newBuckets()
+		}
+		return l.global
+	}
+	if l.topics == nil {
+		l.topics = make(map[string]*rateBuckets)
+	}
+	b, ok := l.topics[topic]
+	if !ok {
+		b = newBuckets()
+		l.topics[topic] = b
+	}
+	return b
+}
+
+// waitPublishRate 按 Config.PublishRateLimit 为一次发布取得配额
+// 阻塞模式下等待至配额可用或 ctx 结束，拒绝模式下配额不足时返回 ErrRateLimited
+func (c *Client) waitPublishRate(ctx context.Context, topic string, size int64) error {
+	cfg := c.config.PublishRateLimit
+	if !cfg.enabled() {
+		return nil
+	}
+	l := &c.limiter
+	for {
+		now := time.Now()
+		l.mutex.Lock()
+		b := l.buckets(cfg, topic, now)
+		wait := max(b.messages.delay(1, now), b.bytes.delay(float64(size), now))
+		if wait == 0 {
+			b.messages.take(1)
+			b.bytes.take(float64(size))
+			l.mutex.Unlock()
+			return nil
+		}
+		l.mutex.Unlock()
+		if cfg.Mode == RateLimitReject {
+			return ErrRateLimited
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
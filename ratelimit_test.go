@@ -0,0 +1,73 @@
+package messagebus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+)
+
+// newRateLimitedClient 创建按 limit 限速发布的内存客户端
+func newRateLimitedClient(t *testing.T, limit messagebus.RateLimitConfig) *messagebustest.MockClient {
+	t.Helper()
+	config := testConfig()
+	config.PublishRateLimit = limit
+	client, err := messagebustest.NewMockClient(messagebus.WithConfig(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestRateLimitRejectsBeyondBurst(t *testing.T) {
+	client := newRateLimitedClient(t, messagebus.RateLimitConfig{MessagesPerSecond: 5, PerTopic: true, Mode: messagebus.RateLimitReject})
+	for i := 0; i < 5; i++ {
+		if err := client.Publish("test/limit/a", "x"); err != nil {
+			t.Fatalf("突发容量内的第 %d 次发布失败: %v", i+1, err)
+		}
+	}
+	if err := client.Publish("test/limit/a", "x"); !errors.Is(err, messagebus.ErrRateLimited) {
+		t.Fatalf("超出配额时应返回 ErrRateLimited，实际 %v", err)
+	}
+	// 每个主题单独限速
+	if err := client.Publish("test/limit/b", "x"); err != nil {
+		t.Fatalf("其他主题不应受限: %v", err)
+	}
+	if n := len(client.Published()); n != 6 {
+		t.Errorf("Broker 收到 %d 条消息，期望 6", n)
+	}
+	time.Sleep(250 * time.Millisecond)
+	if err := client.Publish("test/limit/a", "x"); err != nil {
+		t.Fatalf("令牌补充后发布失败: %v", err)
+	}
+}
+
+func TestRateLimitBlocksUntilTokensAvailable(t *testing.T) {
+	client := newRateLimitedClient(t, messagebus.RateLimitConfig{MessagesPerSecond: 20})
+	start := time.Now()
+	for i := 0; i < 25; i++ {
+		if err := client.Publish("test/limit", "x"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 突发 20 条之后每条等待 50ms
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("25 条消息在 %s 内发布完成，期望阻塞至少 200ms", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	for i := 0; i < 20; i++ {
+		if err := client.PublishWithContext(ctx, "test/limit", "x"); err != nil {
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("等待配额时 ctx 超时应返回 DeadlineExceeded，实际 %v", err)
+			}
+			return
+		}
+	}
+	t.Fatal("配额耗尽后 ctx 超时仍未返回错误")
+}
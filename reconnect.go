@@ -0,0 +1,216 @@
+package messagebus
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+const (
+	defaultReconnectInitialDelay = time.Second      // 默认首次重连等待时间
+	defaultReconnectMaxDelay     = 30 * time.Second // 默认最大重连等待时间
+	defaultReconnectMaxAttempts  = 5                // 默认最大重连尝试次数
+)
+
+// ReconnectConfig 表示重连退避参数
+type ReconnectConfig struct {
+	// Enabled 为 true 时，Broker 断开连接或发布失败都会在后台按以下退避参数自动重连；
+	// 内置的 MQTT/NATS 客户端经本地中继观察连接断开，自定义的 MessageClientFactory 需实现 BrokerDisconnectNotifier，
+	// 否则交给底层客户端自行重连
+	Enabled bool
+	// InitialDelay 首次重试前的等待时间，之后每次翻倍，默认 1 秒
+	InitialDelay time.Duration
+	// MaxDelay 两次重试之间的最大等待时间，默认 30 秒
+	MaxDelay time.Duration
+	// Jitter 等待时间的随机抖动比例 (0~1)，例如 0.2 表示在 ±20% 范围内浮动
+	Jitter float64
+	// MaxAttempts 最大尝试次数，0 表示默认 5 次，负数表示不限次数
+	MaxAttempts int
+}
+
+// withDefaults 返回填充了默认值的重连配置
+func (r ReconnectConfig) withDefaults() ReconnectConfig {
+	if r.InitialDelay <= 0 {
+		r.InitialDelay = defaultReconnectInitialDelay
+	}
+	if r.MaxDelay <= 0 {
+		r.MaxDelay = defaultReconnectMaxDelay
+	}
+	if r.MaxDelay < r.InitialDelay {
+		r.MaxDelay = r.InitialDelay
+	}
+	if r.MaxAttempts == 0 {
+		r.MaxAttempts = defaultReconnectMaxAttempts
+	}
+	return r
+}
+
+// backoff 返回第 attempt 次失败后的等待时间
+func (r ReconnectConfig) backoff(attempt int) time.Duration {
+	delay := r.InitialDelay
+	for i := 1; i < attempt && delay < r.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	if r.Jitter > 0 {
+		jitter := r.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay = time.Duration(float64(delay) * (1 + jitter*(2*rand.Float64()-1)))
+	}
+	return delay
+}
+
+// reconnectState 记录当前重连进度
+type reconnectState struct {
+	active    bool      // 是否正在重连
+	attempt   int       // 当前尝试次数
+	nextRetry time.Time // 下一次重试时间
+}
+
+// IsReconnecting 判断客户端当前是否正在重连
+func (c *Client) IsReconnecting() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.reconnect.active
+}
+
+// Reconnect 重新建立与 MessageBus 的底层连接，并重新订阅所有已注册的主题
+// 失败时按 Config.Reconnect 指数退避重试，调用 Disconnect 会中止重连；
+// 尝试次数用尽后客户端转为未连接状态并发出 EventDisconnected，需重新调用 Connect
+func (c *Client) Reconnect() error {
+	c.mutex.Lock()
+	if !c.isConnected || c.stopping {
+		c.mutex.Unlock()
+		return ErrNotConnected
+	}
+	if c.reconnect.active {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.reconnect = reconnectState{active: true}
+	c.mutex.Unlock()
+	stop := c.lifecycle.stopChan(stageReconnect)
+
+	defer func() {
+		c.mutex.Lock()
+		c.reconnect = reconnectState{}
+		c.mutex.Unlock()
+	}()
+
+	cfg := c.config.Reconnect.withDefaults()
+	var err error
+	attempt := 0
+	for cfg.MaxAttempts < 0 || attempt < cfg.MaxAttempts {
+		attempt++
+		c.mutex.Lock()
+		c.reconnect.attempt = attempt
+		c.reconnect.nextRetry = time.Time{}
+		c.mutex.Unlock()
+		c.emitEvent(LifecycleEvent{Type: EventReconnecting, Attempt: attempt})
+
+		_ = c.messageClient().Disconnect()
+		c.closeVariants()
+		if err = c.messageClient().Connect(); err == nil {
+			if err = c.resubscribe(); err == nil {
+				c.log(LogConnection).Info("已重新连接到MessageBus", c.logFields("attempt", attempt)...)
+				c.metrics.ObserveReconnect()
+				c.stats.reconnects.Add(1)
+				c.emitEvent(LifecycleEvent{Type: EventReconnected, Attempt: attempt})
+				c.startOutboxDrain()
+				return nil
+			}
+		}
+		c.log(LogConnection).Warn("重连MessageBus失败", c.logFields("attempt", attempt, "error", err)...)
+		if attempt == cfg.MaxAttempts {
+			break
+		}
+
+		delay := cfg.backoff(attempt)
+		c.mutex.Lock()
+		c.reconnect.nextRetry = time.Now().Add(delay)
+		c.mutex.Unlock()
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-stop:
+			timer.Stop()
+			return fmt.Errorf("重连已中止: 客户端正在断开连接")
+		}
+	}
+	err = fmt.Errorf("重连失败，已尝试 %d 次: %w", attempt, err)
+	c.emitEvent(LifecycleEvent{Type: EventReconnectFailed, Attempt: attempt, Err: err})
+	c.abandonConnection(err)
+	return err
+}
+
+// abandonConnection 在重连次数用尽后放弃当前连接：标记为未连接并发出 EventDisconnected，
+// 随后在后台停止与本次连接相关的 goroutine 并关闭底层连接，之后可以重新调用 Connect
+// Reconnect 可能运行在 stageReconnect 的 goroutine 中，不能同步等待该阶段退出
+func (c *Client) abandonConnection(cause error) {
+	c.mutex.Lock()
+	if !c.isConnected || c.stopping {
+		c.mutex.Unlock()
+		return
+	}
+	c.isConnected = false
+	c.stopping = true
+	c.mutex.Unlock()
+	c.stats.connectedAt.Store(0)
+	c.log(LogConnection).Error("重连次数用尽，已断开MessageBus连接", c.logFields("error", cause)...)
+	c.emitEvent(LifecycleEvent{Type: EventDisconnected, Err: cause})
+	go func() {
+		if stuck := c.lifecycle.shutdown(c.shutdownTimeout()); len(stuck) > 0 {
+			c.log(LogConnection).Warn("等待后台任务退出超时，继续断开连接", c.logFields("stages", stuck)...)
+		}
+		c.disconnectWill()
+		c.closeVariants()
+		c.mutex.Lock()
+		_ = c.client.Disconnect()
+		c.stopping = false
+		c.mutex.Unlock()
+	}()
+}
+
+// resubscribe 使用原有的消息通道重新订阅所有已注册的主题
+func (c *Client) resubscribe() error {
+	c.mutex.RLock()
+	subs := make([]*subscription, 0, len(c.subscriptions))
+	for _, sub := range c.subscriptions {
+		subs = append(subs, sub)
+	}
+	c.mutex.RUnlock()
+	for _, group := range groupByClientVariant(subs) {
+		topicChannels := make([]types.TopicChannel, len(group))
+		for i, sub := range group {
+			topicChannels[i] = types.TopicChannel{Topic: c.wireTopic(sub.topic), Messages: sub.ingress}
+		}
+		subscriber, err := c.subscriberFor(group[0].opts)
+		if err == nil {
+			err = subscriber.Subscribe(topicChannels, c.errorChan)
+		}
+		if err != nil {
+			return subscribeError("resubscribe", c.wireTopics(group), err)
+		}
+	}
+	return c.resubscribeResponses()
+}
+
+// reconnectInBackground 在启用自动重连时于后台触发一次重连
+func (c *Client) reconnectInBackground(cause error) {
+	if !c.config.Reconnect.Enabled || c.IsReconnecting() {
+		return
+	}
+	c.log(LogConnection).Warn("检测到连接异常，开始自动重连", c.logFields("error", cause)...)
+	c.lifecycle.spawn(stageReconnect, func(<-chan struct{}) {
+		if err := c.Reconnect(); err != nil {
+			c.log(LogConnection).Error("自动重连失败", c.logFields("error", err)...)
+			c.reportError("reconnect", "", err)
+		}
+	})
+}
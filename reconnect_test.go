@@ -0,0 +1,222 @@
+package messagebus_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/brokertest"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// flakyClient 在 failures 大于 0 时使 Connect 失败并递减计数，小于 0 时始终失败
+type flakyClient struct {
+	messaging.MessageClient
+	failures *atomic.Int32
+}
+
+func (c *flakyClient) Connect() error {
+	n := c.failures.Load()
+	if n < 0 {
+		return errors.New("broker 不可用")
+	}
+	if n > 0 && c.failures.CompareAndSwap(n, n-1) {
+		return errors.New("broker 不可用")
+	}
+	return c.MessageClient.Connect()
+}
+
+// newFlakyClient 创建连接内存 Broker 的客户端，Connect 按 failures 失败
+func newFlakyClient(t *testing.T, broker *messagebustest.Broker, failures *atomic.Int32) *messagebus.Client {
+	t.Helper()
+	factory := broker.MessageClientFactory("flaky")
+	config := testConfig()
+	config.Reconnect = messagebus.ReconnectConfig{InitialDelay: 5 * time.Millisecond, MaxDelay: 10 * time.Millisecond, MaxAttempts: 3}
+	client, err := messagebus.NewClientWithOptions(
+		messagebus.WithConfig(config),
+		messagebus.WithLogger(logger.NewMockClient()),
+		messagebus.WithMessageClientFactory(func(busConfig types.MessageBusConfig) (messaging.MessageClient, error) {
+			inner, err := factory(busConfig)
+			if err != nil {
+				return nil, err
+			}
+			return &flakyClient{MessageClient: inner, failures: failures}, nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// collectEvents 读取 timeout 内的生命周期事件类型，直到收到 until
+func collectEvents(t *testing.T, client *messagebus.Client, until messagebus.LifecycleEventType) []messagebus.LifecycleEventType {
+	t.Helper()
+	var events []messagebus.LifecycleEventType
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case event := <-client.LifecycleEvents():
+			events = append(events, event.Type)
+			if event.Type == until {
+				return events
+			}
+		case <-timeout:
+			t.Fatalf("未收到 %s 事件，已收到 %v", until, events)
+		}
+	}
+}
+
+func TestReconnectRetriesUntilSuccess(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	var failures atomic.Int32
+	client := newFlakyClient(t, broker, &failures)
+	collectEvents(t, client, messagebus.EventConnected)
+
+	received := make(chan struct{}, 1)
+	err := client.Subscribe([]string{"test/reconnect"}, func(string, types.MessageEnvelope) error {
+		received <- struct{}{}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collectEvents(t, client, messagebus.EventSubscribed)
+
+	failures.Store(2)
+	if err := client.Reconnect(); err != nil {
+		t.Fatalf("第三次尝试应成功: %v", err)
+	}
+	got := collectEvents(t, client, messagebus.EventReconnected)
+	want := []messagebus.LifecycleEventType{messagebus.EventReconnecting, messagebus.EventReconnecting, messagebus.EventReconnecting, messagebus.EventReconnected}
+	if !equalEvents(got, want) {
+		t.Fatalf("事件序列 %v，期望 %v", got, want)
+	}
+	if !client.IsConnected() || client.IsReconnecting() {
+		t.Fatal("重连成功后应处于已连接且不在重连中")
+	}
+	if stats := client.Stats(); stats.Reconnects != 1 {
+		t.Fatalf("Reconnects = %d，期望 1", stats.Reconnects)
+	}
+	if err := client.Publish("test/reconnect", "after"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("重连后订阅未恢复")
+	}
+}
+
+func TestReconnectExhaustedMarksDisconnected(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	var failures atomic.Int32
+	client := newFlakyClient(t, broker, &failures)
+	collectEvents(t, client, messagebus.EventConnected)
+
+	failures.Store(-1)
+	if err := client.Reconnect(); err == nil {
+		t.Fatal("重连次数用尽时应返回错误")
+	}
+	got := collectEvents(t, client, messagebus.EventDisconnected)
+	want := []messagebus.LifecycleEventType{
+		messagebus.EventReconnecting, messagebus.EventReconnecting, messagebus.EventReconnecting,
+		messagebus.EventReconnectFailed, messagebus.EventDisconnected,
+	}
+	if !equalEvents(got, want) {
+		t.Fatalf("事件序列 %v，期望 %v", got, want)
+	}
+	if client.IsConnected() {
+		t.Fatal("重连次数用尽后 IsConnected 应为 false")
+	}
+	if err := client.Publish("test/reconnect", "x"); !errors.Is(err, messagebus.ErrNotConnected) {
+		t.Fatalf("断开后发布应返回 ErrNotConnected，实际 %v", err)
+	}
+
+	// Broker 恢复后可以重新连接，后台清理结束前 Connect 返回 ErrClientClosing
+	failures.Store(0)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		err := client.Connect()
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, messagebus.ErrClientClosing) || time.Now().After(deadline) {
+			t.Fatalf("重新连接失败: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !client.IsConnected() {
+		t.Fatal("重新连接后 IsConnected 应为 true")
+	}
+}
+
+func TestReconnectOnBrokerDrop(t *testing.T) {
+	broker := brokertest.StartMQTT(t)
+	client := broker.NewClient(t, messagebus.WithReconnect(messagebus.ReconnectConfig{
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     50 * time.Millisecond,
+	}))
+	collectEvents(t, client, messagebus.EventConnected)
+
+	received := make(chan struct{}, 1)
+	err := client.Subscribe([]string{"test/drop"}, func(string, types.MessageEnvelope) error {
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collectEvents(t, client, messagebus.EventSubscribed)
+
+	// 不发布任何消息，只由 Broker 一侧断开连接
+	if !broker.DropClient(client.ClientID()) {
+		t.Fatal("Broker 上没有该客户端的连接")
+	}
+	got := collectEvents(t, client, messagebus.EventReconnected)
+	if got[0] != messagebus.EventReconnecting {
+		t.Fatalf("事件序列 %v，应以 reconnecting 开始", got)
+	}
+	if stats := client.Stats(); stats.Reconnects != 1 {
+		t.Fatalf("Reconnects = %d，期望 1", stats.Reconnects)
+	}
+
+	publisher := broker.NewClient(t)
+	deadline := time.After(2 * time.Second)
+	for {
+		if err := publisher.Publish("test/drop", "after"); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-received:
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("Broker 断开后订阅未恢复")
+		}
+	}
+}
+
+func equalEvents(got, want []messagebus.LifecycleEventType) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,196 @@
+package messagebus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// RecordedMessage 表示录制文件中的一条消息，录制文件为每行一个 JSON 对象的 JSON Lines 格式
+type RecordedMessage struct {
+	Time          time.Time         `json:"time"`
+	Direction     TapDirection      `json:"direction"`
+	Topic         string            `json:"topic"`
+	CorrelationID string            `json:"correlationId,omitempty"`
+	RequestID     string            `json:"requestId,omitempty"`
+	ContentType   string            `json:"contentType,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"` // 信封的 QueryParams
+	Payload       []byte            `json:"payload"`           // JSON 中为 Base64 编码
+}
+
+// RecordOptions 表示录制器的可选参数
+type RecordOptions struct {
+	// Pattern 录制的主题模式，支持通配符，为空时录制所有主题
+	Pattern string
+	// Direction 只录制指定方向的消息，为空时录制收发两个方向
+	Direction TapDirection
+}
+
+// Recorder 通过 Tap 将客户端收发的消息写入录制文件
+// 录制不阻塞收发，写入过慢时被丢弃的消息计入 Stats().DroppedTapEvents
+type Recorder struct {
+	stop  func()
+	done  chan struct{}
+	count atomic.Uint64
+	err   error
+	once  sync.Once
+}
+
+// NewRecorder 开始将匹配的消息录制到 w，调用 Close 停止录制
+func (c *Client) NewRecorder(w io.Writer, opts RecordOptions) *Recorder {
+	events, stop := c.Tap(opts.Pattern)
+	r := &Recorder{stop: stop, done: make(chan struct{})}
+	go r.run(json.NewEncoder(w), events, opts.Direction)
+	return r
+}
+
+func (r *Recorder) run(encoder *json.Encoder, events <-chan TapEvent, direction TapDirection) {
+	defer close(r.done)
+	for event := range events {
+		if r.err != nil || (direction != "" && event.Direction != direction) {
+			continue
+		}
+		record, err := recordedMessage(event)
+		if err == nil {
+			err = encoder.Encode(record)
+		}
+		if err != nil {
+			r.err = fmt.Errorf("录制消息失败: %w", err)
+			continue
+		}
+		r.count.Add(1)
+	}
+}
+
+// recordedMessage 将 Tap 事件转换为录制记录
+func recordedMessage(event TapEvent) (RecordedMessage, error) {
+	payload, err := payloadBytes(event.Message.Payload)
+	if err != nil {
+		return RecordedMessage{}, err
+	}
+	return RecordedMessage{
+		Time:          event.Time,
+		Direction:     event.Direction,
+		Topic:         event.Topic,
+		CorrelationID: event.Message.CorrelationID,
+		RequestID:     event.Message.RequestID,
+		ContentType:   event.Message.ContentType,
+		Headers:       event.Message.QueryParams,
+		Payload:       payload,
+	}, nil
+}
+
+// Count 返回已写入的消息数
+func (r *Recorder) Count() uint64 {
+	return r.count.Load()
+}
+
+// Close 停止录制并等待已镜像的消息写完，返回第一次写入错误；可重复调用
+func (r *Recorder) Close() error {
+	r.once.Do(func() {
+		r.stop()
+		<-r.done
+	})
+	return r.err
+}
+
+// ReplayOptions 表示回放器的可选参数
+type ReplayOptions struct {
+	// Speed 回放速度倍数，1 表示按原始时间间隔，2 表示两倍速，默认 1
+	Speed float64
+	// Immediate 为 true 时忽略原始时间间隔，尽快发布所有消息
+	Immediate bool
+	// Direction 只回放指定方向的消息，为空时回放所有消息
+	Direction TapDirection
+	// Topic 改写回放主题，返回空字符串时跳过该消息，为空时使用原始主题
+	Topic func(topic string) string
+}
+
+// Replayer 将录制文件中的消息按原始或加速的时间间隔重新发布
+type Replayer struct {
+	client *Client
+	source io.Reader
+	opts   ReplayOptions
+}
+
+// NewReplayer 创建从 r 读取录制文件的回放器
+// 消息经过客户端的正常发布流程（拦截器、压缩、加密等），CorrelationID、ContentType 和 QueryParams 保持不变
+func (c *Client) NewReplayer(r io.Reader, opts ReplayOptions) (*Replayer, error) {
+	if opts.Speed < 0 {
+		return nil, fmt.Errorf("回放速度不能为负数")
+	}
+	if opts.Speed == 0 {
+		opts.Speed = 1
+	}
+	return &Replayer{client: c, source: r, opts: opts}, nil
+}
+
+// Replay 读取并回放所有消息，返回已发布的消息数；ctx 取消或发布失败时停止
+func (p *Replayer) Replay(ctx context.Context) (int, error) {
+	decoder := json.NewDecoder(p.source)
+	var first time.Time
+	started := time.Now()
+	published := 0
+	for {
+		var record RecordedMessage
+		if err := decoder.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				return published, nil
+			}
+			return published, fmt.Errorf("读取录制文件失败: %w", err)
+		}
+		if p.opts.Direction != "" && record.Direction != p.opts.Direction {
+			continue
+		}
+		topic := record.Topic
+		if p.opts.Topic != nil {
+			if topic = p.opts.Topic(topic); topic == "" {
+				continue
+			}
+		}
+		if first.IsZero() {
+			first = record.Time
+		}
+		if !p.opts.Immediate {
+			offset := time.Duration(float64(record.Time.Sub(first)) / p.opts.Speed)
+			if err := sleepContext(ctx, time.Until(started.Add(offset))); err != nil {
+				return published, err
+			}
+		} else if err := ctx.Err(); err != nil {
+			return published, err
+		}
+		envelope := types.MessageEnvelope{
+			CorrelationID: record.CorrelationID,
+			RequestID:     record.RequestID,
+			ContentType:   record.ContentType,
+			QueryParams:   record.Headers,
+			Payload:       record.Payload,
+		}
+		if err := p.client.PublishEnvelope(topic, envelope); err != nil {
+			return published, fmt.Errorf("回放消息到 %s 失败: %w", topic, err)
+		}
+		published++
+	}
+}
+
+// sleepContext 等待 d 或 ctx 取消
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,174 @@
+package messagebus_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+func TestRecordReplayRoundTrip(t *testing.T) {
+	source := newMockClient(t)
+	var recording bytes.Buffer
+	recorder := source.NewRecorder(&recording, messagebus.RecordOptions{Pattern: "test/record/#", Direction: messagebus.TapOutbound})
+
+	const gap = 100 * time.Millisecond
+	for i, value := range []string{"a", "b", "c"} {
+		if i > 0 {
+			time.Sleep(gap)
+		}
+		envelope, err := source.CreateMessageEnvelope(map[string]string{"value": value}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		envelope.CorrelationID = "corr-" + value
+		envelope.QueryParams["x-seq"] = value
+		if err := source.PublishEnvelope("test/record/"+value, envelope); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := source.Publish("test/other", "ignored"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for recorder.Count() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Count() != 3 {
+		t.Fatalf("录制了 %d 条消息，期望 3", recorder.Count())
+	}
+
+	target := newMockClient(t)
+	var (
+		mutex    sync.Mutex
+		received []types.MessageEnvelope
+	)
+	if err := target.Subscribe([]string{"replay/#"}, func(_ string, msg types.MessageEnvelope) error {
+		mutex.Lock()
+		received = append(received, msg)
+		mutex.Unlock()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := target.NewReplayer(bytes.NewReader(recording.Bytes()), messagebus.ReplayOptions{
+		Speed: 4,
+		Topic: func(topic string) string { return strings.Replace(topic, "test/record/", "replay/", 1) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	published, err := replayer.Replay(context.Background())
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published != 3 {
+		t.Fatalf("回放了 %d 条消息，期望 3", published)
+	}
+	// 原始间隔共 200ms，四倍速约 50ms
+	if elapsed < 40*time.Millisecond || elapsed > 150*time.Millisecond {
+		t.Errorf("四倍速回放耗时 %s，期望约 50ms", elapsed)
+	}
+
+	deadline = time.Now().Add(time.Second)
+	for {
+		mutex.Lock()
+		n := len(received)
+		mutex.Unlock()
+		if n == 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(received) != 3 {
+		t.Fatalf("收到 %d 条回放消息，期望 3", len(received))
+	}
+	for i, value := range []string{"a", "b", "c"} {
+		msg := received[i]
+		if msg.ReceivedTopic != "replay/"+value || msg.CorrelationID != "corr-"+value || msg.QueryParams["x-seq"] != value {
+			t.Errorf("第 %d 条回放消息不符: topic=%s correlationId=%s headers=%v", i, msg.ReceivedTopic, msg.CorrelationID, msg.QueryParams)
+			continue
+		}
+		data, err := messagebus.EnvelopePayloadBytes(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var payload map[string]string
+		if err := json.Unmarshal(data, &payload); err != nil || payload["value"] != value {
+			t.Errorf("第 %d 条回放消息 Payload = %s", i, data)
+		}
+	}
+}
+
+func TestReplayImmediateAndFilters(t *testing.T) {
+	recording := strings.Join([]string{
+		`{"time":"2026-01-01T00:00:00Z","direction":"outbound","topic":"test/a","payload":"YQ=="}`,
+		`{"time":"2026-01-01T00:01:00Z","direction":"inbound","topic":"test/b","payload":"Yg=="}`,
+		`{"time":"2026-01-01T00:02:00Z","direction":"outbound","topic":"test/skip","payload":"Yw=="}`,
+		`{"time":"2026-01-01T00:03:00Z","direction":"outbound","topic":"test/d","payload":"ZA=="}`,
+	}, "\n")
+	client := newMockClient(t)
+	replayer, err := client.NewReplayer(strings.NewReader(recording), messagebus.ReplayOptions{
+		Immediate: true,
+		Direction: messagebus.TapOutbound,
+		Topic: func(topic string) string {
+			if topic == "test/skip" {
+				return ""
+			}
+			return topic
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	published, err := replayer.Replay(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published != 2 {
+		t.Errorf("回放了 %d 条消息，期望 2", published)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Immediate 回放耗时 %s，不应等待原始间隔", elapsed)
+	}
+	client.ExpectPublished(t, "test/a")
+	client.ExpectPublished(t, "test/d")
+	client.ExpectNotPublished(t, "test/b")
+	client.ExpectNotPublished(t, "test/skip")
+
+	if _, err := client.NewReplayer(strings.NewReader(recording), messagebus.ReplayOptions{Speed: -1}); err == nil {
+		t.Error("负数回放速度应返回错误")
+	}
+}
+
+func TestReplayStopsOnContextCancel(t *testing.T) {
+	recording := `{"time":"2026-01-01T00:00:00Z","direction":"outbound","topic":"test/a","payload":"YQ=="}
+{"time":"2026-01-01T01:00:00Z","direction":"outbound","topic":"test/b","payload":"Yg=="}`
+	client := newMockClient(t)
+	replayer, err := client.NewReplayer(strings.NewReader(recording), messagebus.ReplayOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	published, err := replayer.Replay(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || published != 1 {
+		t.Errorf("Replay 返回 (%d, %v)，期望 (1, context.DeadlineExceeded)", published, err)
+	}
+}
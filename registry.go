@@ -0,0 +1,112 @@
+package messagebus
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// TypeFactory 创建用于解码消息的目标对象，返回值应为指针
+type TypeFactory func() interface{}
+
+// RegisteredHandler 定义按类型注册表解码后的消息处理函数
+// 未匹配注册表的主题，decoded 为原始 []byte
+type RegisteredHandler func(topic string, decoded interface{}, message types.MessageEnvelope) error
+
+// TypeRegistry 维护主题模式到目标类型的映射，按注册顺序匹配
+type TypeRegistry struct {
+	mutex   sync.RWMutex
+	entries []typeEntry
+}
+
+type typeEntry struct {
+	pattern string
+	factory TypeFactory
+}
+
+// NewTypeRegistry 创建一个空的类型注册表
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{}
+}
+
+// Register 为主题模式注册目标类型工厂，模式支持通配符
+func (r *TypeRegistry) Register(pattern string, factory TypeFactory) error {
+	if pattern == "" {
+		return fmt.Errorf("主题模式不能为空")
+	}
+	if factory == nil {
+		return fmt.Errorf("类型工厂不能为空")
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entries = append(r.entries, typeEntry{pattern: pattern, factory: factory})
+	return nil
+}
+
+// Lookup 返回第一个匹配主题的类型工厂
+func (r *TypeRegistry) Lookup(topic string) (TypeFactory, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, entry := range r.entries {
+		if TopicMatches(entry.pattern, topic) {
+			return entry.factory, true
+		}
+	}
+	return nil, false
+}
+
+// SubscribeRegistered 订阅多个主题，并根据注册表将消息解码为对应类型后交给处理函数
+// 解码器按信封 ContentType 选择，未设置时按 JSON 解码
+func (c *Client) SubscribeRegistered(topics []string, registry *TypeRegistry, handler RegisteredHandler) error {
+	if registry == nil {
+		return fmt.Errorf("类型注册表不能为空")
+	}
+	if handler == nil {
+		return fmt.Errorf("消息处理函数不能为空")
+	}
+	return c.Subscribe(topics, func(topic string, message types.MessageEnvelope) error {
+		data, err := payloadBytes(message.Payload)
+		if err != nil {
+			return err
+		}
+		factory, ok := registry.Lookup(topic)
+		if !ok {
+			return handler(topic, data, message)
+		}
+		target := factory()
+		if err := DecodePayload(message, target); err != nil {
+			return fmt.Errorf("解码主题 %s 的消息失败: %w", topic, err)
+		}
+		return handler(topic, target, message)
+	})
+}
+
+// payloadBytes 将信封 Payload 统一转换为字节切片
+// 字节切片经 JSON 信封传输后会变为 base64 字符串，此处一并还原
+func payloadBytes(payload interface{}) ([]byte, error) {
+	switch v := payload.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case string:
+		if decoded, err := base64.StdEncoding.DecodeString(v); err == nil {
+			return decoded, nil
+		}
+		return []byte(v), nil
+	default:
+		return json.Marshal(v)
+	}
+}
+
+// outboundPayloadBytes 将待发布信封的 Payload 转换为字节切片
+// 发布方设置的字符串是原始文本，不会是 JSON 传输产生的 base64，因此不做还原
+func outboundPayloadBytes(payload interface{}) ([]byte, error) {
+	if s, ok := payload.(string); ok {
+		return []byte(s), nil
+	}
+	return payloadBytes(payload)
+}
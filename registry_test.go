@@ -0,0 +1,89 @@
+package messagebus_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+type testEvent struct {
+	Device string `json:"device"`
+}
+
+type testMetric struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+func TestSubscribeRegisteredDecodesByTopic(t *testing.T) {
+	registry := messagebus.NewTypeRegistry()
+	if err := registry.Register("test/events/#", func() interface{} { return &testEvent{} }); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register("test/metrics/+", func() interface{} { return &testMetric{} }); err != nil {
+		t.Fatal(err)
+	}
+
+	client := newMockClient(t)
+	var mutex sync.Mutex
+	decoded := map[string]interface{}{}
+	done := make(chan struct{}, 3)
+	err := client.SubscribeRegistered([]string{"test/#"}, registry, func(topic string, value interface{}, _ types.MessageEnvelope) error {
+		mutex.Lock()
+		decoded[topic] = value
+		mutex.Unlock()
+		done <- struct{}{}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Publish("test/events/dev1", testEvent{Device: "dev1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Publish("test/metrics/cpu", testMetric{Name: "cpu", Value: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Publish("test/other", []byte("raw")); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("只收到 %d 条消息", i)
+		}
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if event, ok := decoded["test/events/dev1"].(*testEvent); !ok || event.Device != "dev1" {
+		t.Errorf("test/events/dev1 解码为 %#v，期望 *testEvent", decoded["test/events/dev1"])
+	}
+	if metric, ok := decoded["test/metrics/cpu"].(*testMetric); !ok || metric.Name != "cpu" || metric.Value != 0.5 {
+		t.Errorf("test/metrics/cpu 解码为 %#v，期望 *testMetric", decoded["test/metrics/cpu"])
+	}
+	if raw, ok := decoded["test/other"].([]byte); !ok || string(raw) != "raw" {
+		t.Errorf("未注册主题应得到原始字节，实际 %#v", decoded["test/other"])
+	}
+}
+
+func TestTypeRegistryLookupOrder(t *testing.T) {
+	registry := messagebus.NewTypeRegistry()
+	_ = registry.Register("test/metrics/cpu", func() interface{} { return &testEvent{} })
+	_ = registry.Register("test/metrics/+", func() interface{} { return &testMetric{} })
+	factory, ok := registry.Lookup("test/metrics/cpu")
+	if !ok {
+		t.Fatal("未匹配已注册的主题")
+	}
+	if _, ok := factory().(*testEvent); !ok {
+		t.Error("应返回最先注册的匹配项")
+	}
+	if _, ok := registry.Lookup("test/events/x"); ok {
+		t.Error("不应匹配未注册的主题")
+	}
+}
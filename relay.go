@@ -0,0 +1,308 @@
+package messagebus
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// relayDialTimeout 是中继建立到 Broker 的连接的超时时间
+const relayDialTimeout = 30 * time.Second
+
+// relayTLSOptions 是由中继处理、不再传递给底层客户端的 TLS 参数
+var relayTLSOptions = []string{"CertFile", "KeyFile", "CaFile", "CertPEMBlock", "KeyPEMBlock", "CaPEMBlock", "SkipCertVerify"}
+
+// brokerDialer 建立到 Broker 的连接，连接上承载原始的 MQTT/NATS 字节流
+type brokerDialer func(ctx context.Context) (net.Conn, error)
+
+// brokerDialerFor 返回需要由客户端自行建立连接时使用的 brokerDialer，底层客户端可以直接连接时返回 nil
+//
+// go-mod-messaging 按 Host、Port、Protocol 拼接 Broker 地址，无法设置 WebSocket 路径、HTTP 头和代理，
+// 这类连接经本地中继转发：底层客户端以明文 TCP 连接中继，中继再按配置连接 Broker。
+func brokerDialerFor(config Config, busConfig types.MessageBusConfig) (brokerDialer, error) {
+	if isWebSocket(busConfig.Broker.Protocol) {
+		return webSocketDialer(config, busConfig)
+	}
+	if config.ProxyURL != "" {
+		return proxiedDialer(config, busConfig)
+	}
+	if config.MessageClientFactory == nil && (config.ClientIDCollision.Detect || reconnectsOnDrop(config)) {
+		// go-mod-messaging 的客户端不报告连接断开，经中继观察 Broker 主动关闭的连接
+		direct := &net.Dialer{Timeout: relayDialTimeout}
+		return tcpDialer(direct.DialContext, busConfig)
+	}
+	return nil, nil
+}
+
+// reconnectsOnDrop 判断内置客户端是否经中继观察连接断开，以便按 Config.Reconnect 重连；
+// NATS 的 tls 协议在收到 INFO 后才升级 TLS，无法经中继转发，仍由底层客户端自行重连
+func reconnectsOnDrop(config Config) bool {
+	if !config.Reconnect.Enabled {
+		return false
+	}
+	return strings.EqualFold(config.Type, TypeMQTT) || !isTLSProtocol(config.Protocol)
+}
+
+// tcpDialer 返回经 dial 连接 Broker 的 brokerDialer，启用 TLS 的协议在建立的连接上完成 TLS 握手
+func tcpDialer(dial netDialer, busConfig types.MessageBusConfig) (brokerDialer, error) {
+	broker := busConfig.Broker
+	address := net.JoinHostPort(broker.Host, strconv.Itoa(broker.Port))
+	var tlsConfig *tls.Config
+	if isTLSProtocol(broker.Protocol) {
+		var err error
+		if tlsConfig, err = TLSConfigFromOptions(busConfig.Optional); err != nil {
+			return nil, err
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = broker.Host
+		}
+	}
+	return func(ctx context.Context) (net.Conn, error) {
+		conn, err := dial(ctx, "tcp", address)
+		if err != nil || tlsConfig == nil {
+			return conn, err
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("与 %s 的 TLS 握手失败: %w", address, err)
+		}
+		return tlsConn, nil
+	}, nil
+}
+
+// transportRelay 在回环地址上监听，将每个连接转发到 dial 建立的 Broker 连接
+type transportRelay struct {
+	dial brokerDialer
+
+	mutex    sync.Mutex
+	address  string
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	dialErr  error  // 最近一次连接 Broker 失败的原因，供 Connect 返回
+	muted    bool   // 主动断开期间不报告 Broker 关闭连接
+	onClose  func() // Broker 一侧先关闭连接时调用
+}
+
+// newTransportRelay 在随机的回环端口上启动中继
+func newTransportRelay(dial brokerDialer) (*transportRelay, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("启动本地中继失败: %w", err)
+	}
+	r := &transportRelay{dial: dial, address: listener.Addr().String(), listener: listener, conns: make(map[net.Conn]struct{})}
+	go r.accept(listener)
+	return r, nil
+}
+
+// start 在关闭后重新监听原地址，底层客户端的 Broker 地址在创建时已固定
+func (r *transportRelay) start() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.dialErr = nil
+	r.muted = false
+	if r.listener != nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", r.address)
+	if err != nil {
+		return fmt.Errorf("重新启动本地中继失败: %w", err)
+	}
+	r.listener = listener
+	go r.accept(listener)
+	return nil
+}
+
+// close 停止监听并关闭所有转发中的连接
+func (r *transportRelay) close() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.listener != nil {
+		_ = r.listener.Close()
+		r.listener = nil
+	}
+	for conn := range r.conns {
+		_ = conn.Close()
+	}
+	r.conns = make(map[net.Conn]struct{})
+}
+
+// mute 在底层客户端主动断开前调用，此后直到下一次 start 不再报告 Broker 关闭连接
+func (r *transportRelay) mute() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.muted = true
+}
+
+// notifyBrokerClose 注册 Broker 一侧先关闭转发中的连接时的回调
+func (r *transportRelay) notifyBrokerClose(handler func()) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.onClose = handler
+}
+
+// lastDialError 返回并清除最近一次连接 Broker 失败的原因
+func (r *transportRelay) lastDialError() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.dialErr
+	r.dialErr = nil
+	return err
+}
+
+// accept 接受底层客户端的连接，直到 listener 关闭
+func (r *transportRelay) accept(listener net.Listener) {
+	for {
+		local, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go r.serve(local)
+	}
+}
+
+// serve 连接 Broker 并双向转发，任一方向结束时关闭两端；Broker 一侧先结束时调用 onClose
+func (r *transportRelay) serve(local net.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), relayDialTimeout)
+	remote, err := r.dial(ctx)
+	cancel()
+	if err != nil {
+		r.mutex.Lock()
+		r.dialErr = err
+		r.mutex.Unlock()
+		_ = local.Close()
+		return
+	}
+	if !r.track(local, remote) {
+		_ = local.Close()
+		_ = remote.Close()
+		return
+	}
+	done := make(chan bool, 2) // true 表示 Broker 一侧先结束
+	go func() {
+		_, _ = io.Copy(remote, local)
+		done <- false
+	}()
+	go func() {
+		_, _ = io.Copy(local, remote)
+		done <- true
+	}()
+	brokerClosed := <-done
+	_ = local.Close()
+	_ = remote.Close()
+	<-done
+	if onClose := r.untrack(local, remote); brokerClosed && onClose != nil {
+		onClose()
+	}
+}
+
+// track 记录转发中的连接，中继已关闭时返回 false
+func (r *transportRelay) track(conns ...net.Conn) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.listener == nil {
+		return false
+	}
+	for _, conn := range conns {
+		r.conns[conn] = struct{}{}
+	}
+	return true
+}
+
+// untrack 移除转发结束的连接，返回此时需要通知的 onClose；中继已关闭或已静默时返回 nil
+func (r *transportRelay) untrack(conns ...net.Conn) func() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, conn := range conns {
+		delete(r.conns, conn)
+	}
+	if r.listener == nil || r.muted {
+		return nil
+	}
+	return r.onClose
+}
+
+// relayedBusConfig 返回让底层客户端以明文 TCP 连接中继的配置，TLS 由中继处理
+func relayedBusConfig(busConfig types.MessageBusConfig, address string) (types.MessageBusConfig, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return busConfig, err
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		return busConfig, err
+	}
+	protocol := "tcp"
+	if strings.HasPrefix(strings.ToLower(busConfig.Type), "nats") {
+		protocol = "nats"
+	}
+	optional := make(map[string]string, len(busConfig.Optional))
+	for k, v := range busConfig.Optional {
+		optional[k] = v
+	}
+	for _, key := range relayTLSOptions {
+		delete(optional, key)
+	}
+	busConfig.Broker = types.HostInfo{Host: host, Port: portNumber, Protocol: protocol}
+	busConfig.Optional = optional
+	return busConfig, nil
+}
+
+// relayClient 包装经中继连接的底层客户端，随 Connect/Disconnect 启停中继
+type relayClient struct {
+	messaging.MessageClient
+	relay *transportRelay
+}
+
+func (r *relayClient) Connect() error {
+	if err := r.relay.start(); err != nil {
+		return err
+	}
+	if err := r.MessageClient.Connect(); err != nil {
+		if dialErr := r.relay.lastDialError(); dialErr != nil {
+			return fmt.Errorf("%w: %v", err, dialErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *relayClient) Disconnect() error {
+	r.relay.mute()
+	err := r.MessageClient.Disconnect()
+	r.relay.close()
+	return err
+}
+
+// NotifyBrokerDisconnect 优先交给能够识别断开原因的底层客户端，否则由中继报告 Broker 关闭连接
+func (r *relayClient) NotifyBrokerDisconnect(handler func(takeover bool)) {
+	if notifier, ok := r.MessageClient.(BrokerDisconnectNotifier); ok {
+		notifier.NotifyBrokerDisconnect(handler)
+		return
+	}
+	r.relay.notifyBrokerClose(func() { handler(false) })
+}
+
+// SupportsMQTT5 在底层客户端支持 MQTT 5 时返回 true
+func (r *relayClient) SupportsMQTT5() bool {
+	publisher, ok := r.MessageClient.(MQTT5Publisher)
+	return ok && publisher.SupportsMQTT5()
+}
+
+// PublishWithProperties 交给底层客户端发布
+func (r *relayClient) PublishWithProperties(message types.MessageEnvelope, topic string, qos int, retain bool, props MQTT5Properties) error {
+	publisher, ok := r.MessageClient.(MQTT5Publisher)
+	if !ok {
+		return ErrMQTT5Unsupported
+	}
+	return publisher.PublishWithProperties(message, topic, qos, retain, props)
+}
@@ -0,0 +1,163 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestOptions 表示请求的结构化元数据，非空字段会合并到发布的请求信封中
+type RequestOptions struct {
+	RequestID   string            // 请求 ID，为空时沿用信封中的值或自动生成
+	ApiVersion  string            // API 版本，为空时沿用信封中的值
+	QueryParams map[string]string // 查询参数，与信封中已有参数合并
+}
+
+// CreateMessageEnvelope 创建消息信封，correlationID 为空时自动生成
+func (c *Client) CreateMessageEnvelope(data interface{}, correlationID string) (types.MessageEnvelope, error) {
+	payload, err := toPayload(data)
+	if err != nil {
+		return types.MessageEnvelope{}, err
+	}
+	if correlationID == "" {
+		correlationID = uuid.NewString()
+	}
+	envelope := types.MessageEnvelope{
+		CorrelationID: correlationID,
+		RequestID:     uuid.NewString(),
+		Payload:       payload,
+		ContentType:   "application/json",
+		QueryParams:   make(map[string]string),
+	}
+	stampSentAt(&envelope, time.Now())
+	if err := applyContractVersion(c.contractVersion(), &envelope); err != nil {
+		return types.MessageEnvelope{}, err
+	}
+	return envelope, nil
+}
+
+// Request 发送请求并等待响应，响应发布在 responseTopic/<RequestID> 上
+// 同一 responseTopic 上的并发请求共享一个订阅，响应按 RequestID 分发给对应的请求，各请求独立计时
+func (c *Client) Request(envelope types.MessageEnvelope, requestTopic string, responseTopic string, timeout time.Duration) (*types.MessageEnvelope, error) {
+	return c.RequestWithOptions(envelope, requestTopic, responseTopic, timeout, RequestOptions{})
+}
+
+// RequestWithOptions 合并请求元数据后发送请求并等待响应
+// 响应的 ErrorCode 非 0 时返回 *RequestError
+func (c *Client) RequestWithOptions(envelope types.MessageEnvelope, requestTopic string, responseTopic string, timeout time.Duration, opts RequestOptions) (*types.MessageEnvelope, error) {
+	return c.request(context.Background(), envelope, requestTopic, responseTopic, timeout, opts)
+}
+
+// request 发送请求并等待响应，ctx 中的追踪上下文会注入到请求信封中
+func (c *Client) request(ctx context.Context, envelope types.MessageEnvelope, requestTopic string, responseTopic string, timeout time.Duration, opts RequestOptions) (response *types.MessageEnvelope, err error) {
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	ctx, span := c.startSpan(ctx, "request", requestTopic, trace.SpanKindClient)
+	defer func() { c.endSpan(span, err) }()
+	envelope.QueryParams = copyQueryParams(envelope.QueryParams)
+	if err := c.prepareRequest(ctx, &envelope, responseTopic, opts); err != nil {
+		return nil, err
+	}
+	response, err = c.roundTrip(ctx, envelope, requestTopic, responseTopic, timeout)
+	if err != nil {
+		c.log(LogPublish).Error("请求失败", c.logFields("topic", requestTopic, "requestId", envelope.RequestID, "error", err)...)
+		return nil, err
+	}
+	if response.ErrorCode != 0 {
+		return response, &RequestError{
+			ErrorCode: response.ErrorCode,
+			Message:   payloadString(response.Payload),
+			Response:  response,
+		}
+	}
+	return response, nil
+}
+
+// prepareRequest 合并请求元数据，写入响应主题前缀并补充发布者、追踪上下文和契约版本
+func (c *Client) prepareRequest(ctx context.Context, envelope *types.MessageEnvelope, responseTopic string, opts RequestOptions) error {
+	applyRequestOptions(envelope, opts)
+	if envelope.QueryParams == nil {
+		envelope.QueryParams = make(map[string]string)
+	}
+	envelope.QueryParams[HeaderResponseTopic] = responseTopic
+	c.stampPublisher(envelope)
+	c.injectTraceContext(ctx, envelope)
+	if envelope.ApiVersion == "" {
+		return applyContractVersion(c.contractVersion(), envelope)
+	}
+	return nil
+}
+
+// roundTrip 在共享响应订阅上登记请求后发布请求，并等待 RequestID 匹配的响应
+func (c *Client) roundTrip(ctx context.Context, envelope types.MessageEnvelope, requestTopic string, responseTopic string, timeout time.Duration) (*types.MessageEnvelope, error) {
+	wait, cancel, err := c.awaitResponse(responseTopic, envelope.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	if err := c.interceptPublish(ctx, requestTopic, envelope, c.sendDirect); err != nil {
+		return nil, fmt.Errorf("发布请求到 %s 失败: %w", requestTopic, publishError(requestTopic, err))
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case response, ok := <-wait:
+		if !ok {
+			return nil, fmt.Errorf("等待响应时客户端已断开")
+		}
+		response, err := c.decodeInbound(response)
+		if err != nil {
+			return nil, &DecodeError{Topic: response.ReceivedTopic, CorrelationID: response.CorrelationID, Err: err}
+		}
+		return &response, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("等待 %s/%s 的响应超时 (%s)", responseTopic, envelope.RequestID, timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// applyRequestOptions 将请求元数据合并到信封中
+func applyRequestOptions(envelope *types.MessageEnvelope, opts RequestOptions) {
+	if opts.RequestID != "" {
+		envelope.RequestID = opts.RequestID
+	}
+	if envelope.RequestID == "" {
+		envelope.RequestID = uuid.NewString()
+	}
+	if opts.ApiVersion != "" {
+		envelope.ApiVersion = opts.ApiVersion
+	}
+	if envelope.CorrelationID == "" {
+		envelope.CorrelationID = uuid.NewString()
+	}
+	if len(opts.QueryParams) > 0 {
+		if envelope.QueryParams == nil {
+			envelope.QueryParams = make(map[string]string, len(opts.QueryParams))
+		}
+		for k, v := range opts.QueryParams {
+			envelope.QueryParams[k] = v
+		}
+	}
+}
+
+// payloadString 将信封的 Payload 转换为字符串，用于错误信息
+// 经 JSON 传输的错误响应 Payload 是 base64 字符串，先按 payloadBytes 还原为原始文本
+func payloadString(payload interface{}) string {
+	switch v := payload.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		data, _ := payloadBytes(v)
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
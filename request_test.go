@@ -0,0 +1,135 @@
+package messagebus_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+func TestRequestWithOptionsReturnsRequestError(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	responder, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = responder.Close() })
+	requester, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = requester.Close() })
+
+	received := make(chan types.MessageEnvelope, 1)
+	err = responder.RegisterRequestHandler("test/command", func(_ context.Context, request types.MessageEnvelope) (interface{}, error) {
+		received <- request
+		return nil, &messagebus.RequestError{ErrorCode: 42, Message: "设备不存在"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope, err := requester.CreateMessageEnvelope(map[string]string{"cmd": "read"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := messagebus.RequestOptions{
+		RequestID:   "req-1",
+		ApiVersion:  "v3",
+		QueryParams: map[string]string{"ds-pushevent": "true"},
+	}
+	response, err := requester.RequestWithOptions(envelope, "test/command", "test/response", time.Second, opts)
+
+	var requestErr *messagebus.RequestError
+	if !errors.As(err, &requestErr) {
+		t.Fatalf("期望 *RequestError，实际 %T: %v", err, err)
+	}
+	if requestErr.ErrorCode != 42 {
+		t.Errorf("ErrorCode = %d，期望 42", requestErr.ErrorCode)
+	}
+	if !strings.Contains(requestErr.Message, "设备不存在") {
+		t.Errorf("Message = %q，期望响应 Payload 中的错误信息", requestErr.Message)
+	}
+	if response == nil || response.RequestID != "req-1" {
+		t.Errorf("应同时返回响应信封，实际 %+v", response)
+	}
+
+	request := <-received
+	if request.RequestID != "req-1" || request.ApiVersion != "v3" || request.QueryParams["ds-pushevent"] != "true" {
+		t.Errorf("请求元数据未合并到信封: %+v", request)
+	}
+}
+
+func TestRequestWithOptionsSuccess(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	responder, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = responder.Close() })
+	requester, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = requester.Close() })
+
+	err = responder.RegisterRequestHandler("test/command", func(context.Context, types.MessageEnvelope) (interface{}, error) {
+		return "ok", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := requester.CreateMessageEnvelope("ping", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := requester.RequestWithOptions(envelope, "test/command", "test/response", time.Second, messagebus.RequestOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload, _ := messagebus.EnvelopePayloadBytes(*response); string(payload) != "ok" {
+		t.Fatalf("响应 Payload = %q，期望 ok", payload)
+	}
+}
+
+func TestRequestDoesNotModifyCallerQueryParams(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	responder, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = responder.Close() })
+	requester, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = requester.Close() })
+
+	err = responder.RegisterRequestHandler("test/command", func(context.Context, types.MessageEnvelope) (interface{}, error) {
+		return "ok", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := requester.CreateMessageEnvelope("ping", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := map[string]string{"device": "d1"}
+	envelope.QueryParams = params
+	opts := messagebus.RequestOptions{QueryParams: map[string]string{"ds-pushevent": "true"}}
+	// 同一信封复用发送多次，调用方的 QueryParams 不应被写入响应主题或请求元数据
+	for i := 0; i < 2; i++ {
+		if _, err := requester.RequestWithOptions(envelope, "test/command", "test/response", time.Second, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(params) != 1 || params["device"] != "d1" {
+		t.Errorf("调用方的 QueryParams 被修改: %v", params)
+	}
+}
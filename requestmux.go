@@ -0,0 +1,145 @@
+package messagebus
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// defaultResponseBuffer 是共享响应订阅接收通道的缓冲大小
+const defaultResponseBuffer = 100
+
+// responseRoute 表示一个响应主题前缀上的共享订阅，及其上等待响应的请求
+type responseRoute struct {
+	topic    string                     // 订阅的主题，即 <响应主题前缀>/#
+	messages chan types.MessageEnvelope // 共享订阅的接收通道
+	pending  map[string]*responseWaiter // RequestID 到等待者的映射
+}
+
+// responseWaiter 表示等待响应的请求，multi 为 true 时持续接收同一 RequestID 的多个响应直到取消
+type responseWaiter struct {
+	messages chan types.MessageEnvelope
+	multi    bool
+}
+
+// requestMux 让同一响应主题前缀上的并发请求共享一个订阅，按 RequestID 将响应分发给对应的等待者
+type requestMux struct {
+	mutex  sync.Mutex
+	routes map[string]*responseRoute // 响应主题前缀到共享订阅的映射
+}
+
+// awaitResponse 登记一个等待 responseTopic/<requestID> 响应的请求，必要时建立共享订阅
+// 返回的通道收到响应后不再使用；客户端断开时通道被关闭。调用方结束等待后必须调用 cancel
+func (c *Client) awaitResponse(responseTopic string, requestID string) (<-chan types.MessageEnvelope, func(), error) {
+	return c.registerWaiter(responseTopic, requestID, &responseWaiter{messages: make(chan types.MessageEnvelope, 1)})
+}
+
+// awaitResponses 登记一个接收 responseTopic/<requestID> 上多个响应的请求，直到调用 cancel
+// 通道缓冲 buffer 个响应，写满时丢弃后续响应；客户端断开时通道被关闭
+func (c *Client) awaitResponses(responseTopic string, requestID string, buffer int) (<-chan types.MessageEnvelope, func(), error) {
+	return c.registerWaiter(responseTopic, requestID, &responseWaiter{messages: make(chan types.MessageEnvelope, buffer), multi: true})
+}
+
+// registerWaiter 在共享响应订阅上登记等待者，必要时建立订阅
+func (c *Client) registerWaiter(responseTopic string, requestID string, waiter *responseWaiter) (<-chan types.MessageEnvelope, func(), error) {
+	mux := &c.requests
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+	route, ok := mux.routes[responseTopic]
+	if !ok {
+		route = &responseRoute{
+			topic:    strings.TrimSuffix(responseTopic, "/") + "/#",
+			messages: make(chan types.MessageEnvelope, defaultResponseBuffer),
+			pending:  make(map[string]*responseWaiter),
+		}
+		topicChannel := types.TopicChannel{Topic: route.topic, Messages: route.messages}
+		if err := c.messageClient().Subscribe([]types.TopicChannel{topicChannel}, c.errorChan); err != nil {
+			return nil, nil, subscribeError("subscribe", []string{route.topic}, err)
+		}
+		if mux.routes == nil {
+			mux.routes = make(map[string]*responseRoute)
+		}
+		mux.routes[responseTopic] = route
+		c.lifecycle.spawn(stageSubscribe, func(stop <-chan struct{}) {
+			c.dispatchResponses(responseTopic, route, stop)
+		})
+	}
+	if _, exists := route.pending[requestID]; exists {
+		return nil, nil, fmt.Errorf("RequestID %s 已有未完成的请求", requestID)
+	}
+	route.pending[requestID] = waiter
+	cancel := func() {
+		mux.mutex.Lock()
+		if route.pending[requestID] == waiter {
+			delete(route.pending, requestID)
+		}
+		mux.mutex.Unlock()
+	}
+	return waiter.messages, cancel, nil
+}
+
+// dispatchResponses 将共享订阅收到的响应交给对应的等待者，客户端断开时关闭所有等待者的通道
+func (c *Client) dispatchResponses(responseTopic string, route *responseRoute, stop <-chan struct{}) {
+	mux := &c.requests
+	defer func() {
+		mux.mutex.Lock()
+		if mux.routes[responseTopic] == route {
+			delete(mux.routes, responseTopic)
+		}
+		for requestID, waiter := range route.pending {
+			close(waiter.messages)
+			delete(route.pending, requestID)
+		}
+		mux.mutex.Unlock()
+	}()
+	for {
+		select {
+		case msg := <-route.messages:
+			msg, complete, err := c.reassemble(route.topic, msg)
+			if err != nil {
+				c.reportError("decode", msg.ReceivedTopic, &DecodeError{Topic: msg.ReceivedTopic, CorrelationID: msg.CorrelationID, Err: err})
+				continue
+			}
+			if !complete {
+				continue
+			}
+			requestID := msg.RequestID
+			if requestID == "" {
+				requestID = msg.ReceivedTopic[strings.LastIndex(msg.ReceivedTopic, "/")+1:]
+			}
+			mux.mutex.Lock()
+			waiter, ok := route.pending[requestID]
+			if ok && !waiter.multi {
+				delete(route.pending, requestID)
+			}
+			mux.mutex.Unlock()
+			if !ok {
+				c.log(LogSubscribe).Debug("丢弃无人等待的响应", c.logFields("topic", msg.ReceivedTopic, "requestId", requestID)...)
+				continue
+			}
+			select {
+			case waiter.messages <- msg:
+			default:
+				c.log(LogSubscribe).Warn("响应过多，丢弃超出预期数量的响应", c.logFields("topic", msg.ReceivedTopic, "requestId", requestID)...)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// resubscribeResponses 在底层连接重建后恢复所有共享响应订阅
+func (c *Client) resubscribeResponses() error {
+	mux := &c.requests
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+	for _, route := range mux.routes {
+		topicChannel := types.TopicChannel{Topic: route.topic, Messages: route.messages}
+		if err := c.messageClient().Subscribe([]types.TopicChannel{topicChannel}, c.errorChan); err != nil {
+			return subscribeError("resubscribe", []string{route.topic}, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package messagebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// HeaderResponseTopic 是请求信封 QueryParams 中记录响应主题前缀的键，由 Request 系列方法自动设置
+const HeaderResponseTopic = "x-response-topic"
+
+// RequestHandlerFunc 定义请求处理函数，返回的 resp 会作为响应 Payload 发布；
+// 返回错误时按 NewErrorResponseEnvelope 构造响应；resp 为 types.MessageEnvelope 时原样作为响应发布
+type RequestHandlerFunc func(ctx context.Context, request types.MessageEnvelope) (resp interface{}, err error)
+
+// RegisterRequestHandler 订阅请求主题，并将处理结果发布到 <响应主题前缀>/<RequestID>
+// 响应主题前缀取自请求的 QueryParams[HeaderResponseTopic]，缺失时使用 Config.ResponseTopicPrefix
+// 响应沿用请求的 CorrelationID 和 RequestID，可直接被 Request 系列方法接收
+func (c *Client) RegisterRequestHandler(requestTopic string, fn RequestHandlerFunc) error {
+	if fn == nil {
+		return fmt.Errorf("请求处理函数不能为空")
+	}
+	handler := c.TracedHandler(func(ctx context.Context, topic string, request types.MessageEnvelope) error {
+		responseTopic, err := c.responseTopic(request)
+		if err != nil {
+			return err
+		}
+		resp, handleErr := fn(ctx, request)
+		var response types.MessageEnvelope
+		if envelope, ok := resp.(*types.MessageEnvelope); ok && envelope != nil {
+			resp = *envelope
+		}
+		switch v := resp.(type) {
+		case types.MessageEnvelope:
+			response = v
+		default:
+			response = NewSuccessResponseEnvelope(request, resp)
+		}
+		if handleErr != nil {
+			response = NewErrorResponseEnvelope(request, handleErr)
+		}
+		// 处理函数自行构造的响应可能缺少关联字段，缺失时沿用请求的值以便 Request 系列方法匹配
+		if response.RequestID == "" {
+			response.RequestID = request.RequestID
+		}
+		if response.CorrelationID == "" {
+			response.CorrelationID = request.CorrelationID
+		}
+		if err := c.publishEnvelope(ctx, responseTopic, response); err != nil {
+			return fmt.Errorf("发布响应到 %s 失败: %w", responseTopic, err)
+		}
+		return handleErr
+	})
+	return c.Subscribe([]string{requestTopic}, handler)
+}
+
+// NewSuccessResponseEnvelope 按 EdgeX 约定构造成功响应：沿用请求的 CorrelationID、RequestID 和 ApiVersion，
+// ErrorCode 为 0，ContentType 为 application/json；[]byte 和 string 原样作为 Payload，其他对象随信封序列化
+func NewSuccessResponseEnvelope(request types.MessageEnvelope, data interface{}) types.MessageEnvelope {
+	payload, _ := toPayload(data)
+	return types.MessageEnvelope{
+		Versionable:   request.Versionable,
+		CorrelationID: request.CorrelationID,
+		RequestID:     request.RequestID,
+		ErrorCode:     0,
+		Payload:       payload,
+		ContentType:   common.ContentTypeJSON,
+		QueryParams:   make(map[string]string),
+	}
+}
+
+// NewErrorResponseEnvelope 按 EdgeX 约定构造错误响应：沿用请求的 CorrelationID、RequestID 和 ApiVersion，
+// Payload 为错误信息文本，ContentType 为 text/plain；ErrorCode 默认为 1，
+// err 包装了 *RequestError 时沿用其中的错误码，便于转发下游服务返回的错误
+func NewErrorResponseEnvelope(request types.MessageEnvelope, err error) types.MessageEnvelope {
+	message := "未知错误"
+	if err != nil {
+		message = err.Error()
+	}
+	errorCode := 1
+	var requestErr *RequestError
+	if errors.As(err, &requestErr) && requestErr.ErrorCode != 0 {
+		errorCode = requestErr.ErrorCode
+	}
+	return types.MessageEnvelope{
+		Versionable:   request.Versionable,
+		CorrelationID: request.CorrelationID,
+		RequestID:     request.RequestID,
+		ErrorCode:     errorCode,
+		Payload:       []byte(message),
+		ContentType:   common.ContentTypeText,
+		QueryParams:   make(map[string]string),
+	}
+}
+
+// responseTopic 计算请求对应的响应主题
+func (c *Client) responseTopic(request types.MessageEnvelope) (string, error) {
+	prefix := request.QueryParams[HeaderResponseTopic]
+	if prefix == "" {
+		prefix = c.config.ResponseTopicPrefix
+	}
+	if prefix == "" {
+		return "", fmt.Errorf("请求 %s 未携带响应主题且未配置 ResponseTopicPrefix", request.RequestID)
+	}
+	if request.RequestID == "" {
+		return "", fmt.Errorf("请求缺少 RequestID，无法确定响应主题")
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + request.RequestID, nil
+}
@@ -0,0 +1,140 @@
+package messagebus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// 处理函数重试的默认值
+const (
+	defaultRetryAttempts       = 3
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = 5 * time.Second
+)
+
+// RetryPolicy 表示处理函数返回错误时在当前 Worker 内立即重试的策略
+// 重试间隔按指数退避增长，重试用尽或错误不可重试时转发到死信主题
+type RetryPolicy struct {
+	// MaxAttempts 包含首次调用在内的最大调用次数，默认 3
+	MaxAttempts int
+	// InitialBackoff 第一次重试前的等待时间，之后每次翻倍，默认 100ms
+	InitialBackoff time.Duration
+	// MaxBackoff 两次重试之间的最大等待时间，默认 5s
+	MaxBackoff time.Duration
+	// Jitter 等待时间的随机抖动比例 (0~1)
+	Jitter float64
+	// Retryable 判断错误是否值得重试，nil 表示除 Permanent 包装的错误外都重试
+	Retryable func(error) bool
+	// DeadLetterTopic 重试用尽后转发到的死信主题，同时设置 Redelivery 时以 Redelivery.DeadLetterTopic 优先
+	DeadLetterTopic string
+}
+
+// validate 校验重试策略
+func (p RetryPolicy) validate() error {
+	if p.MaxAttempts < 0 || p.InitialBackoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("MaxAttempts、InitialBackoff 和 MaxBackoff 不能为负数")
+	}
+	if p.Jitter < 0 {
+		return fmt.Errorf("Jitter 不能为负数")
+	}
+	if strings.ContainsAny(p.DeadLetterTopic, "+#*>") {
+		return fmt.Errorf("DeadLetterTopic 不能包含通配符: %s", p.DeadLetterTopic)
+	}
+	return nil
+}
+
+// backoff 返回第 attempt 次失败后的等待时间，与重连使用相同的退避算法
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	cfg := ReconnectConfig{InitialDelay: p.InitialBackoff, MaxDelay: p.MaxBackoff, Jitter: p.Jitter}
+	if cfg.InitialDelay == 0 {
+		cfg.InitialDelay = defaultRetryInitialBackoff
+	}
+	if cfg.MaxDelay == 0 {
+		cfg.MaxDelay = defaultRetryMaxBackoff
+	}
+	return cfg.withDefaults().backoff(attempt)
+}
+
+// retryable 判断错误是否可以重试，policy 为 nil 时只排除 Permanent 错误
+func (p *RetryPolicy) retryable(err error) bool {
+	if IsPermanent(err) {
+		return false
+	}
+	if p == nil || p.Retryable == nil {
+		return true
+	}
+	return p.Retryable(err)
+}
+
+// permanentError 标记不应重试的处理错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 将处理函数的错误标记为不可重试，消息不再重试或重投，直接转发到死信主题
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent 判断错误是否被 Permanent 标记为不可重试
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// callHandlerWithRetry 调用处理函数，失败时按订阅的 RetryPolicy 退避重试
+func (c *Client) callHandlerWithRetry(sub *subscription, topic string, message types.MessageEnvelope) error {
+	err := c.callHandler(sub, topic, message)
+	policy := sub.opts.Retry
+	if err == nil || policy == nil {
+		return err
+	}
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = defaultRetryAttempts
+	}
+	stop := c.lifecycle.stopChan(stageSubscribe)
+	for attempt := 1; attempt < maxAttempts && policy.retryable(err); attempt++ {
+		delay := policy.backoff(attempt)
+		c.log(LogSubscribe).Debug("消息处理失败，退避后重试", c.logFields("topic", topic, "correlationId", message.CorrelationID, "attempt", attempt, "delay", delay, "error", err)...)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-stop:
+			timer.Stop()
+			return err
+		case <-sub.done:
+			timer.Stop()
+			return err
+		}
+		c.stats.retries.Add(1)
+		if err = c.callHandler(sub, topic, message); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// handleFailure 处理重试后仍失败的消息：可重投时安排重投，否则转发到死信主题
+// raw 为解码前的原始消息，size 为其 Payload 字节数；返回 true 表示消息已安排重投
+func (c *Client) handleFailure(sub *subscription, topic string, raw types.MessageEnvelope, size int64, err error) bool {
+	if sub.opts.Retry.retryable(err) && c.redeliver(sub, topic, raw, size, err) {
+		return true
+	}
+	if sub.opts.Redelivery != nil && sub.opts.Redelivery.DeadLetterTopic != "" {
+		c.deadLetter(topic, raw, sub.opts.Redelivery.DeadLetterTopic, err)
+	} else if sub.opts.Retry != nil {
+		c.deadLetter(topic, raw, sub.opts.Retry.DeadLetterTopic, err)
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package messagebus
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// RouteParams 是路由模式中 {name} 段从实际主题提取的参数
+type RouteParams map[string]string
+
+// RouteHandler 处理路由匹配的消息，params 为模式中 {name} 段对应的主题层级
+type RouteHandler func(topic string, params RouteParams, message types.MessageEnvelope) error
+
+// route 表示一条已注册的路由
+type route struct {
+	pattern string
+	levels  []string
+	handler RouteHandler
+}
+
+// Router 在一个订阅上按主题模式将消息分发给不同的处理函数
+//
+// 模式支持精确层级、单层通配符 + (或 *)、末尾的多层通配符 # (或 >) 以及命名参数 {name}，
+// 例如 edgex/events/device/{service}/{profile}/{device}/#。多条路由同时匹配时，
+// 逐层比较选择最具体的路由：精确层级优先于单层通配符和参数，单层匹配优先于 #。
+type Router struct {
+	mutex    sync.RWMutex
+	routes   []route
+	notFound MessageHandler
+}
+
+// NewRouter 创建空的主题路由器
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle 注册路由模式及其处理函数，模式格式错误或与已有模式重复时返回错误
+func (r *Router) Handle(pattern string, handler RouteHandler) error {
+	if handler == nil {
+		return fmt.Errorf("路由处理函数不能为空")
+	}
+	levels, err := parseRoutePattern(pattern)
+	if err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, existing := range r.routes {
+		if existing.pattern == pattern {
+			return fmt.Errorf("路由 %s 已注册", pattern)
+		}
+	}
+	r.routes = append(r.routes, route{pattern: pattern, levels: levels, handler: handler})
+	return nil
+}
+
+// NotFound 设置没有路由匹配时的处理函数，未设置时忽略这类消息
+func (r *Router) NotFound(handler MessageHandler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.notFound = handler
+}
+
+// Handler 返回按路由分发消息的处理函数，可直接用于 Subscribe
+func (r *Router) Handler() MessageHandler {
+	return r.dispatch
+}
+
+// dispatch 将消息交给最具体的匹配路由
+func (r *Router) dispatch(topic string, message types.MessageEnvelope) error {
+	topicLevels := strings.Split(topic, "/")
+	r.mutex.RLock()
+	var best *route
+	var bestParams RouteParams
+	for i := range r.routes {
+		params, ok := matchRoute(r.routes[i].levels, topicLevels)
+		if !ok {
+			continue
+		}
+		if best == nil || moreSpecific(r.routes[i].levels, best.levels) {
+			best = &r.routes[i]
+			bestParams = params
+		}
+	}
+	notFound := r.notFound
+	r.mutex.RUnlock()
+	if best == nil {
+		if notFound != nil {
+			return notFound(topic, message)
+		}
+		return nil
+	}
+	return best.handler(topic, bestParams, message)
+}
+
+// parseRoutePattern 校验路由模式并按层级拆分
+func parseRoutePattern(pattern string) ([]string, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("路由模式不能为空")
+	}
+	levels := strings.Split(pattern, "/")
+	names := make(map[string]struct{})
+	for i, level := range levels {
+		switch {
+		case level == "#" || level == ">":
+			if i != len(levels)-1 {
+				return nil, fmt.Errorf("路由模式 %s 中的 %s 只能位于末尾", pattern, level)
+			}
+		case strings.HasPrefix(level, "{") || strings.HasSuffix(level, "}"):
+			name := routeParamName(level)
+			if name == "" {
+				return nil, fmt.Errorf("路由模式 %s 中的参数 %s 格式错误", pattern, level)
+			}
+			if _, ok := names[name]; ok {
+				return nil, fmt.Errorf("路由模式 %s 中的参数 %s 重复", pattern, name)
+			}
+			names[name] = struct{}{}
+		case strings.ContainsAny(level, "#>+*{}") && level != "+" && level != "*":
+			return nil, fmt.Errorf("路由模式 %s 的层级 %s 包含非法字符", pattern, level)
+		}
+	}
+	return levels, nil
+}
+
+// routeParamName 返回 {name} 层级中的参数名，不是参数时返回空字符串
+func routeParamName(level string) string {
+	if len(level) < 3 || level[0] != '{' || level[len(level)-1] != '}' {
+		return ""
+	}
+	name := level[1 : len(level)-1]
+	if strings.ContainsAny(name, "{}") {
+		return ""
+	}
+	return name
+}
+
+// matchRoute 判断主题层级是否匹配路由模式，匹配时返回提取的参数
+func matchRoute(levels []string, topicLevels []string) (RouteParams, bool) {
+	var params RouteParams
+	if len(levels) > 0 && len(topicLevels) > 0 && reservedTopic(levels[0], topicLevels[0]) {
+		return nil, false
+	}
+	for i, level := range levels {
+		if level == "#" {
+			return params, true
+		}
+		if i >= len(topicLevels) {
+			return nil, false
+		}
+		if level == ">" {
+			return params, true
+		}
+		switch {
+		case level == "+" || level == "*":
+		case routeParamName(level) != "":
+			if params == nil {
+				params = make(RouteParams)
+			}
+			params[routeParamName(level)] = topicLevels[i]
+		case level != topicLevels[i]:
+			return nil, false
+		}
+	}
+	if len(levels) != len(topicLevels) {
+		return nil, false
+	}
+	return params, true
+}
+
+// moreSpecific 判断路由模式 a 是否比 b 更具体
+func moreSpecific(a, b []string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if ra, rb := levelRank(a[i]), levelRank(b[i]); ra != rb {
+			return ra > rb
+		}
+	}
+	return len(a) > len(b)
+}
+
+// levelRank 返回层级的具体程度：精确层级 2，单层通配符或参数 1，多层通配符 0
+func levelRank(level string) int {
+	switch {
+	case level == "#" || level == ">":
+		return 0
+	case level == "+" || level == "*" || routeParamName(level) != "":
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,95 @@
+package messagebus_test
+
+import (
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// routed 记录一条消息由哪条路由处理
+type routed struct {
+	route  string
+	topic  string
+	params messagebus.RouteParams
+}
+
+func TestRouterDispatchesToMostSpecificRoute(t *testing.T) {
+	client, err := messagebustest.NewMockClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	results := make(chan routed, 16)
+	handle := func(name string) messagebus.RouteHandler {
+		return func(topic string, params messagebus.RouteParams, _ types.MessageEnvelope) error {
+			results <- routed{route: name, topic: topic, params: params}
+			return nil
+		}
+	}
+	router := messagebus.NewRouter()
+	for pattern, name := range map[string]string{
+		"edgex/events/device/{service}/{profile}/{device}/#": "device",
+		"edgex/events/device/core/+/+/#":                     "core",
+		"edgex/events/#":                                     "events",
+	} {
+		if err := router.Handle(pattern, handle(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	router.NotFound(func(topic string, _ types.MessageEnvelope) error {
+		results <- routed{route: "notFound", topic: topic}
+		return nil
+	})
+	if err := client.Subscribe([]string{"edgex/#"}, router.Handler()); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		topic  string
+		route  string
+		params messagebus.RouteParams
+	}{
+		{"edgex/events/device/svc/prof/dev1/temp", "device", messagebus.RouteParams{"service": "svc", "profile": "prof", "device": "dev1"}},
+		{"edgex/events/device/core/prof/dev1/temp", "core", nil},
+		{"edgex/events/core/x", "events", nil},
+		{"edgex/commands/dev1", "notFound", nil},
+	}
+	for _, tc := range cases {
+		if err := client.Publish(tc.topic, "x"); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case got := <-results:
+			if got.route != tc.route || got.topic != tc.topic {
+				t.Errorf("%s 由 %s 处理，期望 %s", tc.topic, got.route, tc.route)
+			}
+			for k, v := range tc.params {
+				if got.params[k] != v {
+					t.Errorf("%s 的参数 %s = %q，期望 %q", tc.topic, k, got.params[k], v)
+				}
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s 未被处理", tc.topic)
+		}
+	}
+}
+
+func TestRouterRejectsInvalidPatterns(t *testing.T) {
+	router := messagebus.NewRouter()
+	handler := func(string, messagebus.RouteParams, types.MessageEnvelope) error { return nil }
+	if err := router.Handle("a/{id}", handler); err != nil {
+		t.Fatal(err)
+	}
+	for _, pattern := range []string{"", "a/#/b", "a/{id}/{id}", "a/{id}"} {
+		if err := router.Handle(pattern, handler); err == nil {
+			t.Errorf("模式 %q 应注册失败", pattern)
+		}
+	}
+	if err := router.Handle("a/b", nil); err == nil {
+		t.Error("处理函数为空时应注册失败")
+	}
+}
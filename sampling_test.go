@@ -0,0 +1,88 @@
+package messagebus_test
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// waitForCount 等待 count 达到 want 或超时，返回最终值
+func waitForCount(count *atomic.Int64, want int64, timeout time.Duration) int64 {
+	deadline := time.Now().Add(timeout)
+	for count.Load() < want && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	return count.Load()
+}
+
+func TestSampleEvery(t *testing.T) {
+	client := newMockClient(t)
+	var handled atomic.Int64
+	err := client.SubscribeWithOptions([]string{"test/sample"}, func(string, types.MessageEnvelope) error {
+		handled.Add(1)
+		return nil
+	}, messagebus.SubscribeOptions{SampleEvery: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if err := client.Publish("test/sample", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := waitForCount(&handled, 10, time.Second); got != 10 {
+		t.Fatalf("处理了 %d 条消息，期望 10 条", got)
+	}
+	deadline := time.Now().Add(time.Second)
+	for client.Stats().DroppedBySampling["test/sample"] < 90 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if dropped := client.Stats().DroppedBySampling["test/sample"]; dropped != 90 {
+		t.Fatalf("DroppedBySampling = %d，期望 90", dropped)
+	}
+}
+
+func TestMaxRateWithinTolerance(t *testing.T) {
+	const rate = 50.0
+	client := newMockClient(t)
+	var handled atomic.Int64
+	err := client.SubscribeWithOptions([]string{"test/rate"}, func(string, types.MessageEnvelope) error {
+		handled.Add(1)
+		return nil
+	}, messagebus.SubscribeOptions{MaxRate: rate})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	published := 0
+	for time.Since(start) < 500*time.Millisecond {
+		if err := client.Publish("test/rate", published); err != nil {
+			t.Fatal(err)
+		}
+		published++
+		time.Sleep(time.Millisecond)
+	}
+	elapsed := time.Since(start)
+	// 令牌桶初始装满 rate 个令牌，之后按 rate 每秒补充
+	want := rate + rate*elapsed.Seconds()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		total := handled.Load() + int64(client.Stats().DroppedBySampling["test/rate"])
+		if total >= int64(published) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	got := float64(handled.Load())
+	if published <= int(want) {
+		t.Skipf("发布速度过慢（%d 条），无法验证限速", published)
+	}
+	if math.Abs(got-want) > want*0.25 {
+		t.Fatalf("%.2fs 内处理了 %.0f 条消息，期望约 %.0f 条（发布 %d 条）", elapsed.Seconds(), got, want, published)
+	}
+}
@@ -0,0 +1,74 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestAll 广播一个请求并收集多个响应（scatter-gather），用于向一组设备下发同一条命令并汇总结果
+//
+// 各响应方应将响应发布到 responseTopicPrefix/<RequestID>。expectedResponses 大于 0 时收齐该数量的响应后立即返回，
+// 超时仍未收齐时返回已收到的响应和超时错误；expectedResponses 不大于 0 时一直收集到超时，此时不返回错误。
+// ErrorCode 非 0 的响应同样计入结果，由调用方逐个检查；无法解码的响应被丢弃并经 OnError 报告。
+func (c *Client) RequestAll(envelope types.MessageEnvelope, requestTopic string, responseTopicPrefix string, timeout time.Duration, expectedResponses int) ([]types.MessageEnvelope, error) {
+	return c.requestAll(context.Background(), envelope, requestTopic, responseTopicPrefix, timeout, expectedResponses)
+}
+
+// requestAll 发送广播请求并在 timeout 内收集响应，ctx 中的追踪上下文会注入到请求信封中
+func (c *Client) requestAll(ctx context.Context, envelope types.MessageEnvelope, requestTopic string, responseTopicPrefix string, timeout time.Duration, expectedResponses int) (responses []types.MessageEnvelope, err error) {
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("RequestAll 的 timeout 必须大于 0")
+	}
+	ctx, span := c.startSpan(ctx, "requestAll", requestTopic, trace.SpanKindClient)
+	defer func() { c.endSpan(span, err) }()
+	envelope.QueryParams = copyQueryParams(envelope.QueryParams)
+	if err := c.prepareRequest(ctx, &envelope, responseTopicPrefix, RequestOptions{}); err != nil {
+		return nil, err
+	}
+
+	buffer := expectedResponses
+	if buffer <= 0 {
+		buffer = defaultResponseBuffer
+	}
+	wait, cancel, err := c.awaitResponses(responseTopicPrefix, envelope.RequestID, buffer)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	if err := c.interceptPublish(ctx, requestTopic, envelope, c.sendDirect); err != nil {
+		return nil, fmt.Errorf("发布请求到 %s 失败: %w", requestTopic, publishError(requestTopic, err))
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for expectedResponses <= 0 || len(responses) < expectedResponses {
+		select {
+		case response, ok := <-wait:
+			if !ok {
+				return responses, fmt.Errorf("等待响应时客户端已断开，已收到 %d 个响应", len(responses))
+			}
+			response, err := c.decodeInbound(response)
+			if err != nil {
+				c.reportError("decode", response.ReceivedTopic, &DecodeError{Topic: response.ReceivedTopic, CorrelationID: response.CorrelationID, Err: err})
+				continue
+			}
+			responses = append(responses, response)
+		case <-timer.C:
+			if expectedResponses <= 0 {
+				return responses, nil
+			}
+			c.log(LogPublish).Warn("未收齐广播请求的响应", c.logFields("topic", requestTopic, "requestId", envelope.RequestID, "received", len(responses), "expected", expectedResponses)...)
+			return responses, fmt.Errorf("等待 %s/%s 的响应超时 (%s)，收到 %d/%d 个响应", responseTopicPrefix, envelope.RequestID, timeout, len(responses), expectedResponses)
+		case <-ctx.Done():
+			return responses, ctx.Err()
+		}
+	}
+	return responses, nil
+}
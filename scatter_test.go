@@ -0,0 +1,86 @@
+package messagebus_test
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+func TestRequestAllGathersResponses(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	for _, name := range []string{"d1", "d2", "d3"} {
+		responder, err := messagebustest.NewMockClientWithBroker(broker)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = responder.Close() })
+		err = responder.RegisterRequestHandler("test/scatter", func(context.Context, types.MessageEnvelope) (interface{}, error) {
+			return name, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	requester, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = requester.Close() })
+	envelope, err := requester.CreateMessageEnvelope("ping", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := func(responses []types.MessageEnvelope) string {
+		var got []string
+		for _, response := range responses {
+			data, _ := messagebus.EnvelopePayloadBytes(response)
+			got = append(got, string(data))
+		}
+		sort.Strings(got)
+		return strings.Join(got, ",")
+	}
+
+	t.Run("expected", func(t *testing.T) {
+		start := time.Now()
+		responses, err := requester.RequestAll(envelope, "test/scatter", "test/scatter/response", 5*time.Second, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(responses); got != "d1,d2,d3" {
+			t.Errorf("响应 = %s，期望 d1,d2,d3", got)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("收齐响应后应立即返回，实际耗时 %s", elapsed)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		responses, err := requester.RequestAll(envelope, "test/scatter", "test/scatter/response", 100*time.Millisecond, 5)
+		if err == nil || !strings.Contains(err.Error(), "3/5") {
+			t.Errorf("未收齐时应返回超时错误，实际 %v", err)
+		}
+		if got := names(responses); got != "d1,d2,d3" {
+			t.Errorf("超时时应返回已收到的响应，实际 %s", got)
+		}
+	})
+
+	t.Run("collect until timeout", func(t *testing.T) {
+		start := time.Now()
+		responses, err := requester.RequestAll(envelope, "test/scatter", "test/scatter/response", 100*time.Millisecond, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(responses); got != "d1,d2,d3" {
+			t.Errorf("响应 = %s，期望 d1,d2,d3", got)
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("expectedResponses 为 0 时应收集到超时，实际 %s 后返回", elapsed)
+		}
+	})
+}
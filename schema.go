@@ -0,0 +1,174 @@
+package messagebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+const (
+	// HeaderSchemaError 是隔离消息 QueryParams 中记录校验失败原因的键
+	HeaderSchemaError = "x-schema-error"
+	// HeaderOriginalTopic 是隔离消息 QueryParams 中记录原始主题的键
+	HeaderOriginalTopic = "x-original-topic"
+)
+
+// ErrSchemaViolation 表示 Payload 不符合主题关联的 Schema，可用 errors.Is 判断
+var ErrSchemaViolation = errors.New("Payload不符合Schema")
+
+// SchemaValidator 校验 Payload 是否符合 Schema，JSON Schema 实现见 jsonschema 子包
+// Validate 返回后 payload 所在的缓冲区可能被复用，实现不能保留该切片
+type SchemaValidator interface {
+	Validate(payload []byte) error
+}
+
+// SchemaValidatorFunc 将普通函数适配为 SchemaValidator
+type SchemaValidatorFunc func(payload []byte) error
+
+// Validate 调用函数本身
+func (f SchemaValidatorFunc) Validate(payload []byte) error {
+	return f(payload)
+}
+
+// SchemaRegistry 返回主题关联的 Schema，没有关联时返回 false
+type SchemaRegistry interface {
+	SchemaFor(topic string) (SchemaValidator, bool)
+}
+
+// SchemaError 表示 Payload 未通过 Schema 校验，与 ErrSchemaViolation 匹配
+type SchemaError struct {
+	Topic string
+	Err   error
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("主题 %s 的Payload不符合Schema: %v", e.Topic, e.Err)
+}
+
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// Is 使 errors.Is(err, ErrSchemaViolation) 成立
+func (e *SchemaError) Is(target error) bool {
+	return target == ErrSchemaViolation
+}
+
+// schemaEntry 表示一条主题模式与 Schema 的关联
+type schemaEntry struct {
+	pattern   string
+	levels    []string
+	validator SchemaValidator
+}
+
+// TopicSchemaRegistry 按主题模式关联 Schema，模式语法与 Router 相同
+// 多个模式同时匹配时使用最具体的模式
+type TopicSchemaRegistry struct {
+	mutex   sync.RWMutex
+	entries []schemaEntry
+}
+
+// NewSchemaRegistry 创建空的 Schema 注册表
+func NewSchemaRegistry() *TopicSchemaRegistry {
+	return &TopicSchemaRegistry{}
+}
+
+// Register 将 Schema 关联到主题模式，同一模式重复注册时替换原有 Schema
+func (r *TopicSchemaRegistry) Register(pattern string, validator SchemaValidator) error {
+	if validator == nil {
+		return fmt.Errorf("Schema校验器不能为空")
+	}
+	levels, err := parseRoutePattern(pattern)
+	if err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i := range r.entries {
+		if r.entries[i].pattern == pattern {
+			r.entries[i].validator = validator
+			return nil
+		}
+	}
+	r.entries = append(r.entries, schemaEntry{pattern: pattern, levels: levels, validator: validator})
+	return nil
+}
+
+// SchemaFor 返回与主题最具体匹配的 Schema
+func (r *TopicSchemaRegistry) SchemaFor(topic string) (SchemaValidator, bool) {
+	topicLevels := strings.Split(topic, "/")
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	var best *schemaEntry
+	for i := range r.entries {
+		if _, ok := matchRoute(r.entries[i].levels, topicLevels); !ok {
+			continue
+		}
+		if best == nil || moreSpecific(r.entries[i].levels, best.levels) {
+			best = &r.entries[i]
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return best.validator, true
+}
+
+// validateSchema 使用主题关联的 Schema 校验信封，没有关联 Schema 时直接通过
+// read 按发布或接收方向读取 Payload，见 withOutboundPayloadBytes
+func validateSchema(registry SchemaRegistry, topic string, envelope types.MessageEnvelope, read func(interface{}, func([]byte) error) error) error {
+	validator, ok := registry.SchemaFor(topic)
+	if !ok {
+		return nil
+	}
+	if err := read(envelope.Payload, validator.Validate); err != nil {
+		return &SchemaError{Topic: topic, Err: err}
+	}
+	return nil
+}
+
+// skipSchemaKey 标记不需要 Schema 校验的发布（隔离消息）
+type skipSchemaKey struct{}
+
+// schemaInterceptor 拒绝不符合主题 Schema 的发布
+func schemaInterceptor(registry SchemaRegistry) PublishInterceptor {
+	return func(next PublishHandler) PublishHandler {
+		return func(ctx context.Context, topic string, envelope types.MessageEnvelope) error {
+			if skip, _ := ctx.Value(skipSchemaKey{}).(bool); !skip {
+				if err := validateSchema(registry, topic, envelope, withOutboundPayloadBytes); err != nil {
+					return err
+				}
+			}
+			return next(ctx, topic, envelope)
+		}
+	}
+}
+
+// checkInboundSchema 校验收到的消息，不符合 Schema 时按订阅选项转发到隔离主题
+func (c *Client) checkInboundSchema(sub *subscription, topic string, envelope types.MessageEnvelope) error {
+	if c.config.Schemas == nil {
+		return nil
+	}
+	err := validateSchema(c.config.Schemas, topic, envelope, withPayloadBytes)
+	if err == nil {
+		return nil
+	}
+	c.stats.schemaViolations.Add(1)
+	if sub.opts.QuarantineTopic == "" {
+		return err
+	}
+	quarantined := envelope
+	quarantined.QueryParams = withParam(envelope.QueryParams, HeaderSchemaError, err.Error())
+	quarantined.QueryParams[HeaderOriginalTopic] = topic
+	ctx := context.WithValue(context.Background(), skipSchemaKey{}, true)
+	if qErr := c.interceptPublish(ctx, sub.opts.QuarantineTopic, quarantined, c.sendDirect); qErr != nil {
+		c.log(LogSubscribe).Error("转发隔离消息失败", c.logFields("topic", topic, "quarantineTopic", sub.opts.QuarantineTopic, "error", qErr)...)
+		return errors.Join(err, qErr)
+	}
+	c.stats.quarantined.Add(1)
+	return err
+}
@@ -0,0 +1,150 @@
+package messagebus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Codec 定义 Payload 的编解码方式及其对应的 ContentType
+type Codec interface {
+	// ContentType 返回序列化结果的内容类型，会写入信封的 ContentType
+	ContentType() string
+	// MarshalPayload 将数据序列化为字节切片
+	MarshalPayload(v interface{}) ([]byte, error)
+	// UnmarshalPayload 将字节切片反序列化到 v 中
+	UnmarshalPayload(data []byte, v interface{}) error
+}
+
+// Serializer 是 Codec 的别名，保留以兼容已有代码
+type Serializer = Codec
+
+// JSONCodec 使用 encoding/json 编解码 Payload
+type JSONCodec struct{}
+
+// ContentType 返回 application/json
+func (JSONCodec) ContentType() string { return common.ContentTypeJSON }
+
+// MarshalPayload 将数据编码为 JSON
+func (JSONCodec) MarshalPayload(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+// UnmarshalPayload 将 JSON 解码到 v 中
+func (JSONCodec) UnmarshalPayload(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// CBORCodec 使用 CBOR 编解码 Payload，EdgeX 的 Event 常以该格式传输
+type CBORCodec struct{}
+
+// ContentType 返回 application/cbor
+func (CBORCodec) ContentType() string { return common.ContentTypeCBOR }
+
+// MarshalPayload 将数据编码为 CBOR
+func (CBORCodec) MarshalPayload(v interface{}) ([]byte, error) { return cbor.Marshal(v) }
+
+// UnmarshalPayload 将 CBOR 解码到 v 中
+func (CBORCodec) UnmarshalPayload(data []byte, v interface{}) error { return cbor.Unmarshal(data, v) }
+
+// codecRegistry 按 ContentType 保存已注册的编解码器，默认包含 JSON 与 CBOR
+var codecRegistry = struct {
+	sync.RWMutex
+	codecs map[string]Codec
+}{codecs: map[string]Codec{
+	common.ContentTypeJSON: JSONCodec{},
+	common.ContentTypeCBOR: CBORCodec{},
+}}
+
+// RegisterCodec 注册编解码器，相同 ContentType 的编解码器会被替换
+// protobuf 子包在导入时会自动注册 application/x-protobuf
+func RegisterCodec(codec Codec) {
+	if codec == nil {
+		return
+	}
+	codecRegistry.Lock()
+	defer codecRegistry.Unlock()
+	codecRegistry.codecs[mediaType(codec.ContentType())] = codec
+}
+
+// CodecFor 返回内容类型对应的编解码器，忽略 charset 等参数；内容类型为空时返回 JSON 编解码器
+func CodecFor(contentType string) (Codec, bool) {
+	if contentType == "" {
+		return JSONCodec{}, true
+	}
+	codecRegistry.RLock()
+	defer codecRegistry.RUnlock()
+	codec, ok := codecRegistry.codecs[mediaType(contentType)]
+	return codec, ok
+}
+
+// mediaType 去掉内容类型中的参数部分
+func mediaType(contentType string) string {
+	if parsed, _, err := mime.ParseMediaType(contentType); err == nil {
+		return parsed
+	}
+	return contentType
+}
+
+// DecodePayload 根据信封的 ContentType 选择编解码器，将 Payload 解码到 v 中
+func DecodePayload(message types.MessageEnvelope, v interface{}) error {
+	codec, ok := CodecFor(message.ContentType)
+	if !ok {
+		return fmt.Errorf("不支持的内容类型: %s", message.ContentType)
+	}
+	data, err := payloadBytes(message.Payload)
+	if err != nil {
+		return err
+	}
+	return codec.UnmarshalPayload(data, v)
+}
+
+// PublishWithSerializer 使用指定编解码器编码数据并发布到指定主题，信封 ContentType 取自编解码器
+func (c *Client) PublishWithSerializer(topic string, data interface{}, serializer Codec) error {
+	if serializer == nil {
+		return fmt.Errorf("序列化器不能为空")
+	}
+	payload, err := serializer.MarshalPayload(data)
+	if err != nil {
+		return fmt.Errorf("序列化Payload失败: %w", err)
+	}
+	return c.publish(context.Background(), topic, payload, serializer.ContentType())
+}
+
+// ContentTypeOctetStream 是 PublishBinary 发布的二进制数据的内容类型
+const ContentTypeOctetStream = "application/octet-stream"
+
+// PublishJSON 将数据编码为 JSON 后发布，ContentType 为 application/json；无法编码时返回错误且不发布
+// 与 Publish 不同，数据在发布前即完成编码，不受 Config.Codec 影响
+func (c *Client) PublishJSON(topic string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("Payload无法编码为JSON: %w", err)
+	}
+	return c.publish(context.Background(), topic, payload, common.ContentTypeJSON)
+}
+
+// PublishString 发布文本，ContentType 为 text/plain
+func (c *Client) PublishString(topic string, text string) error {
+	return c.publish(context.Background(), topic, []byte(text), common.ContentTypeText)
+}
+
+// PublishBinary 发布二进制数据，ContentType 为 application/octet-stream
+func (c *Client) PublishBinary(topic string, data []byte) error {
+	return c.publish(context.Background(), topic, data, ContentTypeOctetStream)
+}
+
+// PublishCBOR 将数据编码为 CBOR 后发布，ContentType 为 application/cbor；data 为 []byte 时视为已编码的 CBOR
+func (c *Client) PublishCBOR(topic string, data interface{}) error {
+	if raw, ok := data.([]byte); ok {
+		return c.publish(context.Background(), topic, raw, common.ContentTypeCBOR)
+	}
+	return c.PublishWithSerializer(topic, data, CBORCodec{})
+}
+
+// EnvelopePayloadBytes 返回信封 Payload 的字节形式，兼容 []byte、base64 字符串及已解码对象
+func EnvelopePayloadBytes(message types.MessageEnvelope) ([]byte, error) {
+	return payloadBytes(message.Payload)
+}
@@ -0,0 +1,86 @@
+package messagebus
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+)
+
+// OptionalSessionExpiryInterval 是设置 SessionExpiryInterval 时写入底层配置 Optional 的键（单位为秒），
+// 支持 MQTT 5 的客户端工厂据此在 CONNECT 中携带 Session Expiry Interval 属性
+const OptionalSessionExpiryInterval = "SessionExpiryInterval"
+
+// maxSessionExpiryInterval 是 MQTT 5 Session Expiry Interval 的上限，该值表示会话永不过期
+const maxSessionExpiryInterval = time.Duration(math.MaxUint32) * time.Second
+
+// CleanSessionFlag 返回指向 clean 的指针，用于设置 Config.CleanSession
+func CleanSessionFlag(clean bool) *bool {
+	return &clean
+}
+
+// persistentSession 判断是否请求 Broker 保留会话
+func (c Config) persistentSession() bool {
+	return c.CleanSession != nil && !*c.CleanSession
+}
+
+// sessionOptions 校验会话参数并转换为 go-mod-messaging 的 Optional 参数
+func sessionOptions(config Config) (map[string]string, error) {
+	if config.CleanSession == nil && config.SessionExpiryInterval == 0 {
+		return nil, nil
+	}
+	if !strings.EqualFold(config.Type, TypeMQTT) {
+		return nil, fmt.Errorf("CleanSession 和 SessionExpiryInterval 仅适用于 %s 类型，当前类型为 %s", TypeMQTT, config.Type)
+	}
+	if config.SessionExpiryInterval < 0 || config.SessionExpiryInterval > maxSessionExpiryInterval {
+		return nil, fmt.Errorf("SessionExpiryInterval 必须在 0 到 %d 秒之间", uint32(math.MaxUint32))
+	}
+	if config.SessionExpiryInterval%time.Second != 0 {
+		return nil, fmt.Errorf("SessionExpiryInterval 必须为整秒")
+	}
+	if config.persistentSession() && config.ClientID == "" {
+		return nil, fmt.Errorf("CleanSession 为 false 时必须设置固定的 ClientID，Broker 按 ClientID 保留会话")
+	}
+	opts := make(map[string]string)
+	if config.CleanSession != nil {
+		opts["CleanSession"] = strconv.FormatBool(*config.CleanSession)
+	}
+	if config.SessionExpiryInterval > 0 {
+		opts[OptionalSessionExpiryInterval] = strconv.FormatInt(int64(config.SessionExpiryInterval/time.Second), 10)
+	}
+	return opts, nil
+}
+
+// ResumeSession 让 Broker 重新投递持久会话中离线期间积压的消息
+//
+// 持久会话下 Broker 在连接建立后立即投递积压的消息，进程重启后这些消息先于重新订阅到达，
+// 底层客户端找不到对应的订阅而不确认它们。重新订阅后调用该方法断开并重新建立底层连接（保留订阅），
+// Broker 会重新投递未确认的消息。未设置 CleanSession 为 false 时不做任何事；
+// 配置了 SubscriptionState.Restore 时 Connect 在恢复订阅后会自动调用。
+func (c *Client) ResumeSession() error {
+	if !c.config.persistentSession() {
+		return nil
+	}
+	if !c.IsConnected() {
+		return ErrNotConnected
+	}
+	clients := []messaging.MessageClient{c.messageClient()}
+	c.variantsMu.Lock()
+	for _, client := range c.variants {
+		clients = append(clients, client)
+	}
+	c.variantsMu.Unlock()
+	for _, client := range clients {
+		_ = client.Disconnect()
+		if err := client.Connect(); err != nil {
+			c.log(LogConnection).Error("恢复持久会话失败", c.logFields("error", err)...)
+			c.reconnectInBackground(err)
+			return fmt.Errorf("恢复持久会话失败: %w", err)
+		}
+	}
+	c.log(LogConnection).Info("已恢复持久会话", c.logFields("clientId", c.config.ClientID)...)
+	return nil
+}
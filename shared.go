@@ -0,0 +1,72 @@
+package messagebus
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sharedPrefix 是 MQTT 5 共享订阅主题的前缀
+const sharedPrefix = "$share/"
+
+// SharedTopic 返回共享订阅主题 $share/<group>/<topic>，可用于 Unsubscribe 取消 SubscribeShared 建立的订阅
+func SharedTopic(group, topic string) string {
+	return sharedPrefix + group + "/" + topic
+}
+
+// splitSharedTopic 拆分共享订阅主题，不是 $share/<group>/<filter> 格式时 ok 为 false
+func splitSharedTopic(topic string) (group, filter string, ok bool) {
+	if !strings.HasPrefix(topic, sharedPrefix) {
+		return "", topic, false
+	}
+	parts := strings.SplitN(topic, "/", 3)
+	if len(parts) < 3 || parts[1] == "" {
+		return "", topic, false
+	}
+	return parts[1], parts[2], true
+}
+
+// SubscribeShared 以共享订阅方式订阅主题，同一 group 下的多个客户端（例如同一服务的多个副本）分摊消息，
+// 每条消息只投递给其中一个客户端
+//
+// MQTT 订阅 $share/<group>/<topic>，需要 Broker 支持共享订阅（MQTT 5 或支持该扩展的 MQTT 3.1.1 Broker）；
+// NATS 与 JetStream 在一条设置了 QueueGroup 的额外连接上以队列组订阅。
+// 订阅以 SharedTopic(group, topic) 为键记录，取消订阅时需传入该主题
+func (c *Client) SubscribeShared(group string, topics []string, handler MessageHandler) error {
+	return c.SubscribeWithOptions(topics, handler, SubscribeOptions{Group: group})
+}
+
+// sharedTopics 为设置了共享订阅组的订阅生成 $share/<group>/<topic> 主题，并检查后端是否支持
+func (c *Client) sharedTopics(topics []string, group string) ([]string, error) {
+	for _, topic := range topics {
+		if _, _, shared := splitSharedTopic(topic); shared && (group != "" || isNATS(c.config.Type)) {
+			return nil, fmt.Errorf("共享订阅请使用 SubscribeShared 或 SubscribeOptions.Group: %s", topic)
+		}
+	}
+	if group == "" {
+		return topics, nil
+	}
+	if !strings.EqualFold(c.config.Type, TypeMQTT) && !isNATS(c.config.Type) {
+		return nil, fmt.Errorf("%s 类型不支持共享订阅", c.config.Type)
+	}
+	shared := make([]string, len(topics))
+	for i, topic := range topics {
+		shared[i] = SharedTopic(group, topic)
+	}
+	return shared, nil
+}
+
+// wireTopic 返回向底层客户端订阅时使用的主题：NATS 以队列组实现共享订阅，需要去掉 $share/<group>/ 前缀
+func (c *Client) wireTopic(topic string) string {
+	if !isNATS(c.config.Type) {
+		return topic
+	}
+	if _, filter, ok := splitSharedTopic(topic); ok {
+		return filter
+	}
+	return topic
+}
+
+// isNATS 判断是否为 NATS Core 或 JetStream 类型
+func isNATS(busType string) bool {
+	return strings.EqualFold(busType, TypeNatsCore) || strings.EqualFold(busType, TypeNatsJetStream)
+}
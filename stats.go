@@ -0,0 +1,253 @@
+package messagebus
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxStatsTopics 是按主题统计发布和接收数量时单独计数的主题数上限，超出的主题计入 StatsOtherTopics，
+// 避免响应主题等包含请求 ID 的主题使计数表无限增长
+const maxStatsTopics = 1000
+
+// StatsOtherTopics 是超出单独计数上限的主题在 PublishedByTopic 和 ReceivedByTopic 中的键
+const StatsOtherTopics = "(other)"
+
+// Stats 表示客户端运行时统计信息
+type Stats struct {
+	InFlightBytes     int64             // 正在由处理函数处理的消息字节数
+	DroppedBySampling map[string]uint64 // 各订阅主题因采样或限速被丢弃的消息数
+	DroppedStale      map[string]uint64 // 各订阅主题因超过 MaxMessageAge 被丢弃的消息数
+	DroppedEcho       map[string]uint64 // 各订阅主题因 NoEcho 被丢弃的本客户端消息数
+	DroppedOverflow   map[string]uint64 // 各订阅主题因缓冲区已满按溢出策略被丢弃的消息数
+	Spilled           map[string]uint64 // 各订阅主题写入 SpillStore 的消息数
+	// DroppedLifecycleEvents 因事件通道已满被丢弃的生命周期事件数
+	DroppedLifecycleEvents uint64
+	MessagesPublished      uint64 // 发布成功的消息数（含离线转发）
+	PublishErrors          uint64 // 发布失败的次数
+	MessagesReceived       uint64 // 交给处理函数的消息数
+	HandlerErrors          uint64 // 处理函数返回错误的次数
+	Reconnects             uint64 // 重连成功的次数
+	DroppedErrors          uint64 // 因错误通道已满被丢弃的错误数
+	HandlerPanics          uint64 // 处理函数发生 panic 的次数（同时计入 HandlerErrors）
+	SchemaViolations       uint64 // 收到的不符合 Schema 的消息数（同时计入 HandlerErrors）
+	Quarantined            uint64 // 转发到隔离主题的消息数
+	Redelivered            uint64 // 按 RedeliveryPolicy 安排重投的次数
+	DeadLettered           uint64 // 重试或重投用尽后转发到死信主题的消息数
+	HandlerRetries         uint64 // 按 RetryPolicy 重新调用处理函数的次数
+
+	PublishedByTopic  map[string]uint64 // 按实际发布主题统计的发布成功数
+	ReceivedByTopic   map[string]uint64 // 按订阅主题统计的交给处理函数的消息数
+	BytesOut          uint64            // 发布成功的 Payload 字节数
+	BytesIn           uint64            // 交给处理函数的 Payload 字节数（解码前）
+	AvgHandlerLatency time.Duration     // 处理函数（含解码和重试）的平均耗时
+	Uptime            time.Duration     // 自本次连接建立以来的时长，未连接时为 0
+	LastPublished     time.Time         // 最近一次发布成功的时间，尚未发布时为零值
+	LastReceived      time.Time         // 最近一次处理消息的时间，尚未接收时为零值
+	QueueDepth        map[string]int    // 各订阅接收通道中排队的消息数
+	SlowHandlers      uint64            // 处理函数执行超过 SlowHandlerThreshold 的次数
+	QueueLagAlerts    uint64            // 订阅通道持续积压告警的次数
+	DroppedTapEvents  uint64            // 因 Tap 通道已满被丢弃的镜像消息数
+}
+
+// statsCollector 收集客户端运行时计数
+type statsCollector struct {
+	mutex             sync.Mutex
+	droppedBySampling map[string]uint64
+	droppedStale      map[string]uint64
+	droppedEcho       map[string]uint64
+	droppedOverflow   map[string]uint64
+	spilled           map[string]uint64
+	droppedEvents     atomic.Uint64
+	published         atomic.Uint64
+	publishErrors     atomic.Uint64
+	received          atomic.Uint64
+	handlerErrors     atomic.Uint64
+	reconnects        atomic.Uint64
+	droppedErrors     atomic.Uint64
+	handlerPanics     atomic.Uint64
+	schemaViolations  atomic.Uint64
+	quarantined       atomic.Uint64
+	redelivered       atomic.Uint64
+	deadLettered      atomic.Uint64
+	retries           atomic.Uint64
+	publishedByTopic  map[string]uint64
+	receivedByTopic   map[string]uint64
+	bytesOut          atomic.Uint64
+	bytesIn           atomic.Uint64
+	handlerNanos      atomic.Int64
+	lastPublished     atomic.Int64 // Unix 纳秒
+	lastReceived      atomic.Int64 // Unix 纳秒
+	connectedAt       atomic.Int64 // Unix 纳秒，未连接时为 0
+	slowHandlers      atomic.Uint64
+	queueLagAlerts    atomic.Uint64
+	droppedTap        atomic.Uint64
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{
+		droppedBySampling: make(map[string]uint64),
+		droppedStale:      make(map[string]uint64),
+		droppedEcho:       make(map[string]uint64),
+		droppedOverflow:   make(map[string]uint64),
+		spilled:           make(map[string]uint64),
+		publishedByTopic:  make(map[string]uint64),
+		receivedByTopic:   make(map[string]uint64),
+	}
+}
+
+// recordPublish 记录一条发布成功的消息
+func (s *statsCollector) recordPublish(topic string, size int64) {
+	s.published.Add(1)
+	s.bytesOut.Add(uint64(size))
+	s.lastPublished.Store(time.Now().UnixNano())
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	countTopic(s.publishedByTopic, topic)
+}
+
+// recordReceive 记录一条交给处理函数的消息及其处理耗时
+func (s *statsCollector) recordReceive(topic string, size int64, elapsed time.Duration) {
+	s.received.Add(1)
+	s.bytesIn.Add(uint64(size))
+	s.handlerNanos.Add(int64(elapsed))
+	s.lastReceived.Store(time.Now().UnixNano())
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	countTopic(s.receivedByTopic, topic)
+}
+
+// countTopic 为主题计数，单独计数的主题达到上限后新主题计入 StatsOtherTopics
+func countTopic(counts map[string]uint64, topic string) {
+	if _, ok := counts[topic]; !ok && len(counts) >= maxStatsTopics {
+		topic = StatsOtherTopics
+	}
+	counts[topic]++
+}
+
+// reset 清零所有计数，连接时间不受影响
+func (s *statsCollector) reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, counts := range []*map[string]uint64{
+		&s.droppedBySampling, &s.droppedStale, &s.droppedEcho, &s.droppedOverflow,
+		&s.spilled, &s.publishedByTopic, &s.receivedByTopic,
+	} {
+		*counts = make(map[string]uint64)
+	}
+	for _, counter := range []*atomic.Uint64{
+		&s.droppedEvents, &s.published, &s.publishErrors, &s.received, &s.handlerErrors,
+		&s.reconnects, &s.droppedErrors, &s.handlerPanics, &s.schemaViolations, &s.quarantined,
+		&s.redelivered, &s.deadLettered, &s.retries, &s.bytesOut, &s.bytesIn,
+		&s.slowHandlers, &s.queueLagAlerts, &s.droppedTap,
+	} {
+		counter.Store(0)
+	}
+	s.handlerNanos.Store(0)
+	s.lastPublished.Store(0)
+	s.lastReceived.Store(0)
+}
+
+// dropBySampling 记录一条因采样或限速被丢弃的消息
+func (s *statsCollector) dropBySampling(topic string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.droppedBySampling[topic]++
+}
+
+// dropStale 记录一条因过期被丢弃的消息
+func (s *statsCollector) dropStale(topic string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.droppedStale[topic]++
+}
+
+// dropEcho 记录一条因 NoEcho 被丢弃的消息
+func (s *statsCollector) dropEcho(topic string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.droppedEcho[topic]++
+}
+
+// dropOverflow 记录一条因缓冲区已满被丢弃的消息
+func (s *statsCollector) dropOverflow(topic string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.droppedOverflow[topic]++
+}
+
+// spill 记录一条写入溢出存储的消息
+func (s *statsCollector) spill(topic string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.spilled[topic]++
+}
+
+// Stats 返回客户端当前的统计信息
+func (c *Client) Stats() Stats {
+	c.stats.mutex.Lock()
+	stats := Stats{
+		DroppedBySampling: copyCounts(c.stats.droppedBySampling),
+		DroppedStale:      copyCounts(c.stats.droppedStale),
+		DroppedEcho:       copyCounts(c.stats.droppedEcho),
+		DroppedOverflow:   copyCounts(c.stats.droppedOverflow),
+		Spilled:           copyCounts(c.stats.spilled),
+		PublishedByTopic:  copyCounts(c.stats.publishedByTopic),
+		ReceivedByTopic:   copyCounts(c.stats.receivedByTopic),
+	}
+	stats.DroppedLifecycleEvents = c.stats.droppedEvents.Load()
+	stats.MessagesPublished = c.stats.published.Load()
+	stats.PublishErrors = c.stats.publishErrors.Load()
+	stats.MessagesReceived = c.stats.received.Load()
+	stats.HandlerErrors = c.stats.handlerErrors.Load()
+	stats.Reconnects = c.stats.reconnects.Load()
+	stats.DroppedErrors = c.stats.droppedErrors.Load()
+	stats.HandlerPanics = c.stats.handlerPanics.Load()
+	stats.SchemaViolations = c.stats.schemaViolations.Load()
+	stats.Quarantined = c.stats.quarantined.Load()
+	stats.Redelivered = c.stats.redelivered.Load()
+	stats.DeadLettered = c.stats.deadLettered.Load()
+	stats.HandlerRetries = c.stats.retries.Load()
+	stats.BytesOut = c.stats.bytesOut.Load()
+	stats.BytesIn = c.stats.bytesIn.Load()
+	if stats.MessagesReceived > 0 {
+		stats.AvgHandlerLatency = time.Duration(c.stats.handlerNanos.Load() / int64(stats.MessagesReceived))
+	}
+	stats.LastPublished = unixNanoTime(c.stats.lastPublished.Load())
+	stats.LastReceived = unixNanoTime(c.stats.lastReceived.Load())
+	if connectedAt := c.stats.connectedAt.Load(); connectedAt != 0 {
+		stats.Uptime = time.Since(time.Unix(0, connectedAt))
+	}
+	stats.SlowHandlers = c.stats.slowHandlers.Load()
+	stats.QueueLagAlerts = c.stats.queueLagAlerts.Load()
+	stats.DroppedTapEvents = c.stats.droppedTap.Load()
+	c.stats.mutex.Unlock()
+
+	stats.QueueDepth = c.queueDepths()
+	if c.budget != nil {
+		stats.InFlightBytes = c.budget.inFlight()
+	}
+	return stats
+}
+
+// ResetStats 清零所有统计计数，InFlightBytes 和 Uptime 不受影响
+func (c *Client) ResetStats() {
+	c.stats.reset()
+}
+
+// unixNanoTime 将 Unix 纳秒转换为时间，0 表示零值
+func unixNanoTime(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// copyCounts 复制计数表
+func copyCounts(counts map[string]uint64) map[string]uint64 {
+	copied := make(map[string]uint64, len(counts))
+	for k, v := range counts {
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,122 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/google/uuid"
+)
+
+const (
+	// HeaderStreamEnd 是信封 QueryParams 中标记流式响应结束的键，值为 "true" 时表示最后一条响应
+	HeaderStreamEnd = "x-stream-end"
+	// HeaderStreamTopic 是请求信封 QueryParams 中记录流式响应主题的键，由 RequestStream 自动设置
+	HeaderStreamTopic = "x-stream-topic"
+	// HeaderStreamSeq 是流式响应信封 QueryParams 中的分块序号，从 0 开始递增，接收方可据此检查丢失或乱序
+	HeaderStreamSeq = "x-stream-seq"
+)
+
+// StreamOptions 表示流式请求的可选参数
+type StreamOptions struct {
+	// IsLast 判断响应是否为最后一条，默认检查 QueryParams[HeaderStreamEnd] == "true"
+	IsLast func(response types.MessageEnvelope) bool
+	// Buffer 响应通道的缓冲大小，默认 16
+	Buffer int
+}
+
+// RequestStream 发送请求并以通道形式返回 CorrelationID 匹配的所有响应
+//
+// 响应方应向 responseTopic 发布与请求 CorrelationID 相同的多条响应，并在最后一条响应的
+// QueryParams 中设置 HeaderStreamEnd 为 "true"。收到结束标记、ctx 取消或客户端断开时，
+// 响应订阅被取消且通道被关闭。
+func (c *Client) RequestStream(ctx context.Context, envelope types.MessageEnvelope, requestTopic string, responseTopic string) (<-chan types.MessageEnvelope, error) {
+	return c.RequestStreamWithOptions(ctx, envelope, requestTopic, responseTopic, StreamOptions{})
+}
+
+// RequestStreamWithOptions 按流式选项发送请求并返回响应通道
+func (c *Client) RequestStreamWithOptions(ctx context.Context, envelope types.MessageEnvelope, requestTopic string, responseTopic string, opts StreamOptions) (<-chan types.MessageEnvelope, error) {
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	if opts.IsLast == nil {
+		opts.IsLast = isStreamEnd
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = 16
+	}
+	if envelope.CorrelationID == "" {
+		envelope.CorrelationID = uuid.NewString()
+	}
+	envelope.QueryParams = copyQueryParams(envelope.QueryParams)
+	envelope.QueryParams[HeaderStreamTopic] = responseTopic
+	c.stampPublisher(&envelope)
+	c.injectTraceContext(ctx, &envelope)
+	if envelope.ApiVersion == "" {
+		if err := applyContractVersion(c.contractVersion(), &envelope); err != nil {
+			return nil, err
+		}
+	}
+
+	messages := make(chan types.MessageEnvelope, opts.Buffer)
+	errs := make(chan error, 1)
+	topicChannel := types.TopicChannel{Topic: responseTopic, Messages: messages}
+	if err := c.messageClient().Subscribe([]types.TopicChannel{topicChannel}, errs); err != nil {
+		return nil, subscribeError("subscribe", []string{responseTopic}, err)
+	}
+	if err := c.interceptPublish(ctx, requestTopic, envelope, c.sendDirect); err != nil {
+		_ = c.messageClient().Unsubscribe(responseTopic)
+		return nil, fmt.Errorf("发布请求到 %s 失败: %w", requestTopic, publishError(requestTopic, err))
+	}
+
+	responses := make(chan types.MessageEnvelope, opts.Buffer)
+	c.lifecycle.spawn(stageSubscribe, func(stop <-chan struct{}) {
+		defer close(responses)
+		defer func() {
+			if err := c.messageClient().Unsubscribe(responseTopic); err != nil {
+				c.log(LogSubscribe).Warn("取消流式响应订阅失败", c.logFields("topic", responseTopic, "error", err)...)
+			}
+		}()
+		for {
+			select {
+			case msg := <-messages:
+				if msg.CorrelationID != envelope.CorrelationID {
+					continue
+				}
+				msg, complete, err := c.reassemble(responseTopic+"\x00"+envelope.CorrelationID, msg)
+				if err == nil && !complete {
+					continue
+				}
+				if err == nil {
+					msg, err = c.decodeInbound(msg)
+				}
+				if err != nil {
+					c.reportError("decode", msg.ReceivedTopic, &DecodeError{Topic: msg.ReceivedTopic, CorrelationID: msg.CorrelationID, Err: err})
+					continue
+				}
+				select {
+				case responses <- msg:
+				case <-ctx.Done():
+					return
+				case <-stop:
+					return
+				}
+				if opts.IsLast(msg) {
+					return
+				}
+			case err := <-errs:
+				c.log(LogSubscribe).Error("流式响应订阅出错", c.logFields("topic", responseTopic, "error", err)...)
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			}
+		}
+	})
+	return responses, nil
+}
+
+// isStreamEnd 判断响应是否携带结束标记
+func isStreamEnd(response types.MessageEnvelope) bool {
+	return response.QueryParams[HeaderStreamEnd] == "true"
+}
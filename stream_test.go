@@ -0,0 +1,167 @@
+package messagebus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+func TestRequestStreamMultipleChunks(t *testing.T) {
+	const chunks = 5
+	broker := messagebustest.NewBroker()
+	responder, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = responder.Close() })
+	requester, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = requester.Close() })
+
+	err = responder.RegisterStreamHandler("test/stream", func(_ context.Context, _ types.MessageEnvelope, stream *messagebus.ResponseStream) error {
+		for i := 0; i < chunks; i++ {
+			if err := stream.Send(fmt.Sprintf("chunk-%d", i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	request, err := requester.CreateMessageEnvelope("start", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses, err := requester.RequestStream(ctx, request, "test/stream", "test/stream/response")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []types.MessageEnvelope
+	for response := range responses {
+		got = append(got, response)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("流未在超时前结束，已收到 %d 条响应", len(got))
+	}
+	// 处理函数返回后自动发送不含数据的结束标记
+	if len(got) != chunks+1 {
+		t.Fatalf("收到 %d 条响应，期望 %d 条", len(got), chunks+1)
+	}
+	for i, response := range got {
+		if response.CorrelationID != request.CorrelationID {
+			t.Errorf("第 %d 条响应的 CorrelationID = %q", i, response.CorrelationID)
+		}
+		if seq := response.QueryParams[messagebus.HeaderStreamSeq]; seq != strconv.Itoa(i) {
+			t.Errorf("第 %d 条响应的序号为 %q", i, seq)
+		}
+		last := response.QueryParams[messagebus.HeaderStreamEnd] == "true"
+		if last != (i == chunks) {
+			t.Errorf("第 %d 条响应的结束标记为 %v", i, last)
+		}
+		if i < chunks {
+			if payload, _ := messagebus.EnvelopePayloadBytes(response); string(payload) != fmt.Sprintf("chunk-%d", i) {
+				t.Errorf("第 %d 条响应的 Payload = %q", i, payload)
+			}
+		}
+	}
+}
+
+func TestStreamHandlerEndsStreamOnce(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	responder, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = responder.Close() })
+	requester, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = requester.Close() })
+
+	sendAfterClose := make(chan error, 1)
+	err = responder.RegisterStreamHandler("test/stream/fail", func(_ context.Context, _ types.MessageEnvelope, stream *messagebus.ResponseStream) error {
+		if err := stream.Send("partial"); err != nil {
+			return err
+		}
+		return errors.New("读取设备失败")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = responder.RegisterStreamHandler("test/stream/close", func(_ context.Context, _ types.MessageEnvelope, stream *messagebus.ResponseStream) error {
+		if err := stream.Close(); err != nil {
+			return err
+		}
+		sendAfterClose <- stream.Send("late")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// stream 发送请求并返回收到的所有响应，以及 Broker 上发往响应主题的消息数
+	stream := func(requestTopic, responseTopic string) ([]types.MessageEnvelope, int) {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		request, err := requester.CreateMessageEnvelope("start", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		responses, err := requester.RequestStream(ctx, request, requestTopic, responseTopic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []types.MessageEnvelope
+		for response := range responses {
+			got = append(got, response)
+		}
+		if ctx.Err() != nil {
+			t.Fatalf("流未在超时前结束，已收到 %d 条响应", len(got))
+		}
+		time.Sleep(50 * time.Millisecond)
+		published := 0
+		for _, msg := range broker.Published() {
+			if msg.Topic == responseTopic {
+				published++
+			}
+		}
+		return got, published
+	}
+
+	got, published := stream("test/stream/fail", "test/stream/fail/response")
+	if len(got) != 2 || published != 2 {
+		t.Fatalf("收到 %d 条响应，Broker 上 %d 条，期望数据分块和错误结束标记共 2 条", len(got), published)
+	}
+	last := got[1]
+	if last.ErrorCode == 0 || last.QueryParams[messagebus.HeaderStreamEnd] != "true" {
+		t.Errorf("处理函数返回错误时最后一条响应应为带结束标记的错误响应，实际 ErrorCode=%d QueryParams=%v", last.ErrorCode, last.QueryParams)
+	}
+	if payload, _ := messagebus.EnvelopePayloadBytes(last); !strings.Contains(string(payload), "读取设备失败") {
+		t.Errorf("错误响应的 Payload = %q", payload)
+	}
+
+	got, published = stream("test/stream/close", "test/stream/close/response")
+	if len(got) != 1 || published != 1 {
+		t.Fatalf("收到 %d 条响应，Broker 上 %d 条，期望处理函数已结束的流不再重复发送结束标记", len(got), published)
+	}
+	if err := <-sendAfterClose; err == nil {
+		t.Error("流结束后 Send 应返回错误")
+	}
+}
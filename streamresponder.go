@@ -0,0 +1,117 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// StreamHandlerFunc 定义流式请求处理函数，通过 stream 逐块发送响应
+// 函数返回后流被自动结束：返回 nil 时发送结束标记，返回错误时发送携带结束标记的错误响应
+type StreamHandlerFunc func(ctx context.Context, request types.MessageEnvelope, stream *ResponseStream) error
+
+// ResponseStream 向 RequestStream 的请求方逐块发送响应
+// 每个分块沿用请求的 CorrelationID 和 RequestID，并在 QueryParams[HeaderStreamSeq] 中携带递增序号；
+// 最后一个分块的 QueryParams[HeaderStreamEnd] 为 "true"。ResponseStream 可以并发调用，分块按调用顺序编号
+type ResponseStream struct {
+	client  *Client
+	ctx     context.Context
+	request types.MessageEnvelope
+	topic   string
+
+	mutex  sync.Mutex
+	seq    int
+	closed bool
+}
+
+// NewResponseStream 为请求创建流式响应
+// 响应主题取自请求的 QueryParams[HeaderStreamTopic]，缺失时按 RegisterRequestHandler 的规则使用 <响应主题前缀>/<RequestID>
+func (c *Client) NewResponseStream(ctx context.Context, request types.MessageEnvelope) (*ResponseStream, error) {
+	topic := request.QueryParams[HeaderStreamTopic]
+	if topic == "" {
+		var err error
+		if topic, err = c.responseTopic(request); err != nil {
+			return nil, err
+		}
+	}
+	return &ResponseStream{client: c, ctx: ctx, request: request, topic: topic}, nil
+}
+
+// Topic 返回分块发布的主题
+func (s *ResponseStream) Topic() string {
+	return s.topic
+}
+
+// Send 将 data 作为一个分块发布，data 的转换规则与 NewSuccessResponseEnvelope 相同；流结束后返回错误
+func (s *ResponseStream) Send(data interface{}) error {
+	return s.send(NewSuccessResponseEnvelope(s.request, data), false)
+}
+
+// Close 发送不含数据的结束标记并结束流，重复调用时直接返回 nil
+func (s *ResponseStream) Close() error {
+	return s.finish(NewSuccessResponseEnvelope(s.request, nil))
+}
+
+// CloseWithError 按 NewErrorResponseEnvelope 发送携带结束标记的错误响应并结束流，重复调用时直接返回 nil
+func (s *ResponseStream) CloseWithError(err error) error {
+	return s.finish(NewErrorResponseEnvelope(s.request, err))
+}
+
+// finish 发送最后一个分块，流已结束时忽略
+func (s *ResponseStream) finish(envelope types.MessageEnvelope) error {
+	s.mutex.Lock()
+	closed := s.closed
+	s.mutex.Unlock()
+	if closed {
+		return nil
+	}
+	return s.send(envelope, true)
+}
+
+// send 为分块编号后发布，last 为 true 时附加结束标记并结束流
+func (s *ResponseStream) send(envelope types.MessageEnvelope, last bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return fmt.Errorf("流式响应 %s 已结束", s.topic)
+	}
+	envelope.QueryParams[HeaderStreamSeq] = strconv.Itoa(s.seq)
+	if last {
+		envelope.QueryParams[HeaderStreamEnd] = "true"
+		s.closed = true
+	}
+	s.seq++
+	// 持锁发布以保证分块按序号顺序发出
+	if err := s.client.publishEnvelope(s.ctx, s.topic, envelope); err != nil {
+		return fmt.Errorf("发布流式响应到 %s 失败: %w", s.topic, err)
+	}
+	return nil
+}
+
+// RegisterStreamHandler 订阅请求主题，由 fn 通过 ResponseStream 逐块发送响应，可直接被 RequestStream 接收
+// fn 返回后流被自动结束，fn 已自行调用 Close 或 CloseWithError 时不再重复发送结束标记
+func (c *Client) RegisterStreamHandler(requestTopic string, fn StreamHandlerFunc) error {
+	if fn == nil {
+		return fmt.Errorf("流式请求处理函数不能为空")
+	}
+	handler := c.TracedHandler(func(ctx context.Context, topic string, request types.MessageEnvelope) error {
+		stream, err := c.NewResponseStream(ctx, request)
+		if err != nil {
+			return err
+		}
+		handleErr := fn(ctx, request, stream)
+		if handleErr != nil {
+			err = stream.CloseWithError(handleErr)
+		} else {
+			err = stream.Close()
+		}
+		if err != nil {
+			return err
+		}
+		return handleErr
+	})
+	return c.Subscribe([]string{requestTopic}, handler)
+}
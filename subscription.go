@@ -0,0 +1,322 @@
+package messagebus
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// SubscribeOptions 表示单次订阅的可选参数
+type SubscribeOptions struct {
+	// SampleEvery 每 N 条消息只处理 1 条，0 或 1 表示全部处理
+	SampleEvery int
+	// MaxRate 每个主题每秒最多处理的消息数，超出的消息被丢弃，0 表示不限制
+	MaxRate float64
+	// MaxMessageAge 消息从发送到处理的最长时间，超过的消息被丢弃，0 表示不限制
+	// 依据信封中的发送时间戳判断，未携带时间戳的消息直接放行
+	MaxMessageAge time.Duration
+	// ClockSkew 允许的收发双方时钟偏差，判断过期时会加到 MaxMessageAge 上
+	ClockSkew time.Duration
+	// Workers 每个主题并发执行处理函数的 goroutine 数，0 或 1 表示串行处理
+	Workers int
+	// BufferSize 每个主题接收通道的缓冲大小，默认 100
+	BufferSize int
+	// Overflow 缓冲区已满时的处理策略，默认 block
+	Overflow OverflowPolicy
+	// SpillStore Overflow 为 spill 时暂存溢出消息的存储（如 boltstore），每个订阅主题须使用独立的存储
+	SpillStore OutboxStore
+	// Ordered 多个 Worker 时是否按实际接收主题保持顺序，同一主题的消息总由同一 Worker 依次处理
+	Ordered bool
+	// OrderingKey 多个 Worker 时按该函数返回的键保持顺序，设置后忽略 Ordered，
+	// 同一键的消息依次处理，不同键的消息在 Worker 间并发处理
+	OrderingKey OrderingKeyFunc
+	// QoS 本次订阅使用的 QoS，nil 表示沿用 Config.QoS，可使用 QoSLevel 设置
+	QoS *int
+	// NoEcho 为 true 时丢弃本客户端（按 ClientID 识别）发布的消息
+	NoEcho bool
+	// QuarantineTopic 不符合 Config.Schemas 的消息转发到的隔离主题，转发时附带失败原因和原始主题
+	QuarantineTopic string
+	// Redelivery 处理函数返回错误时的重投策略，nil 表示不重投
+	Redelivery *RedeliveryPolicy
+	// Retry 处理函数返回错误时在当前 Worker 内立即重试的策略，在重投之前生效，nil 表示不重试
+	Retry *RetryPolicy
+	// PriorityLanes 为 true 时高优先级消息（见 PublishWithPriority）进入独立队列，总在普通消息之前处理；
+	// 高优先级队列大小与 BufferSize 相同，已满时阻塞而不按 Overflow 丢弃
+	PriorityLanes bool
+	// Group 共享订阅组，同组的多个客户端分摊消息而不是各自收到全部消息，仅支持 MQTT 与 NATS（见 SubscribeShared）
+	Group string
+}
+
+// defaultSubscriptionBuffer 是未指定 BufferSize 时接收通道的缓冲大小
+const defaultSubscriptionBuffer = 100
+
+// validate 校验订阅选项
+func (o SubscribeOptions) validate() error {
+	if o.SampleEvery < 0 {
+		return fmt.Errorf("SampleEvery 不能为负数")
+	}
+	if o.MaxRate < 0 {
+		return fmt.Errorf("MaxRate 不能为负数")
+	}
+	if o.MaxMessageAge < 0 || o.ClockSkew < 0 {
+		return fmt.Errorf("MaxMessageAge 和 ClockSkew 不能为负数")
+	}
+	if o.Workers < 0 || o.BufferSize < 0 {
+		return fmt.Errorf("Workers 和 BufferSize 不能为负数")
+	}
+	if o.QoS != nil && (*o.QoS < 0 || *o.QoS > 2) {
+		return fmt.Errorf("QoS 必须为 0、1 或 2，当前为 %d", *o.QoS)
+	}
+	if o.Redelivery != nil {
+		if err := o.Redelivery.validate(); err != nil {
+			return err
+		}
+	}
+	if o.Retry != nil {
+		if err := o.Retry.validate(); err != nil {
+			return err
+		}
+	}
+	switch o.Overflow {
+	case "", OverflowBlock, OverflowDropOldest, OverflowDropNewest:
+	case OverflowSpill:
+		if o.SpillStore == nil {
+			return fmt.Errorf("Overflow 为 %s 时必须设置 SpillStore", OverflowSpill)
+		}
+	default:
+		return fmt.Errorf("不支持的 Overflow %q", o.Overflow)
+	}
+	if strings.ContainsAny(o.Group, "/+#*> ") {
+		return fmt.Errorf("共享订阅组名称不能包含 /、通配符或空格: %s", o.Group)
+	}
+	if strings.ContainsAny(o.QuarantineTopic, "+#*>") {
+		return fmt.Errorf("QuarantineTopic 不能包含通配符: %s", o.QuarantineTopic)
+	}
+	return nil
+}
+
+// groupByClientVariant 按订阅使用的 QoS 和共享订阅组对订阅分组，同一组使用同一条底层连接
+func groupByClientVariant(subs []*subscription) [][]*subscription {
+	type variantKey struct {
+		qos   int
+		group string
+	}
+	index := make(map[variantKey]int)
+	var groups [][]*subscription
+	for _, sub := range subs {
+		key := variantKey{qos: -1, group: sub.opts.Group}
+		if sub.opts.QoS != nil {
+			key.qos = *sub.opts.QoS
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], sub)
+	}
+	return groups
+}
+
+// wireTopics 返回向底层客户端订阅或取消订阅时使用的主题列表（见 wireTopic）
+func (c *Client) wireTopics(subs []*subscription) []string {
+	topics := make([]string, len(subs))
+	for i, sub := range subs {
+		topics[i] = c.wireTopic(sub.topic)
+	}
+	return topics
+}
+
+// subscription 表示一个主题的订阅状态
+type subscription struct {
+	topic    string
+	messages chan types.MessageEnvelope
+	priority chan types.MessageEnvelope // 高优先级队列，未启用 PriorityLanes 时为 nil
+	ingress  chan types.MessageEnvelope // 底层客户端写入的通道，阻塞策略下与 messages 相同
+	// redeliveries 延迟到期待重投的消息，跳过采样等过滤后进入消息循环，未设置 Redelivery 时为 nil
+	redeliveries chan inboundMessage
+	handler      MessageHandler
+	opts         SubscribeOptions
+	done         chan struct{} // 取消订阅时关闭
+
+	mutex    sync.Mutex
+	received uint64    // 已接收消息数，用于按比例采样
+	tokens   float64   // 限速令牌
+	refill   time.Time // 上次补充令牌的时间
+}
+
+func newSubscription(topic string, handler MessageHandler, opts SubscribeOptions) *subscription {
+	buffer := opts.BufferSize
+	if buffer == 0 {
+		buffer = defaultSubscriptionBuffer
+	}
+	messages := make(chan types.MessageEnvelope, buffer)
+	ingress := messages
+	if opts.usesIngress() {
+		ingress = make(chan types.MessageEnvelope, ingressBuffer)
+	}
+	var priority chan types.MessageEnvelope
+	if opts.PriorityLanes {
+		priority = make(chan types.MessageEnvelope, buffer)
+	}
+	var redeliveries chan inboundMessage
+	if opts.Redelivery != nil {
+		redeliveries = make(chan inboundMessage, buffer)
+	}
+	return &subscription{
+		topic:        topic,
+		messages:     messages,
+		priority:     priority,
+		ingress:      ingress,
+		redeliveries: redeliveries,
+		handler:      handler,
+		opts:         opts,
+		done:         make(chan struct{}),
+		tokens:       opts.MaxRate,
+		refill:       time.Now(),
+	}
+}
+
+// sample 判断当前消息是否通过采样与限速，返回 false 表示应丢弃
+func (s *subscription) sample() bool {
+	if s.opts.SampleEvery <= 1 && s.opts.MaxRate <= 0 {
+		return true
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.opts.SampleEvery > 1 {
+		n := s.received
+		s.received++
+		if n%uint64(s.opts.SampleEvery) != 0 {
+			return false
+		}
+	}
+	if s.opts.MaxRate > 0 {
+		now := time.Now()
+		burst := s.opts.MaxRate
+		if burst < 1 {
+			burst = 1
+		}
+		s.tokens += now.Sub(s.refill).Seconds() * s.opts.MaxRate
+		if s.tokens > burst {
+			s.tokens = burst
+		}
+		s.refill = now
+		if s.tokens < 1 {
+			return false
+		}
+		s.tokens--
+	}
+	return true
+}
+
+// expired 判断消息是否超过 MaxMessageAge
+func (s *subscription) expired(msg types.MessageEnvelope, now time.Time) bool {
+	if s.opts.MaxMessageAge <= 0 {
+		return false
+	}
+	sentAt, ok := messageSentAt(msg)
+	if !ok {
+		return false
+	}
+	return now.Sub(sentAt) > s.opts.MaxMessageAge+s.opts.ClockSkew
+}
+
+// OrderingKeyFunc 返回消息的顺序键，message 为解码（解密、解压）之前的原始信封
+type OrderingKeyFunc func(topic string, message types.MessageEnvelope) string
+
+// OrderByTopicLevel 返回使用实际接收主题第 index 层（从 0 开始）作为顺序键的 OrderingKeyFunc，
+// 例如对 edgex/events/device/{service}/{profile}/{device}/{source} 使用 5 可按设备保持顺序；
+// 主题层级不足时使用完整主题
+func OrderByTopicLevel(index int) OrderingKeyFunc {
+	return func(topic string, _ types.MessageEnvelope) string {
+		levels := strings.Split(topic, "/")
+		if index < 0 || index >= len(levels) {
+			return topic
+		}
+		return levels[index]
+	}
+}
+
+// inboundMessage 是进入消息循环的消息及其 Payload 字节数，字节数在收到时只估算一次，
+// 供在途预算、接收统计共用，避免对已解码的 Payload 反复编码
+type inboundMessage struct {
+	envelope types.MessageEnvelope
+	size     int64
+}
+
+// workerPool 在多个 goroutine 中执行同一订阅的处理函数
+// 有序模式下每个 Worker 拥有独立队列，消息按顺序键（默认为实际主题）哈希分配；无序模式下所有 Worker 共享一个队列
+type workerPool struct {
+	queues []chan inboundMessage
+	key    OrderingKeyFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// newWorkerPool 按订阅选项启动 Worker，Workers <= 1 时返回 nil 表示在读取 goroutine 中直接处理
+func newWorkerPool(sub *subscription, handle func(inboundMessage)) *workerPool {
+	workers := sub.opts.Workers
+	if workers <= 1 {
+		return nil
+	}
+	queueCount := 1
+	key := sub.opts.OrderingKey
+	if sub.opts.Ordered || key != nil {
+		queueCount = workers
+	}
+	if key == nil {
+		key = func(topic string, _ types.MessageEnvelope) string { return topic }
+	}
+	p := &workerPool{queues: make([]chan inboundMessage, queueCount), key: key}
+	for i := range p.queues {
+		p.queues[i] = make(chan inboundMessage, workers)
+	}
+	for i := 0; i < workers; i++ {
+		queue := p.queues[i%queueCount]
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for msg := range queue {
+				handle(msg)
+			}
+		}()
+	}
+	return p
+}
+
+// submit 将消息交给 Worker，队列已满时阻塞，stop 或 done 关闭时放弃并返回 false
+func (p *workerPool) submit(msg inboundMessage, stop, done <-chan struct{}) bool {
+	queue := p.queues[0]
+	if len(p.queues) > 1 {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(p.key(msg.envelope.ReceivedTopic, msg.envelope)))
+		queue = p.queues[h.Sum32()%uint32(len(p.queues))]
+	}
+	select {
+	case queue <- msg:
+		return true
+	case <-stop:
+		return false
+	case <-done:
+		return false
+	}
+}
+
+// close 关闭所有队列并等待 Worker 处理完已排队的消息，可重复调用
+func (p *workerPool) close() {
+	if p == nil {
+		return
+	}
+	p.once.Do(func() {
+		for _, queue := range p.queues {
+			close(queue)
+		}
+	})
+	p.wg.Wait()
+}
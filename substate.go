@@ -0,0 +1,257 @@
+package messagebus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SubscriptionRestoreFunc 在恢复订阅时为持久化的主题返回处理函数，返回 nil 表示不再订阅该主题
+// opts 为持久化的订阅选项，可在其中补充无法持久化的字段（SpillStore、OrderingKey、Retry.Retryable）
+type SubscriptionRestoreFunc func(topic string, opts *SubscribeOptions) MessageHandler
+
+// SubscriptionStateConfig 表示订阅状态持久化参数
+type SubscriptionStateConfig struct {
+	// File 保存订阅主题及选项的 JSON 文件，每次订阅或取消订阅后更新，为空时不持久化
+	File string
+	// Restore 连接时为文件中的主题提供处理函数并自动恢复订阅，为空时只保存不恢复
+	Restore SubscriptionRestoreFunc
+}
+
+// subscriptionRecord 是持久化文件中的一条订阅记录，只包含可序列化的订阅选项
+type subscriptionRecord struct {
+	Topic           string            `json:"topic"`
+	QoS             *int              `json:"qos,omitempty"`
+	SampleEvery     int               `json:"sampleEvery,omitempty"`
+	MaxRate         float64           `json:"maxRate,omitempty"`
+	MaxMessageAge   time.Duration     `json:"maxMessageAge,omitempty"`
+	ClockSkew       time.Duration     `json:"clockSkew,omitempty"`
+	Workers         int               `json:"workers,omitempty"`
+	BufferSize      int               `json:"bufferSize,omitempty"`
+	Overflow        OverflowPolicy    `json:"overflow,omitempty"`
+	Ordered         bool              `json:"ordered,omitempty"`
+	NoEcho          bool              `json:"noEcho,omitempty"`
+	QuarantineTopic string            `json:"quarantineTopic,omitempty"`
+	Redelivery      *redeliveryRecord `json:"redelivery,omitempty"`
+	Retry           *retryRecord      `json:"retry,omitempty"`
+	Group           string            `json:"group,omitempty"`
+	PriorityLanes   bool              `json:"priorityLanes,omitempty"`
+}
+
+// redeliveryRecord 是 RedeliveryPolicy 的持久化形式
+type redeliveryRecord struct {
+	MaxAttempts     int           `json:"maxAttempts,omitempty"`
+	Delay           time.Duration `json:"delay,omitempty"`
+	DeadLetterTopic string        `json:"deadLetterTopic,omitempty"`
+	AckTimeout      time.Duration `json:"ackTimeout,omitempty"`
+}
+
+// retryRecord 是 RetryPolicy 的持久化形式，Retryable 无法持久化
+type retryRecord struct {
+	MaxAttempts     int           `json:"maxAttempts,omitempty"`
+	InitialBackoff  time.Duration `json:"initialBackoff,omitempty"`
+	MaxBackoff      time.Duration `json:"maxBackoff,omitempty"`
+	Jitter          float64       `json:"jitter,omitempty"`
+	DeadLetterTopic string        `json:"deadLetterTopic,omitempty"`
+}
+
+// subscriptionStateFile 是持久化文件的结构
+type subscriptionStateFile struct {
+	Subscriptions []subscriptionRecord `json:"subscriptions"`
+}
+
+// subscriptionState 保存订阅状态文件的写入锁和尚未恢复的记录
+type subscriptionState struct {
+	mutex   sync.Mutex
+	pending map[string]subscriptionRecord // 从文件加载但尚未恢复成功的记录，保存时一并写回
+}
+
+// newSubscriptionRecord 将订阅选项转换为持久化记录
+func newSubscriptionRecord(topic string, opts SubscribeOptions) subscriptionRecord {
+	record := subscriptionRecord{
+		Topic:           topic,
+		QoS:             opts.QoS,
+		SampleEvery:     opts.SampleEvery,
+		MaxRate:         opts.MaxRate,
+		MaxMessageAge:   opts.MaxMessageAge,
+		ClockSkew:       opts.ClockSkew,
+		Workers:         opts.Workers,
+		BufferSize:      opts.BufferSize,
+		Overflow:        opts.Overflow,
+		Ordered:         opts.Ordered,
+		NoEcho:          opts.NoEcho,
+		QuarantineTopic: opts.QuarantineTopic,
+		Group:           opts.Group,
+		PriorityLanes:   opts.PriorityLanes,
+	}
+	if p := opts.Redelivery; p != nil {
+		record.Redelivery = &redeliveryRecord{MaxAttempts: p.MaxAttempts, Delay: p.Delay, DeadLetterTopic: p.DeadLetterTopic, AckTimeout: p.AckTimeout}
+	}
+	if p := opts.Retry; p != nil {
+		record.Retry = &retryRecord{MaxAttempts: p.MaxAttempts, InitialBackoff: p.InitialBackoff, MaxBackoff: p.MaxBackoff, Jitter: p.Jitter, DeadLetterTopic: p.DeadLetterTopic}
+	}
+	return record
+}
+
+// options 将持久化记录还原为订阅选项
+func (r subscriptionRecord) options() SubscribeOptions {
+	opts := SubscribeOptions{
+		QoS:             r.QoS,
+		SampleEvery:     r.SampleEvery,
+		MaxRate:         r.MaxRate,
+		MaxMessageAge:   r.MaxMessageAge,
+		ClockSkew:       r.ClockSkew,
+		Workers:         r.Workers,
+		BufferSize:      r.BufferSize,
+		Overflow:        r.Overflow,
+		Ordered:         r.Ordered,
+		NoEcho:          r.NoEcho,
+		QuarantineTopic: r.QuarantineTopic,
+		Group:           r.Group,
+		PriorityLanes:   r.PriorityLanes,
+	}
+	if p := r.Redelivery; p != nil {
+		opts.Redelivery = &RedeliveryPolicy{MaxAttempts: p.MaxAttempts, Delay: p.Delay, DeadLetterTopic: p.DeadLetterTopic, AckTimeout: p.AckTimeout}
+	}
+	if p := r.Retry; p != nil {
+		opts.Retry = &RetryPolicy{MaxAttempts: p.MaxAttempts, InitialBackoff: p.InitialBackoff, MaxBackoff: p.MaxBackoff, Jitter: p.Jitter, DeadLetterTopic: p.DeadLetterTopic}
+	}
+	return opts
+}
+
+// loadSubscriptionState 读取订阅状态文件，文件不存在时返回空列表
+func loadSubscriptionState(path string) ([]subscriptionRecord, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取订阅状态文件失败: %w", err)
+	}
+	var state subscriptionStateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析订阅状态文件 %s 失败: %w", path, err)
+	}
+	return state.Subscriptions, nil
+}
+
+// writeSubscriptionState 写入订阅状态文件
+func writeSubscriptionState(path string, records []subscriptionRecord) error {
+	data, err := json.MarshalIndent(subscriptionStateFile{Subscriptions: records}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("写入订阅状态文件失败: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic 先写入临时文件再重命名，避免进程崩溃时留下不完整的文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// saveSubscriptionState 将当前订阅和尚未恢复的记录写入订阅状态文件，未配置文件时不做任何事
+func (c *Client) saveSubscriptionState() {
+	path := c.config.SubscriptionState.File
+	if path == "" {
+		return
+	}
+	c.subState.mutex.Lock()
+	defer c.subState.mutex.Unlock()
+	c.mutex.RLock()
+	records := make([]subscriptionRecord, 0, len(c.subscriptions)+len(c.subState.pending))
+	for topic, sub := range c.subscriptions {
+		records = append(records, newSubscriptionRecord(topic, sub.opts))
+	}
+	for topic, record := range c.subState.pending {
+		if _, ok := c.subscriptions[topic]; !ok {
+			records = append(records, record)
+		}
+	}
+	c.mutex.RUnlock()
+	sort.Slice(records, func(i, j int) bool { return records[i].Topic < records[j].Topic })
+	if err := writeSubscriptionState(path, records); err != nil {
+		c.log(LogSubscribe).Error("保存订阅状态失败", c.logFields("file", path, "error", err)...)
+	}
+}
+
+// forgetPendingSubscriptions 丢弃尚未恢复的同名记录，返回是否有记录被丢弃
+func (c *Client) forgetPendingSubscriptions(topics []string) bool {
+	c.subState.mutex.Lock()
+	defer c.subState.mutex.Unlock()
+	forgot := false
+	for _, topic := range topics {
+		if _, ok := c.subState.pending[topic]; ok {
+			delete(c.subState.pending, topic)
+			forgot = true
+		}
+	}
+	return forgot
+}
+
+// restoreSubscriptions 连接后按订阅状态文件恢复订阅
+// Restore 返回 nil 的主题从文件中移除；订阅失败的主题保留在文件中，下次连接时再次尝试
+func (c *Client) restoreSubscriptions() {
+	state := c.config.SubscriptionState
+	if state.File == "" || state.Restore == nil {
+		return
+	}
+	records, err := loadSubscriptionState(state.File)
+	if err != nil {
+		c.log(LogSubscribe).Error("恢复订阅失败", c.logFields("file", state.File, "error", err)...)
+		return
+	}
+	c.subState.mutex.Lock()
+	c.subState.pending = make(map[string]subscriptionRecord, len(records))
+	for _, record := range records {
+		c.subState.pending[record.Topic] = record
+	}
+	c.subState.mutex.Unlock()
+
+	var restored []string
+	for _, record := range records {
+		opts := record.options()
+		handler := state.Restore(record.Topic, &opts)
+		if handler != nil {
+			// 共享订阅以 $share/<group>/<topic> 为键记录，按 Group 重新订阅时使用其中的 <topic>
+			topic := record.Topic
+			if _, filter, ok := splitSharedTopic(topic); ok && opts.Group != "" {
+				topic = filter
+			}
+			if err := c.SubscribeWithOptions([]string{topic}, handler, opts); err != nil {
+				c.log(LogSubscribe).Error("恢复订阅失败", c.logFields("topic", record.Topic, "error", err)...)
+				continue
+			}
+			restored = append(restored, record.Topic)
+		}
+		c.forgetPendingSubscriptions([]string{record.Topic})
+	}
+	c.saveSubscriptionState()
+	if len(restored) > 0 {
+		c.log(LogSubscribe).Info("已从订阅状态文件恢复订阅", c.logFields("file", state.File, "topics", restored)...)
+		_ = c.ResumeSession()
+	}
+}
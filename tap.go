@@ -0,0 +1,78 @@
+package messagebus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// tapBufferSize 是 Tap 观察通道的缓冲大小
+const tapBufferSize = 256
+
+// TapDirection 表示被镜像消息的方向
+type TapDirection string
+
+const (
+	TapInbound  TapDirection = "inbound"  // 订阅收到、交给处理函数前的消息（已解码）
+	TapOutbound TapDirection = "outbound" // 成功发布到 Broker 的消息（含离线转发）
+)
+
+// TapEvent 表示一条被镜像的消息
+type TapEvent struct {
+	Direction TapDirection
+	Topic     string                // 实际收发主题
+	Message   types.MessageEnvelope // 消息副本，QueryParams 与原消息共享，不应修改
+	Time      time.Time
+}
+
+// tapObserver 表示一个 Tap 观察者
+type tapObserver struct {
+	pattern string
+	events  chan TapEvent
+}
+
+// Tap 将匹配 pattern（支持 + / # 与 * / > 通配符，为空时匹配所有主题）的收发消息镜像到返回的通道，
+// 用于在线调试和流量检查，不影响正常的订阅处理
+//
+// 通道缓冲已满时新事件被丢弃并计入 Stats().DroppedTapEvents，不会阻塞收发；
+// 调用 stop 后停止镜像并关闭通道，stop 可重复调用。
+func (c *Client) Tap(pattern string) (<-chan TapEvent, func()) {
+	if pattern == "" {
+		pattern = "#"
+	}
+	observer := &tapObserver{pattern: pattern, events: make(chan TapEvent, tapBufferSize)}
+	c.tapsMu.Lock()
+	c.taps[observer] = struct{}{}
+	c.tapsMu.Unlock()
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			c.tapsMu.Lock()
+			delete(c.taps, observer)
+			c.tapsMu.Unlock()
+			close(observer.events)
+		})
+	}
+	return observer.events, stop
+}
+
+// tap 以非阻塞方式将消息镜像给匹配的观察者
+func (c *Client) tap(direction TapDirection, topic string, envelope types.MessageEnvelope) {
+	c.tapsMu.RLock()
+	defer c.tapsMu.RUnlock()
+	if len(c.taps) == 0 {
+		return
+	}
+	event := TapEvent{Direction: direction, Topic: topic, Message: envelope, Time: time.Now()}
+	for observer := range c.taps {
+		if !TopicMatches(observer.pattern, topic) {
+			continue
+		}
+		select {
+		case observer.events <- event:
+		default:
+			c.stats.droppedTap.Add(1)
+		}
+	}
+}
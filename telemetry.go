@@ -0,0 +1,148 @@
+package messagebus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+// defaultTelemetryInterval 是未配置 Interval 时发布服务指标的间隔
+const defaultTelemetryInterval = 30 * time.Second
+
+// EdgeX 服务指标沿用 go-metrics 的字段名：计数器为 count，仪表为 value
+const (
+	metricFieldCount = "count"
+	metricFieldValue = "value"
+)
+
+// ServiceMetricsOptions 表示服务指标发布器的可选参数
+type ServiceMetricsOptions struct {
+	// Interval 发布间隔，默认 30 秒
+	Interval time.Duration
+	// Tags 附加到每个指标的标签，与 Config.Tags 合并，同名时以此处为准
+	Tags map[string]string
+}
+
+// ServiceMetrics 周期性地将客户端统计以 EdgeX Metric DTO 发布到
+// <BaseTopicPrefix>/telemetry/<ServiceName>/<指标名>，使其进入 EdgeX 标准遥测管道
+// 客户端断开连接时发布器随之停止，重新连接后需创建新的发布器
+type ServiceMetrics struct {
+	client    *Client
+	interval  time.Duration
+	tags      []dtos.MetricTag
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewServiceMetrics 创建服务指标发布器并启动定时发布
+func (c *Client) NewServiceMetrics(opts ServiceMetricsOptions) *ServiceMetrics {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultTelemetryInterval
+	}
+	merged := make(map[string]string, len(c.config.Tags)+len(opts.Tags))
+	for k, v := range c.config.Tags {
+		merged[k] = v
+	}
+	for k, v := range opts.Tags {
+		merged[k] = v
+	}
+	tags := make([]dtos.MetricTag, 0, len(merged))
+	for k, v := range merged {
+		tags = append(tags, dtos.MetricTag{Name: k, Value: v})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+	m := &ServiceMetrics{
+		client:   c,
+		interval: opts.Interval,
+		tags:     tags,
+		done:     make(chan struct{}),
+	}
+	c.lifecycle.spawn(stagePublish, m.run)
+	return m
+}
+
+// Publish 立即发布一次所有指标，返回所有失败指标的合并错误
+func (m *ServiceMetrics) Publish() error {
+	c := m.client
+	stats := c.Stats()
+	gauges := map[string]interface{}{
+		"MessageBusInFlightBytes": stats.InFlightBytes,
+	}
+	if c.outboxEnabled() {
+		if n, err := c.config.Outbox.Store.Len(); err == nil {
+			gauges["MessageBusOutboxPending"] = n
+		}
+	}
+	counters := map[string]interface{}{
+		"MessageBusMessagesPublished": stats.MessagesPublished,
+		"MessageBusPublishErrors":     stats.PublishErrors,
+		"MessageBusMessagesReceived":  stats.MessagesReceived,
+		"MessageBusHandlerErrors":     stats.HandlerErrors,
+		"MessageBusReconnects":        stats.Reconnects,
+	}
+
+	var errs []error
+	publish := func(values map[string]interface{}, field string) {
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := m.publishMetric(name, field, values[name]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	publish(counters, metricFieldCount)
+	publish(gauges, metricFieldValue)
+	return errors.Join(errs...)
+}
+
+// publishMetric 构造并发布单个指标，Payload 为 JSON 编码的 Metric DTO
+func (m *ServiceMetrics) publishMetric(name, field string, value interface{}) error {
+	metric, err := dtos.NewMetric(name, []dtos.MetricField{{Name: field, Value: value}}, m.tags)
+	if err != nil {
+		return fmt.Errorf("构造指标 %s 失败: %w", name, err)
+	}
+	payload, err := json.Marshal(metric)
+	if err != nil {
+		return fmt.Errorf("编码指标 %s 失败: %w", name, err)
+	}
+	topic := common.BuildTopic(m.client.baseTopic(), common.MetricsPublishTopic, m.client.serviceName(), name)
+	if err := m.client.publish(context.Background(), topic, payload, common.ContentTypeJSON); err != nil {
+		return fmt.Errorf("发布指标 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+// Close 停止定时发布
+func (m *ServiceMetrics) Close() {
+	m.closeOnce.Do(func() { close(m.done) })
+}
+
+// run 定时发布指标，客户端停止发布阶段或 Close 时退出
+func (m *ServiceMetrics) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.Publish(); err != nil {
+				m.client.log(LogPublish).Warn("发布服务指标失败", m.client.logFields("error", err)...)
+			}
+		case <-stop:
+			return
+		case <-m.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package messagebus
+
+import (
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// HeaderSentAt 是信封 QueryParams 中记录发送时间（RFC3339Nano）的键
+const HeaderSentAt = "x-sent-at"
+
+// stampSentAt 在信封中记录发送时间
+func stampSentAt(envelope *types.MessageEnvelope, now time.Time) {
+	if envelope.QueryParams == nil {
+		envelope.QueryParams = make(map[string]string)
+	}
+	envelope.QueryParams[HeaderSentAt] = now.UTC().Format(time.RFC3339Nano)
+}
+
+// messageSentAt 读取信封中的发送时间
+func messageSentAt(envelope types.MessageEnvelope) (time.Time, bool) {
+	value, ok := envelope.QueryParams[HeaderSentAt]
+	if !ok {
+		return time.Time{}, false
+	}
+	sentAt, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return sentAt, true
+}
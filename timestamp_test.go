@@ -0,0 +1,80 @@
+package messagebus_test
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+func TestMaxMessageAgeDropsStaleMessages(t *testing.T) {
+	broker := messagebustest.NewBroker()
+	client, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	var mutex sync.Mutex
+	var handled []string
+	err = client.SubscribeWithOptions([]string{"test/age"}, func(_ string, msg types.MessageEnvelope) error {
+		mutex.Lock()
+		handled = append(handled, msg.CorrelationID)
+		mutex.Unlock()
+		return nil
+	}, messagebus.SubscribeOptions{MaxMessageAge: time.Second, ClockSkew: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 客户端发布时总会写入当前时间，直接使用底层客户端发布带指定发送时间的信封
+	raw, err := broker.MessageClientFactory("raw")(types.MessageBusConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := raw.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	sentAt := map[string]string{
+		"fresh":  now.UTC().Format(time.RFC3339Nano),
+		"skewed": now.Add(-1100 * time.Millisecond).UTC().Format(time.RFC3339Nano),
+		"stale":  now.Add(-5 * time.Second).UTC().Format(time.RFC3339Nano),
+		"none":   "",
+	}
+	for id, value := range sentAt {
+		envelope := types.MessageEnvelope{CorrelationID: id, Payload: []byte(id), ContentType: "text/plain", QueryParams: map[string]string{}}
+		if value != "" {
+			envelope.QueryParams[messagebus.HeaderSentAt] = value
+		}
+		if err := raw.Publish(envelope, "test/age"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		mutex.Lock()
+		n := len(handled)
+		mutex.Unlock()
+		if n >= 3 && client.Stats().DroppedStale["test/age"] >= 1 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mutex.Lock()
+	got := append([]string(nil), handled...)
+	mutex.Unlock()
+	sort.Strings(got)
+	want := []string{"fresh", "none", "skewed"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Fatalf("处理的消息 %v，期望 %v", got, want)
+	}
+	if dropped := client.Stats().DroppedStale["test/age"]; dropped != 1 {
+		t.Fatalf("DroppedStale = %d，期望 1", dropped)
+	}
+}
@@ -0,0 +1,145 @@
+package messagebus
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// tlsProtocols 是底层客户端会启用 TLS 的协议
+var tlsProtocols = []string{"ssl", "tls", "tcps", "amqps"}
+
+// hasTLS 判断配置中是否设置了 TLS 参数
+func (c Config) hasTLS() bool {
+	return c.CertFile != "" || c.KeyFile != "" || c.CAFile != "" || c.SkipCertVerify || c.TLSConfig != nil
+}
+
+// tlsOptions 校验 TLS 配置并转换为 go-mod-messaging 的 Optional 参数
+func tlsOptions(config Config) (map[string]string, error) {
+	if !config.hasTLS() {
+		return nil, nil
+	}
+	if !isTLSProtocol(config.Protocol) && !strings.EqualFold(config.Protocol, "wss") {
+		return nil, fmt.Errorf("TLS 参数仅在协议为 %s/wss 时生效，当前协议: %s", strings.Join(tlsProtocols, "/"), config.Protocol)
+	}
+	if (config.CertFile == "") != (config.KeyFile == "") {
+		return nil, fmt.Errorf("CertFile 和 KeyFile 必须同时设置")
+	}
+	for name, path := range map[string]string{"CertFile": config.CertFile, "KeyFile": config.KeyFile, "CAFile": config.CAFile} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("无法读取 %s: %w", name, err)
+		}
+	}
+
+	options := map[string]string{}
+	if config.CertFile != "" {
+		options["CertFile"] = config.CertFile
+		options["KeyFile"] = config.KeyFile
+	}
+	if config.CAFile != "" {
+		options["CaFile"] = config.CAFile
+	}
+	skipVerify := config.SkipCertVerify
+	if config.TLSConfig != nil {
+		if config.TLSConfig.RootCAs != nil {
+			return nil, fmt.Errorf("TLSConfig.RootCAs 无法传递给底层客户端，请使用 CAFile")
+		}
+		if len(config.TLSConfig.Certificates) > 1 {
+			return nil, fmt.Errorf("TLSConfig 仅支持一个客户端证书")
+		}
+		if len(config.TLSConfig.Certificates) == 1 {
+			if config.CertFile != "" {
+				return nil, fmt.Errorf("CertFile/KeyFile 与 TLSConfig.Certificates 不能同时设置")
+			}
+			certPEM, keyPEM, err := encodeCertificate(config.TLSConfig.Certificates[0])
+			if err != nil {
+				return nil, err
+			}
+			options["CertPEMBlock"] = certPEM
+			options["KeyPEMBlock"] = keyPEM
+		}
+		skipVerify = skipVerify || config.TLSConfig.InsecureSkipVerify
+	}
+	if skipVerify {
+		options["SkipCertVerify"] = strconv.FormatBool(true)
+	}
+	return options, nil
+}
+
+// isTLSProtocol 判断协议是否启用 TLS
+func isTLSProtocol(protocol string) bool {
+	for _, p := range tlsProtocols {
+		if strings.EqualFold(protocol, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeCertificate 将 tls.Certificate 编码为 PEM 格式的证书链和私钥
+func encodeCertificate(cert tls.Certificate) (string, string, error) {
+	if len(cert.Certificate) == 0 || cert.PrivateKey == nil {
+		return "", "", fmt.Errorf("TLSConfig 中的客户端证书不完整")
+	}
+	var certPEM strings.Builder
+	for _, der := range cert.Certificate {
+		if err := pem.Encode(&certPEM, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+			return "", "", fmt.Errorf("编码客户端证书失败: %w", err)
+		}
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		return "", "", fmt.Errorf("编码客户端私钥失败: %w", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM.String(), string(keyPEM), nil
+}
+
+// TLSConfigFromOptions 根据 go-mod-messaging 的 Optional 参数构造 TLS 配置，
+// 供遗嘱连接以及 kafka、amqp 等自行建立连接的后端使用。
+// CredentialsProvider 提供的 CertPEMBlock/CaPEMBlock 优先于 CertFile/CaFile，最低 TLS 版本为 1.2
+func TLSConfigFromOptions(optional map[string]string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if skip, err := strconv.ParseBool(optional["SkipCertVerify"]); err == nil {
+		tlsConfig.InsecureSkipVerify = skip
+	}
+	certPEM, keyPEM := []byte(optional["CertPEMBlock"]), []byte(optional["KeyPEMBlock"])
+	if len(certPEM) == 0 && optional["CertFile"] != "" {
+		var err error
+		if certPEM, err = os.ReadFile(optional["CertFile"]); err != nil {
+			return nil, fmt.Errorf("读取 CertFile 失败: %w", err)
+		}
+		if keyPEM, err = os.ReadFile(optional["KeyFile"]); err != nil {
+			return nil, fmt.Errorf("读取 KeyFile 失败: %w", err)
+		}
+	}
+	if len(certPEM) > 0 {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("解析客户端证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	caPEM := []byte(optional["CaPEMBlock"])
+	if len(caPEM) == 0 && optional["CaFile"] != "" {
+		var err error
+		if caPEM, err = os.ReadFile(optional["CaFile"]); err != nil {
+			return nil, fmt.Errorf("读取 CaFile 失败: %w", err)
+		}
+	}
+	if len(caPEM) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("解析 CA 证书失败")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
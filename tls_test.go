@@ -0,0 +1,109 @@
+package messagebus_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+)
+
+// selfSignedPEM 生成自签名证书和私钥的 PEM 编码
+func selfSignedPEM(t *testing.T, commonName string) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestTLSConfigFromOptionsPEMBlocksTakePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	fileCert, fileKey := selfSignedPEM(t, "file")
+	for name, data := range map[string][]byte{"cert.pem": fileCert, "key.pem": fileKey} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	blockCert, blockKey := selfSignedPEM(t, "block")
+
+	config, err := messagebus.TLSConfigFromOptions(map[string]string{
+		"CertFile":     filepath.Join(dir, "cert.pem"),
+		"KeyFile":      filepath.Join(dir, "key.pem"),
+		"CaFile":       filepath.Join(dir, "cert.pem"),
+		"CertPEMBlock": string(blockCert),
+		"KeyPEMBlock":  string(blockKey),
+		"CaPEMBlock":   string(blockCert),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MinVersion != tls.VersionTLS12 {
+		t.Errorf("MinVersion = %x，期望 TLS 1.2", config.MinVersion)
+	}
+	if len(config.Certificates) != 1 {
+		t.Fatalf("Certificates 数量 = %d，期望 1", len(config.Certificates))
+	}
+	leaf, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaf.Subject.CommonName != "block" {
+		t.Errorf("客户端证书来自 %s，期望 CertPEMBlock", leaf.Subject.CommonName)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{Roots: config.RootCAs}); err != nil {
+		t.Errorf("RootCAs 应来自 CaPEMBlock: %v", err)
+	}
+}
+
+func TestTLSConfigFromOptionsFiles(t *testing.T) {
+	dir := t.TempDir()
+	cert, key := selfSignedPEM(t, "file")
+	for name, data := range map[string][]byte{"cert.pem": cert, "key.pem": key} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config, err := messagebus.TLSConfigFromOptions(map[string]string{
+		"CertFile":       filepath.Join(dir, "cert.pem"),
+		"KeyFile":        filepath.Join(dir, "key.pem"),
+		"CaFile":         filepath.Join(dir, "cert.pem"),
+		"SkipCertVerify": "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Certificates) != 1 || config.RootCAs == nil || !config.InsecureSkipVerify {
+		t.Errorf("未按 CertFile/CaFile/SkipCertVerify 构造 TLS 配置: %+v", config)
+	}
+	if config.MinVersion != tls.VersionTLS12 {
+		t.Errorf("MinVersion = %x，期望 TLS 1.2", config.MinVersion)
+	}
+}
@@ -0,0 +1,58 @@
+package messagebus
+
+import "strings"
+
+// TopicMatches 判断主题是否匹配订阅模式，与客户端内部分发消息使用相同的规则：
+// 支持 MQTT 的 + / # 与 NATS 的 * / > 通配符，多层通配符只能位于末尾：# 同样匹配其父层级（a/# 匹配 a），
+// > 至少匹配一层（a/> 不匹配 a）；与 MQTT 一致，首层的 # 和 + 不匹配以 $ 开头的主题（如 $SYS/...）；
+// MQTT 5 共享订阅模式 $share/<group>/<filter> 按其中的 filter 匹配
+func TopicMatches(pattern, topic string) bool {
+	pattern = sharedFilter(pattern)
+	if pattern == topic {
+		return true
+	}
+	patternLevels := strings.Split(pattern, "/")
+	topicLevels := strings.Split(topic, "/")
+	if reservedTopic(patternLevels[0], topic) {
+		return false
+	}
+	for i, level := range patternLevels {
+		switch level {
+		case "#":
+			return i == len(patternLevels)-1
+		case ">":
+			return i == len(patternLevels)-1 && i < len(topicLevels)
+		case "+", "*":
+			if i >= len(topicLevels) {
+				return false
+			}
+		default:
+			if i >= len(topicLevels) || level != topicLevels[i] {
+				return false
+			}
+		}
+	}
+	return len(patternLevels) == len(topicLevels)
+}
+
+// TopicParams 按带命名参数的模式匹配主题并提取参数，模式语法与 Router 相同：
+// 例如 edgex/events/device/{service}/{profile}/{device}/# 匹配设备事件主题时返回 service、profile、device 三个参数。
+// 不匹配或模式格式错误时返回 false；模式中没有命名参数时匹配成功返回 nil
+func TopicParams(pattern, topic string) (RouteParams, bool) {
+	levels, err := parseRoutePattern(sharedFilter(pattern))
+	if err != nil {
+		return nil, false
+	}
+	return matchRoute(levels, strings.Split(topic, "/"))
+}
+
+// reservedTopic 判断首层为 MQTT 通配符的模式是否因主题以 $ 开头而不匹配
+func reservedTopic(first, topic string) bool {
+	return (first == "#" || first == "+") && strings.HasPrefix(topic, "$")
+}
+
+// sharedFilter 去掉 MQTT 5 共享订阅的 $share/<group>/ 前缀，格式不完整时原样返回
+func sharedFilter(pattern string) string {
+	_, filter, _ := splitSharedTopic(pattern)
+	return filter
+}
@@ -0,0 +1,54 @@
+package messagebus_test
+
+import (
+	"testing"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+)
+
+func TestTopicMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		topic   string
+		want    bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/b", "a/c", false},
+		{"a/+", "a/b", true},
+		{"a/+", "a/b/c", false},
+		{"a/+", "a", false},
+		{"a/*", "a/b", true},
+		{"a/#", "a/b/c", true},
+		{"a/#", "a", true},
+		{"a/>", "a/b/c", true},
+		{"a/>", "a/b", true},
+		{"a/>", "a", false},
+		{"#", "a/b", true},
+		{"#", "$SYS/broker", false},
+		{"+/broker", "$SYS/broker", false},
+		{"+/+", "$SYS/broker", false},
+		{"$SYS/#", "$SYS/broker/load", true},
+		{"$SYS/+", "$SYS/broker", true},
+		{"a/+/#", "a/$x/y", true},
+		{"$share/g/a/+", "a/b", true},
+		{"$share/g/#", "$SYS/broker", false},
+	}
+	for _, tt := range tests {
+		if got := messagebus.TopicMatches(tt.pattern, tt.topic); got != tt.want {
+			t.Errorf("TopicMatches(%q, %q) = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+		if _, got := messagebus.TopicParams(tt.pattern, tt.topic); got != tt.want {
+			t.Errorf("TopicParams(%q, %q) matched = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestTopicParams(t *testing.T) {
+	params, ok := messagebus.TopicParams("edgex/events/{service}/>", "edgex/events/core-data/device01")
+	if !ok || params["service"] != "core-data" {
+		t.Fatalf("TopicParams = %v, %v", params, ok)
+	}
+	if _, ok := messagebus.TopicParams("edgex/events/{service}/>", "edgex/events/core-data"); ok {
+		t.Fatal("> 不应匹配父层级")
+	}
+}
@@ -0,0 +1,361 @@
+package messagebus
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// TopicRewriter 在应用使用的主题与 Broker 上的实际主题之间双向转换
+// 发布、订阅和请求时调用 ToWire，收到消息时调用 FromWire 还原 ReceivedTopic；不适用的主题原样返回
+type TopicRewriter interface {
+	ToWire(topic string) string
+	FromWire(topic string) string
+}
+
+// templateRewrite 按主题模板双向改写
+type templateRewrite struct {
+	from []string
+	to   []string
+}
+
+// TemplateRewrite 创建基于主题模板的改写规则，from 为应用侧模板，to 为 Broker 侧模板
+//
+// 模板由精确层级、命名参数 {name} 和末尾的 # 组成，两侧的参数名与 # 必须一致，例如
+// TemplateRewrite("edgex/events/device/{service}/#", "site42/{service}/events/#")。
+// 订阅主题中的 + 可以匹配参数层级；# 只能出现在模板 # 对应的位置，否则不改写。
+func TemplateRewrite(from, to string) (TopicRewriter, error) {
+	fromLevels, err := parseRewriteTemplate(from)
+	if err != nil {
+		return nil, err
+	}
+	toLevels, err := parseRewriteTemplate(to)
+	if err != nil {
+		return nil, err
+	}
+	if templateSignature(fromLevels) != templateSignature(toLevels) {
+		return nil, fmt.Errorf("主题模板 %s 与 %s 的参数或 # 不一致", from, to)
+	}
+	return &templateRewrite{from: fromLevels, to: toLevels}, nil
+}
+
+// ToWire 将匹配 from 模板的主题改写为 to 模板
+func (r *templateRewrite) ToWire(topic string) string {
+	return applyTemplate(r.from, r.to, topic)
+}
+
+// FromWire 将匹配 to 模板的主题还原为 from 模板
+func (r *templateRewrite) FromWire(topic string) string {
+	return applyTemplate(r.to, r.from, topic)
+}
+
+// parseRewriteTemplate 校验主题模板并按层级拆分，模板中不允许 + 等单层通配符
+func parseRewriteTemplate(template string) ([]string, error) {
+	if template == "" {
+		return nil, fmt.Errorf("主题模板不能为空")
+	}
+	levels := strings.Split(template, "/")
+	names := make(map[string]struct{})
+	for i, level := range levels {
+		switch {
+		case level == "#" || level == ">":
+			if i != len(levels)-1 {
+				return nil, fmt.Errorf("主题模板 %s 中的 %s 只能位于末尾", template, level)
+			}
+			levels[i] = "#"
+		case routeParamName(level) != "":
+			name := routeParamName(level)
+			if _, ok := names[name]; ok {
+				return nil, fmt.Errorf("主题模板 %s 中的参数 %s 重复", template, name)
+			}
+			names[name] = struct{}{}
+		case strings.ContainsAny(level, "+*#>{}"):
+			return nil, fmt.Errorf("主题模板 %s 的层级 %q 无效", template, level)
+		}
+	}
+	return levels, nil
+}
+
+// templateSignature 返回模板的参数集合与是否以 # 结尾，用于校验两侧模板可以互相转换
+func templateSignature(levels []string) string {
+	var names []string
+	for _, level := range levels {
+		if name := routeParamName(level); name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	signature := strings.Join(names, ",")
+	if levels[len(levels)-1] == "#" {
+		signature += "#"
+	}
+	return signature
+}
+
+// applyTemplate 按 from 模板提取参数和 # 匹配的剩余层级，再按 to 模板生成主题，不匹配时原样返回
+func applyTemplate(from, to []string, topic string) string {
+	topicLevels := strings.Split(topic, "/")
+	params := make(map[string]string)
+	var tail []string
+	matched := false
+	for i, level := range from {
+		if level == "#" {
+			tail = topicLevels[min(i, len(topicLevels)):]
+			matched = true
+			break
+		}
+		if i >= len(topicLevels) || topicLevels[i] == "#" || topicLevels[i] == ">" {
+			return topic
+		}
+		if name := routeParamName(level); name != "" {
+			params[name] = topicLevels[i]
+			continue
+		}
+		if level != topicLevels[i] {
+			return topic
+		}
+	}
+	if !matched && len(from) != len(topicLevels) {
+		return topic
+	}
+	result := make([]string, 0, len(to)+len(tail))
+	for _, level := range to {
+		switch name := routeParamName(level); {
+		case level == "#":
+			result = append(result, tail...)
+		case name != "":
+			result = append(result, params[name])
+		default:
+			result = append(result, level)
+		}
+	}
+	return strings.Join(result, "/")
+}
+
+// regexRewrite 按正则表达式改写
+type regexRewrite struct {
+	pattern            *regexp.Regexp
+	replacement        string
+	reversePattern     *regexp.Regexp
+	reverseReplacement string
+}
+
+// RegexRewrite 创建基于正则表达式的改写规则：发布和订阅时将匹配 pattern 的部分替换为 replacement，
+// 收到消息时将匹配 reversePattern 的部分替换为 reverseReplacement 以还原主题；reversePattern 为空时不还原。
+// 替换串支持 $1、${name} 等分组引用
+func RegexRewrite(pattern, replacement, reversePattern, reverseReplacement string) (TopicRewriter, error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("主题改写正则表达式无效: %w", err)
+	}
+	rewrite := &regexRewrite{pattern: compiled, replacement: replacement, reverseReplacement: reverseReplacement}
+	if reversePattern != "" {
+		if rewrite.reversePattern, err = regexp.Compile(reversePattern); err != nil {
+			return nil, fmt.Errorf("主题还原正则表达式无效: %w", err)
+		}
+	}
+	return rewrite, nil
+}
+
+// ToWire 替换匹配 pattern 的部分
+func (r *regexRewrite) ToWire(topic string) string {
+	return r.pattern.ReplaceAllString(topic, r.replacement)
+}
+
+// FromWire 替换匹配 reversePattern 的部分
+func (r *regexRewrite) FromWire(topic string) string {
+	if r.reversePattern == nil {
+		return topic
+	}
+	return r.reversePattern.ReplaceAllString(topic, r.reverseReplacement)
+}
+
+// topicMapper 组合 TopicRewrites 与 TopicPrefix
+type topicMapper struct {
+	prefix    string
+	rewriters []TopicRewriter
+}
+
+// newTopicMapper 按配置创建主题映射，未配置前缀和改写规则时返回 nil
+func newTopicMapper(config Config) *topicMapper {
+	prefix := strings.Trim(config.TopicPrefix, "/")
+	if prefix == "" && len(config.TopicRewrites) == 0 {
+		return nil
+	}
+	return &topicMapper{prefix: prefix, rewriters: config.TopicRewrites}
+}
+
+// toWire 依次应用改写规则，再加上前缀
+// 共享订阅主题只转换其中的 filter，$share/<group>/ 前缀保持在最前
+func (m *topicMapper) toWire(topic string) string {
+	if group, filter, ok := splitSharedTopic(topic); ok {
+		return SharedTopic(group, m.toWire(filter))
+	}
+	for _, rewriter := range m.rewriters {
+		topic = rewriter.ToWire(topic)
+	}
+	if m.prefix != "" {
+		topic = m.prefix + "/" + topic
+	}
+	return topic
+}
+
+// fromWire 去掉前缀后按相反顺序还原改写规则
+func (m *topicMapper) fromWire(topic string) string {
+	if m.prefix != "" {
+		topic = strings.TrimPrefix(topic, m.prefix+"/")
+	}
+	for i := len(m.rewriters) - 1; i >= 0; i-- {
+		topic = m.rewriters[i].FromWire(topic)
+	}
+	return topic
+}
+
+// toWireAll 转换主题列表
+func (m *topicMapper) toWireAll(topics []string) []string {
+	wire := make([]string, len(topics))
+	for i, topic := range topics {
+		wire[i] = m.toWire(topic)
+	}
+	return wire
+}
+
+// rewritingClient 包装底层客户端，在发布、订阅和请求时转换主题，并还原收到消息的 ReceivedTopic
+type rewritingClient struct {
+	messaging.MessageClient
+	mapper *topicMapper
+
+	mutex    sync.Mutex
+	forwards map[string]chan struct{} // 按 Broker 侧主题记录转发 goroutine 的停止信号
+}
+
+// newRewritingClient 使用主题映射包装底层客户端
+func newRewritingClient(client messaging.MessageClient, mapper *topicMapper) *rewritingClient {
+	return &rewritingClient{MessageClient: client, mapper: mapper, forwards: make(map[string]chan struct{})}
+}
+
+func (r *rewritingClient) Publish(message types.MessageEnvelope, topic string) error {
+	return r.MessageClient.Publish(message, r.mapper.toWire(topic))
+}
+
+func (r *rewritingClient) PublishWithSizeLimit(message types.MessageEnvelope, topic string, limit int64) error {
+	return r.MessageClient.PublishWithSizeLimit(message, r.mapper.toWire(topic), limit)
+}
+
+func (r *rewritingClient) PublishBinaryData(data []byte, topic string) error {
+	return r.MessageClient.PublishBinaryData(data, r.mapper.toWire(topic))
+}
+
+// NotifyBrokerDisconnect 交给底层客户端注册，底层客户端不支持时忽略
+func (r *rewritingClient) NotifyBrokerDisconnect(handler func(takeover bool)) {
+	if notifier, ok := r.MessageClient.(BrokerDisconnectNotifier); ok {
+		notifier.NotifyBrokerDisconnect(handler)
+	}
+}
+
+// SupportsMQTT5 在底层客户端支持 MQTT 5 时返回 true
+func (r *rewritingClient) SupportsMQTT5() bool {
+	publisher, ok := r.MessageClient.(MQTT5Publisher)
+	return ok && publisher.SupportsMQTT5()
+}
+
+// PublishWithProperties 转换主题和响应主题后交给底层客户端发布
+func (r *rewritingClient) PublishWithProperties(message types.MessageEnvelope, topic string, qos int, retain bool, props MQTT5Properties) error {
+	publisher, ok := r.MessageClient.(MQTT5Publisher)
+	if !ok {
+		return ErrMQTT5Unsupported
+	}
+	if props.ResponseTopic != "" {
+		props.ResponseTopic = r.mapper.toWire(props.ResponseTopic)
+	}
+	return publisher.PublishWithProperties(message, r.mapper.toWire(topic), qos, retain, props)
+}
+
+func (r *rewritingClient) Subscribe(topics []types.TopicChannel, messageErrors chan error) error {
+	return r.subscribe(topics, messageErrors, r.MessageClient.Subscribe)
+}
+
+func (r *rewritingClient) SubscribeBinaryData(topics []types.TopicChannel, messageErrors chan error) error {
+	return r.subscribe(topics, messageErrors, r.MessageClient.SubscribeBinaryData)
+}
+
+// subscribe 以 Broker 侧主题订阅，并启动 goroutine 将消息的 ReceivedTopic 还原后转发到应用侧通道
+func (r *rewritingClient) subscribe(topics []types.TopicChannel, messageErrors chan error, subscribe func([]types.TopicChannel, chan error) error) error {
+	wire := make([]types.TopicChannel, len(topics))
+	for i, topic := range topics {
+		wire[i] = types.TopicChannel{Topic: r.mapper.toWire(topic.Topic), Messages: make(chan types.MessageEnvelope, cap(topic.Messages))}
+	}
+	if err := subscribe(wire, messageErrors); err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i, topic := range topics {
+		if stop, ok := r.forwards[wire[i].Topic]; ok {
+			close(stop)
+		}
+		stop := make(chan struct{})
+		r.forwards[wire[i].Topic] = stop
+		go r.forward(wire[i].Messages, topic.Messages, stop)
+	}
+	return nil
+}
+
+// forward 还原 ReceivedTopic 后转发消息，直到 stop 关闭
+func (r *rewritingClient) forward(from <-chan types.MessageEnvelope, to chan<- types.MessageEnvelope, stop <-chan struct{}) {
+	for {
+		select {
+		case message := <-from:
+			message.ReceivedTopic = r.mapper.fromWire(message.ReceivedTopic)
+			if responseTopic, ok := message.QueryParams[HeaderMQTT5ResponseTopic]; ok {
+				message.QueryParams[HeaderMQTT5ResponseTopic] = r.mapper.fromWire(responseTopic)
+			}
+			select {
+			case to <- message:
+			case <-stop:
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (r *rewritingClient) Request(message types.MessageEnvelope, requestTopic string, responseTopicPrefix string, timeout time.Duration) (*types.MessageEnvelope, error) {
+	response, err := r.MessageClient.Request(message, r.mapper.toWire(requestTopic), r.mapper.toWire(responseTopicPrefix), timeout)
+	if response != nil {
+		response.ReceivedTopic = r.mapper.fromWire(response.ReceivedTopic)
+	}
+	return response, err
+}
+
+func (r *rewritingClient) Unsubscribe(topics ...string) error {
+	wire := r.mapper.toWireAll(topics)
+	err := r.MessageClient.Unsubscribe(wire...)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, topic := range wire {
+		if stop, ok := r.forwards[topic]; ok {
+			close(stop)
+			delete(r.forwards, topic)
+		}
+	}
+	return err
+}
+
+func (r *rewritingClient) Disconnect() error {
+	err := r.MessageClient.Disconnect()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for topic, stop := range r.forwards {
+		close(stop)
+		delete(r.forwards, topic)
+	}
+	return err
+}
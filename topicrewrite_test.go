@@ -0,0 +1,73 @@
+package messagebus_test
+
+import (
+	"testing"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/messagebustest"
+)
+
+func TestTopicRewriteAndPrefixRoundTrip(t *testing.T) {
+	rewrite, err := messagebus.TemplateRewrite("edgex/events/device/{service}/#", "site42/{service}/events/#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	broker := messagebustest.NewBroker()
+	opts := []messagebus.Option{messagebus.WithTopicPrefix("tenant1"), messagebus.WithTopicRewrites(rewrite)}
+	publisher, err := messagebustest.NewMockClientWithBroker(broker, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = publisher.Close() })
+	subscriber, err := messagebustest.NewMockClientWithBroker(broker, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = subscriber.Close() })
+	// 未配置改写的客户端直接看到 Broker 上的主题
+	raw, err := messagebustest.NewMockClientWithBroker(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = raw.Close() })
+	received := subscribeChannel(t, subscriber, "edgex/events/device/+/#")
+	rawReceived := subscribeChannel(t, raw, "tenant1/#")
+
+	if err := publisher.Publish("edgex/events/device/svc/prof/d1", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := publisher.Publish("edgex/commands/d1", "y"); err != nil {
+		t.Fatal(err)
+	}
+	published := broker.Published()
+	if len(published) != 2 || published[0].Topic != "tenant1/site42/svc/events/prof/d1" || published[1].Topic != "tenant1/edgex/commands/d1" {
+		t.Fatalf("Broker 上的主题 = %v，期望改写后加前缀", published)
+	}
+	if msg := receiveOne(t, received); msg.ReceivedTopic != "edgex/events/device/svc/prof/d1" {
+		t.Errorf("ReceivedTopic = %q，期望还原为应用侧主题", msg.ReceivedTopic)
+	}
+	for _, want := range []string{"tenant1/site42/svc/events/prof/d1", "tenant1/edgex/commands/d1"} {
+		if msg := receiveOne(t, rawReceived); msg.ReceivedTopic != want {
+			t.Errorf("未改写的客户端收到 %q，期望 %q", msg.ReceivedTopic, want)
+		}
+	}
+}
+
+func TestRegexRewrite(t *testing.T) {
+	rewrite, err := messagebus.RegexRewrite(`^edgex/(.*)$`, "plant/a/$1", `^plant/a/(.*)$`, "edgex/$1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rewrite.ToWire("edgex/events/d1"); got != "plant/a/events/d1" {
+		t.Errorf("ToWire = %q", got)
+	}
+	if got := rewrite.FromWire("plant/a/events/d1"); got != "edgex/events/d1" {
+		t.Errorf("FromWire = %q", got)
+	}
+	if got := rewrite.ToWire("other/topic"); got != "other/topic" {
+		t.Errorf("不匹配的主题应原样返回，实际 %q", got)
+	}
+	if _, err := messagebus.TemplateRewrite("edgex/{service}/#", "site/{device}/#"); err == nil {
+		t.Error("两侧参数不一致的模板应创建失败")
+	}
+}
@@ -0,0 +1,347 @@
+// Package topics 提供 EdgeX 标准主题树的常量和构造器，避免手写 fmt.Sprintf 拼接主题时层级错位或漏写前缀
+//
+// 每类主题对应一个构造器，方法按名称设置层级，与调用顺序无关：
+//
+//	topics.Events().Device("d1").Source("temp").String()
+//	// edgex/events/device/+/+/d1/temp
+//
+//	topics.Events().Base("site42").Service("device-modbus").Profile("p").Device("d1").Source("temp").String()
+//	// site42/events/device/device-modbus/p/d1/temp
+//
+// 未设置的中间层级为单层通配符 +，末尾连续未设置的层级合并为多层通配符 #，因此同一个构造器既可以生成
+// 发布主题，也可以生成订阅模式。构造器是值类型，每次设置返回新的副本，可以安全地复用部分设置的构造器。
+//
+// EdgeX 服务开启 EnableNameFieldEscape 时主题中的名称字段经 URL 转义，此时应调用构造器的 Escaped。
+package topics
+
+import (
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+)
+
+// 主题通配符
+const (
+	SingleLevel = "+" // 匹配一个层级
+	MultiLevel  = "#" // 匹配其后的所有层级，只能位于末尾
+)
+
+// EdgeX 标准主题的固定层级，与 go-mod-core-contracts 的定义一致
+const (
+	DefaultBase   = common.DefaultBaseTopic        // 默认主题前缀 edgex
+	EventsLevel   = common.EventsPublishTopic      // events
+	DeviceLevel   = common.Device                  // device
+	ResponseLevel = common.ResponseTopic           // response
+	MetricsLevel  = common.MetricsPublishTopic     // telemetry
+	SystemLevel   = common.SystemEventPublishTopic // system-events
+
+	CommandRequestLevel      = common.CoreCommandRequestPublishTopic       // core/command/request
+	CommandQueryRequestLevel = common.CoreCommandQueryRequestPublishTopic  // core/commandquery/request
+	DeviceCommandLevel       = common.CoreCommandDeviceRequestPublishTopic // device/command/request
+	ValidateDeviceLevel      = common.ValidateDeviceSubscribeTopic         // validate/device
+)
+
+// 设备命令方法
+const (
+	MethodGet = "get"
+	MethodSet = "set"
+)
+
+// Join 以 / 连接主题层级，空层级视为未设置：中间的替换为 +，末尾连续的合并为 #
+func Join(levels ...string) string {
+	end := len(levels)
+	for end > 0 && levels[end-1] == "" {
+		end--
+	}
+	parts := make([]string, 0, end+1)
+	for _, level := range levels[:end] {
+		if level == "" {
+			level = SingleLevel
+		}
+		parts = append(parts, level)
+	}
+	if end < len(levels) {
+		parts = append(parts, MultiLevel)
+	}
+	return strings.Join(parts, "/")
+}
+
+// Escape 按 EdgeX EnableNameFieldEscape 的规则转义名称字段，通配符和空值保持不变
+func Escape(name string) string {
+	if name == "" || name == SingleLevel || name == MultiLevel {
+		return name
+	}
+	return common.URLEncode(name)
+}
+
+// names 保存构造器的公共设置
+type names struct {
+	base   string
+	escape bool
+}
+
+// prefix 返回主题前缀，未设置时为 edgex
+func (n names) prefix() string {
+	if n.base == "" {
+		return DefaultBase
+	}
+	return n.base
+}
+
+// name 按需转义名称字段
+func (n names) name(value string) string {
+	if n.escape {
+		return Escape(value)
+	}
+	return value
+}
+
+// fixed 将固定层级（可能包含 /）拆分为单独的层级
+func fixed(path string) []string {
+	return strings.Split(path, "/")
+}
+
+// EventTopic 构造设备事件主题 <base>/events/device/<service>/<profile>/<device>/<source>
+type EventTopic struct {
+	names
+	service, profile, device, source string
+}
+
+// Events 返回设备事件主题构造器
+func Events() EventTopic { return EventTopic{} }
+
+// Base 设置主题前缀，默认为 edgex
+func (t EventTopic) Base(base string) EventTopic { t.base = base; return t }
+
+// Escaped 对名称字段做 URL 转义，与开启 EnableNameFieldEscape 的 EdgeX 服务一致
+func (t EventTopic) Escaped() EventTopic { t.escape = true; return t }
+
+// Service 设置发布事件的设备服务名
+func (t EventTopic) Service(service string) EventTopic { t.service = service; return t }
+
+// Profile 设置设备配置文件名
+func (t EventTopic) Profile(profile string) EventTopic { t.profile = profile; return t }
+
+// Device 设置设备名
+func (t EventTopic) Device(device string) EventTopic { t.device = device; return t }
+
+// Source 设置资源名或命令名
+func (t EventTopic) Source(source string) EventTopic { t.source = source; return t }
+
+// String 返回主题
+func (t EventTopic) String() string {
+	return Join(t.prefix(), EventsLevel, DeviceLevel, t.name(t.service), t.name(t.profile), t.name(t.device), t.name(t.source))
+}
+
+// CommandTopic 构造发给 core-command 的设备命令请求主题 <base>/core/command/request/<device>/<command>/<method>
+type CommandTopic struct {
+	names
+	device, command, method string
+}
+
+// Commands 返回 core-command 命令请求主题构造器
+func Commands() CommandTopic { return CommandTopic{} }
+
+// Base 设置主题前缀，默认为 edgex
+func (t CommandTopic) Base(base string) CommandTopic { t.base = base; return t }
+
+// Escaped 对设备名和命令名做 URL 转义
+func (t CommandTopic) Escaped() CommandTopic { t.escape = true; return t }
+
+// Device 设置设备名
+func (t CommandTopic) Device(device string) CommandTopic { t.device = device; return t }
+
+// Command 设置命令名
+func (t CommandTopic) Command(command string) CommandTopic { t.command = command; return t }
+
+// Method 设置命令方法 get 或 set
+func (t CommandTopic) Method(method string) CommandTopic { t.method = method; return t }
+
+// Get 将命令方法设置为 get
+func (t CommandTopic) Get() CommandTopic { return t.Method(MethodGet) }
+
+// Set 将命令方法设置为 set
+func (t CommandTopic) Set() CommandTopic { return t.Method(MethodSet) }
+
+// String 返回主题
+func (t CommandTopic) String() string {
+	levels := append([]string{t.prefix()}, fixed(CommandRequestLevel)...)
+	return Join(append(levels, t.name(t.device), t.name(t.command), t.method)...)
+}
+
+// CommandQueryTopic 构造查询设备命令的请求主题 <base>/core/commandquery/request/<device|all>
+type CommandQueryTopic struct {
+	names
+	device string
+}
+
+// CommandQuery 返回命令查询主题构造器
+func CommandQuery() CommandQueryTopic { return CommandQueryTopic{} }
+
+// Base 设置主题前缀，默认为 edgex
+func (t CommandQueryTopic) Base(base string) CommandQueryTopic { t.base = base; return t }
+
+// Escaped 对设备名做 URL 转义
+func (t CommandQueryTopic) Escaped() CommandQueryTopic { t.escape = true; return t }
+
+// Device 设置要查询的设备名
+func (t CommandQueryTopic) Device(device string) CommandQueryTopic { t.device = device; return t }
+
+// All 查询所有设备的命令
+func (t CommandQueryTopic) All() CommandQueryTopic { t.device = common.All; return t }
+
+// String 返回主题
+func (t CommandQueryTopic) String() string {
+	device := t.device
+	if device != common.All {
+		device = t.name(device)
+	}
+	levels := append([]string{t.prefix()}, fixed(CommandQueryRequestLevel)...)
+	return Join(append(levels, device)...)
+}
+
+// DeviceCommandTopic 构造 core-command 转发给设备服务的命令主题
+// <base>/device/command/request/<service>/<device>/<command>/<method>
+type DeviceCommandTopic struct {
+	names
+	service, device, command, method string
+}
+
+// DeviceCommands 返回设备服务命令主题构造器
+func DeviceCommands() DeviceCommandTopic { return DeviceCommandTopic{} }
+
+// Base 设置主题前缀，默认为 edgex
+func (t DeviceCommandTopic) Base(base string) DeviceCommandTopic { t.base = base; return t }
+
+// Escaped 对设备名和命令名做 URL 转义
+func (t DeviceCommandTopic) Escaped() DeviceCommandTopic { t.escape = true; return t }
+
+// Service 设置设备服务名
+func (t DeviceCommandTopic) Service(service string) DeviceCommandTopic { t.service = service; return t }
+
+// Device 设置设备名
+func (t DeviceCommandTopic) Device(device string) DeviceCommandTopic { t.device = device; return t }
+
+// Command 设置命令名
+func (t DeviceCommandTopic) Command(command string) DeviceCommandTopic { t.command = command; return t }
+
+// Method 设置命令方法 get 或 set
+func (t DeviceCommandTopic) Method(method string) DeviceCommandTopic { t.method = method; return t }
+
+// String 返回主题
+func (t DeviceCommandTopic) String() string {
+	levels := append([]string{t.prefix()}, fixed(DeviceCommandLevel)...)
+	return Join(append(levels, t.service, t.name(t.device), t.name(t.command), t.method)...)
+}
+
+// ResponseTopic 构造请求的响应主题 <base>/response/<service>/<requestId>
+type ResponseTopic struct {
+	names
+	service, requestID string
+}
+
+// Responses 返回响应主题构造器
+func Responses() ResponseTopic { return ResponseTopic{} }
+
+// Base 设置主题前缀，默认为 edgex
+func (t ResponseTopic) Base(base string) ResponseTopic { t.base = base; return t }
+
+// Service 设置发出请求的服务名，例如 core-command
+func (t ResponseTopic) Service(service string) ResponseTopic { t.service = service; return t }
+
+// RequestID 设置请求 ID
+func (t ResponseTopic) RequestID(requestID string) ResponseTopic { t.requestID = requestID; return t }
+
+// Prefix 返回不含请求 ID 的响应主题前缀，可直接作为 Request 系列方法的 responseTopic
+func (t ResponseTopic) Prefix() string {
+	if t.service == "" {
+		return t.prefix() + "/" + ResponseLevel
+	}
+	return t.prefix() + "/" + ResponseLevel + "/" + t.service
+}
+
+// String 返回主题
+func (t ResponseTopic) String() string {
+	return Join(t.prefix(), ResponseLevel, t.service, t.requestID)
+}
+
+// SystemEventTopic 构造系统事件主题 <base>/system-events/<source>/<type>/<action>/<owner>/<profile>
+type SystemEventTopic struct {
+	names
+	source, eventType, action, owner, profile string
+}
+
+// SystemEvents 返回系统事件主题构造器
+func SystemEvents() SystemEventTopic { return SystemEventTopic{} }
+
+// Base 设置主题前缀，默认为 edgex
+func (t SystemEventTopic) Base(base string) SystemEventTopic { t.base = base; return t }
+
+// Escaped 对配置文件名做 URL 转义
+func (t SystemEventTopic) Escaped() SystemEventTopic { t.escape = true; return t }
+
+// Source 设置发布系统事件的服务名，例如 core-metadata
+func (t SystemEventTopic) Source(source string) SystemEventTopic { t.source = source; return t }
+
+// Type 设置系统事件类型，例如 device、deviceprofile
+func (t SystemEventTopic) Type(eventType string) SystemEventTopic { t.eventType = eventType; return t }
+
+// Action 设置系统事件动作，例如 add、update、delete
+func (t SystemEventTopic) Action(action string) SystemEventTopic { t.action = action; return t }
+
+// Owner 设置所属的设备服务名
+func (t SystemEventTopic) Owner(owner string) SystemEventTopic { t.owner = owner; return t }
+
+// Profile 设置设备配置文件名
+func (t SystemEventTopic) Profile(profile string) SystemEventTopic { t.profile = profile; return t }
+
+// String 返回主题
+func (t SystemEventTopic) String() string {
+	return Join(t.prefix(), SystemLevel, t.source, t.eventType, t.action, t.owner, t.name(t.profile))
+}
+
+// TelemetryTopic 构造服务指标主题 <base>/telemetry/<service>/<metric>
+type TelemetryTopic struct {
+	names
+	service, metric string
+}
+
+// Telemetry 返回指标主题构造器
+func Telemetry() TelemetryTopic { return TelemetryTopic{} }
+
+// Base 设置主题前缀，默认为 edgex
+func (t TelemetryTopic) Base(base string) TelemetryTopic { t.base = base; return t }
+
+// Service 设置发布指标的服务名
+func (t TelemetryTopic) Service(service string) TelemetryTopic { t.service = service; return t }
+
+// Metric 设置指标名
+func (t TelemetryTopic) Metric(metric string) TelemetryTopic { t.metric = metric; return t }
+
+// String 返回主题
+func (t TelemetryTopic) String() string {
+	return Join(t.prefix(), MetricsLevel, t.service, t.metric)
+}
+
+// DeviceValidationTopic 构造设备服务校验新设备的请求主题 <base>/<service>/validate/device
+type DeviceValidationTopic struct {
+	names
+	service string
+}
+
+// DeviceValidation 返回设备校验主题构造器
+func DeviceValidation() DeviceValidationTopic { return DeviceValidationTopic{} }
+
+// Base 设置主题前缀，默认为 edgex
+func (t DeviceValidationTopic) Base(base string) DeviceValidationTopic { t.base = base; return t }
+
+// Service 设置负责校验的设备服务名
+func (t DeviceValidationTopic) Service(service string) DeviceValidationTopic {
+	t.service = service
+	return t
+}
+
+// String 返回主题；未设置服务名时为 <base>/+/validate/device
+func (t DeviceValidationTopic) String() string {
+	return Join(append([]string{t.prefix(), t.service}, fixed(ValidateDeviceLevel)...)...)
+}
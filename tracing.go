@@ -0,0 +1,83 @@
+package messagebus
+
+import (
+	"context"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName 创建 Tracer 时使用的埋点库名称
+const tracerName = "github.com/clint456/edgex-messagebus-client"
+
+// ContextMessageHandler 定义携带上下文的消息处理函数，ctx 中包含上游发布方的 Span
+type ContextMessageHandler func(ctx context.Context, topic string, message types.MessageEnvelope) error
+
+// propagator 返回用于在 QueryParams 中注入/提取追踪上下文的传播器
+func (c *Client) propagator() propagation.TextMapPropagator {
+	if c.config.Propagator != nil {
+		return c.config.Propagator
+	}
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// startSpan 在配置了 TracerProvider 时创建 Span，否则返回 ctx 中的当前 Span（可能为空操作 Span）
+func (c *Client) startSpan(ctx context.Context, operation, topic string, kind trace.SpanKind) (context.Context, trace.Span) {
+	if c.config.TracerProvider == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return c.config.TracerProvider.Tracer(tracerName).Start(ctx, operation+" "+topic,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(
+			attribute.String("messaging.system", c.config.Type),
+			attribute.String("messaging.operation", operation),
+			attribute.String("messaging.destination.name", topic),
+		),
+	)
+}
+
+// endSpan 记录错误并结束由 startSpan 创建的 Span
+func (c *Client) endSpan(span trace.Span, err error) {
+	if c.config.TracerProvider == nil {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// injectTraceContext 将 ctx 中的追踪上下文写入信封的 QueryParams
+func (c *Client) injectTraceContext(ctx context.Context, envelope *types.MessageEnvelope) {
+	if envelope.QueryParams == nil {
+		envelope.QueryParams = make(map[string]string)
+	}
+	c.propagator().Inject(ctx, propagation.MapCarrier(envelope.QueryParams))
+}
+
+// ContextFromEnvelope 从信封的 QueryParams 中提取上游追踪上下文，并附加到 ctx 上
+func (c *Client) ContextFromEnvelope(ctx context.Context, envelope types.MessageEnvelope) context.Context {
+	if envelope.QueryParams == nil {
+		return ctx
+	}
+	return c.propagator().Extract(ctx, propagation.MapCarrier(envelope.QueryParams))
+}
+
+// TracedHandler 将携带上下文的处理函数适配为 MessageHandler
+// 处理函数收到的 ctx 以发布方的 Span 为父 Span，配置了 TracerProvider 时还会为每条消息创建消费 Span
+func (c *Client) TracedHandler(handler ContextMessageHandler) MessageHandler {
+	if handler == nil {
+		return nil
+	}
+	return func(topic string, message types.MessageEnvelope) error {
+		ctx := c.ContextFromEnvelope(context.Background(), message)
+		ctx, span := c.startSpan(ctx, "process", topic, trace.SpanKindConsumer)
+		err := handler(ctx, topic, message)
+		c.endSpan(span, err)
+		return err
+	}
+}
@@ -0,0 +1,123 @@
+package messagebus
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// TypedHandler 定义接收已解码消息的处理函数
+type TypedHandler[T any] func(topic string, msg T, message types.MessageEnvelope) error
+
+// DecodeError 表示消息 Payload 无法解码为目标类型，通过错误通道上报
+type DecodeError struct {
+	Topic         string // 实际接收主题
+	CorrelationID string // 消息的 CorrelationID
+	Err           error  // 解码错误
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("解码主题 %s 的消息失败 (correlationId=%s): %v", e.Topic, e.CorrelationID, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// SubscribeJSON 订阅多个主题，将 Payload JSON 解码为 T 后交给处理函数
+// 解码失败的消息不会交给处理函数，而是以 *DecodeError 发送到错误通道
+func SubscribeJSON[T any](client *Client, topics []string, handler TypedHandler[T]) error {
+	if handler == nil {
+		return fmt.Errorf("消息处理函数不能为空")
+	}
+	return client.Subscribe(topics, func(topic string, message types.MessageEnvelope) error {
+		msg, err := DecodeJSON[T](message)
+		if err != nil {
+			client.reportError("decode", topic, &DecodeError{Topic: topic, CorrelationID: message.CorrelationID, Err: err})
+			return nil
+		}
+		return handler(topic, msg, message)
+	})
+}
+
+// DecodeJSON 将信封的 JSON Payload 解码为 T
+// Payload 可以是 []byte、base64 字符串或底层客户端已解码的对象；已是 T 类型时直接返回
+func DecodeJSON[T any](message types.MessageEnvelope) (T, error) {
+	return decodeAs[T](message, json.Unmarshal)
+}
+
+// DecodeCBOR 将信封的 CBOR Payload 解码为 T
+// Payload 为底层客户端已解码的对象时按 JSON 转换为 T；已是 T 类型时直接返回
+func DecodeCBOR[T any](message types.MessageEnvelope) (T, error) {
+	return decodeAs[T](message, cbor.Unmarshal)
+}
+
+// Decode 根据信封的 ContentType 选择编解码器将 Payload 解码为 T
+func Decode[T any](message types.MessageEnvelope) (T, error) {
+	codec, ok := CodecFor(message.ContentType)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("不支持的内容类型: %s", message.ContentType)
+	}
+	return decodeAs[T](message, codec.UnmarshalPayload)
+}
+
+// decodeAs 使用 unmarshal 将字节形式的 Payload 解码为 T，已解码的对象经 JSON 转换
+func decodeAs[T any](message types.MessageEnvelope, unmarshal func(data []byte, v interface{}) error) (T, error) {
+	var v T
+	switch payload := message.Payload.(type) {
+	case T:
+		return payload, nil
+	case nil:
+		return v, fmt.Errorf("Payload为空")
+	case []byte, string:
+		data, err := payloadBytes(payload)
+		if err != nil {
+			return v, err
+		}
+		return v, unmarshal(data, &v)
+	default:
+		return v, withPayloadBytes(payload, func(data []byte) error {
+			return json.Unmarshal(data, &v)
+		})
+	}
+}
+
+// StringHandler 定义以字符串形式接收 Payload 的处理函数
+type StringHandler func(topic string, payload string) error
+
+// BinaryHandler 定义以字节形式接收 Payload 的处理函数
+type BinaryHandler func(topic string, payload []byte) error
+
+// SubscribeSingle 订阅单个主题，取消订阅使用 Unsubscribe(topic)
+func (c *Client) SubscribeSingle(topic string, handler MessageHandler) error {
+	return c.Subscribe([]string{topic}, handler)
+}
+
+// SubscribeString 订阅单个主题，将 Payload 以字符串形式交给处理函数
+func (c *Client) SubscribeString(topic string, handler StringHandler) error {
+	if handler == nil {
+		return fmt.Errorf("消息处理函数不能为空")
+	}
+	return c.SubscribeBinary(topic, func(topic string, payload []byte) error {
+		return handler(topic, string(payload))
+	})
+}
+
+// SubscribeBinary 订阅单个主题，将 Payload 以字节形式交给处理函数
+// 无法转换为字节的消息不会交给处理函数，而是以 *DecodeError 发送到错误通道
+func (c *Client) SubscribeBinary(topic string, handler BinaryHandler) error {
+	if handler == nil {
+		return fmt.Errorf("消息处理函数不能为空")
+	}
+	return c.SubscribeSingle(topic, func(topic string, message types.MessageEnvelope) error {
+		payload, err := payloadBytes(message.Payload)
+		if err != nil {
+			c.reportError("decode", topic, &DecodeError{Topic: topic, CorrelationID: message.CorrelationID, Err: err})
+			return nil
+		}
+		return handler(topic, payload)
+	})
+}
@@ -0,0 +1,179 @@
+package messagebus
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxClientIDLength 是 MQTT 规范中 UTF-8 字符串允许的最大字节数
+const maxClientIDLength = 65535
+
+// supportedProtocols 列出各消息总线类型允许使用的协议
+var supportedProtocols = map[string][]string{
+	TypeMQTT:          {"tcp", "ssl", "tls", "tcps", "ws", "wss", "mqtt", "mqtts"},
+	TypeNatsCore:      {"nats", "tcp", "tls", "ws", "wss"},
+	TypeNatsJetStream: {"nats", "tcp", "tls", "ws", "wss"},
+	TypeKafka:         {"tcp", "tls", "ssl"},
+	TypeAMQP:          {"amqp", "amqps", "tcp", "tls", "ssl"},
+}
+
+// ConfigError 汇总配置校验发现的所有问题
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return ErrInvalidConfig.Error() + ": " + strings.Join(e.Problems, "; ")
+}
+
+// Is 使 errors.Is(err, ErrInvalidConfig) 对 *ConfigError 成立
+func (e *ConfigError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// Validate 在连接前校验配置，返回包含全部问题的 *ConfigError
+// NewClient 会先调用 Validate，因此通常无需手动调用
+func (c Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if strings.TrimSpace(c.Host) == "" {
+		add("Host 不能为空")
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		add("Port 必须在 1-65535 之间，当前为 %d", c.Port)
+	}
+	msgType := strings.ToLower(c.Type)
+	protocols, ok := supportedProtocols[msgType]
+	if !ok {
+		add("不支持的 Type %q，可选值: %s, %s, %s, %s, %s", c.Type, TypeMQTT, TypeNatsCore, TypeNatsJetStream, TypeKafka, TypeAMQP)
+	} else if !containsFold(protocols, c.Protocol) {
+		add("Type %s 不支持协议 %q，可选值: %s", msgType, c.Protocol, strings.Join(protocols, ", "))
+	}
+	if c.QoS < 0 || c.QoS > 2 {
+		add("QoS 必须为 0、1 或 2，当前为 %d", c.QoS)
+	}
+	if len(c.ClientID) > maxClientIDLength {
+		add("ClientID 长度不能超过 %d 字节", maxClientIDLength)
+	}
+	if !utf8.ValidString(c.ClientID) {
+		add("ClientID 必须是合法的 UTF-8 字符串")
+	}
+	if msgType == TypeMQTT && strings.ContainsAny(c.ClientID, "+#/") {
+		add("MQTT ClientID 不能包含 +、# 或 /")
+	}
+	if strings.ContainsAny(c.TopicPrefix, "+#*>") {
+		add("TopicPrefix 不能包含通配符")
+	}
+	for i, rewriter := range c.TopicRewrites {
+		if rewriter == nil {
+			add("TopicRewrites[%d] 不能为空", i)
+		}
+	}
+	if (msgType == TypeKafka || msgType == TypeAMQP) && c.MessageClientFactory == nil {
+		add("Type %s 需要设置 MessageClientFactory（如 %s.Factory）", msgType, msgType)
+	}
+	if c.Compression.Encoding != "" {
+		if _, ok := CompressorFor(c.Compression.Encoding); !ok {
+			add("不支持的压缩方式 %q", c.Compression.Encoding)
+		}
+	}
+	if c.Compression.Threshold < 0 {
+		add("Compression.Threshold 不能为负数")
+	}
+	if err := c.Chunking.validate(); err != nil {
+		add("%v", err)
+	}
+	switch c.ErrorOverflow {
+	case "", ErrorOverflowDropNewest, ErrorOverflowDropOldest:
+	default:
+		add("不支持的 ErrorOverflow %q，可选值: %s, %s", c.ErrorOverflow, ErrorOverflowDropNewest, ErrorOverflowDropOldest)
+	}
+	switch c.ContractVersion {
+	case "", ContractV2, ContractV3:
+	default:
+		add("不支持的契约版本: %s", c.ContractVersion)
+	}
+	if c.MaxInFlightBytes < 0 {
+		add("MaxInFlightBytes 不能为负数")
+	}
+	if c.MaxAsyncPublishes < 0 {
+		add("MaxAsyncPublishes 不能为负数")
+	}
+	if err := c.Logging.validate(); err != nil {
+		add("%v", err)
+	}
+	if c.SubscriptionState.Restore != nil && c.SubscriptionState.File == "" {
+		add("设置 SubscriptionState.Restore 时必须设置 SubscriptionState.File")
+	}
+	if err := c.Lag.validate(); err != nil {
+		add("%v", err)
+	}
+	if c.CircuitBreaker.FailureThreshold < 0 || c.CircuitBreaker.OpenDuration < 0 || c.CircuitBreaker.HalfOpenProbes < 0 {
+		add("CircuitBreaker 参数不能为负数")
+	}
+	if c.PublishRateLimit.MessagesPerSecond < 0 || c.PublishRateLimit.BytesPerSecond < 0 {
+		add("PublishRateLimit 的速率不能为负数")
+	}
+	switch c.PublishRateLimit.Mode {
+	case "", RateLimitBlock, RateLimitReject:
+	default:
+		add("不支持的限速模式: %s", c.PublishRateLimit.Mode)
+	}
+	if c.HealthFailureThreshold < 0 || c.HealthProbeTimeout < 0 {
+		add("HealthFailureThreshold 和 HealthProbeTimeout 不能为负数")
+	}
+	if c.ShutdownTimeout < 0 || c.DrainTimeout < 0 || c.CredentialsRefreshInterval < 0 {
+		add("ShutdownTimeout、DrainTimeout 和 CredentialsRefreshInterval 不能为负数")
+	}
+	if c.Reconnect.InitialDelay < 0 || c.Reconnect.MaxDelay < 0 {
+		add("Reconnect 的延迟参数不能为负数")
+	}
+	if c.Reconnect.Jitter < 0 || c.Reconnect.Jitter > 1 {
+		add("Reconnect.Jitter 必须在 0-1 之间")
+	}
+	if _, err := tlsOptions(c); err != nil {
+		add("%v", err)
+	}
+	if err := c.WebSocket.validate(c.Protocol); err != nil {
+		add("%v", err)
+	}
+	if err := proxyOptions(c); err != nil {
+		add("%v", err)
+	}
+	if err := clientIDOptions(c); err != nil {
+		add("%v", err)
+	}
+	if _, err := sessionOptions(c); err != nil {
+		add("%v", err)
+	}
+	if _, err := jetStreamOptions(c); err != nil {
+		add("%v", err)
+	}
+	if err := c.Will.validate(c.Type); err != nil {
+		add("%v", err)
+	}
+	if c.ConfirmPublish {
+		if err := confirmSupported(c.Type); err != nil {
+			add("%v", err)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ConfigError{Problems: problems}
+}
+
+// containsFold 判断 values 中是否包含忽略大小写后相同的 s
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
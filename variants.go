@@ -0,0 +1,101 @@
+package messagebus
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/messaging"
+)
+
+// clientVariant 标识一种 QoS 与保留标志的组合，或 NATS 共享订阅使用的队列组
+type clientVariant struct {
+	qos        int
+	retain     bool
+	queueGroup string
+}
+
+// String 返回用于日志和错误信息的描述
+func (v clientVariant) String() string {
+	if v.queueGroup != "" {
+		return "队列组 " + v.queueGroup
+	}
+	return "QoS " + strconv.Itoa(v.qos)
+}
+
+// QoSLevel 返回指向 qos 的指针，用于设置 SubscribeOptions.QoS
+func QoSLevel(qos int) *int {
+	return &qos
+}
+
+// subscriberFor 返回按订阅选项订阅使用的底层客户端，未设置 QoS 或与客户端配置一致时返回主连接；
+// NATS 共享订阅使用设置了对应队列组的额外连接
+func (c *Client) subscriberFor(opts SubscribeOptions) (messaging.MessageClient, error) {
+	if opts.Group != "" && isNATS(c.config.Type) {
+		return c.variantClient(clientVariant{queueGroup: opts.Group})
+	}
+	if opts.QoS == nil || !strings.EqualFold(c.config.Type, TypeMQTT) || *opts.QoS == c.config.QoS {
+		return c.messageClient(), nil
+	}
+	return c.variantClient(clientVariant{qos: *opts.QoS})
+}
+
+// variantClient 返回指定 QoS 与保留标志（或队列组）的底层客户端，不存在时按当前配置建立
+//
+// 底层客户端的 QoS、保留标志与 NATS 队列组在创建时固定，每种组合使用一条独立连接，
+// ClientID 追加 -q<QoS> 及 -retain（或 -<队列组>）后缀以免与主连接冲突。
+func (c *Client) variantClient(variant clientVariant) (messaging.MessageClient, error) {
+	c.variantsMu.Lock()
+	defer c.variantsMu.Unlock()
+	if client, ok := c.variants[variant]; ok {
+		return client, nil
+	}
+	c.mutex.RLock()
+	busConfig := c.busConfig
+	c.mutex.RUnlock()
+	optional := make(map[string]string, len(busConfig.Optional)+2)
+	for k, v := range busConfig.Optional {
+		optional[k] = v
+	}
+	var clientID string
+	if variant.queueGroup != "" {
+		clientID = optional["ClientId"] + "-" + variant.queueGroup
+		optional["QueueGroup"] = variant.queueGroup
+	} else {
+		clientID = fmt.Sprintf("%s-q%d", optional["ClientId"], variant.qos)
+		if variant.retain {
+			clientID += "-retain"
+		}
+		optional["Qos"] = strconv.Itoa(variant.qos)
+		optional["Retained"] = strconv.FormatBool(variant.retain)
+	}
+	optional["ClientId"] = clientID
+	busConfig.Optional = optional
+
+	client, err := newMessageClient(c.config, busConfig)
+	if err != nil {
+		return nil, fmt.Errorf("创建 %s 连接失败: %w", variant, err)
+	}
+	if err := client.Connect(); err != nil {
+		return nil, fmt.Errorf("建立 %s 连接失败: %w", variant, err)
+	}
+	if c.variants == nil {
+		c.variants = make(map[clientVariant]messaging.MessageClient)
+	}
+	c.variants[variant] = client
+	c.log(LogConnection).Debug("已建立额外连接", c.logFields("clientId", clientID)...)
+	return client, nil
+}
+
+// closeVariants 断开所有按 QoS 与保留标志建立的额外连接，下次使用时按当前配置重新建立
+func (c *Client) closeVariants() {
+	c.variantsMu.Lock()
+	variants := c.variants
+	c.variants = nil
+	c.variantsMu.Unlock()
+	for _, client := range variants {
+		if err := client.Disconnect(); err != nil {
+			c.log(LogConnection).Warn("断开额外连接失败", c.logFields("error", err)...)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package messagebus
+
+import (
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// ContractVersion 表示信封遵循的 EdgeX 契约版本
+//
+// v3（默认）：ApiVersion 为 "v3"，Payload 可为任意对象，由底层客户端随信封一起序列化。
+// v2：ApiVersion 为 "v2"，Payload 总是预先编码为 []byte（v2 服务只接受字节形式的 Payload），
+// 且不携带空的 QueryParams。两种版本的主题结构相同，均为 edgex/... 的斜杠分隔形式。
+type ContractVersion string
+
+const (
+	ContractV2 ContractVersion = "v2"
+	ContractV3 ContractVersion = "v3"
+)
+
+// contractVersion 返回配置的契约版本，未配置时默认为 v3
+func (c *Client) contractVersion() ContractVersion {
+	if c.config.ContractVersion == "" {
+		return ContractV3
+	}
+	return c.config.ContractVersion
+}
+
+// applyContractVersion 按契约版本填充信封字段
+func applyContractVersion(version ContractVersion, envelope *types.MessageEnvelope) error {
+	switch version {
+	case "", ContractV3:
+		envelope.ApiVersion = common.ApiVersion
+	case ContractV2:
+		envelope.ApiVersion = string(ContractV2)
+		if envelope.Payload != nil {
+			payload, err := types.ConvertMsgPayloadToByteArray(envelope.ContentType, envelope.Payload)
+			if err != nil {
+				return fmt.Errorf("转换v2信封Payload失败: %w", err)
+			}
+			envelope.Payload = payload
+		}
+		if len(envelope.QueryParams) == 0 {
+			envelope.QueryParams = nil
+		}
+	default:
+		return fmt.Errorf("不支持的契约版本: %s", version)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package messagebus_test
+
+import (
+	"testing"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+)
+
+func TestContractVersionEnvelope(t *testing.T) {
+	data := map[string]int{"value": 1}
+	cases := []struct {
+		version     messagebus.ContractVersion
+		apiVersion  string
+		bytePayload bool
+	}{
+		{"", "v3", false},
+		{messagebus.ContractV3, "v3", false},
+		{messagebus.ContractV2, "v2", true},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.version), func(t *testing.T) {
+			config := testConfig()
+			config.ContractVersion = tc.version
+			client := newMockClient(t, messagebus.WithConfig(config))
+			envelope, err := client.CreateMessageEnvelope(data, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if envelope.ApiVersion != tc.apiVersion {
+				t.Errorf("ApiVersion = %q，期望 %q", envelope.ApiVersion, tc.apiVersion)
+			}
+			if _, ok := envelope.Payload.([]byte); ok != tc.bytePayload {
+				t.Errorf("Payload 类型为 %T，期望字节形式: %v", envelope.Payload, tc.bytePayload)
+			}
+			var decoded map[string]int
+			if err := messagebus.DecodePayload(envelope, &decoded); err != nil || decoded["value"] != 1 {
+				t.Errorf("Payload 解码结果 %v, %v", decoded, err)
+			}
+		})
+	}
+}
+
+func TestContractVersionTopicsAndPublish(t *testing.T) {
+	topics := map[messagebus.ContractVersion]string{}
+	for _, version := range []messagebus.ContractVersion{messagebus.ContractV2, messagebus.ContractV3} {
+		config := testConfig()
+		config.ContractVersion = version
+		client := newMockClient(t, messagebus.WithConfig(config))
+		topic := client.EventTopic("profile", "device", "source")
+		topics[version] = topic
+		if err := client.Publish(topic, map[string]string{"k": "v"}); err != nil {
+			t.Fatal(err)
+		}
+		published := client.ExpectPublished(t, topic)
+		if published.ApiVersion != string(version) {
+			t.Errorf("%s: 发布的 ApiVersion = %q", version, published.ApiVersion)
+		}
+	}
+	if topics[messagebus.ContractV2] != topics[messagebus.ContractV3] {
+		t.Errorf("v2 与 v3 的事件主题应相同: %q != %q", topics[messagebus.ContractV2], topics[messagebus.ContractV3])
+	}
+}
+
+func TestContractVersionInvalid(t *testing.T) {
+	config := testConfig()
+	config.ContractVersion = "v1"
+	if _, err := messagebus.NewClientWithOptions(messagebus.WithConfig(config)); err == nil {
+		t.Fatal("不支持的契约版本应在创建客户端时报错")
+	}
+}
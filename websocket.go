@@ -0,0 +1,149 @@
+package messagebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/gorilla/websocket"
+)
+
+// webSocketHandshakeHeaders 由 WebSocket 握手自行设置，不能通过 WebSocketConfig.Headers 指定
+var webSocketHandshakeHeaders = []string{"Upgrade", "Connection", "Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Extensions"}
+
+// WebSocketConfig 表示 ws/wss 协议的连接参数
+type WebSocketConfig struct {
+	// Path 握手请求的路径，例如 /mqtt，可以携带查询参数；为空时使用 /
+	Path string
+	// Headers 握手请求附加的 HTTP 头，例如 Ingress 要求的 Authorization
+	Headers http.Header
+}
+
+// isWebSocket 判断协议是否为 WebSocket
+func isWebSocket(protocol string) bool {
+	return strings.EqualFold(protocol, "ws") || strings.EqualFold(protocol, "wss")
+}
+
+// validate 校验 WebSocket 参数
+func (w WebSocketConfig) validate(protocol string) error {
+	if w.Path == "" && len(w.Headers) == 0 {
+		return nil
+	}
+	if !isWebSocket(protocol) {
+		return fmt.Errorf("WebSocket 参数仅在协议为 ws/wss 时生效，当前协议: %s", protocol)
+	}
+	if w.Path != "" {
+		if !strings.HasPrefix(w.Path, "/") {
+			return fmt.Errorf("WebSocket.Path 必须以 / 开头: %s", w.Path)
+		}
+		if _, err := url.ParseRequestURI(w.Path); err != nil {
+			return fmt.Errorf("WebSocket.Path 无效: %w", err)
+		}
+	}
+	for name := range w.Headers {
+		for _, reserved := range webSocketHandshakeHeaders {
+			if strings.EqualFold(name, reserved) {
+				return fmt.Errorf("WebSocket.Headers 不能设置握手头 %s", name)
+			}
+		}
+	}
+	return nil
+}
+
+// webSocketURL 返回 Broker 的 WebSocket 地址
+func webSocketURL(broker types.HostInfo, path string) string {
+	if path == "" {
+		path = "/"
+	}
+	scheme := strings.ToLower(broker.Protocol)
+	return scheme + "://" + net.JoinHostPort(broker.Host, strconv.Itoa(broker.Port)) + path
+}
+
+// webSocketDialer 返回建立 WebSocket 连接的 brokerDialer
+// 设置了 ProxyURL 时经该代理连接，否则从 HTTPS_PROXY/HTTP_PROXY/NO_PROXY 环境变量读取 HTTP 代理；
+// wss 使用 Optional 中的 TLS 参数
+func webSocketDialer(config Config, busConfig types.MessageBusConfig) (brokerDialer, error) {
+	target := webSocketURL(busConfig.Broker, config.WebSocket.Path)
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: relayDialTimeout,
+	}
+	if config.ProxyURL != "" {
+		dial, err := proxyDialer(config.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		dialer.Proxy = nil
+		dialer.NetDialContext = dial
+	}
+	if strings.EqualFold(busConfig.Broker.Protocol, "wss") {
+		tlsConfig, err := TLSConfigFromOptions(busConfig.Optional)
+		if err != nil {
+			return nil, err
+		}
+		dialer.TLSClientConfig = tlsConfig
+	}
+	if strings.EqualFold(busConfig.Type, TypeMQTT) {
+		dialer.Subprotocols = []string{"mqtt"}
+	}
+	headers := config.WebSocket.Headers.Clone()
+	return func(ctx context.Context) (net.Conn, error) {
+		conn, resp, err := dialer.DialContext(ctx, target, headers)
+		if err != nil {
+			if resp != nil {
+				err = fmt.Errorf("%w (HTTP %s)", err, resp.Status)
+			}
+			return nil, fmt.Errorf("建立 WebSocket 连接 %s 失败: %w", target, err)
+		}
+		return &webSocketConn{Conn: conn}, nil
+	}, nil
+}
+
+// webSocketConn 将 WebSocket 二进制消息适配为字节流
+type webSocketConn struct {
+	*websocket.Conn
+	reader io.Reader
+}
+
+func (c *webSocketConn) Read(p []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			_, reader, err := c.NextReader()
+			if err != nil {
+				return 0, err
+			}
+			c.reader = reader
+		}
+		n, err := c.reader.Read(p)
+		if errors.Is(err, io.EOF) {
+			c.reader = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *webSocketConn) Write(p []byte) (int, error) {
+	if err := c.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *webSocketConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
@@ -0,0 +1,254 @@
+package messagebus_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	messagebus "github.com/clint456/edgex-messagebus-client"
+	"github.com/clint456/edgex-messagebus-client/brokertest"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+	"github.com/gorilla/websocket"
+)
+
+// handshake 记录 WebSocket 网关收到的握手请求
+type handshake struct {
+	path          string
+	query         string
+	authorization string
+	subprotocol   string
+}
+
+// webSocketGateway 模拟只开放 WebSocket 的 Ingress：校验握手后将 WebSocket 二进制消息与 Broker 的 TCP 连接互相转发
+type webSocketGateway struct {
+	broker *brokertest.MQTTBroker
+	status int // 非 0 时以该状态码拒绝握手
+
+	mutex      sync.Mutex
+	handshakes []handshake
+}
+
+func (g *webSocketGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mutex.Lock()
+	g.handshakes = append(g.handshakes, handshake{
+		path:          r.URL.Path,
+		query:         r.URL.RawQuery,
+		authorization: r.Header.Get("Authorization"),
+		subprotocol:   r.Header.Get("Sec-WebSocket-Protocol"),
+	})
+	g.mutex.Unlock()
+	if g.status != 0 {
+		http.Error(w, http.StatusText(g.status), g.status)
+		return
+	}
+	upgrader := websocket.Upgrader{Subprotocols: []string{"mqtt"}}
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+	tcp, err := net.Dial("tcp", net.JoinHostPort(g.broker.Host, strconv.Itoa(g.broker.Port)))
+	if err != nil {
+		return
+	}
+	defer tcp.Close()
+	go func() {
+		defer ws.Close()
+		buf := make([]byte, 4096)
+		for {
+			n, err := tcp.Read(buf)
+			if n > 0 && ws.WriteMessage(websocket.BinaryMessage, buf[:n]) != nil {
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		_, reader, err := ws.NextReader()
+		if err != nil {
+			return
+		}
+		if _, err := io.Copy(tcp, reader); err != nil {
+			return
+		}
+	}
+}
+
+func (g *webSocketGateway) recorded() []handshake {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return append([]handshake(nil), g.handshakes...)
+}
+
+// webSocketConfig 返回经 server 以 protocol 连接的客户端配置
+func webSocketConfig(t *testing.T, server *httptest.Server, protocol string) messagebus.Config {
+	t.Helper()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return messagebus.Config{
+		Host:           u.Hostname(),
+		Port:           port,
+		Protocol:       protocol,
+		Type:           messagebus.TypeMQTT,
+		SkipCertVerify: protocol == "wss",
+	}
+}
+
+func TestWebSocketRelayPathAndHeaders(t *testing.T) {
+	for _, protocol := range []string{"ws", "wss"} {
+		t.Run(protocol, func(t *testing.T) {
+			gateway := &webSocketGateway{broker: brokertest.StartMQTT(t)}
+			server := httptest.NewServer(gateway)
+			if protocol == "wss" {
+				server = httptest.NewTLSServer(gateway)
+			}
+			defer server.Close()
+
+			config := webSocketConfig(t, server, protocol)
+			headers := http.Header{"Authorization": []string{"Bearer token"}}
+			var clients []*messagebus.Client
+			for i := 0; i < 2; i++ {
+				client, err := messagebus.NewClientWithOptions(
+					messagebus.WithConfig(config),
+					messagebus.WithLogger(logger.NewMockClient()),
+					messagebus.WithWebSocket("/mqtt?tenant=a", headers),
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := client.Connect(); err != nil {
+					t.Fatal(err)
+				}
+				defer client.Close()
+				clients = append(clients, client)
+			}
+			// 创建客户端后修改 headers 不应影响之后的握手
+			headers.Set("Authorization", "changed")
+
+			received := make(chan types.MessageEnvelope, 1)
+			if err := clients[0].Subscribe([]string{"test/ws"}, func(_ string, msg types.MessageEnvelope) error {
+				received <- msg
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if err := clients[1].Publish("test/ws", "hello"); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-received:
+			case <-time.After(5 * time.Second):
+				t.Fatal("经 WebSocket 中继未收到消息")
+			}
+
+			handshakes := gateway.recorded()
+			if len(handshakes) < 2 {
+				t.Fatalf("网关收到 %d 次握手，期望至少 2 次", len(handshakes))
+			}
+			for _, h := range handshakes {
+				if h.path != "/mqtt" || h.query != "tenant=a" {
+					t.Errorf("握手路径 %s?%s，期望 /mqtt?tenant=a", h.path, h.query)
+				}
+				if h.authorization != "Bearer token" {
+					t.Errorf("Authorization = %q，期望 Bearer token", h.authorization)
+				}
+				if h.subprotocol != "mqtt" {
+					t.Errorf("Sec-WebSocket-Protocol = %q，期望 mqtt", h.subprotocol)
+				}
+			}
+		})
+	}
+}
+
+func TestWebSocketDefaultPath(t *testing.T) {
+	gateway := &webSocketGateway{broker: brokertest.StartMQTT(t)}
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	client, err := messagebus.NewClientWithOptions(
+		messagebus.WithConfig(webSocketConfig(t, server, "ws")),
+		messagebus.WithLogger(logger.NewMockClient()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if handshakes := gateway.recorded(); len(handshakes) == 0 || handshakes[0].path != "/" {
+		t.Errorf("未设置 Path 时握手 %+v，期望路径 /", handshakes)
+	}
+}
+
+func TestWebSocketHandshakeRejected(t *testing.T) {
+	gateway := &webSocketGateway{status: http.StatusUnauthorized}
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	client, err := messagebus.NewClientWithOptions(
+		messagebus.WithConfig(webSocketConfig(t, server, "ws")),
+		messagebus.WithLogger(logger.NewMockClient()),
+		messagebus.WithWebSocket("/mqtt", nil),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	err = client.Connect()
+	if err == nil {
+		t.Fatal("握手被拒绝时 Connect 应返回错误")
+	}
+	if !strings.Contains(err.Error(), "401") {
+		t.Errorf("错误 %q 应包含握手响应状态", err)
+	}
+}
+
+func TestWebSocketConfigValidation(t *testing.T) {
+	base := messagebus.Config{Host: "localhost", Port: 8080, Protocol: "ws", Type: messagebus.TypeMQTT}
+	cases := []struct {
+		name     string
+		protocol string
+		path     string
+		headers  http.Header
+		valid    bool
+	}{
+		{"path", "ws", "/mqtt", nil, true},
+		{"path with query", "wss", "/mqtt?token=x", nil, true},
+		{"headers", "ws", "", http.Header{"Authorization": []string{"x"}}, true},
+		{"relative path", "ws", "mqtt", nil, false},
+		{"reserved header", "ws", "/mqtt", http.Header{"Sec-Websocket-Key": []string{"x"}}, false},
+		{"reserved header case", "ws", "/mqtt", http.Header{"upgrade": []string{"x"}}, false},
+		{"non websocket protocol", "tcp", "/mqtt", nil, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := base
+			config.Protocol = tc.protocol
+			config.WebSocket = messagebus.WebSocketConfig{Path: tc.path, Headers: tc.headers}
+			err := config.Validate()
+			if tc.valid && err != nil {
+				t.Errorf("期望通过校验，实际 %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("期望校验失败")
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package messagebus
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	pahoMqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// willConnectTimeout 是建立遗嘱连接的超时时间
+const willConnectTimeout = 10 * time.Second
+
+// WillConfig 表示 MQTT 遗嘱（Last Will and Testament）参数
+type WillConfig struct {
+	Topic   string // 遗嘱主题
+	Payload []byte // 遗嘱内容，原样发布，不封装为信封
+	QoS     int    // 遗嘱 QoS (0, 1, 2)
+	Retain  bool   // 是否作为保留消息发布
+}
+
+// validate 校验遗嘱参数
+func (w *WillConfig) validate(busType string) error {
+	if w == nil {
+		return nil
+	}
+	if !strings.EqualFold(busType, TypeMQTT) {
+		return fmt.Errorf("遗嘱消息仅支持 %s 类型", TypeMQTT)
+	}
+	if w.Topic == "" {
+		return fmt.Errorf("遗嘱主题不能为空")
+	}
+	if strings.ContainsAny(w.Topic, "+#") {
+		return fmt.Errorf("遗嘱主题不能包含通配符")
+	}
+	if w.QoS < 0 || w.QoS > 2 {
+		return fmt.Errorf("遗嘱 QoS 必须为 0、1 或 2")
+	}
+	return nil
+}
+
+// connectWill 建立携带遗嘱的 MQTT 连接
+//
+// go-mod-messaging 不支持设置遗嘱，因此使用一条独立的 paho 连接（ClientID 追加 -will 后缀）
+// 注册遗嘱；进程异常退出或网络中断导致该连接丢失时，Broker 会发布遗嘱消息。
+// 主动调用 Disconnect 属于正常下线，不会触发遗嘱。
+func (c *Client) connectWill() error {
+	will := c.config.Will
+	if will == nil {
+		return nil
+	}
+	c.mutex.RLock()
+	busConfig := c.busConfig
+	c.mutex.RUnlock()
+
+	dial, err := brokerDialerFor(c.config, busConfig)
+	if err != nil {
+		return err
+	}
+	opts := pahoMqtt.NewClientOptions()
+	opts.AddBroker(busConfig.Broker.GetHostURL())
+	if dial != nil {
+		// WebSocket 和代理连接与主连接使用同一 brokerDialer，TLS 已在其中完成
+		opts.SetCustomOpenConnectionFn(func(*url.URL, pahoMqtt.ClientOptions) (net.Conn, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), willConnectTimeout)
+			defer cancel()
+			return dial(ctx)
+		})
+	}
+	opts.SetClientID(busConfig.Optional["ClientId"] + "-will")
+	opts.SetUsername(busConfig.Optional["Username"])
+	opts.SetPassword(busConfig.Optional["Password"])
+	opts.SetAutoReconnect(true)
+	opts.SetConnectTimeout(willConnectTimeout)
+	willTopic := will.Topic
+	if mapper := newTopicMapper(c.config); mapper != nil {
+		willTopic = mapper.toWire(willTopic)
+	}
+	opts.SetBinaryWill(willTopic, will.Payload, byte(will.QoS), will.Retain)
+	if dial == nil && isTLSProtocol(busConfig.Broker.Protocol) {
+		tlsConfig, err := TLSConfigFromOptions(busConfig.Optional)
+		if err != nil {
+			return err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	client := pahoMqtt.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(willConnectTimeout) {
+		return fmt.Errorf("建立遗嘱连接超时")
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("建立遗嘱连接失败: %w", err)
+	}
+	c.mutex.Lock()
+	c.willClient = client
+	c.mutex.Unlock()
+	return nil
+}
+
+// disconnectWill 正常关闭遗嘱连接，Broker 不会发布遗嘱
+func (c *Client) disconnectWill() {
+	c.mutex.Lock()
+	client := c.willClient
+	c.willClient = nil
+	c.mutex.Unlock()
+	if client != nil {
+		client.Disconnect(250)
+	}
+}